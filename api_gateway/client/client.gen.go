@@ -0,0 +1,5259 @@
+// Package client provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.4.1 DO NOT EDIT.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/oapi-codegen/runtime"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+const (
+	ApiKeyScopes = "apiKey.Scopes"
+)
+
+// Defines values for BreakerStatusState.
+const (
+	Closed   BreakerStatusState = "closed"
+	HalfOpen BreakerStatusState = "half-open"
+	Open     BreakerStatusState = "open"
+)
+
+// Defines values for EventName.
+const (
+	OrderCancelled EventName = "order.cancelled"
+	OrderPlaced    EventName = "order.placed"
+	StockLow       EventName = "stock.low"
+)
+
+// Defines values for GatewayHealthService.
+const (
+	APIGateway GatewayHealthService = "API Gateway"
+)
+
+// Defines values for GatewayHealthStatus.
+const (
+	GatewayHealthStatusDegraded GatewayHealthStatus = "degraded"
+	GatewayHealthStatusHealthy  GatewayHealthStatus = "healthy"
+)
+
+// Defines values for NotificationCategory.
+const (
+	Marketing     NotificationCategory = "marketing"
+	Transactional NotificationCategory = "transactional"
+)
+
+// Defines values for NotificationRecordStatus.
+const (
+	Delivered  NotificationRecordStatus = "delivered"
+	Digested   NotificationRecordStatus = "digested"
+	Failed     NotificationRecordStatus = "failed"
+	Queued     NotificationRecordStatus = "queued"
+	Sending    NotificationRecordStatus = "sending"
+	Suppressed NotificationRecordStatus = "suppressed"
+)
+
+// Defines values for OrderStatus.
+const (
+	Cancelled OrderStatus = "cancelled"
+	Placed    OrderStatus = "placed"
+)
+
+// Defines values for ServiceHealthStatus.
+const (
+	ServiceHealthStatusHealthy   ServiceHealthStatus = "healthy"
+	ServiceHealthStatusUnhealthy ServiceHealthStatus = "unhealthy"
+)
+
+// Defines values for StockAdjustmentType.
+const (
+	StockAdjustmentTypeAdjustment StockAdjustmentType = "adjustment"
+	StockAdjustmentTypeReceipt    StockAdjustmentType = "receipt"
+	StockAdjustmentTypeReturn     StockAdjustmentType = "return"
+	StockAdjustmentTypeSale       StockAdjustmentType = "sale"
+	StockAdjustmentTypeTransfer   StockAdjustmentType = "transfer"
+)
+
+// Defines values for StockMovementType.
+const (
+	StockMovementTypeAdjustment  StockMovementType = "adjustment"
+	StockMovementTypeReceipt     StockMovementType = "receipt"
+	StockMovementTypeRelease     StockMovementType = "release"
+	StockMovementTypeReservation StockMovementType = "reservation"
+	StockMovementTypeReturn      StockMovementType = "return"
+	StockMovementTypeSale        StockMovementType = "sale"
+	StockMovementTypeTransfer    StockMovementType = "transfer"
+)
+
+// Defines values for UnsubscribeV2FormdataBodyListUnsubscribe.
+const (
+	OneClick UnsubscribeV2FormdataBodyListUnsubscribe = "One-Click"
+)
+
+// BreakerStatus defines model for BreakerStatus.
+type BreakerStatus struct {
+	Failures      int                `json:"failures"`
+	InFlight      int                `json:"in_flight"`
+	MaxConcurrent int                `json:"max_concurrent"`
+	Name          string             `json:"name"`
+	State         BreakerStatusState `json:"state"`
+}
+
+// BreakerStatusState defines model for BreakerStatus.State.
+type BreakerStatusState string
+
+// CategoryOptIns Whether the user gets each category of notifications, transactional or marketing, on each channel, email, sms, push or inbox.
+type CategoryOptIns map[string]map[string]bool
+
+// Contact defines model for Contact.
+type Contact struct {
+	Email *openapi_types.Email `json:"email,omitempty"`
+
+	// Locale Language tag the user's notifications are written in, such as de or pt-BR. Without one, or without templates in it, notifications are in the service's default locale.
+	Locale *string `json:"locale,omitempty"`
+
+	// Phone E.164 number, such as +15550100.
+	Phone *string `json:"phone,omitempty"`
+
+	// Push A browser's push subscription, as PushSubscription.toJSON() returns it.
+	Push *PushSubscription `json:"push,omitempty"`
+}
+
+// ContactDetails defines model for ContactDetails.
+type ContactDetails struct {
+	Email  *openapi_types.Email `json:"email,omitempty"`
+	Locale *string              `json:"locale,omitempty"`
+	Phone  *string              `json:"phone,omitempty"`
+
+	// Push A browser's push subscription, as PushSubscription.toJSON() returns it.
+	Push   *PushSubscription `json:"push,omitempty"`
+	UserId int               `json:"user_id"`
+}
+
+// Error A human-readable error message.
+type Error = string
+
+// EventName defines model for EventName.
+type EventName string
+
+// GatewayHealth The gateway's own state, with a circuit breaker per downstream queue.
+type GatewayHealth struct {
+	Breakers []BreakerStatus      `json:"breakers"`
+	Service  GatewayHealthService `json:"service"`
+	Status   GatewayHealthStatus  `json:"status"`
+}
+
+// GatewayHealthService defines model for GatewayHealth.Service.
+type GatewayHealthService string
+
+// GatewayHealthStatus defines model for GatewayHealth.Status.
+type GatewayHealthStatus string
+
+// HealthEntry A backend service's health, or the gateway's own state.
+type HealthEntry struct {
+	union json.RawMessage
+}
+
+// InboxItem defines model for InboxItem.
+type InboxItem struct {
+	Archived bool `json:"archived"`
+
+	// Category transactional or marketing.
+	Category  string    `json:"category"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	ItemId    int       `json:"item_id"`
+
+	// NotificationId The notification in the user's history the item was made from.
+	NotificationId int        `json:"notification_id"`
+	Read           bool       `json:"read"`
+	ReadAt         *time.Time `json:"read_at,omitempty"`
+	Subject        *string    `json:"subject,omitempty"`
+	UserId         int        `json:"user_id"`
+}
+
+// InboxList defines model for InboxList.
+type InboxList struct {
+	Items []InboxItem `json:"items"`
+
+	// Unread How many items in the inbox, not the archive, are unread.
+	Unread int `json:"unread"`
+}
+
+// InboxUnreadCount defines model for InboxUnreadCount.
+type InboxUnreadCount struct {
+	Unread int `json:"unread"`
+	UserId int `json:"user_id"`
+}
+
+// MovementList defines model for MovementList.
+type MovementList struct {
+	Movements []StockMovement `json:"movements"`
+	ProductId int             `json:"product_id"`
+	Stock     int             `json:"stock"`
+}
+
+// Notification A notification request. The notification service renders its template with its data in the user's locale.
+type Notification struct {
+	// Category Defaults to transactional.
+	Category *NotificationCategory `json:"category,omitempty"`
+	Channel  *string               `json:"channel,omitempty"`
+	Data     *map[string]string    `json:"data,omitempty"`
+	Locale   *string               `json:"locale,omitempty"`
+
+	// Message Text of a notification without a template; empty for templated ones.
+	Message  string  `json:"message"`
+	Template *string `json:"template,omitempty"`
+	UserId   int     `json:"user_id"`
+}
+
+// NotificationCategory Defaults to transactional.
+type NotificationCategory string
+
+// NotificationHistory defines model for NotificationHistory.
+type NotificationHistory struct {
+	Notifications []NotificationRecord `json:"notifications"`
+}
+
+// NotificationRecord defines model for NotificationRecord.
+type NotificationRecord struct {
+	// Attempts Delivery attempts made.
+	Attempts    int        `json:"attempts"`
+	Category    string     `json:"category"`
+	Channel     string     `json:"channel"`
+	Content     string     `json:"content"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+
+	// DigestId The digest a digested notification was sent in, once it was.
+	DigestId       *int `json:"digest_id,omitempty"`
+	NotificationId int  `json:"notification_id"`
+
+	// Status suppressed: not sent because of the user's preferences. digested: held back and sent with others in a digest, the notification digest_id.
+	Status    NotificationRecordStatus `json:"status"`
+	Template  string                   `json:"template"`
+	UpdatedAt time.Time                `json:"updated_at"`
+	UserId    int                      `json:"user_id"`
+}
+
+// NotificationRecordStatus suppressed: not sent because of the user's preferences. digested: held back and sent with others in a digest, the notification digest_id.
+type NotificationRecordStatus string
+
+// Order defines model for Order.
+type Order struct {
+	OrderId   int         `json:"order_id"`
+	ProductId int         `json:"product_id"`
+	Quantity  int         `json:"quantity"`
+	Status    OrderStatus `json:"status"`
+	UserId    int         `json:"user_id"`
+}
+
+// OrderStatus defines model for Order.Status.
+type OrderStatus string
+
+// OrderList defines model for OrderList.
+type OrderList struct {
+	Orders []Order `json:"orders"`
+}
+
+// OrderProcessed defines model for OrderProcessed.
+type OrderProcessed struct {
+	// Notification A notification request. The notification service renders its template with its data in the user's locale.
+	Notification Notification `json:"notification"`
+	OrderId      int          `json:"order_id"`
+	Status       string       `json:"status"`
+	StockCheck   StockCheck   `json:"stock_check"`
+}
+
+// OrderRequest defines model for OrderRequest.
+type OrderRequest struct {
+	OrderId   int `json:"order_id"`
+	ProductId int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+	UserId    int `json:"user_id"`
+}
+
+// PlacedOrder defines model for PlacedOrder.
+type PlacedOrder struct {
+	// Notification A notification request. The notification service renders its template with its data in the user's locale.
+	Notification Notification `json:"notification"`
+	Order        Order        `json:"order"`
+}
+
+// Preferences defines model for Preferences.
+type Preferences struct {
+	// Categories Whether the user gets each category of notifications, transactional or marketing, on each channel, email, sms, push or inbox.
+	Categories *CategoryOptIns `json:"categories,omitempty"`
+
+	// QuietHours Hours during which the user gets no SMS or push notifications. A window whose end is before its start spans midnight.
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+
+	// Unsubscribed Whether the user unsubscribed from marketing notifications.
+	Unsubscribed *bool `json:"unsubscribed,omitempty"`
+}
+
+// PreferencesDetails defines model for PreferencesDetails.
+type PreferencesDetails struct {
+	// Categories Whether the user gets each category of notifications, transactional or marketing, on each channel, email, sms, push or inbox.
+	Categories CategoryOptIns `json:"categories"`
+
+	// QuietHours Hours during which the user gets no SMS or push notifications. A window whose end is before its start spans midnight.
+	QuietHours   *QuietHours `json:"quiet_hours,omitempty"`
+	Unsubscribed bool        `json:"unsubscribed"`
+	UserId       int         `json:"user_id"`
+}
+
+// Product defines model for Product.
+type Product struct {
+	Name      string `json:"name"`
+	ProductId int    `json:"product_id"`
+
+	// ReorderThreshold 0 if the inventory service's default applies.
+	ReorderThreshold int `json:"reorder_threshold"`
+	SafetyStock      int `json:"safety_stock"`
+	Stock            int `json:"stock"`
+}
+
+// PushSubscription A browser's push subscription, as PushSubscription.toJSON() returns it.
+type PushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		Auth   string `json:"auth"`
+		P256dh string `json:"p256dh"`
+	} `json:"keys"`
+}
+
+// QuietHours Hours during which the user gets no SMS or push notifications. A window whose end is before its start spans midnight.
+type QuietHours struct {
+	End   string `json:"end"`
+	Start string `json:"start"`
+
+	// TimeZone IANA time zone, such as Europe/Berlin.
+	TimeZone string `json:"time_zone"`
+}
+
+// ReorderPolicy defines model for ReorderPolicy.
+type ReorderPolicy struct {
+	// ReorderThreshold The stock below which the product is low; 0 for the inventory service's default.
+	ReorderThreshold *int `json:"reorder_threshold,omitempty"`
+
+	// SafetyStock The stock to keep on hand whatever the demand.
+	SafetyStock *int `json:"safety_stock,omitempty"`
+}
+
+// ReorderReport defines model for ReorderReport.
+type ReorderReport struct {
+	CoverDays   int                 `json:"cover_days"`
+	Suggestions []ReorderSuggestion `json:"suggestions"`
+	WindowDays  int                 `json:"window_days"`
+}
+
+// ReorderSuggestion defines model for ReorderSuggestion.
+type ReorderSuggestion struct {
+	// Consumed Units consumed over the window.
+	Consumed int `json:"consumed"`
+
+	// DailyVelocity Units consumed a day.
+	DailyVelocity float32 `json:"daily_velocity"`
+	Name          string  `json:"name"`
+	ProductId     int     `json:"product_id"`
+
+	// Quantity Units to reorder.
+	Quantity int `json:"quantity"`
+
+	// ReorderThreshold The threshold that applies, the product's own or the default.
+	ReorderThreshold int `json:"reorder_threshold"`
+	SafetyStock      int `json:"safety_stock"`
+	Stock            int `json:"stock"`
+}
+
+// ServiceHealth A backend service's reply to the health check.
+type ServiceHealth struct {
+	Database      string              `json:"database"`
+	Error         *string             `json:"error,omitempty"`
+	SchemaVersion int                 `json:"schema_version"`
+	Service       string              `json:"service"`
+	Status        ServiceHealthStatus `json:"status"`
+}
+
+// ServiceHealthStatus defines model for ServiceHealth.Status.
+type ServiceHealthStatus string
+
+// StockAdjustment defines model for StockAdjustment.
+type StockAdjustment struct {
+	// Actor Who made the change; the api_gateway service if empty.
+	Actor *string `json:"actor,omitempty"`
+
+	// Quantity Units to add, or to remove if negative. Must not be 0.
+	Quantity int     `json:"quantity"`
+	Reason   *string `json:"reason,omitempty"`
+
+	// Reference The purchase order, order or other document behind the change.
+	Reference *string `json:"reference,omitempty"`
+
+	// Type The movement to record. Receipts and returns add units, sales remove them, and adjustments and transfers do either.
+	Type *StockAdjustmentType `json:"type,omitempty"`
+}
+
+// StockAdjustmentType The movement to record. Receipts and returns add units, sales remove them, and adjustments and transfers do either.
+type StockAdjustmentType string
+
+// StockCheck defines model for StockCheck.
+type StockCheck struct {
+	IsAvailable bool `json:"is_available"`
+	ProductId   int  `json:"product_id"`
+}
+
+// StockMovement defines model for StockMovement.
+type StockMovement struct {
+	// Actor Who made the change, or the service that made it for them.
+	Actor *string `json:"actor,omitempty"`
+
+	// Balance The sum of the product's movements up to and including this one.
+	Balance    int       `json:"balance"`
+	CreatedAt  time.Time `json:"created_at"`
+	MovementId int64     `json:"movement_id"`
+	ProductId  int       `json:"product_id"`
+
+	// Quantity Units added, or removed if negative.
+	Quantity int     `json:"quantity"`
+	Reason   *string `json:"reason,omitempty"`
+
+	// Reference The order, as order:<order_id>, or purchase order behind the change.
+	Reference *string           `json:"reference,omitempty"`
+	Type      StockMovementType `json:"type"`
+}
+
+// StockMovementType defines model for StockMovement.Type.
+type StockMovementType string
+
+// Webhook defines model for Webhook.
+type Webhook struct {
+	// Active Defaults to true.
+	Active *bool       `json:"active,omitempty"`
+	Events []EventName `json:"events"`
+
+	// Secret The key deliveries are signed with. Generated if a new webhook has none.
+	Secret *string `json:"secret,omitempty"`
+
+	// Url An http or https URL.
+	Url string `json:"url"`
+}
+
+// WebhookDelivery defines model for WebhookDelivery.
+type WebhookDelivery struct {
+	Attempts    int        `json:"attempts"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	DeliveryId  int        `json:"delivery_id"`
+
+	// Error Why the last attempt failed.
+	Error *string   `json:"error,omitempty"`
+	Event EventName `json:"event"`
+
+	// EventId Identifies the event; sent as the Webhook-Id header so receivers can drop duplicates.
+	EventId string `json:"event_id"`
+
+	// ResponseStatus The status the webhook answered the last attempt with.
+	ResponseStatus *int `json:"response_status,omitempty"`
+
+	// Status pending, delivered or failed.
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+	WebhookId int       `json:"webhook_id"`
+}
+
+// WebhookDeliveryList defines model for WebhookDeliveryList.
+type WebhookDeliveryList struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+// WebhookDetails defines model for WebhookDetails.
+type WebhookDetails struct {
+	// Active False once the webhook was disabled after failing too often.
+	Active    bool        `json:"active"`
+	CreatedAt time.Time   `json:"created_at"`
+	Events    []EventName `json:"events"`
+
+	// Failures Deliveries that failed in a row.
+	Failures int `json:"failures"`
+
+	// Secret Only sent back when it was generated or changed.
+	Secret    *string   `json:"secret,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Url       string    `json:"url"`
+	WebhookId int       `json:"webhook_id"`
+}
+
+// WebhookList defines model for WebhookList.
+type WebhookList struct {
+	Webhooks []WebhookDetails `json:"webhooks"`
+}
+
+// DeliveryID defines model for DeliveryID.
+type DeliveryID = int
+
+// ItemID defines model for ItemID.
+type ItemID = int
+
+// OrderID defines model for OrderID.
+type OrderID = int
+
+// ProductID defines model for ProductID.
+type ProductID = int
+
+// UnsubscribeToken defines model for UnsubscribeToken.
+type UnsubscribeToken = string
+
+// UserID defines model for UserID.
+type UserID = int
+
+// WebhookID defines model for WebhookID.
+type WebhookID = int
+
+// ListOrdersParams defines parameters for ListOrders.
+type ListOrdersParams struct {
+	UserId int `form:"user_id" json:"user_id"`
+}
+
+// ListOrdersV2Params defines parameters for ListOrdersV2.
+type ListOrdersV2Params struct {
+	UserId int `form:"user_id" json:"user_id"`
+}
+
+// ReorderSuggestionsV2Params defines parameters for ReorderSuggestionsV2.
+type ReorderSuggestionsV2Params struct {
+	// WindowDays The days, up to today, to measure consumption over.
+	WindowDays *int `form:"window_days,omitempty" json:"window_days,omitempty"`
+
+	// CoverDays The days the stock should last.
+	CoverDays *int `form:"cover_days,omitempty" json:"cover_days,omitempty"`
+}
+
+// UnsubscribePageV2Params defines parameters for UnsubscribePageV2.
+type UnsubscribePageV2Params struct {
+	// Token The signed token from the unsubscribe link.
+	Token UnsubscribeToken `form:"token" json:"token"`
+}
+
+// UnsubscribeV2FormdataBody defines parameters for UnsubscribeV2.
+type UnsubscribeV2FormdataBody struct {
+	ListUnsubscribe *UnsubscribeV2FormdataBodyListUnsubscribe `form:"List-Unsubscribe,omitempty" json:"List-Unsubscribe,omitempty"`
+}
+
+// UnsubscribeV2Params defines parameters for UnsubscribeV2.
+type UnsubscribeV2Params struct {
+	// Token The signed token from the unsubscribe link.
+	Token UnsubscribeToken `form:"token" json:"token"`
+}
+
+// UnsubscribeV2FormdataBodyListUnsubscribe defines parameters for UnsubscribeV2.
+type UnsubscribeV2FormdataBodyListUnsubscribe string
+
+// ListUserInboxV2Params defines parameters for ListUserInboxV2.
+type ListUserInboxV2Params struct {
+	// Archived List the archived items instead.
+	Archived *bool `form:"archived,omitempty" json:"archived,omitempty"`
+}
+
+// ProcessOrderJSONRequestBody defines body for ProcessOrder for application/json ContentType.
+type ProcessOrderJSONRequestBody = OrderRequest
+
+// PlaceOrderV2JSONRequestBody defines body for PlaceOrderV2 for application/json ContentType.
+type PlaceOrderV2JSONRequestBody = OrderRequest
+
+// PutReorderPolicyV2JSONRequestBody defines body for PutReorderPolicyV2 for application/json ContentType.
+type PutReorderPolicyV2JSONRequestBody = ReorderPolicy
+
+// AdjustStockV2JSONRequestBody defines body for AdjustStockV2 for application/json ContentType.
+type AdjustStockV2JSONRequestBody = StockAdjustment
+
+// UnsubscribeV2FormdataRequestBody defines body for UnsubscribeV2 for application/x-www-form-urlencoded ContentType.
+type UnsubscribeV2FormdataRequestBody UnsubscribeV2FormdataBody
+
+// PutUserContactV2JSONRequestBody defines body for PutUserContactV2 for application/json ContentType.
+type PutUserContactV2JSONRequestBody = Contact
+
+// PutUserPreferencesV2JSONRequestBody defines body for PutUserPreferencesV2 for application/json ContentType.
+type PutUserPreferencesV2JSONRequestBody = Preferences
+
+// CreateWebhookV2JSONRequestBody defines body for CreateWebhookV2 for application/json ContentType.
+type CreateWebhookV2JSONRequestBody = Webhook
+
+// PutWebhookV2JSONRequestBody defines body for PutWebhookV2 for application/json ContentType.
+type PutWebhookV2JSONRequestBody = Webhook
+
+// AsServiceHealth returns the union data inside the HealthEntry as a ServiceHealth
+func (t HealthEntry) AsServiceHealth() (ServiceHealth, error) {
+	var body ServiceHealth
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromServiceHealth overwrites any union data inside the HealthEntry as the provided ServiceHealth
+func (t *HealthEntry) FromServiceHealth(v ServiceHealth) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeServiceHealth performs a merge with any union data inside the HealthEntry, using the provided ServiceHealth
+func (t *HealthEntry) MergeServiceHealth(v ServiceHealth) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsGatewayHealth returns the union data inside the HealthEntry as a GatewayHealth
+func (t HealthEntry) AsGatewayHealth() (GatewayHealth, error) {
+	var body GatewayHealth
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromGatewayHealth overwrites any union data inside the HealthEntry as the provided GatewayHealth
+func (t *HealthEntry) FromGatewayHealth(v GatewayHealth) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeGatewayHealth performs a merge with any union data inside the HealthEntry, using the provided GatewayHealth
+func (t *HealthEntry) MergeGatewayHealth(v GatewayHealth) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+func (t HealthEntry) MarshalJSON() ([]byte, error) {
+	b, err := t.union.MarshalJSON()
+	return b, err
+}
+
+func (t *HealthEntry) UnmarshalJSON(b []byte) error {
+	err := t.union.UnmarshalJSON(b)
+	return err
+}
+
+// RequestEditorFn  is the function signature for the RequestEditor callback function
+type RequestEditorFn func(ctx context.Context, req *http.Request) error
+
+// Doer performs HTTP requests.
+//
+// The standard http.Client implements this interface.
+type HttpRequestDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client which conforms to the OpenAPI3 specification for this service.
+type Client struct {
+	// The endpoint of the server conforming to this interface, with scheme,
+	// https://api.deepmap.com for example. This can contain a path relative
+	// to the server, such as https://api.deepmap.com/dev-test, and all the
+	// paths in the swagger spec will be appended to the server.
+	Server string
+
+	// Doer for performing requests, typically a *http.Client with any
+	// customized settings, such as certificate chains.
+	Client HttpRequestDoer
+
+	// A list of callbacks for modifying requests which are generated before sending over
+	// the network.
+	RequestEditors []RequestEditorFn
+}
+
+// ClientOption allows setting custom parameters during construction
+type ClientOption func(*Client) error
+
+// Creates a new Client, with reasonable defaults
+func NewClient(server string, opts ...ClientOption) (*Client, error) {
+	// create a client with sane default values
+	client := Client{
+		Server: server,
+	}
+	// mutate client and add all optional params
+	for _, o := range opts {
+		if err := o(&client); err != nil {
+			return nil, err
+		}
+	}
+	// ensure the server URL always has a trailing slash
+	if !strings.HasSuffix(client.Server, "/") {
+		client.Server += "/"
+	}
+	// create httpClient, if not already present
+	if client.Client == nil {
+		client.Client = &http.Client{}
+	}
+	return &client, nil
+}
+
+// WithHTTPClient allows overriding the default Doer, which is
+// automatically created using http.Client. This is useful for tests.
+func WithHTTPClient(doer HttpRequestDoer) ClientOption {
+	return func(c *Client) error {
+		c.Client = doer
+		return nil
+	}
+}
+
+// WithRequestEditorFn allows setting up a callback function, which will be
+// called right before sending the request. This can be used to mutate the request.
+func WithRequestEditorFn(fn RequestEditorFn) ClientOption {
+	return func(c *Client) error {
+		c.RequestEditors = append(c.RequestEditors, fn)
+		return nil
+	}
+}
+
+// The interface specification for the client above.
+type ClientInterface interface {
+	// GetDocs request
+	GetDocs(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// HealthCheck request
+	HealthCheck(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetOpenAPI request
+	GetOpenAPI(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ListOrders request
+	ListOrders(ctx context.Context, params *ListOrdersParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetOrder request
+	GetOrder(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// CancelOrder request
+	CancelOrder(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ProcessOrderWithBody request with any body
+	ProcessOrderWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	ProcessOrder(ctx context.Context, body ProcessOrderJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ListOrdersV2 request
+	ListOrdersV2(ctx context.Context, params *ListOrdersV2Params, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PlaceOrderV2WithBody request with any body
+	PlaceOrderV2WithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PlaceOrderV2(ctx context.Context, body PlaceOrderV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetOrderV2 request
+	GetOrderV2(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// CancelOrderV2 request
+	CancelOrderV2(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetProductV2 request
+	GetProductV2(ctx context.Context, productId ProductID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ListStockMovementsV2 request
+	ListStockMovementsV2(ctx context.Context, productId ProductID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PutReorderPolicyV2WithBody request with any body
+	PutReorderPolicyV2WithBody(ctx context.Context, productId ProductID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PutReorderPolicyV2(ctx context.Context, productId ProductID, body PutReorderPolicyV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// AdjustStockV2WithBody request with any body
+	AdjustStockV2WithBody(ctx context.Context, productId ProductID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	AdjustStockV2(ctx context.Context, productId ProductID, body AdjustStockV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ReorderSuggestionsV2 request
+	ReorderSuggestionsV2(ctx context.Context, params *ReorderSuggestionsV2Params, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// UnsubscribePageV2 request
+	UnsubscribePageV2(ctx context.Context, params *UnsubscribePageV2Params, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// UnsubscribeV2WithBody request with any body
+	UnsubscribeV2WithBody(ctx context.Context, params *UnsubscribeV2Params, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	UnsubscribeV2WithFormdataBody(ctx context.Context, params *UnsubscribeV2Params, body UnsubscribeV2FormdataRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetUserContactV2 request
+	GetUserContactV2(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PutUserContactV2WithBody request with any body
+	PutUserContactV2WithBody(ctx context.Context, userId UserID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PutUserContactV2(ctx context.Context, userId UserID, body PutUserContactV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ListUserInboxV2 request
+	ListUserInboxV2(ctx context.Context, userId UserID, params *ListUserInboxV2Params, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// MarkUserInboxReadV2 request
+	MarkUserInboxReadV2(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// StreamUserInboxV2 request
+	StreamUserInboxV2(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetUserInboxUnreadCountV2 request
+	GetUserInboxUnreadCountV2(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ArchiveInboxItemV2 request
+	ArchiveInboxItemV2(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ReadInboxItemV2 request
+	ReadInboxItemV2(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// UnarchiveInboxItemV2 request
+	UnarchiveInboxItemV2(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// UnreadInboxItemV2 request
+	UnreadInboxItemV2(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ListUserNotificationsV2 request
+	ListUserNotificationsV2(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetUserPreferencesV2 request
+	GetUserPreferencesV2(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PutUserPreferencesV2WithBody request with any body
+	PutUserPreferencesV2WithBody(ctx context.Context, userId UserID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PutUserPreferencesV2(ctx context.Context, userId UserID, body PutUserPreferencesV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ListWebhooksV2 request
+	ListWebhooksV2(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// CreateWebhookV2WithBody request with any body
+	CreateWebhookV2WithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	CreateWebhookV2(ctx context.Context, body CreateWebhookV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// DeleteWebhookV2 request
+	DeleteWebhookV2(ctx context.Context, webhookId WebhookID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PutWebhookV2WithBody request with any body
+	PutWebhookV2WithBody(ctx context.Context, webhookId WebhookID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PutWebhookV2(ctx context.Context, webhookId WebhookID, body PutWebhookV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// ListWebhookDeliveriesV2 request
+	ListWebhookDeliveriesV2(ctx context.Context, webhookId WebhookID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// RedeliverWebhookV2 request
+	RedeliverWebhookV2(ctx context.Context, webhookId WebhookID, deliveryId DeliveryID, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetMetrics request
+	GetMetrics(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+}
+
+func (c *Client) GetDocs(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetDocsRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) HealthCheck(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewHealthCheckRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetOpenAPI(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetOpenAPIRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ListOrders(ctx context.Context, params *ListOrdersParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListOrdersRequest(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetOrder(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetOrderRequest(c.Server, orderId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) CancelOrder(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewCancelOrderRequest(c.Server, orderId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ProcessOrderWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewProcessOrderRequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ProcessOrder(ctx context.Context, body ProcessOrderJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewProcessOrderRequest(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ListOrdersV2(ctx context.Context, params *ListOrdersV2Params, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListOrdersV2Request(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PlaceOrderV2WithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPlaceOrderV2RequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PlaceOrderV2(ctx context.Context, body PlaceOrderV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPlaceOrderV2Request(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetOrderV2(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetOrderV2Request(c.Server, orderId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) CancelOrderV2(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewCancelOrderV2Request(c.Server, orderId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetProductV2(ctx context.Context, productId ProductID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetProductV2Request(c.Server, productId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ListStockMovementsV2(ctx context.Context, productId ProductID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListStockMovementsV2Request(c.Server, productId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PutReorderPolicyV2WithBody(ctx context.Context, productId ProductID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutReorderPolicyV2RequestWithBody(c.Server, productId, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PutReorderPolicyV2(ctx context.Context, productId ProductID, body PutReorderPolicyV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutReorderPolicyV2Request(c.Server, productId, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) AdjustStockV2WithBody(ctx context.Context, productId ProductID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewAdjustStockV2RequestWithBody(c.Server, productId, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) AdjustStockV2(ctx context.Context, productId ProductID, body AdjustStockV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewAdjustStockV2Request(c.Server, productId, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ReorderSuggestionsV2(ctx context.Context, params *ReorderSuggestionsV2Params, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewReorderSuggestionsV2Request(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) UnsubscribePageV2(ctx context.Context, params *UnsubscribePageV2Params, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewUnsubscribePageV2Request(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) UnsubscribeV2WithBody(ctx context.Context, params *UnsubscribeV2Params, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewUnsubscribeV2RequestWithBody(c.Server, params, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) UnsubscribeV2WithFormdataBody(ctx context.Context, params *UnsubscribeV2Params, body UnsubscribeV2FormdataRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewUnsubscribeV2RequestWithFormdataBody(c.Server, params, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetUserContactV2(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetUserContactV2Request(c.Server, userId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PutUserContactV2WithBody(ctx context.Context, userId UserID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutUserContactV2RequestWithBody(c.Server, userId, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PutUserContactV2(ctx context.Context, userId UserID, body PutUserContactV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutUserContactV2Request(c.Server, userId, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ListUserInboxV2(ctx context.Context, userId UserID, params *ListUserInboxV2Params, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListUserInboxV2Request(c.Server, userId, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) MarkUserInboxReadV2(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewMarkUserInboxReadV2Request(c.Server, userId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) StreamUserInboxV2(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewStreamUserInboxV2Request(c.Server, userId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetUserInboxUnreadCountV2(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetUserInboxUnreadCountV2Request(c.Server, userId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ArchiveInboxItemV2(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewArchiveInboxItemV2Request(c.Server, userId, itemId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ReadInboxItemV2(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewReadInboxItemV2Request(c.Server, userId, itemId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) UnarchiveInboxItemV2(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewUnarchiveInboxItemV2Request(c.Server, userId, itemId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) UnreadInboxItemV2(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewUnreadInboxItemV2Request(c.Server, userId, itemId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ListUserNotificationsV2(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListUserNotificationsV2Request(c.Server, userId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetUserPreferencesV2(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetUserPreferencesV2Request(c.Server, userId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PutUserPreferencesV2WithBody(ctx context.Context, userId UserID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutUserPreferencesV2RequestWithBody(c.Server, userId, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PutUserPreferencesV2(ctx context.Context, userId UserID, body PutUserPreferencesV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutUserPreferencesV2Request(c.Server, userId, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ListWebhooksV2(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListWebhooksV2Request(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) CreateWebhookV2WithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewCreateWebhookV2RequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) CreateWebhookV2(ctx context.Context, body CreateWebhookV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewCreateWebhookV2Request(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) DeleteWebhookV2(ctx context.Context, webhookId WebhookID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewDeleteWebhookV2Request(c.Server, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PutWebhookV2WithBody(ctx context.Context, webhookId WebhookID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutWebhookV2RequestWithBody(c.Server, webhookId, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PutWebhookV2(ctx context.Context, webhookId WebhookID, body PutWebhookV2JSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutWebhookV2Request(c.Server, webhookId, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) ListWebhookDeliveriesV2(ctx context.Context, webhookId WebhookID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListWebhookDeliveriesV2Request(c.Server, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) RedeliverWebhookV2(ctx context.Context, webhookId WebhookID, deliveryId DeliveryID, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewRedeliverWebhookV2Request(c.Server, webhookId, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetMetrics(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetMetricsRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+// NewGetDocsRequest generates requests for GetDocs
+func NewGetDocsRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/docs")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewHealthCheckRequest generates requests for HealthCheck
+func NewHealthCheckRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/health-check")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetOpenAPIRequest generates requests for GetOpenAPI
+func NewGetOpenAPIRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/openapi.json")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewListOrdersRequest generates requests for ListOrders
+func NewListOrdersRequest(server string, params *ListOrdersParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/orders")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "user_id", runtime.ParamLocationQuery, params.UserId); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetOrderRequest generates requests for GetOrder
+func NewGetOrderRequest(server string, orderId OrderID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "order_id", runtime.ParamLocationPath, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/orders/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewCancelOrderRequest generates requests for CancelOrder
+func NewCancelOrderRequest(server string, orderId OrderID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "order_id", runtime.ParamLocationPath, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/orders/%s/cancel", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewProcessOrderRequest calls the generic ProcessOrder builder with application/json body
+func NewProcessOrderRequest(server string, body ProcessOrderJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewProcessOrderRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewProcessOrderRequestWithBody generates requests for ProcessOrder with any type of body
+func NewProcessOrderRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/process-order")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewListOrdersV2Request generates requests for ListOrdersV2
+func NewListOrdersV2Request(server string, params *ListOrdersV2Params) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/orders")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "user_id", runtime.ParamLocationQuery, params.UserId); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewPlaceOrderV2Request calls the generic PlaceOrderV2 builder with application/json body
+func NewPlaceOrderV2Request(server string, body PlaceOrderV2JSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPlaceOrderV2RequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewPlaceOrderV2RequestWithBody generates requests for PlaceOrderV2 with any type of body
+func NewPlaceOrderV2RequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/orders")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewGetOrderV2Request generates requests for GetOrderV2
+func NewGetOrderV2Request(server string, orderId OrderID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "order_id", runtime.ParamLocationPath, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/orders/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewCancelOrderV2Request generates requests for CancelOrderV2
+func NewCancelOrderV2Request(server string, orderId OrderID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "order_id", runtime.ParamLocationPath, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/orders/%s/cancel", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetProductV2Request generates requests for GetProductV2
+func NewGetProductV2Request(server string, productId ProductID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "product_id", runtime.ParamLocationPath, productId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/products/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewListStockMovementsV2Request generates requests for ListStockMovementsV2
+func NewListStockMovementsV2Request(server string, productId ProductID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "product_id", runtime.ParamLocationPath, productId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/products/%s/movements", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewPutReorderPolicyV2Request calls the generic PutReorderPolicyV2 builder with application/json body
+func NewPutReorderPolicyV2Request(server string, productId ProductID, body PutReorderPolicyV2JSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPutReorderPolicyV2RequestWithBody(server, productId, "application/json", bodyReader)
+}
+
+// NewPutReorderPolicyV2RequestWithBody generates requests for PutReorderPolicyV2 with any type of body
+func NewPutReorderPolicyV2RequestWithBody(server string, productId ProductID, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "product_id", runtime.ParamLocationPath, productId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/products/%s/reorder_policy", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewAdjustStockV2Request calls the generic AdjustStockV2 builder with application/json body
+func NewAdjustStockV2Request(server string, productId ProductID, body AdjustStockV2JSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewAdjustStockV2RequestWithBody(server, productId, "application/json", bodyReader)
+}
+
+// NewAdjustStockV2RequestWithBody generates requests for AdjustStockV2 with any type of body
+func NewAdjustStockV2RequestWithBody(server string, productId ProductID, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "product_id", runtime.ParamLocationPath, productId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/products/%s/stock_adjustments", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewReorderSuggestionsV2Request generates requests for ReorderSuggestionsV2
+func NewReorderSuggestionsV2Request(server string, params *ReorderSuggestionsV2Params) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/reports/reorder_suggestions")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.WindowDays != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "window_days", runtime.ParamLocationQuery, *params.WindowDays); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.CoverDays != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "cover_days", runtime.ParamLocationQuery, *params.CoverDays); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewUnsubscribePageV2Request generates requests for UnsubscribePageV2
+func NewUnsubscribePageV2Request(server string, params *UnsubscribePageV2Params) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/unsubscribe")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "token", runtime.ParamLocationQuery, params.Token); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewUnsubscribeV2RequestWithFormdataBody calls the generic UnsubscribeV2 builder with application/x-www-form-urlencoded body
+func NewUnsubscribeV2RequestWithFormdataBody(server string, params *UnsubscribeV2Params, body UnsubscribeV2FormdataRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	bodyStr, err := runtime.MarshalForm(body, nil)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = strings.NewReader(bodyStr.Encode())
+	return NewUnsubscribeV2RequestWithBody(server, params, "application/x-www-form-urlencoded", bodyReader)
+}
+
+// NewUnsubscribeV2RequestWithBody generates requests for UnsubscribeV2 with any type of body
+func NewUnsubscribeV2RequestWithBody(server string, params *UnsubscribeV2Params, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/unsubscribe")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if queryFrag, err := runtime.StyleParamWithLocation("form", true, "token", runtime.ParamLocationQuery, params.Token); err != nil {
+			return nil, err
+		} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+			return nil, err
+		} else {
+			for k, v := range parsed {
+				for _, v2 := range v {
+					queryValues.Add(k, v2)
+				}
+			}
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewGetUserContactV2Request generates requests for GetUserContactV2
+func NewGetUserContactV2Request(server string, userId UserID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/contact", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewPutUserContactV2Request calls the generic PutUserContactV2 builder with application/json body
+func NewPutUserContactV2Request(server string, userId UserID, body PutUserContactV2JSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPutUserContactV2RequestWithBody(server, userId, "application/json", bodyReader)
+}
+
+// NewPutUserContactV2RequestWithBody generates requests for PutUserContactV2 with any type of body
+func NewPutUserContactV2RequestWithBody(server string, userId UserID, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/contact", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewListUserInboxV2Request generates requests for ListUserInboxV2
+func NewListUserInboxV2Request(server string, userId UserID, params *ListUserInboxV2Params) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/inbox", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.Archived != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "archived", runtime.ParamLocationQuery, *params.Archived); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewMarkUserInboxReadV2Request generates requests for MarkUserInboxReadV2
+func NewMarkUserInboxReadV2Request(server string, userId UserID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/inbox/mark_all_read", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewStreamUserInboxV2Request generates requests for StreamUserInboxV2
+func NewStreamUserInboxV2Request(server string, userId UserID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/inbox/stream", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetUserInboxUnreadCountV2Request generates requests for GetUserInboxUnreadCountV2
+func NewGetUserInboxUnreadCountV2Request(server string, userId UserID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/inbox/unread_count", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewArchiveInboxItemV2Request generates requests for ArchiveInboxItemV2
+func NewArchiveInboxItemV2Request(server string, userId UserID, itemId ItemID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "item_id", runtime.ParamLocationPath, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/inbox/%s/archive", pathParam0, pathParam1)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewReadInboxItemV2Request generates requests for ReadInboxItemV2
+func NewReadInboxItemV2Request(server string, userId UserID, itemId ItemID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "item_id", runtime.ParamLocationPath, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/inbox/%s/read", pathParam0, pathParam1)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewUnarchiveInboxItemV2Request generates requests for UnarchiveInboxItemV2
+func NewUnarchiveInboxItemV2Request(server string, userId UserID, itemId ItemID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "item_id", runtime.ParamLocationPath, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/inbox/%s/unarchive", pathParam0, pathParam1)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewUnreadInboxItemV2Request generates requests for UnreadInboxItemV2
+func NewUnreadInboxItemV2Request(server string, userId UserID, itemId ItemID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "item_id", runtime.ParamLocationPath, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/inbox/%s/unread", pathParam0, pathParam1)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewListUserNotificationsV2Request generates requests for ListUserNotificationsV2
+func NewListUserNotificationsV2Request(server string, userId UserID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/notifications", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetUserPreferencesV2Request generates requests for GetUserPreferencesV2
+func NewGetUserPreferencesV2Request(server string, userId UserID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/preferences", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewPutUserPreferencesV2Request calls the generic PutUserPreferencesV2 builder with application/json body
+func NewPutUserPreferencesV2Request(server string, userId UserID, body PutUserPreferencesV2JSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPutUserPreferencesV2RequestWithBody(server, userId, "application/json", bodyReader)
+}
+
+// NewPutUserPreferencesV2RequestWithBody generates requests for PutUserPreferencesV2 with any type of body
+func NewPutUserPreferencesV2RequestWithBody(server string, userId UserID, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "user_id", runtime.ParamLocationPath, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/users/%s/preferences", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewListWebhooksV2Request generates requests for ListWebhooksV2
+func NewListWebhooksV2Request(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/webhooks")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewCreateWebhookV2Request calls the generic CreateWebhookV2 builder with application/json body
+func NewCreateWebhookV2Request(server string, body CreateWebhookV2JSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewCreateWebhookV2RequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewCreateWebhookV2RequestWithBody generates requests for CreateWebhookV2 with any type of body
+func NewCreateWebhookV2RequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/webhooks")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewDeleteWebhookV2Request generates requests for DeleteWebhookV2
+func NewDeleteWebhookV2Request(server string, webhookId WebhookID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "webhook_id", runtime.ParamLocationPath, webhookId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/webhooks/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewPutWebhookV2Request calls the generic PutWebhookV2 builder with application/json body
+func NewPutWebhookV2Request(server string, webhookId WebhookID, body PutWebhookV2JSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPutWebhookV2RequestWithBody(server, webhookId, "application/json", bodyReader)
+}
+
+// NewPutWebhookV2RequestWithBody generates requests for PutWebhookV2 with any type of body
+func NewPutWebhookV2RequestWithBody(server string, webhookId WebhookID, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "webhook_id", runtime.ParamLocationPath, webhookId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/webhooks/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewListWebhookDeliveriesV2Request generates requests for ListWebhookDeliveriesV2
+func NewListWebhookDeliveriesV2Request(server string, webhookId WebhookID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "webhook_id", runtime.ParamLocationPath, webhookId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/webhooks/%s/deliveries", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewRedeliverWebhookV2Request generates requests for RedeliverWebhookV2
+func NewRedeliverWebhookV2Request(server string, webhookId WebhookID, deliveryId DeliveryID) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "webhook_id", runtime.ParamLocationPath, webhookId)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "delivery_id", runtime.ParamLocationPath, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v2/webhooks/%s/deliveries/%s/redeliver", pathParam0, pathParam1)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetMetricsRequest generates requests for GetMetrics
+func NewGetMetricsRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/metrics")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
+	for _, r := range c.RequestEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	for _, r := range additionalEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClientWithResponses builds on ClientInterface to offer response payloads
+type ClientWithResponses struct {
+	ClientInterface
+}
+
+// NewClientWithResponses creates a new ClientWithResponses, which wraps
+// Client with return type handling
+func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
+	client, err := NewClient(server, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientWithResponses{client}, nil
+}
+
+// WithBaseURL overrides the baseURL.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		newBaseURL, err := url.Parse(baseURL)
+		if err != nil {
+			return err
+		}
+		c.Server = newBaseURL.String()
+		return nil
+	}
+}
+
+// ClientWithResponsesInterface is the interface specification for the client with responses above.
+type ClientWithResponsesInterface interface {
+	// GetDocsWithResponse request
+	GetDocsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetDocsResponse, error)
+
+	// HealthCheckWithResponse request
+	HealthCheckWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*HealthCheckResponse, error)
+
+	// GetOpenAPIWithResponse request
+	GetOpenAPIWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetOpenAPIResponse, error)
+
+	// ListOrdersWithResponse request
+	ListOrdersWithResponse(ctx context.Context, params *ListOrdersParams, reqEditors ...RequestEditorFn) (*ListOrdersResponse, error)
+
+	// GetOrderWithResponse request
+	GetOrderWithResponse(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*GetOrderResponse, error)
+
+	// CancelOrderWithResponse request
+	CancelOrderWithResponse(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*CancelOrderResponse, error)
+
+	// ProcessOrderWithBodyWithResponse request with any body
+	ProcessOrderWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*ProcessOrderResponse, error)
+
+	ProcessOrderWithResponse(ctx context.Context, body ProcessOrderJSONRequestBody, reqEditors ...RequestEditorFn) (*ProcessOrderResponse, error)
+
+	// ListOrdersV2WithResponse request
+	ListOrdersV2WithResponse(ctx context.Context, params *ListOrdersV2Params, reqEditors ...RequestEditorFn) (*ListOrdersV2Response, error)
+
+	// PlaceOrderV2WithBodyWithResponse request with any body
+	PlaceOrderV2WithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PlaceOrderV2Response, error)
+
+	PlaceOrderV2WithResponse(ctx context.Context, body PlaceOrderV2JSONRequestBody, reqEditors ...RequestEditorFn) (*PlaceOrderV2Response, error)
+
+	// GetOrderV2WithResponse request
+	GetOrderV2WithResponse(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*GetOrderV2Response, error)
+
+	// CancelOrderV2WithResponse request
+	CancelOrderV2WithResponse(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*CancelOrderV2Response, error)
+
+	// GetProductV2WithResponse request
+	GetProductV2WithResponse(ctx context.Context, productId ProductID, reqEditors ...RequestEditorFn) (*GetProductV2Response, error)
+
+	// ListStockMovementsV2WithResponse request
+	ListStockMovementsV2WithResponse(ctx context.Context, productId ProductID, reqEditors ...RequestEditorFn) (*ListStockMovementsV2Response, error)
+
+	// PutReorderPolicyV2WithBodyWithResponse request with any body
+	PutReorderPolicyV2WithBodyWithResponse(ctx context.Context, productId ProductID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutReorderPolicyV2Response, error)
+
+	PutReorderPolicyV2WithResponse(ctx context.Context, productId ProductID, body PutReorderPolicyV2JSONRequestBody, reqEditors ...RequestEditorFn) (*PutReorderPolicyV2Response, error)
+
+	// AdjustStockV2WithBodyWithResponse request with any body
+	AdjustStockV2WithBodyWithResponse(ctx context.Context, productId ProductID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*AdjustStockV2Response, error)
+
+	AdjustStockV2WithResponse(ctx context.Context, productId ProductID, body AdjustStockV2JSONRequestBody, reqEditors ...RequestEditorFn) (*AdjustStockV2Response, error)
+
+	// ReorderSuggestionsV2WithResponse request
+	ReorderSuggestionsV2WithResponse(ctx context.Context, params *ReorderSuggestionsV2Params, reqEditors ...RequestEditorFn) (*ReorderSuggestionsV2Response, error)
+
+	// UnsubscribePageV2WithResponse request
+	UnsubscribePageV2WithResponse(ctx context.Context, params *UnsubscribePageV2Params, reqEditors ...RequestEditorFn) (*UnsubscribePageV2Response, error)
+
+	// UnsubscribeV2WithBodyWithResponse request with any body
+	UnsubscribeV2WithBodyWithResponse(ctx context.Context, params *UnsubscribeV2Params, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*UnsubscribeV2Response, error)
+
+	UnsubscribeV2WithFormdataBodyWithResponse(ctx context.Context, params *UnsubscribeV2Params, body UnsubscribeV2FormdataRequestBody, reqEditors ...RequestEditorFn) (*UnsubscribeV2Response, error)
+
+	// GetUserContactV2WithResponse request
+	GetUserContactV2WithResponse(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*GetUserContactV2Response, error)
+
+	// PutUserContactV2WithBodyWithResponse request with any body
+	PutUserContactV2WithBodyWithResponse(ctx context.Context, userId UserID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutUserContactV2Response, error)
+
+	PutUserContactV2WithResponse(ctx context.Context, userId UserID, body PutUserContactV2JSONRequestBody, reqEditors ...RequestEditorFn) (*PutUserContactV2Response, error)
+
+	// ListUserInboxV2WithResponse request
+	ListUserInboxV2WithResponse(ctx context.Context, userId UserID, params *ListUserInboxV2Params, reqEditors ...RequestEditorFn) (*ListUserInboxV2Response, error)
+
+	// MarkUserInboxReadV2WithResponse request
+	MarkUserInboxReadV2WithResponse(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*MarkUserInboxReadV2Response, error)
+
+	// StreamUserInboxV2WithResponse request
+	StreamUserInboxV2WithResponse(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*StreamUserInboxV2Response, error)
+
+	// GetUserInboxUnreadCountV2WithResponse request
+	GetUserInboxUnreadCountV2WithResponse(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*GetUserInboxUnreadCountV2Response, error)
+
+	// ArchiveInboxItemV2WithResponse request
+	ArchiveInboxItemV2WithResponse(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*ArchiveInboxItemV2Response, error)
+
+	// ReadInboxItemV2WithResponse request
+	ReadInboxItemV2WithResponse(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*ReadInboxItemV2Response, error)
+
+	// UnarchiveInboxItemV2WithResponse request
+	UnarchiveInboxItemV2WithResponse(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*UnarchiveInboxItemV2Response, error)
+
+	// UnreadInboxItemV2WithResponse request
+	UnreadInboxItemV2WithResponse(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*UnreadInboxItemV2Response, error)
+
+	// ListUserNotificationsV2WithResponse request
+	ListUserNotificationsV2WithResponse(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*ListUserNotificationsV2Response, error)
+
+	// GetUserPreferencesV2WithResponse request
+	GetUserPreferencesV2WithResponse(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*GetUserPreferencesV2Response, error)
+
+	// PutUserPreferencesV2WithBodyWithResponse request with any body
+	PutUserPreferencesV2WithBodyWithResponse(ctx context.Context, userId UserID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutUserPreferencesV2Response, error)
+
+	PutUserPreferencesV2WithResponse(ctx context.Context, userId UserID, body PutUserPreferencesV2JSONRequestBody, reqEditors ...RequestEditorFn) (*PutUserPreferencesV2Response, error)
+
+	// ListWebhooksV2WithResponse request
+	ListWebhooksV2WithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListWebhooksV2Response, error)
+
+	// CreateWebhookV2WithBodyWithResponse request with any body
+	CreateWebhookV2WithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*CreateWebhookV2Response, error)
+
+	CreateWebhookV2WithResponse(ctx context.Context, body CreateWebhookV2JSONRequestBody, reqEditors ...RequestEditorFn) (*CreateWebhookV2Response, error)
+
+	// DeleteWebhookV2WithResponse request
+	DeleteWebhookV2WithResponse(ctx context.Context, webhookId WebhookID, reqEditors ...RequestEditorFn) (*DeleteWebhookV2Response, error)
+
+	// PutWebhookV2WithBodyWithResponse request with any body
+	PutWebhookV2WithBodyWithResponse(ctx context.Context, webhookId WebhookID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutWebhookV2Response, error)
+
+	PutWebhookV2WithResponse(ctx context.Context, webhookId WebhookID, body PutWebhookV2JSONRequestBody, reqEditors ...RequestEditorFn) (*PutWebhookV2Response, error)
+
+	// ListWebhookDeliveriesV2WithResponse request
+	ListWebhookDeliveriesV2WithResponse(ctx context.Context, webhookId WebhookID, reqEditors ...RequestEditorFn) (*ListWebhookDeliveriesV2Response, error)
+
+	// RedeliverWebhookV2WithResponse request
+	RedeliverWebhookV2WithResponse(ctx context.Context, webhookId WebhookID, deliveryId DeliveryID, reqEditors ...RequestEditorFn) (*RedeliverWebhookV2Response, error)
+
+	// GetMetricsWithResponse request
+	GetMetricsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetMetricsResponse, error)
+}
+
+type GetDocsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r GetDocsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetDocsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type HealthCheckResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]HealthEntry
+}
+
+// Status returns HTTPResponse.Status
+func (r HealthCheckResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r HealthCheckResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetOpenAPIResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *map[string]interface{}
+}
+
+// Status returns HTTPResponse.Status
+func (r GetOpenAPIResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetOpenAPIResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListOrdersResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *OrderList
+}
+
+// Status returns HTTPResponse.Status
+func (r ListOrdersResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListOrdersResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetOrderResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Order
+}
+
+// Status returns HTTPResponse.Status
+func (r GetOrderResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetOrderResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type CancelOrderResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Order
+}
+
+// Status returns HTTPResponse.Status
+func (r CancelOrderResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r CancelOrderResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ProcessOrderResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *OrderProcessed
+}
+
+// Status returns HTTPResponse.Status
+func (r ProcessOrderResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ProcessOrderResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListOrdersV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *OrderList
+}
+
+// Status returns HTTPResponse.Status
+func (r ListOrdersV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListOrdersV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type PlaceOrderV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON201      *PlacedOrder
+}
+
+// Status returns HTTPResponse.Status
+func (r PlaceOrderV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PlaceOrderV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetOrderV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Order
+}
+
+// Status returns HTTPResponse.Status
+func (r GetOrderV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetOrderV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type CancelOrderV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Order
+}
+
+// Status returns HTTPResponse.Status
+func (r CancelOrderV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r CancelOrderV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetProductV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Product
+}
+
+// Status returns HTTPResponse.Status
+func (r GetProductV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetProductV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListStockMovementsV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *MovementList
+}
+
+// Status returns HTTPResponse.Status
+func (r ListStockMovementsV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListStockMovementsV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type PutReorderPolicyV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Product
+}
+
+// Status returns HTTPResponse.Status
+func (r PutReorderPolicyV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PutReorderPolicyV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type AdjustStockV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *Product
+}
+
+// Status returns HTTPResponse.Status
+func (r AdjustStockV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r AdjustStockV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ReorderSuggestionsV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ReorderReport
+}
+
+// Status returns HTTPResponse.Status
+func (r ReorderSuggestionsV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ReorderSuggestionsV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type UnsubscribePageV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r UnsubscribePageV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r UnsubscribePageV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type UnsubscribeV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r UnsubscribeV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r UnsubscribeV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetUserContactV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ContactDetails
+}
+
+// Status returns HTTPResponse.Status
+func (r GetUserContactV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetUserContactV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type PutUserContactV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *ContactDetails
+}
+
+// Status returns HTTPResponse.Status
+func (r PutUserContactV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PutUserContactV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListUserInboxV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *InboxList
+}
+
+// Status returns HTTPResponse.Status
+func (r ListUserInboxV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListUserInboxV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type MarkUserInboxReadV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *InboxUnreadCount
+}
+
+// Status returns HTTPResponse.Status
+func (r MarkUserInboxReadV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r MarkUserInboxReadV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type StreamUserInboxV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r StreamUserInboxV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r StreamUserInboxV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetUserInboxUnreadCountV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *InboxUnreadCount
+}
+
+// Status returns HTTPResponse.Status
+func (r GetUserInboxUnreadCountV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetUserInboxUnreadCountV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ArchiveInboxItemV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *InboxItem
+}
+
+// Status returns HTTPResponse.Status
+func (r ArchiveInboxItemV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ArchiveInboxItemV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ReadInboxItemV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *InboxItem
+}
+
+// Status returns HTTPResponse.Status
+func (r ReadInboxItemV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ReadInboxItemV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type UnarchiveInboxItemV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *InboxItem
+}
+
+// Status returns HTTPResponse.Status
+func (r UnarchiveInboxItemV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r UnarchiveInboxItemV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type UnreadInboxItemV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *InboxItem
+}
+
+// Status returns HTTPResponse.Status
+func (r UnreadInboxItemV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r UnreadInboxItemV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListUserNotificationsV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *NotificationHistory
+}
+
+// Status returns HTTPResponse.Status
+func (r ListUserNotificationsV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListUserNotificationsV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetUserPreferencesV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *PreferencesDetails
+}
+
+// Status returns HTTPResponse.Status
+func (r GetUserPreferencesV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetUserPreferencesV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type PutUserPreferencesV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *PreferencesDetails
+}
+
+// Status returns HTTPResponse.Status
+func (r PutUserPreferencesV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PutUserPreferencesV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListWebhooksV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *WebhookList
+}
+
+// Status returns HTTPResponse.Status
+func (r ListWebhooksV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListWebhooksV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type CreateWebhookV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON201      *WebhookDetails
+}
+
+// Status returns HTTPResponse.Status
+func (r CreateWebhookV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r CreateWebhookV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type DeleteWebhookV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r DeleteWebhookV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DeleteWebhookV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type PutWebhookV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *WebhookDetails
+}
+
+// Status returns HTTPResponse.Status
+func (r PutWebhookV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PutWebhookV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type ListWebhookDeliveriesV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *WebhookDeliveryList
+}
+
+// Status returns HTTPResponse.Status
+func (r ListWebhookDeliveriesV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListWebhookDeliveriesV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type RedeliverWebhookV2Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON202      *WebhookDelivery
+}
+
+// Status returns HTTPResponse.Status
+func (r RedeliverWebhookV2Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r RedeliverWebhookV2Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetMetricsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r GetMetricsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetMetricsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+// GetDocsWithResponse request returning *GetDocsResponse
+func (c *ClientWithResponses) GetDocsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetDocsResponse, error) {
+	rsp, err := c.GetDocs(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetDocsResponse(rsp)
+}
+
+// HealthCheckWithResponse request returning *HealthCheckResponse
+func (c *ClientWithResponses) HealthCheckWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*HealthCheckResponse, error) {
+	rsp, err := c.HealthCheck(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseHealthCheckResponse(rsp)
+}
+
+// GetOpenAPIWithResponse request returning *GetOpenAPIResponse
+func (c *ClientWithResponses) GetOpenAPIWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetOpenAPIResponse, error) {
+	rsp, err := c.GetOpenAPI(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetOpenAPIResponse(rsp)
+}
+
+// ListOrdersWithResponse request returning *ListOrdersResponse
+func (c *ClientWithResponses) ListOrdersWithResponse(ctx context.Context, params *ListOrdersParams, reqEditors ...RequestEditorFn) (*ListOrdersResponse, error) {
+	rsp, err := c.ListOrders(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListOrdersResponse(rsp)
+}
+
+// GetOrderWithResponse request returning *GetOrderResponse
+func (c *ClientWithResponses) GetOrderWithResponse(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*GetOrderResponse, error) {
+	rsp, err := c.GetOrder(ctx, orderId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetOrderResponse(rsp)
+}
+
+// CancelOrderWithResponse request returning *CancelOrderResponse
+func (c *ClientWithResponses) CancelOrderWithResponse(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*CancelOrderResponse, error) {
+	rsp, err := c.CancelOrder(ctx, orderId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCancelOrderResponse(rsp)
+}
+
+// ProcessOrderWithBodyWithResponse request with arbitrary body returning *ProcessOrderResponse
+func (c *ClientWithResponses) ProcessOrderWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*ProcessOrderResponse, error) {
+	rsp, err := c.ProcessOrderWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseProcessOrderResponse(rsp)
+}
+
+func (c *ClientWithResponses) ProcessOrderWithResponse(ctx context.Context, body ProcessOrderJSONRequestBody, reqEditors ...RequestEditorFn) (*ProcessOrderResponse, error) {
+	rsp, err := c.ProcessOrder(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseProcessOrderResponse(rsp)
+}
+
+// ListOrdersV2WithResponse request returning *ListOrdersV2Response
+func (c *ClientWithResponses) ListOrdersV2WithResponse(ctx context.Context, params *ListOrdersV2Params, reqEditors ...RequestEditorFn) (*ListOrdersV2Response, error) {
+	rsp, err := c.ListOrdersV2(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListOrdersV2Response(rsp)
+}
+
+// PlaceOrderV2WithBodyWithResponse request with arbitrary body returning *PlaceOrderV2Response
+func (c *ClientWithResponses) PlaceOrderV2WithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PlaceOrderV2Response, error) {
+	rsp, err := c.PlaceOrderV2WithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePlaceOrderV2Response(rsp)
+}
+
+func (c *ClientWithResponses) PlaceOrderV2WithResponse(ctx context.Context, body PlaceOrderV2JSONRequestBody, reqEditors ...RequestEditorFn) (*PlaceOrderV2Response, error) {
+	rsp, err := c.PlaceOrderV2(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePlaceOrderV2Response(rsp)
+}
+
+// GetOrderV2WithResponse request returning *GetOrderV2Response
+func (c *ClientWithResponses) GetOrderV2WithResponse(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*GetOrderV2Response, error) {
+	rsp, err := c.GetOrderV2(ctx, orderId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetOrderV2Response(rsp)
+}
+
+// CancelOrderV2WithResponse request returning *CancelOrderV2Response
+func (c *ClientWithResponses) CancelOrderV2WithResponse(ctx context.Context, orderId OrderID, reqEditors ...RequestEditorFn) (*CancelOrderV2Response, error) {
+	rsp, err := c.CancelOrderV2(ctx, orderId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCancelOrderV2Response(rsp)
+}
+
+// GetProductV2WithResponse request returning *GetProductV2Response
+func (c *ClientWithResponses) GetProductV2WithResponse(ctx context.Context, productId ProductID, reqEditors ...RequestEditorFn) (*GetProductV2Response, error) {
+	rsp, err := c.GetProductV2(ctx, productId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetProductV2Response(rsp)
+}
+
+// ListStockMovementsV2WithResponse request returning *ListStockMovementsV2Response
+func (c *ClientWithResponses) ListStockMovementsV2WithResponse(ctx context.Context, productId ProductID, reqEditors ...RequestEditorFn) (*ListStockMovementsV2Response, error) {
+	rsp, err := c.ListStockMovementsV2(ctx, productId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListStockMovementsV2Response(rsp)
+}
+
+// PutReorderPolicyV2WithBodyWithResponse request with arbitrary body returning *PutReorderPolicyV2Response
+func (c *ClientWithResponses) PutReorderPolicyV2WithBodyWithResponse(ctx context.Context, productId ProductID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutReorderPolicyV2Response, error) {
+	rsp, err := c.PutReorderPolicyV2WithBody(ctx, productId, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutReorderPolicyV2Response(rsp)
+}
+
+func (c *ClientWithResponses) PutReorderPolicyV2WithResponse(ctx context.Context, productId ProductID, body PutReorderPolicyV2JSONRequestBody, reqEditors ...RequestEditorFn) (*PutReorderPolicyV2Response, error) {
+	rsp, err := c.PutReorderPolicyV2(ctx, productId, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutReorderPolicyV2Response(rsp)
+}
+
+// AdjustStockV2WithBodyWithResponse request with arbitrary body returning *AdjustStockV2Response
+func (c *ClientWithResponses) AdjustStockV2WithBodyWithResponse(ctx context.Context, productId ProductID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*AdjustStockV2Response, error) {
+	rsp, err := c.AdjustStockV2WithBody(ctx, productId, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAdjustStockV2Response(rsp)
+}
+
+func (c *ClientWithResponses) AdjustStockV2WithResponse(ctx context.Context, productId ProductID, body AdjustStockV2JSONRequestBody, reqEditors ...RequestEditorFn) (*AdjustStockV2Response, error) {
+	rsp, err := c.AdjustStockV2(ctx, productId, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAdjustStockV2Response(rsp)
+}
+
+// ReorderSuggestionsV2WithResponse request returning *ReorderSuggestionsV2Response
+func (c *ClientWithResponses) ReorderSuggestionsV2WithResponse(ctx context.Context, params *ReorderSuggestionsV2Params, reqEditors ...RequestEditorFn) (*ReorderSuggestionsV2Response, error) {
+	rsp, err := c.ReorderSuggestionsV2(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReorderSuggestionsV2Response(rsp)
+}
+
+// UnsubscribePageV2WithResponse request returning *UnsubscribePageV2Response
+func (c *ClientWithResponses) UnsubscribePageV2WithResponse(ctx context.Context, params *UnsubscribePageV2Params, reqEditors ...RequestEditorFn) (*UnsubscribePageV2Response, error) {
+	rsp, err := c.UnsubscribePageV2(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUnsubscribePageV2Response(rsp)
+}
+
+// UnsubscribeV2WithBodyWithResponse request with arbitrary body returning *UnsubscribeV2Response
+func (c *ClientWithResponses) UnsubscribeV2WithBodyWithResponse(ctx context.Context, params *UnsubscribeV2Params, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*UnsubscribeV2Response, error) {
+	rsp, err := c.UnsubscribeV2WithBody(ctx, params, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUnsubscribeV2Response(rsp)
+}
+
+func (c *ClientWithResponses) UnsubscribeV2WithFormdataBodyWithResponse(ctx context.Context, params *UnsubscribeV2Params, body UnsubscribeV2FormdataRequestBody, reqEditors ...RequestEditorFn) (*UnsubscribeV2Response, error) {
+	rsp, err := c.UnsubscribeV2WithFormdataBody(ctx, params, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUnsubscribeV2Response(rsp)
+}
+
+// GetUserContactV2WithResponse request returning *GetUserContactV2Response
+func (c *ClientWithResponses) GetUserContactV2WithResponse(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*GetUserContactV2Response, error) {
+	rsp, err := c.GetUserContactV2(ctx, userId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetUserContactV2Response(rsp)
+}
+
+// PutUserContactV2WithBodyWithResponse request with arbitrary body returning *PutUserContactV2Response
+func (c *ClientWithResponses) PutUserContactV2WithBodyWithResponse(ctx context.Context, userId UserID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutUserContactV2Response, error) {
+	rsp, err := c.PutUserContactV2WithBody(ctx, userId, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutUserContactV2Response(rsp)
+}
+
+func (c *ClientWithResponses) PutUserContactV2WithResponse(ctx context.Context, userId UserID, body PutUserContactV2JSONRequestBody, reqEditors ...RequestEditorFn) (*PutUserContactV2Response, error) {
+	rsp, err := c.PutUserContactV2(ctx, userId, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutUserContactV2Response(rsp)
+}
+
+// ListUserInboxV2WithResponse request returning *ListUserInboxV2Response
+func (c *ClientWithResponses) ListUserInboxV2WithResponse(ctx context.Context, userId UserID, params *ListUserInboxV2Params, reqEditors ...RequestEditorFn) (*ListUserInboxV2Response, error) {
+	rsp, err := c.ListUserInboxV2(ctx, userId, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListUserInboxV2Response(rsp)
+}
+
+// MarkUserInboxReadV2WithResponse request returning *MarkUserInboxReadV2Response
+func (c *ClientWithResponses) MarkUserInboxReadV2WithResponse(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*MarkUserInboxReadV2Response, error) {
+	rsp, err := c.MarkUserInboxReadV2(ctx, userId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMarkUserInboxReadV2Response(rsp)
+}
+
+// StreamUserInboxV2WithResponse request returning *StreamUserInboxV2Response
+func (c *ClientWithResponses) StreamUserInboxV2WithResponse(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*StreamUserInboxV2Response, error) {
+	rsp, err := c.StreamUserInboxV2(ctx, userId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStreamUserInboxV2Response(rsp)
+}
+
+// GetUserInboxUnreadCountV2WithResponse request returning *GetUserInboxUnreadCountV2Response
+func (c *ClientWithResponses) GetUserInboxUnreadCountV2WithResponse(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*GetUserInboxUnreadCountV2Response, error) {
+	rsp, err := c.GetUserInboxUnreadCountV2(ctx, userId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetUserInboxUnreadCountV2Response(rsp)
+}
+
+// ArchiveInboxItemV2WithResponse request returning *ArchiveInboxItemV2Response
+func (c *ClientWithResponses) ArchiveInboxItemV2WithResponse(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*ArchiveInboxItemV2Response, error) {
+	rsp, err := c.ArchiveInboxItemV2(ctx, userId, itemId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseArchiveInboxItemV2Response(rsp)
+}
+
+// ReadInboxItemV2WithResponse request returning *ReadInboxItemV2Response
+func (c *ClientWithResponses) ReadInboxItemV2WithResponse(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*ReadInboxItemV2Response, error) {
+	rsp, err := c.ReadInboxItemV2(ctx, userId, itemId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReadInboxItemV2Response(rsp)
+}
+
+// UnarchiveInboxItemV2WithResponse request returning *UnarchiveInboxItemV2Response
+func (c *ClientWithResponses) UnarchiveInboxItemV2WithResponse(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*UnarchiveInboxItemV2Response, error) {
+	rsp, err := c.UnarchiveInboxItemV2(ctx, userId, itemId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUnarchiveInboxItemV2Response(rsp)
+}
+
+// UnreadInboxItemV2WithResponse request returning *UnreadInboxItemV2Response
+func (c *ClientWithResponses) UnreadInboxItemV2WithResponse(ctx context.Context, userId UserID, itemId ItemID, reqEditors ...RequestEditorFn) (*UnreadInboxItemV2Response, error) {
+	rsp, err := c.UnreadInboxItemV2(ctx, userId, itemId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUnreadInboxItemV2Response(rsp)
+}
+
+// ListUserNotificationsV2WithResponse request returning *ListUserNotificationsV2Response
+func (c *ClientWithResponses) ListUserNotificationsV2WithResponse(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*ListUserNotificationsV2Response, error) {
+	rsp, err := c.ListUserNotificationsV2(ctx, userId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListUserNotificationsV2Response(rsp)
+}
+
+// GetUserPreferencesV2WithResponse request returning *GetUserPreferencesV2Response
+func (c *ClientWithResponses) GetUserPreferencesV2WithResponse(ctx context.Context, userId UserID, reqEditors ...RequestEditorFn) (*GetUserPreferencesV2Response, error) {
+	rsp, err := c.GetUserPreferencesV2(ctx, userId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetUserPreferencesV2Response(rsp)
+}
+
+// PutUserPreferencesV2WithBodyWithResponse request with arbitrary body returning *PutUserPreferencesV2Response
+func (c *ClientWithResponses) PutUserPreferencesV2WithBodyWithResponse(ctx context.Context, userId UserID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutUserPreferencesV2Response, error) {
+	rsp, err := c.PutUserPreferencesV2WithBody(ctx, userId, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutUserPreferencesV2Response(rsp)
+}
+
+func (c *ClientWithResponses) PutUserPreferencesV2WithResponse(ctx context.Context, userId UserID, body PutUserPreferencesV2JSONRequestBody, reqEditors ...RequestEditorFn) (*PutUserPreferencesV2Response, error) {
+	rsp, err := c.PutUserPreferencesV2(ctx, userId, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutUserPreferencesV2Response(rsp)
+}
+
+// ListWebhooksV2WithResponse request returning *ListWebhooksV2Response
+func (c *ClientWithResponses) ListWebhooksV2WithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*ListWebhooksV2Response, error) {
+	rsp, err := c.ListWebhooksV2(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListWebhooksV2Response(rsp)
+}
+
+// CreateWebhookV2WithBodyWithResponse request with arbitrary body returning *CreateWebhookV2Response
+func (c *ClientWithResponses) CreateWebhookV2WithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*CreateWebhookV2Response, error) {
+	rsp, err := c.CreateWebhookV2WithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCreateWebhookV2Response(rsp)
+}
+
+func (c *ClientWithResponses) CreateWebhookV2WithResponse(ctx context.Context, body CreateWebhookV2JSONRequestBody, reqEditors ...RequestEditorFn) (*CreateWebhookV2Response, error) {
+	rsp, err := c.CreateWebhookV2(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCreateWebhookV2Response(rsp)
+}
+
+// DeleteWebhookV2WithResponse request returning *DeleteWebhookV2Response
+func (c *ClientWithResponses) DeleteWebhookV2WithResponse(ctx context.Context, webhookId WebhookID, reqEditors ...RequestEditorFn) (*DeleteWebhookV2Response, error) {
+	rsp, err := c.DeleteWebhookV2(ctx, webhookId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDeleteWebhookV2Response(rsp)
+}
+
+// PutWebhookV2WithBodyWithResponse request with arbitrary body returning *PutWebhookV2Response
+func (c *ClientWithResponses) PutWebhookV2WithBodyWithResponse(ctx context.Context, webhookId WebhookID, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutWebhookV2Response, error) {
+	rsp, err := c.PutWebhookV2WithBody(ctx, webhookId, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutWebhookV2Response(rsp)
+}
+
+func (c *ClientWithResponses) PutWebhookV2WithResponse(ctx context.Context, webhookId WebhookID, body PutWebhookV2JSONRequestBody, reqEditors ...RequestEditorFn) (*PutWebhookV2Response, error) {
+	rsp, err := c.PutWebhookV2(ctx, webhookId, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutWebhookV2Response(rsp)
+}
+
+// ListWebhookDeliveriesV2WithResponse request returning *ListWebhookDeliveriesV2Response
+func (c *ClientWithResponses) ListWebhookDeliveriesV2WithResponse(ctx context.Context, webhookId WebhookID, reqEditors ...RequestEditorFn) (*ListWebhookDeliveriesV2Response, error) {
+	rsp, err := c.ListWebhookDeliveriesV2(ctx, webhookId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListWebhookDeliveriesV2Response(rsp)
+}
+
+// RedeliverWebhookV2WithResponse request returning *RedeliverWebhookV2Response
+func (c *ClientWithResponses) RedeliverWebhookV2WithResponse(ctx context.Context, webhookId WebhookID, deliveryId DeliveryID, reqEditors ...RequestEditorFn) (*RedeliverWebhookV2Response, error) {
+	rsp, err := c.RedeliverWebhookV2(ctx, webhookId, deliveryId, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRedeliverWebhookV2Response(rsp)
+}
+
+// GetMetricsWithResponse request returning *GetMetricsResponse
+func (c *ClientWithResponses) GetMetricsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetMetricsResponse, error) {
+	rsp, err := c.GetMetrics(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetMetricsResponse(rsp)
+}
+
+// ParseGetDocsResponse parses an HTTP response from a GetDocsWithResponse call
+func ParseGetDocsResponse(rsp *http.Response) (*GetDocsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetDocsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseHealthCheckResponse parses an HTTP response from a HealthCheckWithResponse call
+func ParseHealthCheckResponse(rsp *http.Response) (*HealthCheckResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &HealthCheckResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []HealthEntry
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetOpenAPIResponse parses an HTTP response from a GetOpenAPIWithResponse call
+func ParseGetOpenAPIResponse(rsp *http.Response) (*GetOpenAPIResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetOpenAPIResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest map[string]interface{}
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseListOrdersResponse parses an HTTP response from a ListOrdersWithResponse call
+func ParseListOrdersResponse(rsp *http.Response) (*ListOrdersResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListOrdersResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest OrderList
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetOrderResponse parses an HTTP response from a GetOrderWithResponse call
+func ParseGetOrderResponse(rsp *http.Response) (*GetOrderResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetOrderResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Order
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseCancelOrderResponse parses an HTTP response from a CancelOrderWithResponse call
+func ParseCancelOrderResponse(rsp *http.Response) (*CancelOrderResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &CancelOrderResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Order
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseProcessOrderResponse parses an HTTP response from a ProcessOrderWithResponse call
+func ParseProcessOrderResponse(rsp *http.Response) (*ProcessOrderResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ProcessOrderResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest OrderProcessed
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseListOrdersV2Response parses an HTTP response from a ListOrdersV2WithResponse call
+func ParseListOrdersV2Response(rsp *http.Response) (*ListOrdersV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListOrdersV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest OrderList
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParsePlaceOrderV2Response parses an HTTP response from a PlaceOrderV2WithResponse call
+func ParsePlaceOrderV2Response(rsp *http.Response) (*PlaceOrderV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PlaceOrderV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 201:
+		var dest PlacedOrder
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON201 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetOrderV2Response parses an HTTP response from a GetOrderV2WithResponse call
+func ParseGetOrderV2Response(rsp *http.Response) (*GetOrderV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetOrderV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Order
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseCancelOrderV2Response parses an HTTP response from a CancelOrderV2WithResponse call
+func ParseCancelOrderV2Response(rsp *http.Response) (*CancelOrderV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &CancelOrderV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Order
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetProductV2Response parses an HTTP response from a GetProductV2WithResponse call
+func ParseGetProductV2Response(rsp *http.Response) (*GetProductV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetProductV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Product
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseListStockMovementsV2Response parses an HTTP response from a ListStockMovementsV2WithResponse call
+func ParseListStockMovementsV2Response(rsp *http.Response) (*ListStockMovementsV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListStockMovementsV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest MovementList
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParsePutReorderPolicyV2Response parses an HTTP response from a PutReorderPolicyV2WithResponse call
+func ParsePutReorderPolicyV2Response(rsp *http.Response) (*PutReorderPolicyV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PutReorderPolicyV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Product
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseAdjustStockV2Response parses an HTTP response from a AdjustStockV2WithResponse call
+func ParseAdjustStockV2Response(rsp *http.Response) (*AdjustStockV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &AdjustStockV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest Product
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseReorderSuggestionsV2Response parses an HTTP response from a ReorderSuggestionsV2WithResponse call
+func ParseReorderSuggestionsV2Response(rsp *http.Response) (*ReorderSuggestionsV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ReorderSuggestionsV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ReorderReport
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseUnsubscribePageV2Response parses an HTTP response from a UnsubscribePageV2WithResponse call
+func ParseUnsubscribePageV2Response(rsp *http.Response) (*UnsubscribePageV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &UnsubscribePageV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseUnsubscribeV2Response parses an HTTP response from a UnsubscribeV2WithResponse call
+func ParseUnsubscribeV2Response(rsp *http.Response) (*UnsubscribeV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &UnsubscribeV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseGetUserContactV2Response parses an HTTP response from a GetUserContactV2WithResponse call
+func ParseGetUserContactV2Response(rsp *http.Response) (*GetUserContactV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetUserContactV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ContactDetails
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParsePutUserContactV2Response parses an HTTP response from a PutUserContactV2WithResponse call
+func ParsePutUserContactV2Response(rsp *http.Response) (*PutUserContactV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PutUserContactV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest ContactDetails
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseListUserInboxV2Response parses an HTTP response from a ListUserInboxV2WithResponse call
+func ParseListUserInboxV2Response(rsp *http.Response) (*ListUserInboxV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListUserInboxV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest InboxList
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseMarkUserInboxReadV2Response parses an HTTP response from a MarkUserInboxReadV2WithResponse call
+func ParseMarkUserInboxReadV2Response(rsp *http.Response) (*MarkUserInboxReadV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &MarkUserInboxReadV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest InboxUnreadCount
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseStreamUserInboxV2Response parses an HTTP response from a StreamUserInboxV2WithResponse call
+func ParseStreamUserInboxV2Response(rsp *http.Response) (*StreamUserInboxV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &StreamUserInboxV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseGetUserInboxUnreadCountV2Response parses an HTTP response from a GetUserInboxUnreadCountV2WithResponse call
+func ParseGetUserInboxUnreadCountV2Response(rsp *http.Response) (*GetUserInboxUnreadCountV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetUserInboxUnreadCountV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest InboxUnreadCount
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseArchiveInboxItemV2Response parses an HTTP response from a ArchiveInboxItemV2WithResponse call
+func ParseArchiveInboxItemV2Response(rsp *http.Response) (*ArchiveInboxItemV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ArchiveInboxItemV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest InboxItem
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseReadInboxItemV2Response parses an HTTP response from a ReadInboxItemV2WithResponse call
+func ParseReadInboxItemV2Response(rsp *http.Response) (*ReadInboxItemV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ReadInboxItemV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest InboxItem
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseUnarchiveInboxItemV2Response parses an HTTP response from a UnarchiveInboxItemV2WithResponse call
+func ParseUnarchiveInboxItemV2Response(rsp *http.Response) (*UnarchiveInboxItemV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &UnarchiveInboxItemV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest InboxItem
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseUnreadInboxItemV2Response parses an HTTP response from a UnreadInboxItemV2WithResponse call
+func ParseUnreadInboxItemV2Response(rsp *http.Response) (*UnreadInboxItemV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &UnreadInboxItemV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest InboxItem
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseListUserNotificationsV2Response parses an HTTP response from a ListUserNotificationsV2WithResponse call
+func ParseListUserNotificationsV2Response(rsp *http.Response) (*ListUserNotificationsV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListUserNotificationsV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest NotificationHistory
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetUserPreferencesV2Response parses an HTTP response from a GetUserPreferencesV2WithResponse call
+func ParseGetUserPreferencesV2Response(rsp *http.Response) (*GetUserPreferencesV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetUserPreferencesV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest PreferencesDetails
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParsePutUserPreferencesV2Response parses an HTTP response from a PutUserPreferencesV2WithResponse call
+func ParsePutUserPreferencesV2Response(rsp *http.Response) (*PutUserPreferencesV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PutUserPreferencesV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest PreferencesDetails
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseListWebhooksV2Response parses an HTTP response from a ListWebhooksV2WithResponse call
+func ParseListWebhooksV2Response(rsp *http.Response) (*ListWebhooksV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListWebhooksV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest WebhookList
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseCreateWebhookV2Response parses an HTTP response from a CreateWebhookV2WithResponse call
+func ParseCreateWebhookV2Response(rsp *http.Response) (*CreateWebhookV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &CreateWebhookV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 201:
+		var dest WebhookDetails
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON201 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseDeleteWebhookV2Response parses an HTTP response from a DeleteWebhookV2WithResponse call
+func ParseDeleteWebhookV2Response(rsp *http.Response) (*DeleteWebhookV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DeleteWebhookV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParsePutWebhookV2Response parses an HTTP response from a PutWebhookV2WithResponse call
+func ParsePutWebhookV2Response(rsp *http.Response) (*PutWebhookV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PutWebhookV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest WebhookDetails
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseListWebhookDeliveriesV2Response parses an HTTP response from a ListWebhookDeliveriesV2WithResponse call
+func ParseListWebhookDeliveriesV2Response(rsp *http.Response) (*ListWebhookDeliveriesV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListWebhookDeliveriesV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest WebhookDeliveryList
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseRedeliverWebhookV2Response parses an HTTP response from a RedeliverWebhookV2WithResponse call
+func ParseRedeliverWebhookV2Response(rsp *http.Response) (*RedeliverWebhookV2Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &RedeliverWebhookV2Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 202:
+		var dest WebhookDelivery
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON202 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetMetricsResponse parses an HTTP response from a GetMetricsWithResponse call
+func ParseGetMetricsResponse(rsp *http.Response) (*GetMetricsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetMetricsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
@@ -0,0 +1,17 @@
+package client
+
+//go:generate oapi-codegen -config oapi-codegen.yaml ../gateway/openapi.json
+
+import (
+	"context"
+	"net/http"
+)
+
+// WithAPIKey sends key in the X-API-Key header of every request, for
+// gateways started with API_KEYS.
+func WithAPIKey(key string) ClientOption {
+	return WithRequestEditorFn(func(_ context.Context, req *http.Request) error {
+		req.Header.Set("X-API-Key", key)
+		return nil
+	})
+}
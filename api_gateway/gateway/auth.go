@@ -0,0 +1,124 @@
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyHeader carries the caller's API key, as an HTTP header and as gRPC
+// metadata.
+const apiKeyHeader = "X-API-Key"
+
+// apiKeys authenticates callers of the REST and gRPC APIs by their API
+// key. With no keys configured every caller is let through.
+type apiKeys struct {
+	keys [][]byte
+}
+
+func newAPIKeys(keys []string) *apiKeys {
+	a := &apiKeys{}
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			a.keys = append(a.keys, []byte(key))
+		}
+	}
+	return a
+}
+
+// check reports whether key may call the API.
+func (a *apiKeys) check(key string) bool {
+	if len(a.keys) == 0 {
+		return true
+	}
+	valid := 0
+	for _, k := range a.keys {
+		valid |= subtle.ConstantTimeCompare(k, []byte(key))
+	}
+	return valid == 1
+}
+
+// authenticated returns ctx carrying key, which check accepted, as the
+// caller's identity. With no keys configured any key is accepted, so none
+// identifies the caller.
+func (a *apiKeys) authenticated(ctx context.Context, key string) context.Context {
+	if len(a.keys) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+type apiKeyKey struct{}
+
+// authenticatedKey returns the API key the caller was authenticated with,
+// or "" if it was not authenticated by one.
+func authenticatedKey(ctx context.Context) string {
+	key, _ := ctx.Value(apiKeyKey{}).(string)
+	return key
+}
+
+// require wraps next so that only callers with a valid API key reach it.
+func (a *apiKeys) require(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(apiKeyHeader)
+		if !a.check(key) {
+			authFailures.WithLabelValues("rest").Inc()
+			w.Header().Set("WWW-Authenticate", apiKeyHeader)
+			http.Error(w, "Missing or invalid API key", http.StatusUnauthorized)
+			return
+		}
+		next(w, r.WithContext(a.authenticated(r.Context(), key)))
+	}
+}
+
+// publicMethod reports whether a gRPC method may be called without an API
+// key. Health checks are public so load balancers can probe the gateway.
+func publicMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/")
+}
+
+// grpcAPIKey returns the API key in the incoming gRPC metadata.
+func grpcAPIKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(apiKeyHeader); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// authorize checks the API key of a call to fullMethod and returns ctx
+// carrying the key it was authenticated with.
+func (a *apiKeys) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	if publicMethod(fullMethod) {
+		return ctx, nil
+	}
+	key := grpcAPIKey(ctx)
+	if !a.check(key) {
+		authFailures.WithLabelValues("grpc").Inc()
+		return ctx, status.Error(codes.Unauthenticated, "missing or invalid API key")
+	}
+	return a.authenticated(ctx, key), nil
+}
+
+// unaryInterceptor rejects unary calls without a valid API key.
+func (a *apiKeys) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor rejects streaming calls without a valid API key.
+func (a *apiKeys) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if _, err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,216 @@
+package gateway
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	errBreakerOpen  = errors.New("circuit breaker is open")
+	errBulkheadFull = errors.New("too many concurrent requests")
+)
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case stateOpen:
+		return "open"
+	case stateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerConfig controls when a breaker opens, how long it stays open and
+// how many calls it lets through concurrently.
+type BreakerConfig struct {
+	FailureThreshold int           // consecutive failures that open the breaker
+	OpenTimeout      time.Duration // how long to fail fast before probing again
+	HalfOpenMax      int           // trial calls allowed while half-open
+	MaxConcurrent    int           // bulkhead size
+}
+
+// circuitBreaker guards calls to one downstream dependency. It combines a
+// classic closed/open/half-open breaker with a bulkhead that caps the number
+// of calls in flight, so a slow dependency cannot tie up the whole gateway.
+type circuitBreaker struct {
+	name string
+	cfg  BreakerConfig
+	sem  chan struct{}
+
+	mu               sync.Mutex
+	state            breakerState
+	failures         int
+	openedAt         time.Time
+	halfOpenInFlight int
+}
+
+func newCircuitBreaker(name string, cfg BreakerConfig) *circuitBreaker {
+	return &circuitBreaker{name: name, cfg: cfg, sem: make(chan struct{}, cfg.MaxConcurrent)}
+}
+
+// call runs fn if the breaker and bulkhead allow it and records the outcome.
+// It returns errBreakerOpen or errBulkheadFull without running fn when the
+// call is rejected.
+func (cb *circuitBreaker) call(fn func() error) error {
+	if err := cb.allow(time.Now()); err != nil {
+		return err
+	}
+	select {
+	case cb.sem <- struct{}{}:
+	default:
+		cb.cancel()
+		return errBulkheadFull
+	}
+	defer func() { <-cb.sem }()
+
+	err := fn()
+	cb.record(err == nil, time.Now())
+	return err
+}
+
+func (cb *circuitBreaker) allow(now time.Time) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == stateOpen {
+		if now.Sub(cb.openedAt) < cb.cfg.OpenTimeout {
+			return errBreakerOpen
+		}
+		cb.state = stateHalfOpen
+		cb.halfOpenInFlight = 0
+	}
+	if cb.state == stateHalfOpen {
+		if cb.halfOpenInFlight >= cb.cfg.HalfOpenMax {
+			return errBreakerOpen
+		}
+		cb.halfOpenInFlight++
+	}
+	return nil
+}
+
+// cancel undoes allow for a call that never reached the dependency.
+func (cb *circuitBreaker) cancel() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == stateHalfOpen && cb.halfOpenInFlight > 0 {
+		cb.halfOpenInFlight--
+	}
+}
+
+func (cb *circuitBreaker) record(success bool, now time.Time) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == stateHalfOpen {
+		cb.halfOpenInFlight--
+	}
+	if success {
+		cb.state = stateClosed
+		cb.failures = 0
+		return
+	}
+	cb.failures++
+	if cb.state == stateHalfOpen || cb.failures >= cb.cfg.FailureThreshold {
+		cb.state = stateOpen
+		cb.openedAt = now
+	}
+}
+
+// retryAfter reports how long an open breaker will keep failing fast.
+func (cb *circuitBreaker) retryAfter(now time.Time) time.Duration {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != stateOpen {
+		return 0
+	}
+	return cb.cfg.OpenTimeout - now.Sub(cb.openedAt)
+}
+
+// BreakerStatus is the view of a breaker reported by the health check.
+type BreakerStatus struct {
+	Name          string `json:"name"`
+	State         string `json:"state"`
+	Failures      int    `json:"failures"`
+	InFlight      int    `json:"in_flight"`
+	MaxConcurrent int    `json:"max_concurrent"`
+}
+
+func (cb *circuitBreaker) status() BreakerStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return BreakerStatus{
+		Name:          cb.name,
+		State:         cb.state.String(),
+		Failures:      cb.failures,
+		InFlight:      len(cb.sem),
+		MaxConcurrent: cb.cfg.MaxConcurrent,
+	}
+}
+
+// breakerSet holds one breaker per downstream queue.
+type breakerSet struct {
+	cfg      BreakerConfig
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+	order    []string
+}
+
+func newBreakerSet(cfg BreakerConfig, names ...string) *breakerSet {
+	bs := &breakerSet{cfg: cfg, breakers: map[string]*circuitBreaker{}}
+	for _, name := range names {
+		bs.get(name)
+	}
+	return bs
+}
+
+func (bs *breakerSet) get(name string) *circuitBreaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	cb, ok := bs.breakers[name]
+	if !ok {
+		cb = newCircuitBreaker(name, bs.cfg)
+		bs.breakers[name] = cb
+		bs.order = append(bs.order, name)
+	}
+	return cb
+}
+
+// call runs fn through the breaker for the named dependency.
+func (bs *breakerSet) call(name string, fn func() error) error {
+	return bs.get(name).call(fn)
+}
+
+func (bs *breakerSet) statuses() []BreakerStatus {
+	bs.mu.Lock()
+	names := append([]string(nil), bs.order...)
+	bs.mu.Unlock()
+
+	statuses := make([]BreakerStatus, 0, len(names))
+	for _, name := range names {
+		statuses = append(statuses, bs.get(name).status())
+	}
+	return statuses
+}
+
+func loadBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: getEnvInt("BREAKER_FAILURE_THRESHOLD", 5),
+		OpenTimeout:      getEnvDuration("BREAKER_OPEN_TIMEOUT", 30*time.Second),
+		HalfOpenMax:      getEnvInt("BREAKER_HALF_OPEN_MAX", 1),
+		MaxConcurrent:    getEnvInt("BULKHEAD_MAX_CONCURRENT", 50),
+	}
+}
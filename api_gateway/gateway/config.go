@@ -0,0 +1,148 @@
+package gateway
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getEnv returns the value of the environment variable key, or fallback when
+// it is unset or empty.
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvInt is getEnv for integer settings. Malformed values are logged and
+// replaced by the fallback so a typo never takes the gateway down.
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("Invalid setting, using default", "key", key, "value", value, "default", fallback)
+		return fallback
+	}
+	return n
+}
+
+// getEnvDuration is getEnv for durations such as "30s" or "2m".
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		slog.Warn("Invalid setting, using default", "key", key, "value", value, "default", fallback.String())
+		return fallback
+	}
+	return d
+}
+
+// getEnvTime is getEnv for points in time, given as an RFC 3339 timestamp or
+// a date such as "2027-04-30". Unset or malformed values yield the zero time.
+func getEnvTime(key string) time.Time {
+	value := os.Getenv(key)
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	slog.Warn("Invalid setting, ignoring it", "key", key, "value", value)
+	return time.Time{}
+}
+
+// RateLimit is a token-bucket setting: Rate tokens are added per second
+// up to a maximum of Burst.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// parseRateLimit parses a "rate:burst" pair such as "2:10".
+func parseRateLimit(value string) (RateLimit, bool) {
+	rate, burst, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found {
+		return RateLimit{}, false
+	}
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil || r <= 0 {
+		return RateLimit{}, false
+	}
+	b, err := strconv.Atoi(burst)
+	if err != nil || b <= 0 {
+		return RateLimit{}, false
+	}
+	return RateLimit{Rate: r, Burst: b}, true
+}
+
+// loadRouteLimits reads per-route limits from RATE_LIMIT_ROUTES, formatted as
+// "route=rate:burst" entries separated by semicolons, e.g.
+// "/api/process-order=2:10;/api/health-check=0.2:2".
+func loadRouteLimits() map[string]RateLimit {
+	limits := map[string]RateLimit{}
+	for _, entry := range strings.Split(os.Getenv("RATE_LIMIT_ROUTES"), ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, value, found := strings.Cut(entry, "=")
+		cfg, ok := parseRateLimit(value)
+		if !found || !ok {
+			slog.Warn("Ignoring malformed RATE_LIMIT_ROUTES entry", "entry", entry)
+			continue
+		}
+		limits[strings.TrimSpace(route)] = cfg
+	}
+	return limits
+}
+
+// loadDefaultLimit reads the limit applied to routes without their own entry
+// from RATE_LIMIT_DEFAULT.
+func loadDefaultLimit() RateLimit {
+	fallback := RateLimit{Rate: 5, Burst: 10}
+	value := os.Getenv("RATE_LIMIT_DEFAULT")
+	if value == "" {
+		return fallback
+	}
+	cfg, ok := parseRateLimit(value)
+	if !ok {
+		slog.Warn("Invalid setting, using default", "key", "RATE_LIMIT_DEFAULT", "value", value, "rate", fallback.Rate, "burst", fallback.Burst)
+		return fallback
+	}
+	return cfg
+}
+
+// loadAPIKeys reads the API keys callers must present from API_KEYS, a
+// comma-separated list. Without keys the API is open.
+func loadAPIKeys() []string {
+	var keys []string
+	for _, key := range strings.Split(os.Getenv("API_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// loadCORSOrigins reads the origins allowed to call the API from browser
+// scripts from CORS_ALLOWED_ORIGINS, a comma-separated list in which "*"
+// allows any origin.
+func loadCORSOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
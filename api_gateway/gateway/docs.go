@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec is the OpenAPI document describing the gateway's REST API. The
+// client in api_gateway/client is generated from it.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// docsPage renders openAPISpec in a browser without external assets.
+//
+//go:embed docs.html
+var docsPage []byte
+
+// openAPIHandler serves GET /api/openapi.json.
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	serveStatic(w, r, "application/json", openAPISpec)
+}
+
+// docsHandler serves GET /api/docs.
+func docsHandler(w http.ResponseWriter, r *http.Request) {
+	serveStatic(w, r, "text/html; charset=utf-8", docsPage)
+}
+
+func serveStatic(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
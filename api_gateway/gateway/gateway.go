@@ -0,0 +1,458 @@
+// Package gateway is the HTTP front end of the shop. It turns order requests
+// into messages for the inventory, order and notification services.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// OrderRequest is the body of a POST to /api/v1/process-order and
+// /api/v2/orders. It is placed as a contracts.OrderPlaced message with the
+// same fields.
+type OrderRequest struct {
+	OrderID   int `json:"order_id"`
+	ProductID int `json:"product_id"`
+	UserID    int `json:"user_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// errRPCTimeout is returned when a downstream service does not reply in time.
+var errRPCTimeout = errors.New("timeout waiting for response")
+
+// msgLog is the sampled logger for per-request logs.
+var msgLog = slog.Default()
+
+// Config holds the gateway's limits and timeouts.
+type Config struct {
+	DefaultLimit      RateLimit            // rate limit for routes without their own
+	RouteLimits       map[string]RateLimit // rate limits by route
+	OrderDailyQuota   int                  // orders per client per day; 0 disables the quota
+	Breaker           BreakerConfig        // settings shared by every dependency's breaker
+	RPCTimeout        time.Duration        // how long to wait for a stock-check reply
+	HealthCheckWindow time.Duration        // how long to collect health-check replies
+	Encodings         contracts.Encodings  // message encoding by queue
+	APIKeys           []string             // keys accepted in X-API-Key; none leaves the API open
+	CORSOrigins       []string             // origins browser scripts may call from; "*" allows any
+	V1Sunset          time.Time            // when /api/v1 will be removed; zero if not yet decided
+}
+
+// LoadConfig reads the gateway's configuration from the environment.
+func LoadConfig() Config {
+	return Config{
+		DefaultLimit:      loadDefaultLimit(),
+		RouteLimits:       loadRouteLimits(),
+		OrderDailyQuota:   getEnvInt("ORDER_DAILY_QUOTA", 0),
+		Breaker:           loadBreakerConfig(),
+		RPCTimeout:        getEnvDuration("RPC_TIMEOUT", 10*time.Second),
+		HealthCheckWindow: getEnvDuration("HEALTH_CHECK_WINDOW", 10*time.Second),
+		Encodings:         contracts.LoadEncodings(),
+		APIKeys:           loadAPIKeys(),
+		CORSOrigins:       loadCORSOrigins(),
+		V1Sunset:          getEnvTime("API_V1_SUNSET"),
+	}
+}
+
+// Gateway is the HTTP front end. It checks stock, places orders and sends
+// notifications by messaging the backend services through a broker.
+type Gateway struct {
+	broker            broker.Broker
+	limiters          *routeLimiters
+	orderQuota        *dailyQuota
+	breakers          *breakerSet
+	rpcTimeout        time.Duration
+	healthCheckWindow time.Duration
+	encodings         contracts.Encodings
+	apiKeys           *apiKeys
+	cors              *corsPolicy
+	router            *router
+	inbox             *inboxHub
+}
+
+// New returns a gateway that talks to the backend services through b.
+func New(b broker.Broker, cfg Config) *Gateway {
+	g := &Gateway{
+		broker:            b,
+		limiters:          newRouteLimiters(cfg.DefaultLimit, cfg.RouteLimits),
+		orderQuota:        newDailyQuota(cfg.OrderDailyQuota),
+		breakers:          newBreakerSet(cfg.Breaker, "check_stock", "place_order", "notifications", "order_requests", "notification_requests", "inventory_requests"),
+		rpcTimeout:        cfg.RPCTimeout,
+		healthCheckWindow: cfg.HealthCheckWindow,
+		encodings:         cfg.Encodings,
+		apiKeys:           newAPIKeys(cfg.APIKeys),
+		cors:              newCORSPolicy(cfg.CORSOrigins),
+		router:            newRouter(),
+		inbox:             newInboxHub(),
+	}
+	g.routes(cfg)
+	return g
+}
+
+// v1Deprecated is when /api/v1 was deprecated in favour of /api/v2.
+var v1Deprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// routes registers the gateway's routes. Versioned APIs live under /api/v1
+// and /api/v2; the unversioned /api paths predate versioning and remain
+// aliases of v1.
+func (g *Gateway) routes(cfg Config) {
+	// Every route is instrumented, traced, logged, protected against panics
+	// and open to CORS; the versioned routes also need an API key, except
+	// for unsubscribing.
+	with := func(mws ...middleware) []middleware {
+		return append([]middleware{instrument, traced, logRequests, recoverPanics, g.cors.middleware}, mws...)
+	}
+	v1Deprecation := deprecation{since: v1Deprecated, sunset: cfg.V1Sunset, successor: "/api/v2"}
+
+	v1 := g.router.group("/api/v1", with(v1Deprecation.middleware, g.apiKeys.require, g.limiters.rateLimit)...).alias("/api")
+	v1.post("/process-order", g.unifiedHandler)
+	v1.get("/orders", g.listOrdersHandler)
+	v1.get("/orders/{order_id}", g.getOrderHandler)
+	v1.post("/orders/{order_id}/cancel", g.cancelOrderHandler)
+
+	v2 := g.router.group("/api/v2", with(g.apiKeys.require, g.limiters.rateLimit)...)
+	v2.post("/orders", g.placeOrderHandler)
+	v2.get("/orders", g.listOrdersHandler)
+	v2.get("/orders/{order_id}", g.getOrderHandler)
+	v2.post("/orders/{order_id}/cancel", g.cancelOrderHandler)
+	v2.get("/products/{product_id}", g.getProductHandler)
+	v2.put("/products/{product_id}/reorder_policy", g.putReorderPolicyHandler)
+	v2.post("/products/{product_id}/stock_adjustments", g.adjustStockHandler)
+	v2.get("/products/{product_id}/movements", g.stockMovementsHandler)
+	v2.get("/reports/reorder_suggestions", g.reorderSuggestionsHandler)
+	v2.get("/users/{user_id}/notifications", g.listNotificationsHandler)
+	v2.get("/users/{user_id}/contact", g.getContactHandler)
+	v2.put("/users/{user_id}/contact", g.putContactHandler)
+	v2.get("/users/{user_id}/preferences", g.getPreferencesHandler)
+	v2.put("/users/{user_id}/preferences", g.putPreferencesHandler)
+	v2.get("/users/{user_id}/inbox", g.listInboxHandler)
+	v2.get("/users/{user_id}/inbox/unread_count", g.unreadCountHandler)
+	v2.get("/users/{user_id}/inbox/stream", g.inboxStreamHandler)
+	v2.post("/users/{user_id}/inbox/mark_all_read", g.markAllReadHandler)
+	v2.post("/users/{user_id}/inbox/{item_id}/read", g.inboxItemHandler(contracts.InboxActionRead))
+	v2.post("/users/{user_id}/inbox/{item_id}/unread", g.inboxItemHandler(contracts.InboxActionUnread))
+	v2.post("/users/{user_id}/inbox/{item_id}/archive", g.inboxItemHandler(contracts.InboxActionArchive))
+	v2.post("/users/{user_id}/inbox/{item_id}/unarchive", g.inboxItemHandler(contracts.InboxActionUnarchive))
+	v2.post("/webhooks", g.createWebhookHandler)
+	v2.get("/webhooks", g.listWebhooksHandler)
+	v2.put("/webhooks/{webhook_id}", g.putWebhookHandler)
+	v2.delete("/webhooks/{webhook_id}", g.deleteWebhookHandler)
+	v2.get("/webhooks/{webhook_id}/deliveries", g.listWebhookDeliveriesHandler)
+	v2.post("/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver", g.redeliverWebhookHandler)
+
+	// Unsubscribe links are followed from emails, without an API key.
+	public := g.router.group("/api/v2", with(g.limiters.rateLimit)...)
+	public.get("/unsubscribe", g.unsubscribeHandler)
+	public.post("/unsubscribe", g.unsubscribeHandler)
+
+	api := g.router.group("/api", with(g.limiters.rateLimit)...)
+	api.get("/health-check", g.healthHandler)
+	api.get("/openapi.json", openAPIHandler)
+	api.get("/docs", docsHandler)
+
+	metrics := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	g.router.handle(http.MethodGet, "/metrics", "/metrics", metrics.ServeHTTP, nil)
+
+	// Limits configured for an unversioned path apply to its v1 route.
+	for alias, route := range g.router.aliases {
+		g.limiters.alias(alias, route)
+	}
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.router.ServeHTTP(w, r)
+}
+
+// StartSweeper periodically drops idle rate-limit buckets.
+func (g *Gateway) StartSweeper(interval time.Duration) {
+	g.limiters.startSweeper(interval)
+}
+
+func (g *Gateway) unifiedHandler(w http.ResponseWriter, r *http.Request) {
+	var orderReq OrderRequest
+	err := json.NewDecoder(r.Body).Decode(&orderReq)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	ctx := observability.WithLogFields(r.Context(),
+		slog.Int("order_id", orderReq.OrderID),
+		slog.Int("user_id", orderReq.UserID),
+	)
+
+	placed, err := g.placeOrder(ctx, clientKey(r), orderReq)
+	if err != nil {
+		g.writePlaceOrderError(ctx, w, err)
+		return
+	}
+
+	// Final Response
+	response := map[string]interface{}{
+		"order_id":    orderReq.OrderID,
+		"status":      "Order processed successfully",
+		"stock_check": placed.stock,
+		"notification": map[string]interface{}{
+			"user_id": placed.notification.UserID,
+			"message": placedMessage,
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// requestStock asks inventory_service to reserve the requested quantity and
+// waits for its reply, which says whether the stock was available. Every
+// failure is a *dependencyError. An InventoryError reply, the inventory
+// service refusing the check, does not count against the check_stock
+// breaker, which only trips when the service cannot be reached.
+func (g *Gateway) requestStock(ctx context.Context, orderReq OrderRequest) (contracts.StockCheckResult, error) {
+	var reply contracts.Payload
+	err := g.breakers.call("check_stock", func() error {
+		var err error
+		reply, err = g.rpc(ctx, "check_stock", contracts.StockCheck{
+			ProductID: orderReq.ProductID,
+			Quantity:  orderReq.Quantity,
+			Reserve:   true,
+			OrderID:   orderReq.OrderID,
+		})
+		if err != nil {
+			return err
+		}
+		switch reply.(type) {
+		case contracts.StockCheckResult, contracts.InventoryError:
+			return nil
+		}
+		return fmt.Errorf("invalid stock response: got %s", reply.MessageType())
+	})
+	if err != nil {
+		return contracts.StockCheckResult{}, &dependencyError{"check_stock", err}
+	}
+	if inventoryErr, ok := reply.(contracts.InventoryError); ok {
+		return contracts.StockCheckResult{}, &dependencyError{"check_stock", inventoryErr}
+	}
+	return reply.(contracts.StockCheckResult), nil
+}
+
+// rpc sends p to queue and waits for the reply, in the queue's encoding.
+func (g *Gateway) rpc(ctx context.Context, queue string, p contracts.Payload) (contracts.Payload, error) {
+	enc := g.encodings.For(queue)
+	env, requestBody, err := contracts.Encode(enc, serviceName, p)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span, headers := observability.StartPublishSpan(ctx, tracer, queue)
+	defer span.End()
+	ctx, cancel := context.WithTimeout(ctx, g.rpcTimeout)
+	defer cancel()
+
+	start := time.Now()
+	reply, err := g.broker.RPC(ctx, "", queue, broker.Message{
+		ContentType: enc.ContentType(),
+		MessageID:   env.MessageID,
+		Headers:     headers,
+		Body:        requestBody,
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		span.SetStatus(codes.Error, "timeout waiting for reply")
+		rpcTimeouts.WithLabelValues(queue).Inc()
+		return nil, errRPCTimeout
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("%s request failed: %w", queue, err)
+	}
+	messagesPublished.WithLabelValues(queue).Inc()
+	messagesConsumed.WithLabelValues("reply").Inc()
+	rpcDuration.WithLabelValues(queue).Observe(time.Since(start).Seconds())
+
+	_, payload, err := contracts.Decode(reply.ContentType, reply.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s response: %w", queue, err)
+	}
+	return payload, nil
+}
+
+// releaseStock hands back stock reserved for an order that could not be
+// placed, for reason. The inventory service keeps reservations per order,
+// so a release for an order it never reserved for is harmless and keeps a
+// late reservation from happening. Failures are only logged: the
+// reservation is then lost until the stock is corrected by hand.
+func (g *Gateway) releaseStock(ctx context.Context, orderReq OrderRequest, reason string) {
+	release := contracts.StockRelease{ProductID: orderReq.ProductID, Quantity: orderReq.Quantity, OrderID: orderReq.OrderID, Reason: reason}
+	if err := g.publishToQueue(ctx, "release_stock", release); err != nil {
+		slog.ErrorContext(ctx, "Failed to release reserved stock", "product_id", orderReq.ProductID, "quantity", orderReq.Quantity, "error", err)
+	}
+}
+
+// publishToQueue publishes p in an envelope to queue on the default
+// exchange, in the queue's encoding.
+func (g *Gateway) publishToQueue(ctx context.Context, queue string, p contracts.Payload) error {
+	enc := g.encodings.For(queue)
+	env, body, err := contracts.Encode(enc, serviceName, p)
+	if err != nil {
+		return err
+	}
+	ctx, span, headers := observability.StartPublishSpan(ctx, tracer, queue)
+	defer span.End()
+
+	err = g.broker.Publish(ctx, "", queue, broker.Message{
+		ContentType: enc.ContentType(),
+		MessageID:   env.MessageID,
+		Headers:     headers,
+		Body:        body,
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "publish failed")
+		return err
+	}
+	messagesPublished.WithLabelValues(queue).Inc()
+	return nil
+}
+
+// writeDependencyError maps a failed call to a downstream dependency onto
+// an HTTP error response.
+func (g *Gateway) writeDependencyError(ctx context.Context, w http.ResponseWriter, dependency string, err error) {
+	switch {
+	case errors.Is(err, errBreakerOpen):
+		retryAfter := g.breakers.get(dependency).retryAfter(time.Now())
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+		http.Error(w, dependency+" is unavailable", http.StatusServiceUnavailable)
+	case errors.Is(err, errBulkheadFull):
+		http.Error(w, dependency+" is overloaded", http.StatusServiceUnavailable)
+	case errors.Is(err, errRPCTimeout):
+		http.Error(w, "Timeout waiting for "+dependency+" response", http.StatusGatewayTimeout)
+	default:
+		slog.ErrorContext(ctx, "Call to dependency failed", "dependency", dependency, "error", err)
+		http.Error(w, "Failed to call "+dependency, http.StatusInternalServerError)
+	}
+}
+
+func (g *Gateway) healthHandler(w http.ResponseWriter, r *http.Request) {
+	replies, err := g.collectHealth(r.Context(), nil)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Health check failed", "error", err)
+		http.Error(w, "Health check failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	results := []interface{}{}
+	for _, reply := range replies {
+		results = append(results, reply.status)
+	}
+	results = append(results, g.gatewayHealth())
+	slog.InfoContext(r.Context(), "Consolidated health-check results", "responses", len(results))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// healthReply is a service's answer to a health check.
+type healthReply struct {
+	producer string // the service's name, such as inventory_service
+	status   contracts.HealthStatus
+}
+
+// collectHealth broadcasts a health check to every service and gathers the
+// replies until the health-check window closes or, when want is not empty,
+// until every service named in want has replied.
+func (g *Gateway) collectHealth(ctx context.Context, want []string) ([]healthReply, error) {
+	// Declare the fanout exchange
+	err := g.broker.DeclareExchange("health_check_exchange", broker.Fanout)
+	if err != nil {
+		return nil, fmt.Errorf("declare exchange: %w", err)
+	}
+
+	// Declare a unique response queue
+	responseQueue, err := g.broker.DeclareQueue("", broker.QueueOptions{AutoDelete: true, Exclusive: true})
+	if err != nil {
+		return nil, fmt.Errorf("declare response queue: %w", err)
+	}
+
+	// Collect responses from all services until the window closes
+	ctx, cancel := context.WithTimeout(ctx, g.healthCheckWindow)
+	defer cancel()
+	msgs, err := g.broker.Consume(ctx, responseQueue)
+	if err != nil {
+		return nil, fmt.Errorf("consume response queue: %w", err)
+	}
+
+	corrID := "health-check-correlation-id"
+	ctx = observability.WithLogFields(ctx, slog.String("correlation_id", corrID))
+	slog.DebugContext(ctx, "Publishing health check request", "exchange", "health_check_exchange")
+
+	// Publish the health-check request
+	enc := g.encodings.For("health_check_exchange")
+	env, body, err := contracts.Encode(enc, serviceName, contracts.HealthCheck{})
+	if err != nil {
+		return nil, fmt.Errorf("encode health-check message: %w", err)
+	}
+	ctx, span, headers := observability.StartPublishSpan(ctx, tracer, "health_check_exchange")
+	err = g.broker.Publish(ctx, "health_check_exchange", "", broker.Message{
+		ContentType:   enc.ContentType(),
+		CorrelationID: corrID,
+		ReplyTo:       responseQueue,
+		MessageID:     env.MessageID,
+		Headers:       headers,
+		Body:          body,
+	})
+	span.End()
+	if err != nil {
+		return nil, fmt.Errorf("publish health-check message: %w", err)
+	}
+	messagesPublished.WithLabelValues("health_check_exchange").Inc()
+
+	waiting := map[string]bool{}
+	for _, name := range want {
+		waiting[name] = true
+	}
+	var replies []healthReply
+	for msg := range msgs {
+		msg.Ack()
+		messagesConsumed.WithLabelValues("reply").Inc()
+		env, payload, err := contracts.Decode(msg.ContentType, msg.Body)
+		status, ok := payload.(contracts.HealthStatus)
+		if err != nil || !ok {
+			slog.WarnContext(ctx, "Failed to parse health-check response", "error", err)
+			continue
+		}
+		slog.DebugContext(ctx, "Parsed health-check response", "service", status.Service, "status", status.Status)
+		replies = append(replies, healthReply{producer: env.Producer, status: status})
+		delete(waiting, env.Producer)
+		if len(want) > 0 && len(waiting) == 0 {
+			break
+		}
+	}
+	return replies, nil
+}
+
+// gatewayHealth reports the gateway's own state, including the breaker for
+// each downstream dependency.
+func (g *Gateway) gatewayHealth() map[string]interface{} {
+	statuses := g.breakers.statuses()
+	status := "healthy"
+	for _, s := range statuses {
+		if s.State != stateClosed.String() {
+			status = "degraded"
+		}
+	}
+	return map[string]interface{}{
+		"service":  "API Gateway",
+		"status":   status,
+		"breakers": statuses,
+	}
+}
@@ -0,0 +1,315 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	msgLog = slog.Default()
+	os.Exit(m.Run())
+}
+
+// testConfig is a configuration with limits high enough not to interfere.
+func testConfig() Config {
+	return Config{
+		DefaultLimit: RateLimit{Rate: 1000, Burst: 1000},
+		Breaker: BreakerConfig{
+			FailureThreshold: 5,
+			OpenTimeout:      time.Minute,
+			HalfOpenMax:      1,
+			MaxConcurrent:    10,
+		},
+		RPCTimeout:        time.Second,
+		HealthCheckWindow: 50 * time.Millisecond,
+	}
+}
+
+// setupGateway returns a gateway on a fresh in-memory broker with the
+// queues the backend services would declare.
+func setupGateway(t *testing.T, cfg Config) (*Gateway, *broker.Memory) {
+	t.Helper()
+	b := broker.NewMemory()
+	for _, q := range []string{"check_stock", "release_stock", "place_order", "notifications", "order_requests", "notification_requests", "inventory_requests"} {
+		if _, err := b.DeclareQueue(q, broker.QueueOptions{Durable: true}); err != nil {
+			t.Fatalf("DeclareQueue(%q): %v", q, err)
+		}
+	}
+	return New(b, cfg), b
+}
+
+// fakeInventory answers check_stock requests from stock, in the request's
+// encoding, until the test ends.
+func fakeInventory(t *testing.T, b broker.Broker, stock map[int]int) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	msgs, err := b.Consume(ctx, "check_stock")
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	go func() {
+		for msg := range msgs {
+			_, payload, _ := contracts.Decode(msg.ContentType, msg.Body)
+			req, _ := payload.(contracts.StockCheck)
+			enc, _ := contracts.EncodingOf(msg.ContentType)
+			_, body, _ := contracts.Encode(enc, "inventory_service", contracts.StockCheckResult{
+				ProductID:   req.ProductID,
+				IsAvailable: stock[req.ProductID] >= req.Quantity,
+			})
+			b.Publish(ctx, "", msg.ReplyTo, broker.Message{ContentType: enc.ContentType(), CorrelationID: msg.CorrelationID, Body: body})
+			msg.Ack()
+		}
+	}()
+}
+
+// decodePayload decodes the payload of a message the gateway published.
+func decodePayload[T contracts.Payload](t *testing.T, msg broker.Message) T {
+	t.Helper()
+	env, payload, err := contracts.Decode(msg.ContentType, msg.Body)
+	if err != nil {
+		t.Fatalf("invalid message %s: %v", msg.Body, err)
+	}
+	if env.Producer != serviceName || msg.MessageID != env.MessageID {
+		t.Errorf("envelope = %+v, message ID %q; want it produced by %s", env, msg.MessageID, serviceName)
+	}
+	p, ok := payload.(T)
+	if !ok {
+		t.Fatalf("payload is %T", payload)
+	}
+	return p
+}
+
+// failingBroker fails every publish to queue.
+type failingBroker struct {
+	broker.Broker
+	queue string
+}
+
+func (f failingBroker) Publish(ctx context.Context, exchange, key string, msg broker.Message) error {
+	if key == f.queue {
+		return errors.New("publish failed")
+	}
+	return f.Broker.Publish(ctx, exchange, key, msg)
+}
+
+func postOrder(g *Gateway, order OrderRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(order)
+	req := httptest.NewRequest(http.MethodPost, "/api/process-order", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUnifiedHandlerPlacesOrder(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeInventory(t, b, map[int]int{101: 5})
+
+	rec := postOrder(g, OrderRequest{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	// The v1 response is a public contract: keep it byte for byte.
+	wantBody := `{"notification":{"message":"Your order has been successfully placed!","user_id":7},` +
+		`"order_id":1,"status":"Order processed successfully","stock_check":{"product_id":101,"is_available":true}}` + "\n"
+	if rec.Body.String() != wantBody {
+		t.Errorf("body = %s, want %s", rec.Body, wantBody)
+	}
+	placed := b.Pending("place_order")
+	if len(placed) != 1 {
+		t.Fatalf("place_order holds %d messages, want 1", len(placed))
+	}
+	want := contracts.OrderPlaced{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2}
+	if order := decodePayload[contracts.OrderPlaced](t, placed[0]); order != want {
+		t.Errorf("placed order = %+v, want %+v", order, want)
+	}
+	notifications := b.Pending("notifications")
+	if len(notifications) != 1 {
+		t.Fatalf("notifications holds %d messages, want 1", len(notifications))
+	}
+	// The notification service renders the text; the gateway sends none.
+	if notif := decodePayload[contracts.NotificationRequested](t, notifications[0]); notif.UserID != 7 || notif.Template != "order_placed" || notif.Message != "" {
+		t.Errorf("notification = %+v, want the order_placed template for user 7", notif)
+	}
+}
+
+func TestUnifiedHandlerUsesQueueEncodings(t *testing.T) {
+	cfg := testConfig()
+	cfg.Encodings = contracts.Encodings{"check_stock": contracts.Protobuf, "place_order": contracts.Protobuf}
+	g, b := setupGateway(t, cfg)
+	fakeInventory(t, b, map[int]int{101: 5})
+
+	rec := postOrder(g, OrderRequest{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	for queue, want := range map[string]string{
+		"place_order":   contracts.ContentTypeProtobuf,
+		"notifications": contracts.ContentTypeJSON,
+	} {
+		msgs := b.Pending(queue)
+		if len(msgs) != 1 {
+			t.Fatalf("%s holds %d messages, want 1", queue, len(msgs))
+		}
+		if msgs[0].ContentType != want {
+			t.Errorf("%s message content type = %q, want %q", queue, msgs[0].ContentType, want)
+		}
+	}
+	want := contracts.OrderPlaced{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2}
+	if order := decodePayload[contracts.OrderPlaced](t, b.Pending("place_order")[0]); order != want {
+		t.Errorf("placed order = %+v, want %+v", order, want)
+	}
+}
+
+func TestUnifiedHandlerRejectsOutOfStock(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeInventory(t, b, map[int]int{101: 1})
+
+	rec := postOrder(g, OrderRequest{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2})
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409: %s", rec.Code, rec.Body)
+	}
+	if got := b.Pending("place_order"); len(got) != 0 {
+		t.Errorf("out-of-stock order was placed: %v", got)
+	}
+}
+
+func TestUnifiedHandlerReleasesStockWhenOrderCannotBePlaced(t *testing.T) {
+	_, b := setupGateway(t, testConfig())
+	fakeInventory(t, b, map[int]int{101: 5})
+	g := New(failingBroker{b, "place_order"}, testConfig())
+
+	rec := postOrder(g, OrderRequest{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2})
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", rec.Code, rec.Body)
+	}
+	releases := b.Pending("release_stock")
+	if len(releases) != 1 {
+		t.Fatalf("release_stock holds %d messages, want 1", len(releases))
+	}
+	want := contracts.StockRelease{ProductID: 101, Quantity: 2, OrderID: 1, Reason: "order not placed"}
+	if release := decodePayload[contracts.StockRelease](t, releases[0]); release != want {
+		t.Errorf("release = %+v, want %+v", release, want)
+	}
+}
+
+func TestUnifiedHandlerRejectsNonPositiveQuantities(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeInventory(t, b, map[int]int{101: 5})
+
+	for _, quantity := range []int{0, -3} {
+		rec := postOrder(g, OrderRequest{OrderID: 1, ProductID: 101, UserID: 7, Quantity: quantity})
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("order of %d units: status = %d, want 400: %s", quantity, rec.Code, rec.Body)
+		}
+	}
+	if placed := b.Pending("place_order"); len(placed) != 0 {
+		t.Errorf("place_order holds %d messages, want none", len(placed))
+	}
+}
+
+func TestUnifiedHandlerTimesOutWithoutInventory(t *testing.T) {
+	cfg := testConfig()
+	cfg.RPCTimeout = 20 * time.Millisecond
+	g, b := setupGateway(t, cfg)
+
+	rec := postOrder(g, OrderRequest{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 1})
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504: %s", rec.Code, rec.Body)
+	}
+	// The inventory may still reserve stock for the order once it gets to
+	// the check, so the order's stock is released.
+	releases := b.Pending("release_stock")
+	if len(releases) != 1 {
+		t.Fatalf("release_stock holds %d messages, want 1", len(releases))
+	}
+	_, p, err := contracts.Decode(releases[0].ContentType, releases[0].Body)
+	if release, ok := p.(contracts.StockRelease); err != nil || !ok || release.OrderID != 1 || release.Quantity != 1 {
+		t.Errorf("release = %+v, %v; want order 1's unit", p, err)
+	}
+}
+
+func TestRefusedStockChecksDoNotOpenBreaker(t *testing.T) {
+	cfg := testConfig()
+	cfg.Breaker.FailureThreshold = 2
+	g, b := setupGateway(t, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	msgs, err := b.Consume(ctx, "check_stock")
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	go func() {
+		for msg := range msgs {
+			_, body, _ := contracts.Encode(contracts.JSON, "inventory_service", contracts.InventoryError{Code: contracts.InventoryErrInvalid, Message: "bad check"})
+			b.Publish(ctx, "", msg.ReplyTo, broker.Message{ContentType: contracts.JSON.ContentType(), CorrelationID: msg.CorrelationID, Body: body})
+			msg.Ack()
+		}
+	}()
+
+	for i := 1; i <= 4; i++ {
+		if rec := postOrder(g, OrderRequest{OrderID: i, ProductID: 101, UserID: 7, Quantity: 1}); rec.Code == http.StatusServiceUnavailable {
+			t.Fatalf("order %d = %d: %s; a refused check tripped the breaker", i, rec.Code, rec.Body)
+		}
+	}
+	// A refused check reserved nothing.
+	if releases := b.Pending("release_stock"); len(releases) != 0 {
+		t.Errorf("release_stock holds %d messages, want none", len(releases))
+	}
+}
+
+func TestHealthHandlerCollectsReplies(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	b.DeclareExchange("health_check_exchange", broker.Fanout)
+	queue, _ := b.DeclareQueue("", broker.QueueOptions{AutoDelete: true})
+	b.BindQueue(queue, "", "health_check_exchange")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	msgs, err := b.Consume(ctx, queue)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	go func() {
+		for msg := range msgs {
+			_, body, _ := contracts.Encode(contracts.JSON, "inventory_service", contracts.HealthStatus{Service: "Stock Service", Status: "healthy"})
+			b.Publish(ctx, "", msg.ReplyTo, broker.Message{CorrelationID: msg.CorrelationID, Body: body})
+			// Replies that are not enveloped health statuses are skipped.
+			b.Publish(ctx, "", msg.ReplyTo, broker.Message{CorrelationID: msg.CorrelationID, Body: []byte(`{"service":"Legacy Service"}`)})
+			msg.Ack()
+		}
+	}()
+
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health-check", nil))
+
+	var results []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body, err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want the stock service and the gateway: %v", len(results), results)
+	}
+	if results[0]["service"] != "Stock Service" || results[1]["service"] != "API Gateway" {
+		t.Errorf("results = %v", results)
+	}
+}
@@ -0,0 +1,241 @@
+package gateway
+
+//go:generate protoc -I pb --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative orders.proto
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"ecomm-sample/api_gateway/gateway/pb"
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// backendServices are the services the gRPC health service reports on, by
+// the producer name in their health-check replies.
+var backendServices = []string{"inventory_service", "order_service", "notification_service"}
+
+// healthWatchInterval is how often a Watch call re-checks the backends.
+const healthWatchInterval = 5 * time.Second
+
+// GRPCServer returns a gRPC server offering the gateway's OrderService, the
+// grpc.health.v1 health service and server reflection. Every call except
+// health checks needs a valid API key, and unary calls are rate limited
+// per client like REST requests.
+func (g *Gateway) GRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(instrumentUnary, g.apiKeys.unaryInterceptor, g.limiters.unaryInterceptor),
+		grpc.ChainStreamInterceptor(g.apiKeys.streamInterceptor),
+	)
+	srv := grpc.NewServer(opts...)
+	pb.RegisterOrderServiceServer(srv, &orderServer{g: g})
+	grpc_health_v1.RegisterHealthServer(srv, &healthServer{g: g})
+	reflection.Register(srv)
+	return srv
+}
+
+// instrumentUnary records call counts and latency and writes an access log
+// line for every unary call.
+func instrumentUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	code := status.Code(err).String()
+	grpcRequests.WithLabelValues(info.FullMethod, code).Inc()
+	grpcRequestDuration.WithLabelValues(info.FullMethod, code).Observe(time.Since(start).Seconds())
+	msgLog.InfoContext(ctx, "gRPC call handled",
+		"method", info.FullMethod,
+		"code", code,
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+	return resp, err
+}
+
+// orderServer implements the gRPC OrderService over the same flows as the
+// REST handlers.
+type orderServer struct {
+	pb.UnimplementedOrderServiceServer
+	g *Gateway
+}
+
+func (s *orderServer) PlaceOrder(ctx context.Context, req *pb.PlaceOrderRequest) (*pb.PlaceOrderResponse, error) {
+	orderReq := OrderRequest{
+		OrderID:   int(req.OrderId),
+		ProductID: int(req.ProductId),
+		UserID:    int(req.UserId),
+		Quantity:  int(req.Quantity),
+	}
+	ctx = observability.WithLogFields(ctx, slog.Int("order_id", orderReq.OrderID), slog.Int("user_id", orderReq.UserID))
+
+	placed, err := s.g.placeOrder(ctx, grpcClientKey(ctx), orderReq)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &pb.PlaceOrderResponse{
+		Order: &pb.Order{
+			OrderId:   req.OrderId,
+			ProductId: req.ProductId,
+			UserId:    req.UserId,
+			Quantity:  req.Quantity,
+			Status:    contracts.OrderStatusPlaced,
+		},
+		Notification: placedMessage,
+		Template:     placed.notification.Template,
+	}, nil
+}
+
+func (s *orderServer) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.Order, error) {
+	ctx = observability.WithLogFields(ctx, slog.Int("order_id", int(req.OrderId)))
+	order, err := s.g.getOrder(ctx, int(req.OrderId))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return orderToProto(order), nil
+}
+
+func (s *orderServer) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", int(req.UserId)))
+	orders, err := s.g.listOrders(ctx, int(req.UserId))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	resp := &pb.ListOrdersResponse{Orders: make([]*pb.Order, len(orders))}
+	for i, o := range orders {
+		resp.Orders[i] = orderToProto(o)
+	}
+	return resp, nil
+}
+
+func (s *orderServer) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (*pb.Order, error) {
+	ctx = observability.WithLogFields(ctx, slog.Int("order_id", int(req.OrderId)))
+	order, err := s.g.cancelOrder(ctx, int(req.OrderId))
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return orderToProto(order), nil
+}
+
+func orderToProto(o contracts.OrderDetails) *pb.Order {
+	return &pb.Order{
+		OrderId:   int64(o.OrderID),
+		ProductId: int64(o.ProductID),
+		UserId:    int64(o.UserID),
+		Quantity:  int64(o.Quantity),
+		Status:    o.Status,
+	}
+}
+
+// grpcError maps an error from the order flows onto a gRPC status, as
+// writeDependencyError and writeOrderError do for REST.
+func grpcError(ctx context.Context, err error) error {
+	var quotaErr *quotaError
+	var orderErr contracts.OrderError
+	var depErr *dependencyError
+	switch {
+	case errors.Is(err, errInvalidQuantity):
+		return status.Error(codes.InvalidArgument, "quantity must be positive")
+	case errors.As(err, &quotaErr):
+		return status.Errorf(codes.ResourceExhausted, "daily order quota exceeded, resets in %ds", ceilSeconds(quotaErr.resetIn))
+	case errors.Is(err, errOutOfStock):
+		return status.Error(codes.FailedPrecondition, "stock not available")
+	case errors.As(err, &orderErr) && orderErr.Code == contracts.OrderErrNotFound:
+		return status.Error(codes.NotFound, orderErr.Message)
+	case errors.As(err, &orderErr) && orderErr.Code == contracts.OrderErrAlreadyCancelled:
+		return status.Error(codes.FailedPrecondition, orderErr.Message)
+	case errors.As(err, &orderErr):
+		return status.Error(codes.Unavailable, orderErr.Message)
+	case errors.As(err, &depErr) && (errors.Is(err, errBreakerOpen) || errors.Is(err, errBulkheadFull)):
+		return status.Error(codes.Unavailable, depErr.dependency+" is unavailable")
+	case errors.As(err, &depErr) && errors.Is(err, errRPCTimeout):
+		return status.Error(codes.DeadlineExceeded, "timeout waiting for "+depErr.dependency+" response")
+	case errors.As(err, &depErr):
+		slog.ErrorContext(ctx, "Call to dependency failed", "dependency", depErr.dependency, "error", depErr.err)
+		return status.Error(codes.Internal, "failed to call "+depErr.dependency)
+	}
+	slog.ErrorContext(ctx, "gRPC call failed", "error", err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// healthServer implements grpc.health.v1 by broadcasting a health check to
+// the backend services. The empty service name and the OrderService name
+// report on the whole system; each backend can also be checked by name.
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	g *Gateway
+}
+
+func (h *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	st, err := h.status(ctx, req.Service)
+	if err != nil {
+		return nil, err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch sends the service's status, then every change to it.
+func (h *healthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+
+	last := grpc_health_v1.HealthCheckResponse_UNKNOWN
+	for first := true; ; first = false {
+		st, err := h.status(ctx, req.Service)
+		if status.Code(err) == codes.NotFound {
+			st = grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		} else if err != nil {
+			return err
+		}
+		if first || st != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// status checks the backends and reports on service.
+func (h *healthServer) status(ctx context.Context, service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, error) {
+	want := backendServices
+	switch service {
+	case "", pb.OrderService_ServiceDesc.ServiceName:
+	default:
+		known := false
+		for _, name := range backendServices {
+			known = known || name == service
+		}
+		if !known {
+			return 0, status.Errorf(codes.NotFound, "unknown service %q", service)
+		}
+		want = []string{service}
+	}
+
+	replies, err := h.g.collectHealth(ctx, want)
+	if err != nil {
+		slog.ErrorContext(ctx, "Health check failed", "error", err)
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, nil
+	}
+	healthy := map[string]bool{}
+	for _, reply := range replies {
+		healthy[reply.producer] = reply.status.Status == "healthy"
+	}
+	for _, name := range want {
+		if !healthy[name] {
+			return grpc_health_v1.HealthCheckResponse_NOT_SERVING, nil
+		}
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING, nil
+}
@@ -0,0 +1,277 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"sort"
+	"testing"
+
+	"ecomm-sample/api_gateway/gateway/pb"
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialGRPC serves g's gRPC API on an in-memory listener and returns a
+// client connection to it.
+func dialGRPC(t *testing.T, g *Gateway) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := g.GRPCServer()
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// fakeHealth answers health checks with the given status for each producer
+// until the test ends.
+func fakeHealth(t *testing.T, b broker.Broker, statuses map[string]string) {
+	t.Helper()
+	b.DeclareExchange("health_check_exchange", broker.Fanout)
+	queue, _ := b.DeclareQueue("", broker.QueueOptions{AutoDelete: true})
+	b.BindQueue(queue, "", "health_check_exchange")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	msgs, err := b.Consume(ctx, queue)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	go func() {
+		for msg := range msgs {
+			for producer, st := range statuses {
+				_, body, _ := contracts.Encode(contracts.JSON, producer, contracts.HealthStatus{Service: producer, Status: st})
+				b.Publish(ctx, "", msg.ReplyTo, broker.Message{CorrelationID: msg.CorrelationID, Body: body})
+			}
+			msg.Ack()
+		}
+	}()
+}
+
+func TestGRPCOrderService(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeInventory(t, b, map[int]int{101: 5})
+	fakeOrders(t, b, testOrders())
+	client := pb.NewOrderServiceClient(dialGRPC(t, g))
+	ctx := context.Background()
+
+	placed, err := client.PlaceOrder(ctx, &pb.PlaceOrderRequest{OrderId: 3, ProductId: 101, UserId: 7, Quantity: 2})
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if placed.Order.OrderId != 3 || placed.Order.Status != contracts.OrderStatusPlaced ||
+		placed.Notification != "Your order has been successfully placed!" || placed.Template != "order_placed" {
+		t.Errorf("PlaceOrder = %v", placed)
+	}
+	if msgs := b.Pending("place_order"); len(msgs) != 1 {
+		t.Errorf("place_order holds %d messages, want 1", len(msgs))
+	}
+
+	_, err = client.PlaceOrder(ctx, &pb.PlaceOrderRequest{OrderId: 4, ProductId: 101, UserId: 7, Quantity: 9})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("PlaceOrder beyond stock: error = %v, want FailedPrecondition", err)
+	}
+	_, err = client.PlaceOrder(ctx, &pb.PlaceOrderRequest{OrderId: 4, ProductId: 101, UserId: 7, Quantity: 0})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("PlaceOrder of no units: error = %v, want InvalidArgument", err)
+	}
+
+	order, err := client.GetOrder(ctx, &pb.GetOrderRequest{OrderId: 1})
+	if err != nil || order.ProductId != 101 || order.Status != contracts.OrderStatusPlaced {
+		t.Errorf("GetOrder = %v, %v", order, err)
+	}
+	if _, err := client.GetOrder(ctx, &pb.GetOrderRequest{OrderId: 9}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetOrder of unknown order: error = %v, want NotFound", err)
+	}
+
+	list, err := client.ListOrders(ctx, &pb.ListOrdersRequest{UserId: 7})
+	if err != nil || len(list.Orders) != 2 || list.Orders[0].OrderId != 1 || list.Orders[1].OrderId != 2 {
+		t.Errorf("ListOrders = %v, %v", list, err)
+	}
+
+	cancelled, err := client.CancelOrder(ctx, &pb.CancelOrderRequest{OrderId: 1})
+	if err != nil || cancelled.Status != contracts.OrderStatusCancelled {
+		t.Errorf("CancelOrder = %v, %v", cancelled, err)
+	}
+	if _, err := client.CancelOrder(ctx, &pb.CancelOrderRequest{OrderId: 1}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("second CancelOrder: error = %v, want FailedPrecondition", err)
+	}
+}
+
+func TestGRPCQuota(t *testing.T) {
+	cfg := testConfig()
+	cfg.OrderDailyQuota = 1
+	g, b := setupGateway(t, cfg)
+	fakeInventory(t, b, map[int]int{101: 5})
+	client := pb.NewOrderServiceClient(dialGRPC(t, g))
+
+	for i, want := range []codes.Code{codes.OK, codes.ResourceExhausted} {
+		_, err := client.PlaceOrder(context.Background(), &pb.PlaceOrderRequest{OrderId: int64(i + 1), ProductId: 101, UserId: 7, Quantity: 1})
+		if status.Code(err) != want {
+			t.Errorf("order %d: error = %v, want %s", i+1, err, want)
+		}
+	}
+}
+
+func TestGRPCRateLimit(t *testing.T) {
+	cfg := testConfig()
+	cfg.APIKeys = []string{"a", "b"}
+	cfg.RouteLimits = map[string]RateLimit{"/ecomm.gateway.v1.OrderService/GetOrder": {Rate: 0.001, Burst: 1}}
+	g, b := setupGateway(t, cfg)
+	fakeOrders(t, b, testOrders())
+	client := pb.NewOrderServiceClient(dialGRPC(t, g))
+
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	}
+	var header metadata.MD
+	if _, err := client.GetOrder(withKey("a"), &pb.GetOrderRequest{OrderId: 1}, grpc.Header(&header)); err != nil {
+		t.Fatalf("first GetOrder: %v", err)
+	}
+	if got := header.Get("ratelimit-remaining"); len(got) != 1 || got[0] != "0" {
+		t.Errorf("ratelimit-remaining = %v, want 0", got)
+	}
+	_, err := client.GetOrder(withKey("a"), &pb.GetOrderRequest{OrderId: 1}, grpc.Header(&header))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second GetOrder: error = %v, want ResourceExhausted", err)
+	}
+	if len(header.Get("retry-after")) != 1 {
+		t.Errorf("rejected call headers = %v, want retry-after", header)
+	}
+	if _, err := client.GetOrder(withKey("b"), &pb.GetOrderRequest{OrderId: 1}); err != nil {
+		t.Errorf("GetOrder with another key: %v", err)
+	}
+	if _, err := client.ListOrders(withKey("a"), &pb.ListOrdersRequest{UserId: 7}); err != nil {
+		t.Errorf("ListOrders, which has the default limit: %v", err)
+	}
+}
+
+func TestGRPCAPIKeys(t *testing.T) {
+	cfg := testConfig()
+	cfg.APIKeys = []string{"secret"}
+	g, b := setupGateway(t, cfg)
+	fakeOrders(t, b, testOrders())
+	conn := dialGRPC(t, g)
+	client := pb.NewOrderServiceClient(conn)
+
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	}
+	for _, tt := range []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"no key", context.Background(), codes.Unauthenticated},
+		{"wrong key", withKey("guess"), codes.Unauthenticated},
+		{"valid key", withKey("secret"), codes.OK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.GetOrder(tt.ctx, &pb.GetOrderRequest{OrderId: 1}); status.Code(err) != tt.want {
+				t.Errorf("GetOrder error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("streams", func(t *testing.T) {
+		stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("reflection without key: error = %v, want Unauthenticated", err)
+		}
+	})
+
+	t.Run("health checks need no key", func(t *testing.T) {
+		_, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			t.Errorf("Check: %v", err)
+		}
+	})
+}
+
+func TestGRPCReflection(t *testing.T) {
+	g, _ := setupGateway(t, testConfig())
+	stream, err := reflectionpb.NewServerReflectionClient(dialGRPC(t, g)).ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatalf("ServerReflectionInfo: %v", err)
+	}
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	var services []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		services = append(services, s.Name)
+	}
+	sort.Strings(services)
+	want := []string{"ecomm.gateway.v1.OrderService", "grpc.health.v1.Health", "grpc.reflection.v1.ServerReflection", "grpc.reflection.v1alpha.ServerReflection"}
+	if len(services) != len(want) {
+		t.Fatalf("services = %v, want %v", services, want)
+	}
+	for i := range want {
+		if services[i] != want[i] {
+			t.Errorf("services = %v, want %v", services, want)
+		}
+	}
+}
+
+func TestGRPCHealth(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeHealth(t, b, map[string]string{
+		"inventory_service":    "healthy",
+		"order_service":        "healthy",
+		"notification_service": "unhealthy",
+	})
+	client := grpc_health_v1.NewHealthClient(dialGRPC(t, g))
+
+	for _, tt := range []struct {
+		service string
+		want    grpc_health_v1.HealthCheckResponse_ServingStatus
+	}{
+		{"", grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		{"ecomm.gateway.v1.OrderService", grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		{"inventory_service", grpc_health_v1.HealthCheckResponse_SERVING},
+		{"notification_service", grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+	} {
+		resp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: tt.service})
+		if err != nil || resp.Status != tt.want {
+			t.Errorf("Check(%q) = %v, %v; want %s", tt.service, resp, err, tt.want)
+		}
+	}
+	if _, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "billing_service"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Check of unknown service: error = %v, want NotFound", err)
+	}
+
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "order_service"})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if resp, err := stream.Recv(); err != nil || resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("Watch first status = %v, %v; want SERVING", resp, err)
+	}
+}
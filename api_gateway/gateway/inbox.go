@@ -0,0 +1,275 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+)
+
+// inboxHeartbeat is how often an idle inbox stream sends a comment, so
+// that proxies do not close it.
+const inboxHeartbeat = 30 * time.Second
+
+// inboxBuffer is how many new items a stream holds for a client that is
+// slow to read them. Items past that are not sent on the stream; the
+// client sees them when it lists the inbox.
+const inboxBuffer = 16
+
+// inboxHub passes the inbox items the notification service announces on to
+// the streams of the users they are for.
+type inboxHub struct {
+	mu      sync.Mutex
+	streams map[int]map[chan contracts.InboxItemDetails]bool
+}
+
+func newInboxHub() *inboxHub {
+	return &inboxHub{streams: map[int]map[chan contracts.InboxItemDetails]bool{}}
+}
+
+// subscribe opens a stream of the new items in a user's inbox. The
+// returned function closes it.
+func (h *inboxHub) subscribe(userID int) (<-chan contracts.InboxItemDetails, func()) {
+	ch := make(chan contracts.InboxItemDetails, inboxBuffer)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.streams[userID] == nil {
+		h.streams[userID] = map[chan contracts.InboxItemDetails]bool{}
+	}
+	h.streams[userID][ch] = true
+	inboxStreams.Inc()
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.streams[userID], ch)
+		if len(h.streams[userID]) == 0 {
+			delete(h.streams, userID)
+		}
+		inboxStreams.Dec()
+	}
+}
+
+// publish sends item to every stream of its user that has room for it.
+func (h *inboxHub) publish(item contracts.InboxItemDetails) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.streams[item.UserID] {
+		select {
+		case ch <- item:
+		default:
+			slog.Warn("Inbox stream is full, dropping item", "user_id", item.UserID, "item_id", item.ItemID)
+		}
+	}
+}
+
+// StartInbox binds a queue of this gateway's own to the inbox exchange and
+// passes the new items announced on it to the users' inbox streams in the
+// background until ctx is cancelled. Until it is called streams get no
+// new items.
+func (g *Gateway) StartInbox(ctx context.Context) error {
+	if err := g.broker.DeclareExchange(contracts.InboxExchange, broker.Fanout); err != nil {
+		return fmt.Errorf("declare inbox exchange: %w", err)
+	}
+	queue, err := g.broker.DeclareQueue("", broker.QueueOptions{AutoDelete: true, Exclusive: true})
+	if err != nil {
+		return fmt.Errorf("declare inbox queue: %w", err)
+	}
+	if err := g.broker.BindQueue(queue, "", contracts.InboxExchange); err != nil {
+		return fmt.Errorf("bind inbox queue: %w", err)
+	}
+	msgs, err := g.broker.Consume(ctx, queue)
+	if err != nil {
+		return fmt.Errorf("consume inbox queue: %w", err)
+	}
+	go func() {
+		for msg := range msgs {
+			msg.Ack()
+			messagesConsumed.WithLabelValues(contracts.InboxExchange).Inc()
+			_, payload, err := contracts.Decode(msg.ContentType, msg.Body)
+			added, ok := payload.(contracts.InboxItemAdded)
+			if err != nil || !ok {
+				slog.Warn("Failed to parse inbox item", "error", err)
+				continue
+			}
+			g.inbox.publish(contracts.InboxItemDetails(added))
+		}
+	}()
+	return nil
+}
+
+// inboxRequest sends an inbox request to the notification service and
+// returns the reply, which must be a T.
+func inboxRequest[T contracts.Payload](ctx context.Context, g *Gateway, p contracts.Payload) (T, error) {
+	reply, err := g.notificationRequest(ctx, p)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return expectReply[T](reply)
+}
+
+// listInboxHandler serves GET /api/v2/users/{user_id}/inbox: the latest
+// items in the user's inbox, or in their archive with ?archived=true, and
+// how many are unread.
+func (g *Gateway) listInboxHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(pathParam(r, "user_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	archived := false
+	if v := r.URL.Query().Get("archived"); v != "" {
+		if archived, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "Invalid archived parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("user_id", userID))
+	result, err := inboxRequest[contracts.InboxListResult](ctx, g, contracts.InboxList{UserID: userID, Archived: archived})
+	if err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+	if result.Items == nil {
+		result.Items = []contracts.InboxItemDetails{}
+	}
+	writeJSON(w, result)
+}
+
+// unreadCountHandler serves GET /api/v2/users/{user_id}/inbox/unread_count.
+func (g *Gateway) unreadCountHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(pathParam(r, "user_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("user_id", userID))
+	count, err := inboxRequest[contracts.InboxCountResult](ctx, g, contracts.InboxCount{UserID: userID})
+	if err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+	writeJSON(w, count)
+}
+
+// markAllReadHandler serves POST
+// /api/v2/users/{user_id}/inbox/mark_all_read and answers with the unread
+// count left.
+func (g *Gateway) markAllReadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(pathParam(r, "user_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("user_id", userID))
+	count, err := inboxRequest[contracts.InboxCountResult](ctx, g, contracts.InboxMarkAllRead{UserID: userID})
+	if err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+	writeJSON(w, count)
+}
+
+// inboxItemHandler returns the handler of POST
+// /api/v2/users/{user_id}/inbox/{item_id}/{action}, which applies action,
+// one of the contracts.InboxAction constants, to the item and answers with
+// it.
+func (g *Gateway) inboxItemHandler(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := strconv.Atoi(pathParam(r, "user_id"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		itemID, err := strconv.Atoi(pathParam(r, "item_id"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		ctx := observability.WithLogFields(r.Context(), slog.Int("user_id", userID), slog.Int("item_id", itemID))
+		item, err := inboxRequest[contracts.InboxItemDetails](ctx, g, contracts.InboxUpdate{UserID: userID, ItemID: itemID, Action: action})
+		if err != nil {
+			g.writeNotificationError(ctx, w, err)
+			return
+		}
+		writeJSON(w, item)
+	}
+}
+
+// inboxStreamHandler serves GET /api/v2/users/{user_id}/inbox/stream, a
+// server-sent event stream of the user's inbox. It starts with an "unread"
+// event holding the unread count and then sends an "item" event for each
+// new item, until the client disconnects.
+func (g *Gateway) inboxStreamHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(pathParam(r, "user_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("user_id", userID))
+
+	// Subscribe before counting, so that no item falls in between.
+	items, unsubscribe := g.inbox.subscribe(userID)
+	defer unsubscribe()
+	count, err := inboxRequest[contracts.InboxCountResult](ctx, g, contracts.InboxCount{UserID: userID})
+	if err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := writeEvent(w, rc, "unread", "", count); err != nil {
+		slog.WarnContext(ctx, "Failed to start inbox stream", "error", err)
+		return
+	}
+	msgLog.InfoContext(ctx, "Inbox stream opened")
+
+	heartbeat := time.NewTicker(inboxHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			msgLog.InfoContext(ctx, "Inbox stream closed")
+			return
+		case item := <-items:
+			err = writeEvent(w, rc, "item", strconv.Itoa(item.ItemID), item)
+		case <-heartbeat.C:
+			if _, err = fmt.Fprint(w, ": keep-alive\n\n"); err == nil {
+				err = rc.Flush()
+			}
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "Inbox stream broken", "error", err)
+			return
+		}
+	}
+}
+
+// writeEvent sends v as a server-sent event of type event, with id if it
+// is not empty, and flushes it to the client.
+func writeEvent(w http.ResponseWriter, rc *http.ResponseController, event, id string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
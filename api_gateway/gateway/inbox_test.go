@@ -0,0 +1,138 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+func TestInbox(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeNotifications(t, b, testNotifications())
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/api/v2/users/7/inbox")
+	var list contracts.InboxListResult
+	if err := json.NewDecoder(rec.Body).Decode(&list); rec.Code != http.StatusOK || err != nil || len(list.Items) != 2 || list.Unread != 2 || list.Items[0].ItemID != 2 {
+		t.Fatalf("GET inbox = %d, %+v, %v; want both items, newest first", rec.Code, list, err)
+	}
+
+	rec = serve(http.MethodPost, "/api/v2/users/7/inbox/1/read")
+	var item contracts.InboxItemDetails
+	if err := json.NewDecoder(rec.Body).Decode(&item); rec.Code != http.StatusOK || err != nil || !item.Read {
+		t.Fatalf("POST read = %d, %+v, %v; want the item read", rec.Code, item, err)
+	}
+	rec = serve(http.MethodGet, "/api/v2/users/7/inbox/unread_count")
+	var count contracts.InboxCountResult
+	if err := json.NewDecoder(rec.Body).Decode(&count); rec.Code != http.StatusOK || err != nil || count.Unread != 1 {
+		t.Fatalf("GET unread_count = %d, %+v, %v; want 1", rec.Code, count, err)
+	}
+
+	serve(http.MethodPost, "/api/v2/users/7/inbox/2/archive")
+	rec = serve(http.MethodGet, "/api/v2/users/7/inbox?archived=true")
+	list = contracts.InboxListResult{}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil || len(list.Items) != 1 || list.Items[0].ItemID != 2 || !list.Items[0].Archived {
+		t.Fatalf("GET archived = %d, %+v, %v; want the archived item", rec.Code, list, err)
+	}
+
+	for _, tt := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/v2/users/7/inbox?archived=maybe", http.StatusBadRequest},
+		{http.MethodGet, "/api/v2/users/me/inbox", http.StatusNotFound},
+		{http.MethodPost, "/api/v2/users/7/inbox/2/unarchive", http.StatusOK},
+		{http.MethodPost, "/api/v2/users/7/inbox/1/unread", http.StatusOK},
+		{http.MethodPost, "/api/v2/users/8/inbox/1/read", http.StatusNotFound},
+		{http.MethodPost, "/api/v2/users/7/inbox/first/read", http.StatusNotFound},
+		{http.MethodPost, "/api/v2/users/7/inbox/mark_all_read", http.StatusOK},
+	} {
+		if rec := serve(tt.method, tt.path); rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.path, rec.Code, tt.want, rec.Body)
+		}
+	}
+	if rec := serve(http.MethodGet, "/api/v2/users/7/inbox/unread_count"); !strings.Contains(rec.Body.String(), `"unread":0`) {
+		t.Errorf("unread count after marking all read = %s, want 0", rec.Body)
+	}
+}
+
+func TestInboxStream(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeNotifications(t, b, testNotifications())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := g.StartInbox(ctx); err != nil {
+		t.Fatalf("StartInbox: %v", err)
+	}
+	srv := httptest.NewServer(g)
+	defer srv.Close()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v2/users/7/inbox/stream", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET stream = %d %q, want an event stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	events := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		var event []string
+		for scanner.Scan() {
+			if scanner.Text() != "" {
+				event = append(event, scanner.Text())
+				continue
+			}
+			events <- strings.Join(event, "\n")
+			event = nil
+		}
+		close(events)
+	}()
+	next := func() string {
+		t.Helper()
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event on the stream")
+			return ""
+		}
+	}
+
+	if e := next(); e != `event: unread`+"\n"+`data: {"user_id":7,"unread":2}` {
+		t.Fatalf("first event = %q, want the unread count", e)
+	}
+
+	created := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	for _, item := range []contracts.InboxItemAdded{
+		{ItemID: 3, UserID: 8, NotificationID: 3, Category: contracts.CategoryTransactional, Content: "not yours", CreatedAt: created},
+		{ItemID: 4, UserID: 7, NotificationID: 4, Category: contracts.CategoryTransactional, Subject: "Shipped", Content: "Your order shipped", CreatedAt: created},
+	} {
+		_, body, _ := contracts.Encode(contracts.JSON, "notification_service", item)
+		if err := b.Publish(ctx, contracts.InboxExchange, "", broker.Message{ContentType: contracts.JSON.ContentType(), Body: body}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	e := next()
+	id, data, _ := strings.Cut(e, "\nevent: item\ndata: ")
+	var item contracts.InboxItemDetails
+	if err := json.Unmarshal([]byte(data), &item); id != "id: 4" || err != nil || item.Subject != "Shipped" {
+		t.Fatalf("item event = %q, want user 7's new item", e)
+	}
+}
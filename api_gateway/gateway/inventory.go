@@ -0,0 +1,204 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+)
+
+// ReorderPolicy is the body of PUT
+// /api/v2/products/{product_id}/reorder_policy: the stock level below which
+// the product's stock is low, 0 for the inventory service's default, and
+// the safety stock to keep on hand.
+type ReorderPolicy struct {
+	ReorderThreshold int `json:"reorder_threshold"`
+	SafetyStock      int `json:"safety_stock"`
+}
+
+// StockAdjustment is the body of POST
+// /api/v2/products/{product_id}/stock_adjustments: the units to add to the
+// product's stock, negative to remove them, and why. Type is the movement
+// it records, an adjustment if empty; Actor is who made the change and
+// Reference the purchase order, order or other document behind it.
+type StockAdjustment struct {
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Actor     string `json:"actor,omitempty"`
+	Reference string `json:"reference,omitempty"`
+}
+
+// inventoryRequest sends a request to the inventory service and returns
+// its reply, which must be a T. InventoryError replies are returned as
+// errors.
+func inventoryRequest[T contracts.Payload](ctx context.Context, g *Gateway, p contracts.Payload) (T, error) {
+	var reply contracts.Payload
+	err := g.breakers.call("inventory_requests", func() error {
+		var err error
+		reply, err = g.rpc(ctx, "inventory_requests", p)
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, &dependencyError{"inventory_requests", err}
+	}
+	if inventoryErr, ok := reply.(contracts.InventoryError); ok {
+		var zero T
+		return zero, inventoryErr
+	}
+	return expectReply[T](reply)
+}
+
+// writeInventoryError answers a request whose inventory request failed.
+func (g *Gateway) writeInventoryError(ctx context.Context, w http.ResponseWriter, err error) {
+	var inventoryErr contracts.InventoryError
+	var depErr *dependencyError
+	switch {
+	case errors.As(err, &inventoryErr):
+		switch inventoryErr.Code {
+		case contracts.InventoryErrInvalid:
+			http.Error(w, inventoryErr.Message, http.StatusBadRequest)
+		case contracts.InventoryErrNotFound:
+			http.Error(w, inventoryErr.Message, http.StatusNotFound)
+		case contracts.InventoryErrInsufficientStock:
+			http.Error(w, inventoryErr.Message, http.StatusConflict)
+		default:
+			http.Error(w, inventoryErr.Message, http.StatusServiceUnavailable)
+		}
+	case errors.As(err, &depErr):
+		g.writeDependencyError(ctx, w, depErr.dependency, depErr.err)
+	default:
+		slog.ErrorContext(ctx, "Inventory request failed", "error", err)
+		http.Error(w, "Inventory request failed", http.StatusInternalServerError)
+	}
+}
+
+// getProductHandler serves GET /api/v2/products/{product_id}, the
+// product's stock and reorder policy.
+func (g *Gateway) getProductHandler(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(pathParam(r, "product_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("product_id", productID))
+	product, err := inventoryRequest[contracts.ProductDetails](ctx, g, contracts.ProductGet{ProductID: productID})
+	if err != nil {
+		g.writeInventoryError(ctx, w, err)
+		return
+	}
+	writeJSON(w, product)
+}
+
+// putReorderPolicyHandler serves PUT
+// /api/v2/products/{product_id}/reorder_policy and answers with the
+// product.
+func (g *Gateway) putReorderPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(pathParam(r, "product_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	var body ReorderPolicy
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("product_id", productID))
+	product, err := inventoryRequest[contracts.ProductDetails](ctx, g, contracts.ReorderPolicySet{
+		ProductID:        productID,
+		ReorderThreshold: body.ReorderThreshold,
+		SafetyStock:      body.SafetyStock,
+	})
+	if err != nil {
+		g.writeInventoryError(ctx, w, err)
+		return
+	}
+	writeJSON(w, product)
+}
+
+// adjustStockHandler serves POST
+// /api/v2/products/{product_id}/stock_adjustments and answers with the
+// adjusted product.
+func (g *Gateway) adjustStockHandler(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(pathParam(r, "product_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	var body StockAdjustment
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("product_id", productID))
+	product, err := inventoryRequest[contracts.ProductDetails](ctx, g, contracts.StockAdjust{
+		ProductID: productID,
+		Quantity:  body.Quantity,
+		Reason:    body.Reason,
+		Type:      body.Type,
+		Actor:     body.Actor,
+		Reference: body.Reference,
+	})
+	if err != nil {
+		g.writeInventoryError(ctx, w, err)
+		return
+	}
+	writeJSON(w, product)
+}
+
+// stockMovementsHandler serves GET
+// /api/v2/products/{product_id}/movements, the product's stock and its
+// latest movements, newest first.
+func (g *Gateway) stockMovementsHandler(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(pathParam(r, "product_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("product_id", productID))
+	list, err := inventoryRequest[contracts.MovementListResult](ctx, g, contracts.MovementList{ProductID: productID})
+	if err != nil {
+		g.writeInventoryError(ctx, w, err)
+		return
+	}
+	if list.Movements == nil {
+		list.Movements = []contracts.StockMovement{}
+	}
+	writeJSON(w, list)
+}
+
+// reorderSuggestionsHandler serves GET
+// /api/v2/reports/reorder_suggestions: how much of which products to
+// reorder, given their consumption over the last ?window_days= days and
+// the ?cover_days= days the stock should last.
+func (g *Gateway) reorderSuggestionsHandler(w http.ResponseWriter, r *http.Request) {
+	var req contracts.ReorderReport
+	for name, days := range map[string]*int{"window_days": &req.WindowDays, "cover_days": &req.CoverDays} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid "+name+" parameter", http.StatusBadRequest)
+			return
+		}
+		*days = n
+	}
+	report, err := inventoryRequest[contracts.ReorderReportResult](r.Context(), g, req)
+	if err != nil {
+		g.writeInventoryError(r.Context(), w, err)
+		return
+	}
+	if report.Suggestions == nil {
+		report.Suggestions = []contracts.ReorderSuggestion{}
+	}
+	writeJSON(w, report)
+}
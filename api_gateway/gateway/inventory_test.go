@@ -0,0 +1,224 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+// fakeInventoryRequests answers inventory_requests from and about products
+// until the test ends. Stock can't go negative, each adjustment is
+// recorded as a movement, and the reorder report suggests topping every
+// product up to its safety stock, leaving the report windows at 30 days
+// unless given.
+func fakeInventoryRequests(t *testing.T, b broker.Broker, products map[int]contracts.ProductDetails) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	msgs, err := b.Consume(ctx, "inventory_requests")
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	go func() {
+		notFound := contracts.InventoryError{Code: contracts.InventoryErrNotFound, Message: "product not found"}
+		movements := make(map[int][]contracts.StockMovement)
+		for msg := range msgs {
+			_, payload, _ := contracts.Decode(msg.ContentType, msg.Body)
+			var reply contracts.Payload = contracts.InventoryError{Code: contracts.InventoryErrUnavailable, Message: "inventory database unavailable"}
+			switch req := payload.(type) {
+			case contracts.ProductGet:
+				reply = notFound
+				if p, ok := products[req.ProductID]; ok {
+					reply = p
+				}
+			case contracts.ReorderPolicySet:
+				p, ok := products[req.ProductID]
+				switch {
+				case req.ReorderThreshold < 0 || req.SafetyStock < 0:
+					reply = contracts.InventoryError{Code: contracts.InventoryErrInvalid, Message: "reorder_threshold and safety_stock must not be negative"}
+				case !ok:
+					reply = notFound
+				default:
+					p.ReorderThreshold, p.SafetyStock = req.ReorderThreshold, req.SafetyStock
+					products[req.ProductID] = p
+					reply = p
+				}
+			case contracts.StockAdjust:
+				p, ok := products[req.ProductID]
+				switch {
+				case req.Quantity == 0:
+					reply = contracts.InventoryError{Code: contracts.InventoryErrInvalid, Message: "quantity must not be 0"}
+				case req.Type == contracts.MovementReservation || req.Type == contracts.MovementRelease:
+					reply = contracts.InventoryError{Code: contracts.InventoryErrInvalid, Message: "type must be receipt, sale, adjustment, return or transfer"}
+				case !ok:
+					reply = notFound
+				case p.Stock+req.Quantity < 0:
+					reply = contracts.InventoryError{Code: contracts.InventoryErrInsufficientStock, Message: "not enough stock"}
+				default:
+					p.Stock += req.Quantity
+					products[req.ProductID] = p
+					if req.Type == "" {
+						req.Type = contracts.MovementAdjustment
+					}
+					movements[req.ProductID] = append([]contracts.StockMovement{{
+						MovementID: int64(len(movements[req.ProductID]) + 1),
+						ProductID:  req.ProductID,
+						Type:       req.Type,
+						Quantity:   req.Quantity,
+						Balance:    p.Stock,
+						Reason:     req.Reason,
+						Actor:      req.Actor,
+						Reference:  req.Reference,
+						CreatedAt:  time.Now(),
+					}}, movements[req.ProductID]...)
+					reply = p
+				}
+			case contracts.MovementList:
+				reply = notFound
+				if p, ok := products[req.ProductID]; ok {
+					reply = contracts.MovementListResult{ProductID: p.ProductID, Stock: p.Stock, Movements: movements[req.ProductID]}
+				}
+			case contracts.ReorderReport:
+				result := contracts.ReorderReportResult{WindowDays: req.WindowDays, CoverDays: req.CoverDays}
+				if result.WindowDays == 0 {
+					result.WindowDays = 30
+				}
+				if result.CoverDays == 0 {
+					result.CoverDays = 30
+				}
+				for _, p := range products {
+					if p.Stock < p.SafetyStock {
+						result.Suggestions = append(result.Suggestions, contracts.ReorderSuggestion{ProductID: p.ProductID, Name: p.Name, Stock: p.Stock, ReorderThreshold: p.ReorderThreshold, SafetyStock: p.SafetyStock, Quantity: p.SafetyStock - p.Stock})
+					}
+				}
+				reply = result
+			}
+			enc, _ := contracts.EncodingOf(msg.ContentType)
+			_, body, _ := contracts.Encode(enc, "inventory_service", reply)
+			b.Publish(ctx, "", msg.ReplyTo, broker.Message{ContentType: enc.ContentType(), CorrelationID: msg.CorrelationID, Body: body})
+			msg.Ack()
+		}
+	}()
+}
+
+// testProducts returns the products fakeInventoryRequests starts with.
+func testProducts() map[int]contracts.ProductDetails {
+	return map[int]contracts.ProductDetails{
+		101: {ProductID: 101, Name: "Broccoli", Stock: 20, ReorderThreshold: 10},
+	}
+}
+
+func TestProducts(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeInventoryRequests(t, b, testProducts())
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := serve(http.MethodPut, "/api/v2/products/101/reorder_policy", `{"reorder_threshold":15,"safety_stock":25}`)
+	var product contracts.ProductDetails
+	if err := json.NewDecoder(rec.Body).Decode(&product); rec.Code != http.StatusOK || err != nil || product.ReorderThreshold != 15 || product.SafetyStock != 25 {
+		t.Fatalf("PUT reorder_policy = %d, %+v, %v; want the new policy", rec.Code, product, err)
+	}
+	rec = serve(http.MethodPost, "/api/v2/products/101/stock_adjustments", `{"quantity":-4,"reason":"damaged"}`)
+	product = contracts.ProductDetails{}
+	if err := json.NewDecoder(rec.Body).Decode(&product); rec.Code != http.StatusOK || err != nil || product.Stock != 16 {
+		t.Fatalf("POST stock_adjustments = %d, %+v, %v; want 16 in stock", rec.Code, product, err)
+	}
+	rec = serve(http.MethodGet, "/api/v2/products/101", "")
+	product = contracts.ProductDetails{}
+	if err := json.NewDecoder(rec.Body).Decode(&product); rec.Code != http.StatusOK || err != nil || product.Stock != 16 || product.SafetyStock != 25 {
+		t.Fatalf("GET product = %d, %+v, %v; want the adjusted product", rec.Code, product, err)
+	}
+	rec = serve(http.MethodPost, "/api/v2/products/101/stock_adjustments", `{"quantity":5,"type":"receipt","actor":"ada","reference":"PO-1001"}`)
+	product = contracts.ProductDetails{}
+	if err := json.NewDecoder(rec.Body).Decode(&product); rec.Code != http.StatusOK || err != nil || product.Stock != 21 {
+		t.Fatalf("POST receipt = %d, %+v, %v; want 21 in stock", rec.Code, product, err)
+	}
+	rec = serve(http.MethodGet, "/api/v2/products/101/movements", "")
+	var list contracts.MovementListResult
+	if err := json.NewDecoder(rec.Body).Decode(&list); rec.Code != http.StatusOK || err != nil || list.Stock != 21 || len(list.Movements) != 2 {
+		t.Fatalf("GET movements = %d, %+v, %v; want 2 movements", rec.Code, list, err)
+	}
+	if m := list.Movements[0]; m.Type != contracts.MovementReceipt || m.Quantity != 5 || m.Balance != 21 || m.Actor != "ada" || m.Reference != "PO-1001" {
+		t.Errorf("latest movement = %+v, want the receipt of PO-1001", m)
+	}
+	rec = serve(http.MethodPost, "/api/v2/products/101/stock_adjustments", `{"quantity":-5,"reason":"recount"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST stock_adjustments = %d, want 200", rec.Code)
+	}
+	rec = serve(http.MethodGet, "/api/v2/reports/reorder_suggestions?window_days=7", "")
+	var report contracts.ReorderReportResult
+	if err := json.NewDecoder(rec.Body).Decode(&report); rec.Code != http.StatusOK || err != nil || report.WindowDays != 7 || report.CoverDays != 30 || len(report.Suggestions) != 1 || report.Suggestions[0].Quantity != 9 {
+		t.Fatalf("GET reorder_suggestions = %d, %+v, %v; want 9 units of 101", rec.Code, report, err)
+	}
+
+	for _, tt := range []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodGet, "/api/v2/products/404", "", http.StatusNotFound},
+		{http.MethodGet, "/api/v2/products/broccoli", "", http.StatusNotFound},
+		{http.MethodPut, "/api/v2/products/101/reorder_policy", `{"safety_stock":-1}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/v2/products/101/reorder_policy", `{"safety_stock":`, http.StatusBadRequest},
+		{http.MethodPut, "/api/v2/products/404/reorder_policy", `{}`, http.StatusNotFound},
+		{http.MethodPost, "/api/v2/products/101/stock_adjustments", `{"quantity":0}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/v2/products/101/stock_adjustments", `{"quantity":-17}`, http.StatusConflict},
+		{http.MethodPost, "/api/v2/products/101/stock_adjustments", `{"quantity":"many"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/v2/products/101/stock_adjustments", `{"quantity":1,"type":"reservation"}`, http.StatusBadRequest},
+		{http.MethodGet, "/api/v2/products/404/movements", "", http.StatusNotFound},
+		{http.MethodGet, "/api/v2/reports/reorder_suggestions?cover_days=0", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/v2/reports/reorder_suggestions?window_days=week", "", http.StatusBadRequest},
+	} {
+		if rec := serve(tt.method, tt.path, tt.body); rec.Code != tt.want {
+			t.Errorf("%s %s %s = %d, want %d: %s", tt.method, tt.path, tt.body, rec.Code, tt.want, rec.Body)
+		}
+	}
+	if rec := serve(http.MethodGet, "/api/v2/products/101", ""); !strings.Contains(rec.Body.String(), `"stock":16`) {
+		t.Errorf("product after refused adjustments = %s, want 16 in stock", rec.Body)
+	}
+}
+
+func TestReorderSuggestionsWithoutSuggestions(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeInventoryRequests(t, b, testProducts())
+
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/reports/reorder_suggestions", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"suggestions":[]`) {
+		t.Errorf("GET reorder_suggestions = %d %s, want an empty list", rec.Code, rec.Body)
+	}
+}
+
+func TestProductTimeout(t *testing.T) {
+	cfg := testConfig()
+	cfg.RPCTimeout = 20 * time.Millisecond
+	g, _ := setupGateway(t, cfg)
+
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/products/101", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want 504", rec.Code)
+	}
+}
+
+func TestStockMovementsWithoutMovements(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeInventoryRequests(t, b, testProducts())
+
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/products/101/movements", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"movements":[]`) {
+		t.Errorf("GET movements = %d %s, want an empty list", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"ecomm-sample/observability"
+)
+
+// SetupLogging installs the gateway's default and per-request loggers, as
+// described at observability.SetupLogging.
+func SetupLogging() {
+	msgLog = observability.SetupLogging(serviceName)
+}
+
+// logRequests writes an access log line for every request to route. The
+// request context carries the caller's correlation ID, taken from the
+// X-Correlation-ID header, for the handler's own log lines.
+func logRequests(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := r.Context()
+		if corrID := r.Header.Get("X-Correlation-ID"); corrID != "" {
+			ctx = observability.WithLogFields(ctx, slog.String("correlation_id", corrID))
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r.WithContext(ctx))
+
+		msgLog.InfoContext(ctx, "Request handled",
+			"method", r.Method,
+			"route", route,
+			"status", rec.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	}
+}
@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// registry holds this service's metrics. It is separate from the default
+// registry so that several services can share a process in tests.
+var registry = prometheus.NewRegistry()
+
+var factory = promauto.With(registry)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled, by route, method and status code.",
+	}, []string{"route", "method", "status"})
+	httpRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency, by route, method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+	grpcRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_requests_total",
+		Help: "gRPC calls handled, by method and status code.",
+	}, []string{"method", "code"})
+	grpcRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_request_duration_seconds",
+		Help:    "gRPC call latency, by method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "code"})
+	panicsRecovered = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_panics_recovered_total",
+		Help: "Handler panics turned into 500 responses, by route.",
+	}, []string{"route"})
+	authFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_failures_total",
+		Help: "Requests refused for a missing or invalid API key, by API.",
+	}, []string{"api"})
+	messagesConsumed = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messages_consumed_total",
+		Help: "Messages received from a queue.",
+	}, []string{"queue"})
+	inboxStreams = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "inbox_streams",
+		Help: "Open inbox streams.",
+	})
+	messagesPublished = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messages_published_total",
+		Help: "Messages published, by destination queue or exchange.",
+	}, []string{"queue"})
+	rpcDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rpc_duration_seconds",
+		Help:    "Time from publishing a request to receiving its reply.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"queue"})
+	rpcTimeouts = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "rpc_timeouts_total",
+		Help: "Requests that got no reply within the RPC timeout.",
+	}, []string{"queue"})
+	ordersPlaced = factory.NewCounter(prometheus.CounterOpts{
+		Name: "orders_placed_total",
+		Help: "Orders accepted and handed to the order service.",
+	})
+	ordersRejected = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_rejected_total",
+		Help: "Orders refused by the gateway, by reason.",
+	}, []string{"reason"})
+)
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the underlying writer, so that http.ResponseController
+// can flush streamed responses through the recorder.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// instrument records request counts and latency for route.
+func instrument(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		status := strconv.Itoa(rec.status)
+		httpRequests.WithLabelValues(route, r.Method, status).Inc()
+		httpRequestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,118 @@
+package gateway
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recoverPanics turns a panicking handler into a 500 response instead of
+// a dropped connection, and logs the panic with its stack.
+func recoverPanics(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				panicsRecovered.WithLabelValues(route).Inc()
+				slog.ErrorContext(r.Context(), "Handler panicked",
+					"route", route,
+					"panic", p,
+					"stack", string(debug.Stack()),
+				)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			}
+		}()
+		next(w, r)
+	}
+}
+
+// corsRequestHeaders are the request headers browsers may send cross-origin.
+var corsRequestHeaders = []string{"Content-Type", apiKeyHeader, "X-User-ID", "X-Correlation-ID"}
+
+// corsExposedHeaders are the response headers cross-origin scripts may read.
+var corsExposedHeaders = []string{
+	"Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+	"Location", "Deprecation", "Sunset", "Link",
+}
+
+// corsPolicy lets browser scripts on the allowed origins call the API. With
+// no origins configured no CORS headers are sent, so only same-origin pages
+// can read responses.
+type corsPolicy struct {
+	any     bool
+	origins map[string]bool
+}
+
+func newCORSPolicy(origins []string) *corsPolicy {
+	c := &corsPolicy{origins: map[string]bool{}}
+	for _, origin := range origins {
+		switch origin = strings.TrimSpace(origin); origin {
+		case "":
+		case "*":
+			c.any = true
+		default:
+			c.origins[strings.TrimSuffix(origin, "/")] = true
+		}
+	}
+	return c
+}
+
+func (c *corsPolicy) allows(origin string) bool {
+	return origin != "" && (c.any || c.origins[origin])
+}
+
+// middleware adds CORS headers for allowed origins and answers preflight
+// requests itself, ahead of authentication and rate limiting.
+func (c *corsPolicy) middleware(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if !c.allows(origin) {
+			next(w, r)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if c.any {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", strings.Join(allowedMethods(r), ", "))
+			h.Set("Access-Control-Allow-Headers", strings.Join(corsRequestHeaders, ", "))
+			h.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		next(w, r)
+	}
+}
+
+// deprecation describes a deprecated API version.
+type deprecation struct {
+	since     time.Time // when the version was deprecated
+	sunset    time.Time // when it will be removed; zero if not yet decided
+	successor string    // path prefix of the version that replaces it
+}
+
+// middleware announces the deprecation on every response with the
+// Deprecation (RFC 9745) and Sunset (RFC 8594) headers and a link to the
+// successor version.
+func (d deprecation) middleware(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Deprecation", "@"+strconv.FormatInt(d.since.Unix(), 10))
+		if !d.sunset.IsZero() {
+			h.Set("Sunset", d.sunset.UTC().Format(http.TimeFormat))
+		}
+		h.Add("Link", "<"+d.successor+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
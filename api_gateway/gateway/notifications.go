@@ -0,0 +1,283 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+)
+
+// notificationRequest sends p to the notification service and returns its
+// reply. A contracts.NotificationError reply is returned as the error;
+// failing to reach the notification service is a *dependencyError.
+func (g *Gateway) notificationRequest(ctx context.Context, p contracts.Payload) (contracts.Payload, error) {
+	var reply contracts.Payload
+	err := g.breakers.call("notification_requests", func() error {
+		var err error
+		reply, err = g.rpc(ctx, "notification_requests", p)
+		return err
+	})
+	if err != nil {
+		return nil, &dependencyError{"notification_requests", err}
+	}
+	if notificationErr, ok := reply.(contracts.NotificationError); ok {
+		return nil, notificationErr
+	}
+	return reply, nil
+}
+
+// listNotifications returns a user's notifications.
+func (g *Gateway) listNotifications(ctx context.Context, userID int) ([]contracts.NotificationDetails, error) {
+	reply, err := g.notificationRequest(ctx, contracts.NotificationList{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	result, err := expectReply[contracts.NotificationListResult](reply)
+	return result.Notifications, err
+}
+
+// writeNotificationError answers a failed notification request: requests
+// the notification service refused are the client's fault, the others its
+// own.
+func (g *Gateway) writeNotificationError(ctx context.Context, w http.ResponseWriter, err error) {
+	var notificationErr contracts.NotificationError
+	var depErr *dependencyError
+	switch {
+	case errors.As(err, &notificationErr):
+		switch notificationErr.Code {
+		case contracts.NotificationErrInvalid:
+			http.Error(w, notificationErr.Message, http.StatusBadRequest)
+		case contracts.NotificationErrInvalidToken:
+			http.Error(w, "Invalid unsubscribe link", http.StatusBadRequest)
+		case contracts.NotificationErrNotFound:
+			http.Error(w, notificationErr.Message, http.StatusNotFound)
+		default:
+			http.Error(w, notificationErr.Message, http.StatusServiceUnavailable)
+		}
+	case errors.As(err, &depErr):
+		g.writeDependencyError(ctx, w, depErr.dependency, depErr.err)
+	default:
+		slog.ErrorContext(ctx, "Notification request failed", "error", err)
+		http.Error(w, "Notification request failed", http.StatusInternalServerError)
+	}
+}
+
+// listNotificationsHandler serves GET /api/v2/users/{user_id}/notifications.
+func (g *Gateway) listNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(pathParam(r, "user_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("user_id", userID))
+	notifications, err := g.listNotifications(ctx, userID)
+	if err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+	if notifications == nil {
+		notifications = []contracts.NotificationDetails{}
+	}
+	writeJSON(w, map[string]interface{}{"notifications": notifications})
+}
+
+// Contact is the body of PUT /api/v2/users/{user_id}/contact: how a user
+// wants to be notified. Empty fields are channels the user is not reached
+// on.
+type Contact struct {
+	Email  string                      `json:"email,omitempty"`
+	Phone  string                      `json:"phone,omitempty"`
+	Push   *contracts.PushSubscription `json:"push,omitempty"`
+	Locale string                      `json:"locale,omitempty"`
+}
+
+// e164 matches phone numbers in E.164 form, such as +15550100.
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// languageTag matches BCP 47 language tags such as "de" or "pt-BR".
+var languageTag = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// validate reports what is wrong with c, if anything.
+func (c Contact) validate() error {
+	if c.Email != "" {
+		if addr, err := mail.ParseAddress(c.Email); err != nil || addr.Address != c.Email {
+			return fmt.Errorf("email %q is not an email address", c.Email)
+		}
+	}
+	if c.Phone != "" && !e164.MatchString(c.Phone) {
+		return fmt.Errorf("phone %q is not an E.164 number such as +15550100", c.Phone)
+	}
+	if c.Push != nil {
+		if u, err := url.Parse(c.Push.Endpoint); err != nil || u.Scheme != "https" || u.Host == "" {
+			return errors.New("push endpoint must be an https URL")
+		}
+		if c.Push.Keys.P256dh == "" || c.Push.Keys.Auth == "" {
+			return errors.New("push subscription needs its p256dh and auth keys")
+		}
+	}
+	if c.Locale != "" && !languageTag.MatchString(c.Locale) {
+		return fmt.Errorf("locale %q is not a language tag such as de or pt-BR", c.Locale)
+	}
+	return nil
+}
+
+// contactRequest sends a contact request to the notification service and
+// returns the user's contact details.
+func (g *Gateway) contactRequest(ctx context.Context, p contracts.Payload) (contracts.ContactDetails, error) {
+	reply, err := g.notificationRequest(ctx, p)
+	if err != nil {
+		return contracts.ContactDetails{}, err
+	}
+	return expectReply[contracts.ContactDetails](reply)
+}
+
+// getContactHandler serves GET /api/v2/users/{user_id}/contact.
+func (g *Gateway) getContactHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(pathParam(r, "user_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("user_id", userID))
+	contact, err := g.contactRequest(ctx, contracts.ContactGet{UserID: userID})
+	if err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+	writeJSON(w, contact)
+}
+
+// putContactHandler serves PUT /api/v2/users/{user_id}/contact, which
+// replaces the user's contact details.
+func (g *Gateway) putContactHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(pathParam(r, "user_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	var body Contact
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := body.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("user_id", userID))
+	contact, err := g.contactRequest(ctx, contracts.ContactSet{UserID: userID, Email: body.Email, Phone: body.Phone, Push: body.Push, Locale: body.Locale})
+	if err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+	writeJSON(w, contact)
+}
+
+// Preferences is the body of PUT /api/v2/users/{user_id}/preferences: which
+// categories of notifications a user wants on which channels, when not to
+// disturb them and whether they unsubscribed from marketing. Channels left
+// out of a category get its default.
+type Preferences struct {
+	Categories   map[string]map[string]bool `json:"categories,omitempty"`
+	QuietHours   *contracts.QuietHours      `json:"quiet_hours,omitempty"`
+	Unsubscribed bool                       `json:"unsubscribed,omitempty"`
+}
+
+// preferencesRequest sends a preferences request to the notification
+// service and returns the user's preferences.
+func (g *Gateway) preferencesRequest(ctx context.Context, p contracts.Payload) (contracts.PreferencesDetails, error) {
+	reply, err := g.notificationRequest(ctx, p)
+	if err != nil {
+		return contracts.PreferencesDetails{}, err
+	}
+	return expectReply[contracts.PreferencesDetails](reply)
+}
+
+// getPreferencesHandler serves GET /api/v2/users/{user_id}/preferences.
+func (g *Gateway) getPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(pathParam(r, "user_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("user_id", userID))
+	prefs, err := g.preferencesRequest(ctx, contracts.PreferencesGet{UserID: userID})
+	if err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+	writeJSON(w, prefs)
+}
+
+// putPreferencesHandler serves PUT /api/v2/users/{user_id}/preferences,
+// which replaces the user's notification preferences. The notification
+// service validates them.
+func (g *Gateway) putPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(pathParam(r, "user_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	var body Preferences
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("user_id", userID))
+	prefs, err := g.preferencesRequest(ctx, contracts.PreferencesSet{UserID: userID, Categories: body.Categories, QuietHours: body.QuietHours, Unsubscribed: body.Unsubscribed})
+	if err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+	writeJSON(w, prefs)
+}
+
+// unsubscribePage is the page of the unsubscribe link in marketing emails.
+// Following the link only asks for confirmation, so that mail scanners
+// that fetch links do not unsubscribe anyone; the form posts back to it.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+{{if .Done}}<p>You have been unsubscribed from marketing notifications. You will still get notifications about your orders.</p>
+{{else}}<form method="post" action="?token={{.Token}}">
+<p>Stop getting marketing notifications from the shop?</p>
+<input type="hidden" name="List-Unsubscribe" value="One-Click">
+<button type="submit">Unsubscribe</button>
+</form>
+{{end}}</body>
+</html>
+`))
+
+// unsubscribeHandler serves GET and POST /api/v2/unsubscribe?token=. GET
+// shows a confirmation page; POST, which mail clients send for one-click
+// unsubscribing as RFC 8058 describes, unsubscribes the user the token was
+// issued for. The token is the credential, so no API key is needed.
+func (g *Gateway) unsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Missing unsubscribe token", http.StatusBadRequest)
+		return
+	}
+	if r.Method == http.MethodPost {
+		if _, err := g.preferencesRequest(r.Context(), contracts.Unsubscribe{Token: token}); err != nil {
+			g.writeNotificationError(r.Context(), w, err)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	unsubscribePage.Execute(w, struct {
+		Token string
+		Done  bool
+	}{token, r.Method == http.MethodPost})
+}
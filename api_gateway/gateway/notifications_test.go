@@ -0,0 +1,358 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+// fakeNotifications answers notification_requests from a list of
+// notifications, and keeps contact details, preferences and webhooks, until
+// the test ends. The only valid unsubscribe token is user 7's,
+// "7.signature". Every webhook has a failed delivery with ID 1.
+func fakeNotifications(t *testing.T, b broker.Broker, notifications []contracts.NotificationDetails) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	msgs, err := b.Consume(ctx, "notification_requests")
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	go func() {
+		contacts := map[int]contracts.ContactDetails{}
+		preferences := map[int]contracts.PreferencesDetails{}
+		getPreferences := func(userID int) contracts.PreferencesDetails {
+			prefs, ok := preferences[userID]
+			if !ok {
+				prefs = contracts.PreferencesDetails{UserID: userID, Categories: map[string]map[string]bool{}}
+			}
+			return prefs
+		}
+		// Every notification is in its user's inbox, unread.
+		inbox := make([]contracts.InboxItemDetails, len(notifications))
+		for i, n := range notifications {
+			inbox[i] = contracts.InboxItemDetails{ItemID: n.NotificationID, UserID: n.UserID, NotificationID: n.NotificationID, Category: contracts.CategoryTransactional, Content: n.Content, CreatedAt: n.CreatedAt}
+		}
+		unread := func(userID int) contracts.InboxCountResult {
+			count := contracts.InboxCountResult{UserID: userID}
+			for _, item := range inbox {
+				if item.UserID == userID && !item.Read && !item.Archived {
+					count.Unread++
+				}
+			}
+			return count
+		}
+		webhooks := map[int]contracts.WebhookDetails{}
+		lastWebhook := 0
+		webhookNotFound := contracts.NotificationError{Code: contracts.NotificationErrNotFound, Message: "webhook not found"}
+		failedDelivery := func(webhookID int) contracts.WebhookDeliveryDetails {
+			created := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+			return contracts.WebhookDeliveryDetails{DeliveryID: 1, WebhookID: webhookID, EventID: "evt-1", Event: contracts.EventOrderPlaced, Status: contracts.WebhookDeliveryFailed, Attempts: 6, ResponseStatus: 500, Error: "webhook answered 500 Internal Server Error", CreatedAt: created, UpdatedAt: created}
+		}
+		for msg := range msgs {
+			_, payload, _ := contracts.Decode(msg.ContentType, msg.Body)
+			var reply contracts.Payload = contracts.NotificationError{Code: contracts.NotificationErrUnavailable, Message: "notification database unavailable"}
+			switch req := payload.(type) {
+			case contracts.NotificationList:
+				result := contracts.NotificationListResult{Notifications: []contracts.NotificationDetails{}}
+				for _, n := range notifications {
+					if n.UserID == req.UserID {
+						result.Notifications = append(result.Notifications, n)
+					}
+				}
+				reply = result
+			case contracts.ContactGet:
+				contact, ok := contacts[req.UserID]
+				if !ok {
+					contact = contracts.ContactDetails{UserID: req.UserID}
+				}
+				reply = contact
+			case contracts.ContactSet:
+				contacts[req.UserID] = contracts.ContactDetails(req)
+				reply = contracts.ContactDetails(req)
+			case contracts.PreferencesGet:
+				reply = getPreferences(req.UserID)
+			case contracts.PreferencesSet:
+				prefs := contracts.PreferencesDetails(req)
+				if prefs.Categories == nil {
+					prefs.Categories = map[string]map[string]bool{}
+				}
+				reply = prefs
+				for _, channels := range req.Categories {
+					for channel := range channels {
+						if channel != "email" && channel != "sms" && channel != "push" && channel != "inbox" {
+							reply = contracts.NotificationError{Code: contracts.NotificationErrInvalid, Message: "unknown notification channel " + channel}
+						}
+					}
+				}
+				if _, invalid := reply.(contracts.NotificationError); !invalid {
+					preferences[req.UserID] = prefs
+				}
+			case contracts.Unsubscribe:
+				reply = contracts.NotificationError{Code: contracts.NotificationErrInvalidToken, Message: "invalid unsubscribe token"}
+				if req.Token == "7.signature" {
+					prefs := getPreferences(7)
+					prefs.Unsubscribed = true
+					preferences[7] = prefs
+					reply = prefs
+				}
+			case contracts.InboxList:
+				result := contracts.InboxListResult{Items: []contracts.InboxItemDetails{}, Unread: unread(req.UserID).Unread}
+				for i := len(inbox) - 1; i >= 0; i-- {
+					if inbox[i].UserID == req.UserID && inbox[i].Archived == req.Archived {
+						result.Items = append(result.Items, inbox[i])
+					}
+				}
+				reply = result
+			case contracts.InboxCount:
+				reply = unread(req.UserID)
+			case contracts.InboxMarkAllRead:
+				for i := range inbox {
+					if inbox[i].UserID == req.UserID {
+						inbox[i].Read = true
+					}
+				}
+				reply = unread(req.UserID)
+			case contracts.InboxUpdate:
+				reply = contracts.NotificationError{Code: contracts.NotificationErrNotFound, Message: "inbox item not found"}
+				for i := range inbox {
+					if inbox[i].ItemID != req.ItemID || inbox[i].UserID != req.UserID {
+						continue
+					}
+					switch req.Action {
+					case contracts.InboxActionRead, contracts.InboxActionUnread:
+						inbox[i].Read = req.Action == contracts.InboxActionRead
+					case contracts.InboxActionArchive, contracts.InboxActionUnarchive:
+						inbox[i].Archived = req.Action == contracts.InboxActionArchive
+					}
+					reply = inbox[i]
+				}
+			case contracts.WebhookSet:
+				webhook := contracts.WebhookDetails{WebhookID: req.WebhookID, URL: req.URL, Events: req.Events, Secret: req.Secret, Active: req.Active}
+				if stored, ok := webhooks[req.WebhookID]; ok {
+					webhook.CreatedAt = stored.CreatedAt
+				} else if req.WebhookID != 0 {
+					reply = webhookNotFound
+					break
+				} else {
+					lastWebhook++
+					webhook.WebhookID = lastWebhook
+					webhook.CreatedAt = time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+					if webhook.Secret == "" {
+						webhook.Secret = "whsec_generated"
+					}
+				}
+				webhook.UpdatedAt = webhook.CreatedAt
+				if !strings.HasPrefix(req.URL, "https://") && !strings.HasPrefix(req.URL, "http://") {
+					reply = contracts.NotificationError{Code: contracts.NotificationErrInvalid, Message: "webhook URL is not an http or https URL"}
+					break
+				}
+				reply = webhook
+				webhook.Secret = ""
+				webhooks[webhook.WebhookID] = webhook
+			case contracts.WebhookList:
+				result := contracts.WebhookListResult{Webhooks: []contracts.WebhookDetails{}}
+				for id := 1; id <= lastWebhook; id++ {
+					if webhook, ok := webhooks[id]; ok {
+						result.Webhooks = append(result.Webhooks, webhook)
+					}
+				}
+				reply = result
+			case contracts.WebhookDelete:
+				reply = webhookNotFound
+				if webhook, ok := webhooks[req.WebhookID]; ok {
+					delete(webhooks, req.WebhookID)
+					reply = webhook
+				}
+			case contracts.WebhookDeliveryList:
+				reply = webhookNotFound
+				if _, ok := webhooks[req.WebhookID]; ok {
+					reply = contracts.WebhookDeliveryListResult{Deliveries: []contracts.WebhookDeliveryDetails{failedDelivery(req.WebhookID)}}
+				}
+			case contracts.WebhookRedeliver:
+				reply = webhookNotFound
+				if _, ok := webhooks[req.WebhookID]; ok && req.DeliveryID == 1 {
+					d := failedDelivery(req.WebhookID)
+					d.Status = contracts.WebhookDeliveryPending
+					reply = d
+				}
+			}
+			enc, _ := contracts.EncodingOf(msg.ContentType)
+			_, body, _ := contracts.Encode(enc, "notification_service", reply)
+			b.Publish(ctx, "", msg.ReplyTo, broker.Message{ContentType: enc.ContentType(), CorrelationID: msg.CorrelationID, Body: body})
+			msg.Ack()
+		}
+	}()
+}
+
+// testNotifications returns two notifications of user 7, the first
+// delivered and the second failed.
+func testNotifications() []contracts.NotificationDetails {
+	sent := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	return []contracts.NotificationDetails{
+		{NotificationID: 1, UserID: 7, Channel: "log", Template: "order_placed", Content: "Your order has been successfully placed!", Status: contracts.NotificationStatusDelivered, Attempts: 1, CreatedAt: sent, UpdatedAt: sent, DeliveredAt: &sent},
+		{NotificationID: 2, UserID: 7, Channel: "log", Content: "Order processed successfully!", Status: contracts.NotificationStatusFailed, Attempts: 1, CreatedAt: sent, UpdatedAt: sent},
+	}
+}
+
+func TestNotificationHistory(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeNotifications(t, b, testNotifications())
+
+	for _, tt := range []struct {
+		path string
+		want int
+		n    int
+	}{
+		{"/api/v2/users/7/notifications", http.StatusOK, 2},
+		{"/api/v2/users/99/notifications", http.StatusOK, 0},
+		{"/api/v2/users/me/notifications", http.StatusNotFound, 0},
+	} {
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("GET %s = %d, want %d: %s", tt.path, rec.Code, tt.want, rec.Body)
+			continue
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		var body struct {
+			Notifications []contracts.NotificationDetails `json:"notifications"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Notifications == nil {
+			t.Errorf("GET %s body: %v, notifications %v", tt.path, err, body.Notifications)
+			continue
+		}
+		if len(body.Notifications) != tt.n {
+			t.Errorf("GET %s returned %d notifications, want %d", tt.path, len(body.Notifications), tt.n)
+		}
+	}
+}
+
+func TestNotificationHistoryTimeout(t *testing.T) {
+	cfg := testConfig()
+	cfg.RPCTimeout = 20 * time.Millisecond
+	g, _ := setupGateway(t, cfg)
+
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/users/7/notifications", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want 504", rec.Code)
+	}
+}
+
+func TestContactDetails(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeNotifications(t, b, nil)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/api/v2/users/7/contact", "")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"user_id":7}` {
+		t.Fatalf("GET before PUT = %d %s, want an empty contact", rec.Code, rec.Body)
+	}
+
+	contact := `{"email":"ada@example.com","phone":"+15550100","push":{"endpoint":"https://push.example/abc","keys":{"p256dh":"BKey","auth":"secret"}},"locale":"pt-BR"}`
+	if rec := serve(http.MethodPut, "/api/v2/users/7/contact", contact); rec.Code != http.StatusOK {
+		t.Fatalf("PUT = %d: %s", rec.Code, rec.Body)
+	}
+	rec = serve(http.MethodGet, "/api/v2/users/7/contact", "")
+	var got contracts.ContactDetails
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("GET body: %v", err)
+	}
+	if got.UserID != 7 || got.Email != "ada@example.com" || got.Phone != "+15550100" || got.Push == nil || got.Push.Keys.Auth != "secret" || got.Locale != "pt-BR" {
+		t.Fatalf("GET after PUT = %+v", got)
+	}
+
+	for name, body := range map[string]string{
+		"malformed body":       `{"email":`,
+		"bad email":            `{"email":"ada at example.com"}`,
+		"email with name":      `{"email":"Ada <ada@example.com>"}`,
+		"local phone":          `{"phone":"555-0100"}`,
+		"plain http push":      `{"push":{"endpoint":"http://push.example/abc","keys":{"p256dh":"BKey","auth":"secret"}}}`,
+		"push without keys":    `{"push":{"endpoint":"https://push.example/abc"}}`,
+		"push without address": `{"push":{"endpoint":"","keys":{"p256dh":"BKey","auth":"secret"}}}`,
+		"bad locale":           `{"locale":"German"}`,
+	} {
+		if rec := serve(http.MethodPut, "/api/v2/users/7/contact", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("PUT with %s = %d, want 400", name, rec.Code)
+		}
+	}
+
+	if rec := serve(http.MethodDelete, "/api/v2/users/7/contact", ""); rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, PUT" {
+		t.Errorf("DELETE = %d with Allow %q, want 405 with GET, PUT", rec.Code, rec.Header().Get("Allow"))
+	}
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	cfg := testConfig()
+	cfg.APIKeys = []string{"secret"}
+	g, b := setupGateway(t, cfg)
+	fakeNotifications(t, b, nil)
+
+	serve := func(method, path, body string, key bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key {
+			req.Header.Set(apiKeyHeader, "secret")
+		}
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, req)
+		return rec
+	}
+
+	prefs := `{"categories":{"marketing":{"email":true}},"quiet_hours":{"start":"22:00","end":"07:00","time_zone":"Europe/Berlin"}}`
+	if rec := serve(http.MethodPut, "/api/v2/users/7/preferences", prefs, true); rec.Code != http.StatusOK {
+		t.Fatalf("PUT = %d: %s", rec.Code, rec.Body)
+	}
+	rec := serve(http.MethodGet, "/api/v2/users/7/preferences", "", true)
+	var got contracts.PreferencesDetails
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("GET body: %v", err)
+	}
+	if got.UserID != 7 || !got.Categories["marketing"]["email"] || got.QuietHours == nil || got.QuietHours.TimeZone != "Europe/Berlin" || got.Unsubscribed {
+		t.Fatalf("GET after PUT = %+v", got)
+	}
+	if rec := serve(http.MethodPut, "/api/v2/users/7/preferences", `{"categories":{"marketing":{"fax":true}}}`, true); rec.Code != http.StatusBadRequest {
+		t.Errorf("PUT refused by the notification service = %d, want 400", rec.Code)
+	}
+	if rec := serve(http.MethodPut, "/api/v2/users/7/preferences", `{"categories":`, true); rec.Code != http.StatusBadRequest {
+		t.Errorf("PUT with a malformed body = %d, want 400", rec.Code)
+	}
+	if rec := serve(http.MethodGet, "/api/v2/users/7/preferences", "", false); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET without an API key = %d, want 401", rec.Code)
+	}
+
+	// Following the link only asks for confirmation.
+	rec = serve(http.MethodGet, "/api/v2/unsubscribe?token=7.signature", "", false)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `action="?token=7.signature"`) {
+		t.Fatalf("GET unsubscribe = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(http.MethodGet, "/api/v2/users/7/preferences", "", true); strings.Contains(rec.Body.String(), `"unsubscribed":true`) {
+		t.Fatal("GET unsubscribe unsubscribed the user")
+	}
+	rec = serve(http.MethodPost, "/api/v2/unsubscribe?token=7.signature", "List-Unsubscribe=One-Click", false)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "You have been unsubscribed") {
+		t.Fatalf("POST unsubscribe = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(http.MethodGet, "/api/v2/users/7/preferences", "", true); !strings.Contains(rec.Body.String(), `"unsubscribed":true`) {
+		t.Fatalf("preferences after unsubscribing = %s", rec.Body)
+	}
+	for _, path := range []string{"/api/v2/unsubscribe?token=8.forged", "/api/v2/unsubscribe"} {
+		if rec := serve(http.MethodPost, path, "", false); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST %s = %d, want 400", path, rec.Code)
+		}
+	}
+}
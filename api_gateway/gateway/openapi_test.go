@@ -0,0 +1,230 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+// loadSpec parses and validates openAPISpec and returns a router over it
+// that accepts any host.
+func loadSpec(t *testing.T) (*openapi3.T, routers.Router) {
+	t.Helper()
+	openapi3filter.RegisterBodyDecoder("text/html", openapi3filter.FileBodyDecoder)
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	if err != nil {
+		t.Fatalf("load openapi.json: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("openapi.json is invalid: %v", err)
+	}
+	doc.Servers = nil
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	return doc, router
+}
+
+func TestOpenAPIIsServed(t *testing.T) {
+	g, _ := setupGateway(t, testConfig())
+	for _, tt := range []struct {
+		path, contentType string
+	}{
+		{"/api/openapi.json", "application/json"},
+		{"/api/docs", "text/html; charset=utf-8"},
+	} {
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("GET %s = %d %q, want 200 %q", tt.path, rec.Code, rec.Header().Get("Content-Type"), tt.contentType)
+		}
+	}
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if !json.Valid(rec.Body.Bytes()) {
+		t.Error("/api/openapi.json is not valid JSON")
+	}
+}
+
+// TestHandlersConformToOpenAPI drives every documented operation through the
+// gateway and checks each request and response against openapi.json.
+func TestHandlersConformToOpenAPI(t *testing.T) {
+	doc, router := loadSpec(t)
+
+	cfg := testConfig()
+	cfg.APIKeys = []string{"secret"}
+	cfg.OrderDailyQuota = 2
+	g, b := setupGateway(t, cfg)
+	fakeInventory(t, b, map[int]int{101: 5})
+	fakeOrders(t, b, testOrders())
+	fakeNotifications(t, b, testNotifications())
+	fakeInventoryRequests(t, b, testProducts())
+	fakeHealth(t, b, map[string]string{"inventory_service": "healthy"})
+
+	// A gateway without an order service, for timeouts.
+	slowCfg := testConfig()
+	slowCfg.RPCTimeout = 20 * time.Millisecond
+	slow, _ := setupGateway(t, slowCfg)
+
+	order := func(id, quantity int) string {
+		body, _ := json.Marshal(OrderRequest{OrderID: id, ProductID: 101, UserID: 7, Quantity: quantity})
+		return string(body)
+	}
+	covered := map[string]bool{}
+	for _, tt := range []struct {
+		name         string
+		gw           *Gateway
+		method, path string
+		body         string
+		noKey        bool
+		badRequest   bool // the request itself breaks the spec
+		want         int
+	}{
+		{"v1 place order", g, http.MethodPost, "/api/v1/process-order", order(3, 1), false, false, http.StatusOK},
+		{"v1 out of stock", g, http.MethodPost, "/api/v1/process-order", order(4, 9), false, false, http.StatusConflict},
+		{"v1 invalid body", g, http.MethodPost, "/api/v1/process-order", "not json", false, true, http.StatusBadRequest},
+		{"v1 no units", g, http.MethodPost, "/api/v1/process-order", order(8, 0), false, true, http.StatusBadRequest},
+		{"v1 no API key", g, http.MethodPost, "/api/v1/process-order", order(5, 1), true, false, http.StatusUnauthorized},
+		{"v1 list orders", g, http.MethodGet, "/api/v1/orders?user_id=7", "", false, false, http.StatusOK},
+		{"v1 list no orders", g, http.MethodGet, "/api/v1/orders?user_id=99", "", false, false, http.StatusOK},
+		{"v1 list without user", g, http.MethodGet, "/api/v1/orders", "", false, true, http.StatusBadRequest},
+		{"v1 get order", g, http.MethodGet, "/api/v1/orders/1", "", false, false, http.StatusOK},
+		{"v1 unknown order", g, http.MethodGet, "/api/v1/orders/9", "", false, false, http.StatusNotFound},
+		{"v1 cancel order", g, http.MethodPost, "/api/v1/orders/1/cancel", "", false, false, http.StatusOK},
+		{"v1 cancel twice", g, http.MethodPost, "/api/v1/orders/1/cancel", "", false, false, http.StatusConflict},
+		{"v1 order service timeout", slow, http.MethodGet, "/api/v1/orders/1", "", false, false, http.StatusGatewayTimeout},
+		{"v2 place order", g, http.MethodPost, "/api/v2/orders", order(6, 1), false, false, http.StatusCreated},
+		{"v2 quota exceeded", g, http.MethodPost, "/api/v2/orders", order(7, 1), false, false, http.StatusTooManyRequests},
+		{"v2 invalid body", g, http.MethodPost, "/api/v2/orders", "not json", false, true, http.StatusBadRequest},
+		{"v2 list orders", g, http.MethodGet, "/api/v2/orders?user_id=7", "", false, false, http.StatusOK},
+		{"v2 get order", g, http.MethodGet, "/api/v2/orders/2", "", false, false, http.StatusOK},
+		{"v2 unknown order", g, http.MethodGet, "/api/v2/orders/9", "", false, false, http.StatusNotFound},
+		{"v2 cancel cancelled order", g, http.MethodPost, "/api/v2/orders/2/cancel", "", false, false, http.StatusConflict},
+		{"v2 order service timeout", slow, http.MethodPost, "/api/v2/orders/1/cancel", "", false, false, http.StatusGatewayTimeout},
+		{"v2 get product", g, http.MethodGet, "/api/v2/products/101", "", false, false, http.StatusOK},
+		{"v2 unknown product", g, http.MethodGet, "/api/v2/products/404", "", false, false, http.StatusNotFound},
+		{"v2 set reorder policy", g, http.MethodPut, "/api/v2/products/101/reorder_policy", `{"reorder_threshold":15,"safety_stock":25}`, false, false, http.StatusOK},
+		{"v2 invalid reorder policy", g, http.MethodPut, "/api/v2/products/101/reorder_policy", `{"safety_stock":-1}`, false, true, http.StatusBadRequest},
+		{"v2 adjust stock", g, http.MethodPost, "/api/v2/products/101/stock_adjustments", `{"quantity":-4,"reason":"damaged"}`, false, false, http.StatusOK},
+		{"v2 adjust below zero", g, http.MethodPost, "/api/v2/products/101/stock_adjustments", `{"quantity":-17}`, false, false, http.StatusConflict},
+		{"v2 receive stock", g, http.MethodPost, "/api/v2/products/101/stock_adjustments", `{"quantity":4,"type":"receipt","actor":"ada","reference":"PO-1001"}`, false, false, http.StatusOK},
+		{"v2 invalid adjustment type", g, http.MethodPost, "/api/v2/products/101/stock_adjustments", `{"quantity":1,"type":"reservation"}`, false, true, http.StatusBadRequest},
+		{"v2 stock movements", g, http.MethodGet, "/api/v2/products/101/movements", "", false, false, http.StatusOK},
+		{"v2 movements of unknown product", g, http.MethodGet, "/api/v2/products/404/movements", "", false, false, http.StatusNotFound},
+		{"v2 reorder suggestions", g, http.MethodGet, "/api/v2/reports/reorder_suggestions?cover_days=14", "", false, false, http.StatusOK},
+		{"v2 invalid report window", g, http.MethodGet, "/api/v2/reports/reorder_suggestions?window_days=0", "", false, true, http.StatusBadRequest},
+		{"v2 inventory service timeout", slow, http.MethodGet, "/api/v2/products/101", "", false, false, http.StatusGatewayTimeout},
+		{"v2 notification history", g, http.MethodGet, "/api/v2/users/7/notifications", "", false, false, http.StatusOK},
+		{"v2 notification service timeout", slow, http.MethodGet, "/api/v2/users/7/notifications", "", false, false, http.StatusGatewayTimeout},
+		{"v2 contact before update", g, http.MethodGet, "/api/v2/users/8/contact", "", false, false, http.StatusOK},
+		{"v2 update contact", g, http.MethodPut, "/api/v2/users/7/contact", `{"email":"ada@example.com","phone":"+15550100","push":{"endpoint":"https://push.example/abc","keys":{"p256dh":"BKey","auth":"secret"}}}`, false, false, http.StatusOK},
+		{"v2 get contact", g, http.MethodGet, "/api/v2/users/7/contact", "", false, false, http.StatusOK},
+		{"v2 invalid phone", g, http.MethodPut, "/api/v2/users/7/contact", `{"phone":"555-0100"}`, false, true, http.StatusBadRequest},
+		{"v2 contact service timeout", slow, http.MethodPut, "/api/v2/users/7/contact", `{"email":"ada@example.com"}`, false, false, http.StatusGatewayTimeout},
+		{"v2 preferences before update", g, http.MethodGet, "/api/v2/users/8/preferences", "", false, false, http.StatusOK},
+		{"v2 update preferences", g, http.MethodPut, "/api/v2/users/7/preferences", `{"categories":{"marketing":{"email":true}},"quiet_hours":{"start":"22:00","end":"07:00","time_zone":"Europe/Berlin"}}`, false, false, http.StatusOK},
+		{"v2 invalid preferences", g, http.MethodPut, "/api/v2/users/7/preferences", `{"categories":{"marketing":{"fax":true}}}`, false, false, http.StatusBadRequest},
+		{"v2 preferences service timeout", slow, http.MethodGet, "/api/v2/users/7/preferences", "", false, false, http.StatusGatewayTimeout},
+		{"v2 inbox", g, http.MethodGet, "/api/v2/users/7/inbox", "", false, false, http.StatusOK},
+		{"v2 archived inbox", g, http.MethodGet, "/api/v2/users/7/inbox?archived=true", "", false, false, http.StatusOK},
+		{"v2 invalid archived flag", g, http.MethodGet, "/api/v2/users/7/inbox?archived=maybe", "", false, true, http.StatusBadRequest},
+		{"v2 unread count", g, http.MethodGet, "/api/v2/users/7/inbox/unread_count", "", false, false, http.StatusOK},
+		{"v2 inbox stream timeout", slow, http.MethodGet, "/api/v2/users/7/inbox/stream", "", false, false, http.StatusGatewayTimeout},
+		{"v2 read inbox item", g, http.MethodPost, "/api/v2/users/7/inbox/1/read", "", false, false, http.StatusOK},
+		{"v2 read unknown inbox item", g, http.MethodPost, "/api/v2/users/7/inbox/9/read", "", false, false, http.StatusNotFound},
+		{"v2 unread inbox item", g, http.MethodPost, "/api/v2/users/7/inbox/1/unread", "", false, false, http.StatusOK},
+		{"v2 archive inbox item", g, http.MethodPost, "/api/v2/users/7/inbox/2/archive", "", false, false, http.StatusOK},
+		{"v2 unarchive inbox item", g, http.MethodPost, "/api/v2/users/7/inbox/2/unarchive", "", false, false, http.StatusOK},
+		{"v2 mark inbox read", g, http.MethodPost, "/api/v2/users/7/inbox/mark_all_read", "", false, false, http.StatusOK},
+		{"v2 unsubscribe page", g, http.MethodGet, "/api/v2/unsubscribe?token=7.signature", "", true, false, http.StatusOK},
+		{"v2 unsubscribe without token", g, http.MethodGet, "/api/v2/unsubscribe", "", true, true, http.StatusBadRequest},
+		{"v2 unsubscribe", g, http.MethodPost, "/api/v2/unsubscribe?token=7.signature", "", true, false, http.StatusOK},
+		{"v2 unsubscribe forged token", g, http.MethodPost, "/api/v2/unsubscribe?token=7.forged", "", true, false, http.StatusBadRequest},
+		{"v2 create webhook", g, http.MethodPost, "/api/v2/webhooks", `{"url":"https://partner.example/hooks","events":["order.placed","stock.low"]}`, false, false, http.StatusCreated},
+		{"v2 invalid webhook URL", g, http.MethodPost, "/api/v2/webhooks", `{"url":"ftp://partner.example/hooks","events":["order.placed"]}`, false, false, http.StatusBadRequest},
+		{"v2 list webhooks", g, http.MethodGet, "/api/v2/webhooks", "", false, false, http.StatusOK},
+		{"v2 update webhook", g, http.MethodPut, "/api/v2/webhooks/1", `{"url":"https://partner.example/v2","events":["order.cancelled"],"active":true}`, false, false, http.StatusOK},
+		{"v2 update unknown webhook", g, http.MethodPut, "/api/v2/webhooks/9", `{"url":"https://partner.example/v2","events":["order.cancelled"]}`, false, false, http.StatusNotFound},
+		{"v2 webhook deliveries", g, http.MethodGet, "/api/v2/webhooks/1/deliveries", "", false, false, http.StatusOK},
+		{"v2 redeliver webhook", g, http.MethodPost, "/api/v2/webhooks/1/deliveries/1/redeliver", "", false, false, http.StatusAccepted},
+		{"v2 redeliver unknown delivery", g, http.MethodPost, "/api/v2/webhooks/1/deliveries/9/redeliver", "", false, false, http.StatusNotFound},
+		{"v2 delete webhook", g, http.MethodDelete, "/api/v2/webhooks/1", "", false, false, http.StatusNoContent},
+		{"v2 delete unknown webhook", g, http.MethodDelete, "/api/v2/webhooks/1", "", false, false, http.StatusNotFound},
+		{"v2 webhook service timeout", slow, http.MethodGet, "/api/v2/webhooks", "", false, false, http.StatusGatewayTimeout},
+		{"health check", g, http.MethodGet, "/api/health-check", "", true, false, http.StatusOK},
+		{"openapi", g, http.MethodGet, "/api/openapi.json", "", true, false, http.StatusOK},
+		{"docs", g, http.MethodGet, "/api/docs", "", true, false, http.StatusOK},
+		{"metrics", g, http.MethodGet, "/metrics", "", true, false, http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if !tt.noKey {
+				req.Header.Set(apiKeyHeader, "secret")
+			}
+			rec := httptest.NewRecorder()
+			tt.gw.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("%s %s = %d, want %d: %s", tt.method, tt.path, rec.Code, tt.want, rec.Body)
+			}
+
+			route, params, err := router.FindRoute(httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body)))
+			if err != nil {
+				t.Fatalf("%s %s is not in openapi.json: %v", tt.method, tt.path, err)
+			}
+			covered[route.Method+" "+route.Path] = true
+			reqInput := &openapi3filter.RequestValidationInput{
+				Request:    httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body)),
+				PathParams: params,
+				Route:      route,
+				Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+			}
+			if tt.body != "" {
+				reqInput.Request.Header.Set("Content-Type", "application/json")
+			}
+			err = openapi3filter.ValidateRequest(context.Background(), reqInput)
+			if tt.badRequest != (err != nil) {
+				t.Errorf("request validation error = %v, want error %t", err, tt.badRequest)
+			}
+
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: reqInput,
+				Status:                 rec.Code,
+				Header:                 rec.Header(),
+				Body:                   io.NopCloser(rec.Body),
+				Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+			})
+			if err != nil {
+				t.Errorf("response does not match openapi.json: %v", err)
+			}
+		})
+	}
+
+	// Every documented operation should be exercised above.
+	var missing []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			if !covered[method+" "+path] {
+				missing = append(missing, method+" "+path)
+			}
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("operations not exercised: %v", missing)
+	}
+}
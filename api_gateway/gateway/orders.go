@@ -0,0 +1,300 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+)
+
+// errOutOfStock is returned when an order asks for more than is in stock.
+var errOutOfStock = errors.New("stock not available")
+
+// errInvalidQuantity is returned for an order of no units, or fewer.
+var errInvalidQuantity = errors.New("quantity must be positive")
+
+// placedMessage is what v1 and gRPC callers are told was sent to the user
+// for a placed order. The notification itself is rendered from the
+// order_placed template.
+const placedMessage = "Your order has been successfully placed!"
+
+// quotaError is returned when the account an order is charged to has used
+// up its daily quota.
+type quotaError struct {
+	resetIn time.Duration // until the quota resets
+}
+
+func (e *quotaError) Error() string { return "daily order quota exceeded" }
+
+// dependencyError is a failed call to a downstream queue.
+type dependencyError struct {
+	dependency string
+	err        error
+}
+
+func (e *dependencyError) Error() string { return e.dependency + ": " + e.err.Error() }
+
+func (e *dependencyError) Unwrap() error { return e.err }
+
+// placement is the outcome of a placed order.
+type placement struct {
+	stock        contracts.StockCheckResult
+	notification contracts.NotificationRequested
+}
+
+// placeOrder reserves stock for orderReq, hands the order to the order
+// service and notifies the user, charging the order to account's daily
+// quota. It is shared by the REST and gRPC APIs and returns
+// errInvalidQuantity, a *quotaError, errOutOfStock or a *dependencyError
+// when the order is not placed.
+func (g *Gateway) placeOrder(ctx context.Context, account string, orderReq OrderRequest) (placement, error) {
+	if orderReq.Quantity <= 0 {
+		ordersRejected.WithLabelValues("invalid").Inc()
+		return placement{}, errInvalidQuantity
+	}
+	ok, resetIn := g.orderQuota.reserve(account, time.Now())
+	if !ok {
+		ordersRejected.WithLabelValues("quota").Inc()
+		return placement{}, &quotaError{resetIn: resetIn}
+	}
+	placed := false
+	defer func() {
+		if !placed {
+			g.orderQuota.release(account)
+		}
+	}()
+
+	// Step 1: Check Stock
+	stockResp, err := g.requestStock(ctx, orderReq)
+	if err != nil {
+		var inventoryErr contracts.InventoryError
+		if !errors.Is(err, errBreakerOpen) && !errors.Is(err, errBulkheadFull) && !errors.As(err, &inventoryErr) {
+			// The check was sent and not refused, so stock may have been
+			// reserved, even after the gateway stopped waiting for the reply.
+			g.releaseStock(ctx, orderReq, "stock check failed")
+		}
+		return placement{}, err
+	}
+
+	if !stockResp.IsAvailable {
+		ordersRejected.WithLabelValues("out_of_stock").Inc()
+		return placement{}, errOutOfStock
+	}
+
+	// Step 2: Place Order
+	err = g.breakers.call("place_order", func() error {
+		return g.publishToQueue(ctx, "place_order", contracts.OrderPlaced(orderReq))
+	})
+	if err != nil {
+		g.releaseStock(ctx, orderReq, "order not placed")
+		return placement{}, &dependencyError{"place_order", err}
+	}
+	placed = true
+	ordersPlaced.Inc()
+
+	// Step 3: Notify User
+	notification := contracts.NotificationRequested{
+		UserID:   orderReq.UserID,
+		Template: "order_placed",
+		Data: map[string]string{
+			"order_id":   strconv.Itoa(orderReq.OrderID),
+			"product_id": strconv.Itoa(orderReq.ProductID),
+			"quantity":   strconv.Itoa(orderReq.Quantity),
+		},
+	}
+	err = g.breakers.call("notifications", func() error {
+		return g.publishToQueue(ctx, "notifications", notification)
+	})
+	if err != nil {
+		return placement{}, &dependencyError{"notifications", err}
+	}
+	return placement{stock: stockResp, notification: notification}, nil
+}
+
+// orderRequest sends an order query or cancellation to the order service
+// and returns its reply. A contracts.OrderError reply is returned as the
+// error; failing to reach the order service is a *dependencyError.
+func (g *Gateway) orderRequest(ctx context.Context, req contracts.Payload) (contracts.Payload, error) {
+	var reply contracts.Payload
+	err := g.breakers.call("order_requests", func() error {
+		var err error
+		reply, err = g.rpc(ctx, "order_requests", req)
+		return err
+	})
+	if err != nil {
+		return nil, &dependencyError{"order_requests", err}
+	}
+	if orderErr, ok := reply.(contracts.OrderError); ok {
+		return nil, orderErr
+	}
+	return reply, nil
+}
+
+// getOrder returns a single order.
+func (g *Gateway) getOrder(ctx context.Context, orderID int) (contracts.OrderDetails, error) {
+	reply, err := g.orderRequest(ctx, contracts.OrderGet{OrderID: orderID})
+	if err != nil {
+		return contracts.OrderDetails{}, err
+	}
+	return expectReply[contracts.OrderDetails](reply)
+}
+
+// listOrders returns a user's orders.
+func (g *Gateway) listOrders(ctx context.Context, userID int) ([]contracts.OrderDetails, error) {
+	reply, err := g.orderRequest(ctx, contracts.OrderList{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	result, err := expectReply[contracts.OrderListResult](reply)
+	return result.Orders, err
+}
+
+// cancelOrder cancels an order. The order service returns its stock.
+func (g *Gateway) cancelOrder(ctx context.Context, orderID int) (contracts.OrderDetails, error) {
+	reply, err := g.orderRequest(ctx, contracts.OrderCancel{OrderID: orderID})
+	if err != nil {
+		return contracts.OrderDetails{}, err
+	}
+	return expectReply[contracts.OrderDetails](reply)
+}
+
+// expectReply returns reply as a T, or an error if it is another type.
+func expectReply[T contracts.Payload](reply contracts.Payload) (T, error) {
+	p, ok := reply.(T)
+	if !ok {
+		return p, fmt.Errorf("unexpected %s reply", reply.MessageType())
+	}
+	return p, nil
+}
+
+// placeOrderHandler serves POST /api/v2/orders. Unlike v1's process-order
+// it answers 201 Created with the order resource and its location.
+func (g *Gateway) placeOrderHandler(w http.ResponseWriter, r *http.Request) {
+	var orderReq OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&orderReq); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(),
+		slog.Int("order_id", orderReq.OrderID),
+		slog.Int("user_id", orderReq.UserID),
+	)
+
+	placed, err := g.placeOrder(ctx, clientKey(r), orderReq)
+	if err != nil {
+		g.writePlaceOrderError(ctx, w, err)
+		return
+	}
+	order := contracts.OrderDetails{
+		OrderID:   orderReq.OrderID,
+		ProductID: orderReq.ProductID,
+		UserID:    orderReq.UserID,
+		Quantity:  orderReq.Quantity,
+		Status:    contracts.OrderStatusPlaced,
+	}
+	w.Header().Set("Location", "/api/v2/orders/"+strconv.Itoa(orderReq.OrderID))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"order":        order,
+		"notification": placed.notification,
+	})
+}
+
+// listOrdersHandler serves GET /api/v1/orders?user_id=N and its v2 twin.
+func (g *Gateway) listOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil {
+		http.Error(w, "user_id query parameter is required", http.StatusBadRequest)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("user_id", userID))
+	orders, err := g.listOrders(ctx, userID)
+	if err != nil {
+		g.writeOrderError(ctx, w, err)
+		return
+	}
+	if orders == nil {
+		orders = []contracts.OrderDetails{}
+	}
+	writeJSON(w, map[string]interface{}{"orders": orders})
+}
+
+// getOrderHandler serves GET /api/v1/orders/{order_id} and its v2 twin.
+func (g *Gateway) getOrderHandler(w http.ResponseWriter, r *http.Request) {
+	g.serveOrder(w, r, g.getOrder)
+}
+
+// cancelOrderHandler serves POST /api/v1/orders/{order_id}/cancel and its
+// v2 twin.
+func (g *Gateway) cancelOrderHandler(w http.ResponseWriter, r *http.Request) {
+	g.serveOrder(w, r, g.cancelOrder)
+}
+
+// serveOrder answers with the order named by the request path after
+// applying do to it.
+func (g *Gateway) serveOrder(w http.ResponseWriter, r *http.Request, do func(context.Context, int) (contracts.OrderDetails, error)) {
+	orderID, err := strconv.Atoi(pathParam(r, "order_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("order_id", orderID))
+	order, err := do(ctx, orderID)
+	if err != nil {
+		g.writeOrderError(ctx, w, err)
+		return
+	}
+	writeJSON(w, order)
+}
+
+// writePlaceOrderError maps a failed placeOrder onto an HTTP error response.
+func (g *Gateway) writePlaceOrderError(ctx context.Context, w http.ResponseWriter, err error) {
+	var quotaErr *quotaError
+	var depErr *dependencyError
+	switch {
+	case errors.Is(err, errInvalidQuantity):
+		http.Error(w, "Quantity must be positive", http.StatusBadRequest)
+	case errors.As(err, &quotaErr):
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(quotaErr.resetIn)))
+		http.Error(w, "Daily order quota exceeded", http.StatusTooManyRequests)
+	case errors.Is(err, errOutOfStock):
+		http.Error(w, "Stock not available", http.StatusConflict)
+	case errors.As(err, &depErr):
+		g.writeDependencyError(ctx, w, depErr.dependency, depErr.err)
+	default:
+		slog.ErrorContext(ctx, "Failed to place order", "error", err)
+		http.Error(w, "Failed to place order", http.StatusInternalServerError)
+	}
+}
+
+// writeOrderError maps a failed order request onto an HTTP error response.
+func (g *Gateway) writeOrderError(ctx context.Context, w http.ResponseWriter, err error) {
+	var orderErr contracts.OrderError
+	var depErr *dependencyError
+	switch {
+	case errors.As(err, &orderErr) && orderErr.Code == contracts.OrderErrNotFound:
+		http.Error(w, orderErr.Message, http.StatusNotFound)
+	case errors.As(err, &orderErr) && orderErr.Code == contracts.OrderErrAlreadyCancelled:
+		http.Error(w, orderErr.Message, http.StatusConflict)
+	case errors.As(err, &orderErr):
+		http.Error(w, orderErr.Message, http.StatusServiceUnavailable)
+	case errors.As(err, &depErr):
+		g.writeDependencyError(ctx, w, depErr.dependency, depErr.err)
+	default:
+		slog.ErrorContext(ctx, "Order request failed", "error", err)
+		http.Error(w, "Order request failed", http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
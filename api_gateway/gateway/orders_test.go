@@ -0,0 +1,225 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+// fakeOrders answers order_requests from a map of orders until the test
+// ends, cancelling orders in place.
+func fakeOrders(t *testing.T, b broker.Broker, orders map[int]contracts.OrderDetails) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	msgs, err := b.Consume(ctx, "order_requests")
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	var mu sync.Mutex
+	go func() {
+		for msg := range msgs {
+			_, payload, _ := contracts.Decode(msg.ContentType, msg.Body)
+			mu.Lock()
+			var reply contracts.Payload = contracts.OrderError{Code: contracts.OrderErrNotFound, Message: "order not found"}
+			switch req := payload.(type) {
+			case contracts.OrderGet:
+				if o, ok := orders[req.OrderID]; ok {
+					reply = o
+				}
+			case contracts.OrderList:
+				result := contracts.OrderListResult{Orders: []contracts.OrderDetails{}}
+				for id := 1; id <= len(orders); id++ {
+					if o := orders[id]; o.UserID == req.UserID {
+						result.Orders = append(result.Orders, o)
+					}
+				}
+				reply = result
+			case contracts.OrderCancel:
+				o, ok := orders[req.OrderID]
+				switch {
+				case ok && o.Status == contracts.OrderStatusCancelled:
+					reply = contracts.OrderError{Code: contracts.OrderErrAlreadyCancelled, Message: "order already cancelled"}
+				case ok:
+					o.Status = contracts.OrderStatusCancelled
+					orders[req.OrderID] = o
+					reply = o
+				}
+			}
+			mu.Unlock()
+			enc, _ := contracts.EncodingOf(msg.ContentType)
+			_, body, _ := contracts.Encode(enc, "order_service", reply)
+			b.Publish(ctx, "", msg.ReplyTo, broker.Message{ContentType: enc.ContentType(), CorrelationID: msg.CorrelationID, Body: body})
+			msg.Ack()
+		}
+	}()
+}
+
+// testOrders returns two orders of user 7, the second cancelled.
+func testOrders() map[int]contracts.OrderDetails {
+	return map[int]contracts.OrderDetails{
+		1: {OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2, Status: contracts.OrderStatusPlaced},
+		2: {OrderID: 2, ProductID: 102, UserID: 7, Quantity: 1, Status: contracts.OrderStatusCancelled},
+	}
+}
+
+func TestOrderEndpoints(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	orders := testOrders()
+	fakeOrders(t, b, testOrders())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		want   interface{} // decoded JSON body for successful requests
+	}{
+		{"get", http.MethodGet, "/api/orders/1", http.StatusOK, orders[1]},
+		{"get unknown", http.MethodGet, "/api/orders/3", http.StatusNotFound, nil},
+		{"get bad ID", http.MethodGet, "/api/orders/abc", http.StatusNotFound, nil},
+		{"list", http.MethodGet, "/api/orders?user_id=7", http.StatusOK,
+			map[string][]contracts.OrderDetails{"orders": {orders[1], orders[2]}}},
+		{"list without user", http.MethodGet, "/api/orders", http.StatusBadRequest, nil},
+		{"cancel", http.MethodPost, "/api/orders/1/cancel", http.StatusOK,
+			contracts.OrderDetails{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2, Status: contracts.OrderStatusCancelled}},
+		{"cancel again", http.MethodPost, "/api/orders/1/cancel", http.StatusConflict, nil},
+		{"cancel unknown", http.MethodPost, "/api/orders/3/cancel", http.StatusNotFound, nil},
+		{"cancel with GET", http.MethodGet, "/api/orders/1/cancel", http.StatusMethodNotAllowed, nil},
+		{"delete", http.MethodDelete, "/api/orders/1", http.StatusMethodNotAllowed, nil},
+		{"unknown action", http.MethodPost, "/api/orders/1/refund", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			g.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.want == nil {
+				return
+			}
+			got := reflect.New(reflect.TypeOf(tt.want))
+			if err := json.Unmarshal(rec.Body.Bytes(), got.Interface()); err != nil {
+				t.Fatalf("invalid body %s: %v", rec.Body, err)
+			}
+			if !reflect.DeepEqual(got.Elem().Interface(), tt.want) {
+				t.Errorf("body = %s, want %+v", rec.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderEndpointsTimeOutWithoutOrderService(t *testing.T) {
+	cfg := testConfig()
+	cfg.RPCTimeout = 20 * time.Millisecond
+	g, _ := setupGateway(t, cfg)
+
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/orders/1", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	cfg := testConfig()
+	cfg.APIKeys = []string{"secret", "other"}
+	g, b := setupGateway(t, cfg)
+	fakeOrders(t, b, testOrders())
+
+	for _, tt := range []struct {
+		name   string
+		path   string
+		key    string
+		status int
+	}{
+		{"no key", "/api/orders/1", "", http.StatusUnauthorized},
+		{"wrong key", "/api/orders/1", "guess", http.StatusUnauthorized},
+		{"valid key", "/api/orders/1", "secret", http.StatusOK},
+		{"second key", "/api/orders/1", "other", http.StatusOK},
+		{"health check needs no key", "/api/health-check", "", http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			g.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestRateLimitsTrustOnlyAuthenticatedKeys(t *testing.T) {
+	get := func(g *Gateway, header, value string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/orders/1", nil)
+		req.Header.Set(header, value)
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("unauthenticated callers share their address's bucket", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.RouteLimits = map[string]RateLimit{"/api/v1/orders/{order_id}": {Rate: 0.001, Burst: 1}}
+		g, b := setupGateway(t, cfg)
+		fakeOrders(t, b, testOrders())
+
+		if code := get(g, "X-User-ID", "7"); code != http.StatusOK {
+			t.Fatalf("first request = %d, want 200", code)
+		}
+		if code := get(g, "X-User-ID", "8"); code != http.StatusTooManyRequests {
+			t.Errorf("request as another user = %d, want 429", code)
+		}
+		if code := get(g, apiKeyHeader, "made-up"); code != http.StatusTooManyRequests {
+			t.Errorf("request with an unchecked key = %d, want 429", code)
+		}
+	})
+
+	t.Run("each valid key has its own bucket", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.APIKeys = []string{"secret", "other"}
+		cfg.RouteLimits = map[string]RateLimit{"/api/v1/orders/{order_id}": {Rate: 0.001, Burst: 1}}
+		g, b := setupGateway(t, cfg)
+		fakeOrders(t, b, testOrders())
+
+		for i, tt := range []struct {
+			key  string
+			want int
+		}{
+			{"secret", http.StatusOK},
+			{"secret", http.StatusTooManyRequests},
+			{"other", http.StatusOK},
+		} {
+			if code := get(g, apiKeyHeader, tt.key); code != tt.want {
+				t.Errorf("request %d with key %q = %d, want %d", i+1, tt.key, code, tt.want)
+			}
+		}
+	})
+
+	t.Run("the quota is not charged to the user in the body", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.OrderDailyQuota = 1
+		g, b := setupGateway(t, cfg)
+		fakeInventory(t, b, map[int]int{101: 5})
+
+		if rec := postOrder(g, OrderRequest{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 1}); rec.Code != http.StatusOK {
+			t.Fatalf("first order = %d, want 200: %s", rec.Code, rec.Body)
+		}
+		if rec := postOrder(g, OrderRequest{OrderID: 2, ProductID: 101, UserID: 8, Quantity: 1}); rec.Code != http.StatusTooManyRequests {
+			t.Errorf("order for another user = %d, want 429: %s", rec.Code, rec.Body)
+		}
+	})
+}
@@ -0,0 +1,282 @@
+package gateway
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// tokenBucket holds the state of a single client's bucket.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a set of token buckets, one per client key, sharing a
+// single rate and burst.
+type rateLimiter struct {
+	cfg     RateLimit
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// rateLimitResult describes the outcome of a single take from a bucket.
+type rateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // time until the next token, set when not allowed
+	Reset      time.Duration // time until the bucket is full again
+}
+
+func newRateLimiter(cfg RateLimit) *rateLimiter {
+	return &rateLimiter{cfg: cfg, buckets: map[string]*tokenBucket{}}
+}
+
+// take removes a token from key's bucket if one is available.
+func (rl *rateLimiter) take(key string, now time.Time) rateLimitResult {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	burst := float64(rl.cfg.Burst)
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		rl.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rl.cfg.Rate)
+	b.last = now
+
+	result := rateLimitResult{Limit: rl.cfg.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = rl.secondsToDuration((1 - b.tokens) / rl.cfg.Rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = rl.secondsToDuration((burst - b.tokens) / rl.cfg.Rate)
+	return result
+}
+
+func (rl *rateLimiter) secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// sweep drops buckets that have been idle long enough to be full again, so
+// the map does not grow with every client ever seen.
+func (rl *rateLimiter) sweep(now time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	refill := rl.secondsToDuration(float64(rl.cfg.Burst) / rl.cfg.Rate)
+	for key, b := range rl.buckets {
+		if now.Sub(b.last) > refill {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// routeLimiters applies a separate limiter per route, falling back to a
+// shared default limit for routes that are not configured.
+type routeLimiters struct {
+	defaultCfg RateLimit
+	mu         sync.Mutex
+	limiters   map[string]*rateLimiter
+	configured map[string]RateLimit
+}
+
+func newRouteLimiters(defaultCfg RateLimit, routes map[string]RateLimit) *routeLimiters {
+	configured := make(map[string]RateLimit, len(routes))
+	for route, cfg := range routes {
+		configured[route] = cfg
+	}
+	return &routeLimiters{
+		defaultCfg: defaultCfg,
+		limiters:   map[string]*rateLimiter{},
+		configured: configured,
+	}
+}
+
+// alias applies the limit configured for alias, another path of route, to
+// route unless route has a limit of its own.
+func (rls *routeLimiters) alias(alias, route string) {
+	rls.mu.Lock()
+	defer rls.mu.Unlock()
+	if cfg, ok := rls.configured[alias]; ok {
+		if _, own := rls.configured[route]; !own {
+			rls.configured[route] = cfg
+		}
+	}
+}
+
+func (rls *routeLimiters) forRoute(route string) *rateLimiter {
+	rls.mu.Lock()
+	defer rls.mu.Unlock()
+
+	rl, ok := rls.limiters[route]
+	if !ok {
+		cfg, ok := rls.configured[route]
+		if !ok {
+			cfg = rls.defaultCfg
+		}
+		rl = newRateLimiter(cfg)
+		rls.limiters[route] = rl
+	}
+	return rl
+}
+
+// startSweeper periodically evicts idle buckets from every route limiter.
+func (rls *routeLimiters) startSweeper(interval time.Duration) {
+	go func() {
+		for now := range time.Tick(interval) {
+			rls.mu.Lock()
+			limiters := make([]*rateLimiter, 0, len(rls.limiters))
+			for _, rl := range rls.limiters {
+				limiters = append(limiters, rl)
+			}
+			rls.mu.Unlock()
+
+			for _, rl := range limiters {
+				rl.sweep(now)
+			}
+		}
+	}()
+}
+
+// clientKey identifies the caller for rate limits and quotas by the API key
+// it was authenticated with, or else by its IP address. Nothing else the
+// caller sends, such as a user ID, is trusted to tell callers apart.
+func clientKey(r *http.Request) string {
+	if key := authenticatedKey(r.Context()); key != "" {
+		return "key:" + key
+	}
+	return "ip:" + clientIP(r)
+}
+
+// grpcClientKey is clientKey for gRPC callers.
+func grpcClientKey(ctx context.Context) string {
+	if key := authenticatedKey(ctx); key != "" {
+		return "key:" + key
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "ip:"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return "ip:" + p.Addr.String()
+	}
+	return "ip:" + host
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// setRateLimitHeaders writes the RateLimit-* headers describing result.
+func setRateLimitHeaders(w http.ResponseWriter, result rateLimitResult) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// rateLimit wraps next so that requests to route are limited per client.
+func (rls *routeLimiters) rateLimit(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result := rls.forRoute(route).take(clientKey(r), time.Now())
+		setRateLimitHeaders(w, result)
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// unaryInterceptor limits unary calls per client like rateLimit, with the
+// full method name as the route. The RateLimit-* values are sent as
+// response headers.
+func (rls *routeLimiters) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	result := rls.forRoute(info.FullMethod).take(grpcClientKey(ctx), time.Now())
+	md := metadata.Pairs(
+		"ratelimit-limit", strconv.Itoa(result.Limit),
+		"ratelimit-remaining", strconv.Itoa(result.Remaining),
+		"ratelimit-reset", strconv.Itoa(ceilSeconds(result.Reset)),
+	)
+	if !result.Allowed {
+		md.Set("retry-after", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		grpc.SetHeader(ctx, md)
+		return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry in %ds", ceilSeconds(result.RetryAfter))
+	}
+	grpc.SetHeader(ctx, md)
+	return handler(ctx, req)
+}
+
+// dailyQuota caps the number of orders an account may place per UTC day.
+// A limit of zero disables the quota.
+type dailyQuota struct {
+	limit  int
+	mu     sync.Mutex
+	day    string
+	counts map[string]int
+}
+
+func newDailyQuota(limit int) *dailyQuota {
+	return &dailyQuota{limit: limit, counts: map[string]int{}}
+}
+
+// reserve claims one unit of account's quota for today. When the quota is
+// exhausted it returns false and the time until the quota resets.
+func (q *dailyQuota) reserve(account string, now time.Time) (bool, time.Duration) {
+	if q.limit <= 0 {
+		return true, 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now = now.UTC()
+	if day := now.Format("2006-01-02"); day != q.day {
+		q.day = day
+		q.counts = map[string]int{}
+	}
+	if q.counts[account] >= q.limit {
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		return false, midnight.Sub(now)
+	}
+	q.counts[account]++
+	return true, 0
+}
+
+// release returns a unit reserved earlier today, used when the order it was
+// reserved for could not be placed.
+func (q *dailyQuota) release(account string) {
+	if q.limit <= 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.counts[account] > 0 {
+		q.counts[account]--
+	}
+}
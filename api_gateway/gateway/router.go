@@ -0,0 +1,198 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// middleware wraps the handler for route, the pattern a request matched,
+// such as "/api/v1/orders/{order_id}".
+type middleware func(route string, next http.HandlerFunc) http.HandlerFunc
+
+// chain wraps h in mws, the first being the outermost.
+func chain(route string, h http.HandlerFunc, mws []middleware) http.HandlerFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](route, h)
+	}
+	return h
+}
+
+// route is a path pattern and its handler for each method. Segments of the
+// form {name} match any single path segment.
+type route struct {
+	segments []string
+	handlers map[string]http.HandlerFunc
+	// other answers methods without a handler, behind the same middleware
+	// as the handlers so that CORS preflights and 405s are served alike.
+	other http.HandlerFunc
+}
+
+// methods returns the methods route has handlers for, sorted.
+func (rt *route) methods() []string {
+	methods := make([]string, 0, len(rt.handlers))
+	for m := range rt.handlers {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// match reports whether path's segments fit the route and returns the
+// values of its parameters.
+func (rt *route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+	var params map[string]string
+	for i, seg := range rt.segments {
+		if name, ok := paramName(seg); ok {
+			if segments[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = map[string]string{}
+			}
+			params[name] = segments[i]
+		} else if seg != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func paramName(segment string) (string, bool) {
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+// router dispatches requests by method and path pattern. Unlike
+// http.ServeMux it matches path parameters and answers unsupported methods
+// with 405 and an Allow header.
+type router struct {
+	routes  map[string]*route // by pattern
+	order   []string          // patterns in registration order
+	aliases map[string]string // label of each aliased pattern
+}
+
+func newRouter() *router {
+	return &router{routes: map[string]*route{}, aliases: map[string]string{}}
+}
+
+// handle registers h for method and pattern behind mws. label names the
+// route in metrics, traces, logs and rate limits; aliases of a route share
+// their canonical route's label.
+func (rtr *router) handle(method, pattern, label string, h http.HandlerFunc, mws []middleware) {
+	rt, ok := rtr.routes[pattern]
+	if !ok {
+		rt = &route{segments: splitPath(pattern), handlers: map[string]http.HandlerFunc{}}
+		rt.other = chain(label, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", strings.Join(rt.methods(), ", "))
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}, mws)
+		rtr.routes[pattern] = rt
+		rtr.order = append(rtr.order, pattern)
+		if pattern != label {
+			rtr.aliases[pattern] = label
+		}
+	}
+	rt.handlers[method] = chain(label, h, mws)
+}
+
+// group returns a group of routes under prefix that share mws.
+func (rtr *router) group(prefix string, mws ...middleware) *routeGroup {
+	return &routeGroup{router: rtr, prefix: prefix, mws: mws}
+}
+
+func (rtr *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt, params := rtr.find(r.URL.Path)
+	if rt == nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := context.WithValue(r.Context(), routeKey{}, rt)
+	if params != nil {
+		ctx = context.WithValue(ctx, paramsKey{}, params)
+	}
+	r = r.WithContext(ctx)
+
+	h, ok := rt.handlers[r.Method]
+	if !ok && r.Method == http.MethodHead {
+		h, ok = rt.handlers[http.MethodGet]
+	}
+	if !ok {
+		h = rt.other
+	}
+	h(w, r)
+}
+
+// find returns the route matching path. Routes with fewer parameters win,
+// so /orders/mine would be preferred over /orders/{order_id}.
+func (rtr *router) find(path string) (*route, map[string]string) {
+	segments := splitPath(path)
+	var best *route
+	var bestParams map[string]string
+	for _, pattern := range rtr.order {
+		rt := rtr.routes[pattern]
+		params, ok := rt.match(segments)
+		if ok && (best == nil || len(params) < len(bestParams)) {
+			best, bestParams = rt, params
+		}
+	}
+	return best, bestParams
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+// routeGroup registers routes under a common prefix and middleware chain.
+type routeGroup struct {
+	router  *router
+	prefix  string
+	aliases []string
+	mws     []middleware
+}
+
+// alias serves the group's routes under prefix too, labelled as the
+// group's own routes.
+func (g *routeGroup) alias(prefix string) *routeGroup {
+	g.aliases = append(g.aliases, prefix)
+	return g
+}
+
+func (g *routeGroup) handle(method, pattern string, h http.HandlerFunc) {
+	label := g.prefix + pattern
+	g.router.handle(method, label, label, h, g.mws)
+	for _, prefix := range g.aliases {
+		g.router.handle(method, prefix+pattern, label, h, g.mws)
+	}
+}
+
+func (g *routeGroup) get(pattern string, h http.HandlerFunc)  { g.handle(http.MethodGet, pattern, h) }
+func (g *routeGroup) post(pattern string, h http.HandlerFunc) { g.handle(http.MethodPost, pattern, h) }
+func (g *routeGroup) put(pattern string, h http.HandlerFunc)  { g.handle(http.MethodPut, pattern, h) }
+func (g *routeGroup) delete(pattern string, h http.HandlerFunc) {
+	g.handle(http.MethodDelete, pattern, h)
+}
+
+type routeKey struct{}
+
+type paramsKey struct{}
+
+// pathParam returns the value of the {name} segment of the route r matched.
+func pathParam(r *http.Request, name string) string {
+	params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+	return params[name]
+}
+
+// allowedMethods returns the methods the route r matched has handlers for.
+func allowedMethods(r *http.Request) []string {
+	if rt, ok := r.Context().Value(routeKey{}).(*route); ok {
+		return rt.methods()
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package gateway
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouterMatchesMethodsAndParameters(t *testing.T) {
+	rtr := newRouter()
+	reply := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, body+pathParam(r, "id"))
+		}
+	}
+	rtr.handle(http.MethodGet, "/items/{id}", "/items/{id}", reply("get "), nil)
+	rtr.handle(http.MethodDelete, "/items/{id}", "/items/{id}", reply("delete "), nil)
+	rtr.handle(http.MethodGet, "/items/latest", "/items/latest", reply("latest"), nil)
+	rtr.handle(http.MethodPost, "/items/{id}/archive", "/items/{id}/archive", reply("archive "), nil)
+
+	for _, tt := range []struct {
+		method, path string
+		want         int
+		body, allow  string
+	}{
+		{http.MethodGet, "/items/42", http.StatusOK, "get 42", ""},
+		{http.MethodDelete, "/items/42", http.StatusOK, "delete 42", ""},
+		{http.MethodHead, "/items/42", http.StatusOK, "", ""},
+		{http.MethodGet, "/items/latest", http.StatusOK, "latest", ""},
+		{http.MethodPost, "/items/7/archive", http.StatusOK, "archive 7", ""},
+		{http.MethodPut, "/items/42", http.StatusMethodNotAllowed, "", "DELETE, GET"},
+		{http.MethodGet, "/items/7/archive", http.StatusMethodNotAllowed, "", "POST"},
+		{http.MethodGet, "/items/", http.StatusNotFound, "", ""},
+		{http.MethodGet, "/items/7/8", http.StatusNotFound, "", ""},
+		{http.MethodGet, "/elsewhere", http.StatusNotFound, "", ""},
+	} {
+		rec := httptest.NewRecorder()
+		rtr.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+			continue
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%s %s body = %q, want %q", tt.method, tt.path, rec.Body, tt.body)
+		}
+		if got := rec.Header().Get("Allow"); got != tt.allow {
+			t.Errorf("%s %s Allow = %q, want %q", tt.method, tt.path, got, tt.allow)
+		}
+	}
+}
+
+func TestRouterGroupsShareLabelsWithAliases(t *testing.T) {
+	rtr := newRouter()
+	var labels []string
+	record := func(route string, next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			labels = append(labels, route)
+			next(w, r)
+		}
+	}
+	v1 := rtr.group("/api/v1", record).alias("/api")
+	v1.get("/orders/{order_id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, path := range []string{"/api/v1/orders/1", "/api/orders/1"} {
+		rtr.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if len(labels) != 2 || labels[0] != "/api/v1/orders/{order_id}" || labels[1] != labels[0] {
+		t.Errorf("labels = %v, want /api/v1/orders/{order_id} twice", labels)
+	}
+	if got := rtr.aliases["/api/orders/{order_id}"]; got != "/api/v1/orders/{order_id}" {
+		t.Errorf("alias label = %q", got)
+	}
+}
+
+func TestRecoverPanics(t *testing.T) {
+	h := recoverPanics("/boom", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	cfg := testConfig()
+	cfg.V1Sunset = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+	g, b := setupGateway(t, cfg)
+	fakeOrders(t, b, testOrders())
+
+	for _, tt := range []struct {
+		path       string
+		deprecated bool
+	}{
+		{"/api/v1/orders/1", true},
+		{"/api/orders/1", true},
+		{"/api/v2/orders/1", false},
+	} {
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200: %s", tt.path, rec.Code, rec.Body)
+		}
+		h := rec.Header()
+		if !tt.deprecated {
+			if h.Get("Deprecation") != "" || h.Get("Sunset") != "" {
+				t.Errorf("GET %s: Deprecation %q, Sunset %q; want neither", tt.path, h.Get("Deprecation"), h.Get("Sunset"))
+			}
+			continue
+		}
+		if got, want := h.Get("Deprecation"), "@1792368000"; got != want {
+			t.Errorf("GET %s: Deprecation = %q, want %q", tt.path, got, want)
+		}
+		if got, want := h.Get("Sunset"), "Fri, 30 Apr 2027 00:00:00 GMT"; got != want {
+			t.Errorf("GET %s: Sunset = %q, want %q", tt.path, got, want)
+		}
+		if got, want := h.Get("Link"), `</api/v2>; rel="successor-version"`; got != want {
+			t.Errorf("GET %s: Link = %q, want %q", tt.path, got, want)
+		}
+	}
+}
+
+func TestPlaceOrderV2(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeInventory(t, b, map[int]int{101: 5})
+
+	body := `{"order_id":3,"product_id":101,"user_id":7,"quantity":2}`
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v2/orders", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Location"); got != "/api/v2/orders/3" {
+		t.Errorf("Location = %q, want /api/v2/orders/3", got)
+	}
+	if !strings.Contains(rec.Body.String(), `"order":{"order_id":3,"product_id":101,"user_id":7,"quantity":2,"status":"placed"}`) {
+		t.Errorf("body = %s", rec.Body)
+	}
+	if msgs := b.Pending("place_order"); len(msgs) != 1 {
+		t.Errorf("place_order holds %d messages, want 1", len(msgs))
+	}
+}
+
+func TestRouteLimitsApplyToV1Aliases(t *testing.T) {
+	cfg := testConfig()
+	cfg.RouteLimits = map[string]RateLimit{"/api/orders/{order_id}": {Rate: 0.001, Burst: 1}}
+	g, b := setupGateway(t, cfg)
+	fakeOrders(t, b, testOrders())
+
+	// The alias and the v1 path draw from the same bucket.
+	for i, tt := range []struct {
+		path string
+		want int
+	}{
+		{"/api/orders/1", http.StatusOK},
+		{"/api/v1/orders/1", http.StatusTooManyRequests},
+		{"/api/v2/orders/1", http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("request %d, GET %s = %d, want %d", i+1, tt.path, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	cfg := testConfig()
+	cfg.APIKeys = []string{"secret"}
+	cfg.CORSOrigins = []string{"https://shop.example"}
+	g, b := setupGateway(t, cfg)
+	fakeOrders(t, b, testOrders())
+
+	t.Run("preflight", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/api/v2/orders/1/cancel", nil)
+		req.Header.Set("Origin", "https://shop.example")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status = %d, want 204 without an API key", rec.Code)
+		}
+		h := rec.Header()
+		if h.Get("Access-Control-Allow-Origin") != "https://shop.example" || h.Get("Access-Control-Allow-Methods") != "POST" {
+			t.Errorf("headers = %v", h)
+		}
+		if !strings.Contains(h.Get("Access-Control-Allow-Headers"), apiKeyHeader) {
+			t.Errorf("Access-Control-Allow-Headers = %q, want it to include %s", h.Get("Access-Control-Allow-Headers"), apiKeyHeader)
+		}
+	})
+
+	t.Run("request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/orders/1", nil)
+		req.Header.Set("Origin", "https://shop.example")
+		req.Header.Set(apiKeyHeader, "secret")
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, req)
+		h := rec.Header()
+		if rec.Code != http.StatusOK || h.Get("Access-Control-Allow-Origin") != "https://shop.example" {
+			t.Errorf("status %d, Access-Control-Allow-Origin %q", rec.Code, h.Get("Access-Control-Allow-Origin"))
+		}
+		if !strings.Contains(h.Get("Access-Control-Expose-Headers"), "Deprecation") {
+			t.Errorf("Access-Control-Expose-Headers = %q, want it to include Deprecation", h.Get("Access-Control-Expose-Headers"))
+		}
+	})
+
+	t.Run("other origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/api/v2/orders/1/cancel", nil)
+		req.Header.Set("Origin", "https://evil.example")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, req)
+		if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("Access-Control-Allow-Origin = %q for a foreign origin", rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+
+	"ecomm-sample/observability"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "api_gateway"
+
+var tracer = otel.Tracer(serviceName)
+
+// SetupTracing installs the gateway's tracer provider, as described at
+// observability.SetupTracing. The returned function flushes pending spans.
+func SetupTracing() func(context.Context) error {
+	return observability.SetupTracing(serviceName)
+}
+
+// traced wraps next in a server span for route, continuing any trace the
+// caller started through the traceparent header.
+func traced(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	}
+}
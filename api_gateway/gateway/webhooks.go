@@ -0,0 +1,180 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+)
+
+// Webhook is the body of POST /api/v2/webhooks and PUT
+// /api/v2/webhooks/{webhook_id}: the URL events are POSTed to, the events
+// it receives and, optionally, the secret they are signed with. Without a
+// secret a new webhook gets a generated one and an existing one keeps its
+// own. Active defaults to true; setting it re-enables a webhook that was
+// disabled after failing too often.
+type Webhook struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// set returns the request to store w as the webhook with the given ID, 0
+// for a new one.
+func (w Webhook) set(id int) contracts.WebhookSet {
+	active := w.Active == nil || *w.Active
+	return contracts.WebhookSet{WebhookID: id, URL: w.URL, Events: w.Events, Secret: w.Secret, Active: active}
+}
+
+// webhookRequest sends a webhook request to the notification service and
+// returns the webhook.
+func (g *Gateway) webhookRequest(ctx context.Context, p contracts.Payload) (contracts.WebhookDetails, error) {
+	reply, err := g.notificationRequest(ctx, p)
+	if err != nil {
+		return contracts.WebhookDetails{}, err
+	}
+	return expectReply[contracts.WebhookDetails](reply)
+}
+
+// decodeWebhook reads the webhook in r's body, answering r itself if it
+// is not valid JSON. The notification service validates the rest.
+func decodeWebhook(w http.ResponseWriter, r *http.Request) (Webhook, bool) {
+	var body Webhook
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return Webhook{}, false
+	}
+	return body, true
+}
+
+// createWebhookHandler serves POST /api/v2/webhooks. The reply holds the
+// webhook's secret, which is not shown again.
+func (g *Gateway) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	body, ok := decodeWebhook(w, r)
+	if !ok {
+		return
+	}
+	webhook, err := g.webhookRequest(r.Context(), body.set(0))
+	if err != nil {
+		g.writeNotificationError(r.Context(), w, err)
+		return
+	}
+	w.Header().Set("Location", "/api/v2/webhooks/"+strconv.Itoa(webhook.WebhookID))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// listWebhooksHandler serves GET /api/v2/webhooks.
+func (g *Gateway) listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	reply, err := g.notificationRequest(r.Context(), contracts.WebhookList{})
+	var result contracts.WebhookListResult
+	if err == nil {
+		result, err = expectReply[contracts.WebhookListResult](reply)
+	}
+	if err != nil {
+		g.writeNotificationError(r.Context(), w, err)
+		return
+	}
+	if result.Webhooks == nil {
+		result.Webhooks = []contracts.WebhookDetails{}
+	}
+	writeJSON(w, result)
+}
+
+// putWebhookHandler serves PUT /api/v2/webhooks/{webhook_id}, which
+// replaces the webhook.
+func (g *Gateway) putWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.Atoi(pathParam(r, "webhook_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	body, ok := decodeWebhook(w, r)
+	if !ok {
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("webhook_id", webhookID))
+	webhook, err := g.webhookRequest(ctx, body.set(webhookID))
+	if err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+	writeJSON(w, webhook)
+}
+
+// deleteWebhookHandler serves DELETE /api/v2/webhooks/{webhook_id}, which
+// deletes the webhook and its delivery log.
+func (g *Gateway) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.Atoi(pathParam(r, "webhook_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("webhook_id", webhookID))
+	if _, err := g.webhookRequest(ctx, contracts.WebhookDelete{WebhookID: webhookID}); err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listWebhookDeliveriesHandler serves GET
+// /api/v2/webhooks/{webhook_id}/deliveries, the webhook's latest
+// deliveries, newest first.
+func (g *Gateway) listWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.Atoi(pathParam(r, "webhook_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("webhook_id", webhookID))
+	reply, err := g.notificationRequest(ctx, contracts.WebhookDeliveryList{WebhookID: webhookID})
+	var result contracts.WebhookDeliveryListResult
+	if err == nil {
+		result, err = expectReply[contracts.WebhookDeliveryListResult](reply)
+	}
+	if err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+	if result.Deliveries == nil {
+		result.Deliveries = []contracts.WebhookDeliveryDetails{}
+	}
+	writeJSON(w, result)
+}
+
+// redeliverWebhookHandler serves POST
+// /api/v2/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver, which
+// queues the delivery to be sent again. It answers 202 Accepted with the
+// pending delivery.
+func (g *Gateway) redeliverWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.Atoi(pathParam(r, "webhook_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	deliveryID, err := strconv.Atoi(pathParam(r, "delivery_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := observability.WithLogFields(r.Context(), slog.Int("webhook_id", webhookID), slog.Int("delivery_id", deliveryID))
+	reply, err := g.notificationRequest(ctx, contracts.WebhookRedeliver{WebhookID: webhookID, DeliveryID: deliveryID})
+	var delivery contracts.WebhookDeliveryDetails
+	if err == nil {
+		delivery, err = expectReply[contracts.WebhookDeliveryDetails](reply)
+	}
+	if err != nil {
+		g.writeNotificationError(ctx, w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
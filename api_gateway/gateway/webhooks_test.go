@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ecomm-sample/contracts"
+)
+
+func TestWebhooks(t *testing.T) {
+	g, b := setupGateway(t, testConfig())
+	fakeNotifications(t, b, nil)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		g.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "/api/v2/webhooks", `{"url":"https://partner.example/hooks","events":["order.placed"]}`)
+	if rec.Code != http.StatusCreated || rec.Header().Get("Location") != "/api/v2/webhooks/1" {
+		t.Fatalf("POST = %d, Location %q: %s", rec.Code, rec.Header().Get("Location"), rec.Body)
+	}
+	var created contracts.WebhookDetails
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil || !created.Active || created.Secret == "" {
+		t.Fatalf("created webhook = %+v, %v; want active with its secret", created, err)
+	}
+
+	rec = serve(http.MethodPut, "/api/v2/webhooks/1", `{"url":"https://partner.example/hooks","events":["stock.low"],"active":false}`)
+	var updated contracts.WebhookDetails
+	if err := json.NewDecoder(rec.Body).Decode(&updated); rec.Code != http.StatusOK || err != nil || updated.Active || updated.Secret != "" {
+		t.Fatalf("PUT = %d, %+v; want an inactive webhook without its secret", rec.Code, updated)
+	}
+
+	rec = serve(http.MethodGet, "/api/v2/webhooks", "")
+	var list struct {
+		Webhooks []contracts.WebhookDetails `json:"webhooks"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil || len(list.Webhooks) != 1 || list.Webhooks[0].Events[0] != contracts.EventStockLow {
+		t.Fatalf("GET = %d, %+v, %v; want the updated webhook", rec.Code, list, err)
+	}
+
+	for _, tt := range []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodPut, "/api/v2/webhooks/1", "not json", http.StatusBadRequest},
+		{http.MethodPut, "/api/v2/webhooks/hook", `{}`, http.StatusNotFound},
+		{http.MethodGet, "/api/v2/webhooks/2/deliveries", "", http.StatusNotFound},
+		{http.MethodPost, "/api/v2/webhooks/1/deliveries/1/redeliver", "", http.StatusAccepted},
+		{http.MethodDelete, "/api/v2/webhooks/1", "", http.StatusNoContent},
+		{http.MethodGet, "/api/v2/webhooks/1/deliveries", "", http.StatusNotFound},
+	} {
+		if rec := serve(tt.method, tt.path, tt.body); rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.path, rec.Code, tt.want, rec.Body)
+		}
+	}
+}
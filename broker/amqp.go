@@ -0,0 +1,196 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// prefetch is how many unsettled deliveries a consumer holds at most.
+const prefetch = 10
+
+// Requeues after a failure are delayed by requeueBackoff, starting at
+// minRequeueDelay and doubling up to maxRequeueDelay.
+const (
+	minRequeueDelay = 100 * time.Millisecond
+	maxRequeueDelay = 30 * time.Second
+)
+
+// AMQP is a Broker backed by a RabbitMQ connection.
+type AMQP struct {
+	conn *amqp091.Connection
+
+	mu sync.Mutex // serialises use of ch
+	ch *amqp091.Channel
+}
+
+// NewAMQP wraps an open connection. Declarations and publishes share one
+// channel; every consumer gets a channel of its own, which is sent at most
+// prefetch deliveries at a time.
+func NewAMQP(conn *amqp091.Connection) (*AMQP, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	return &AMQP{conn: conn, ch: ch}, nil
+}
+
+func (a *AMQP) DeclareExchange(name, kind string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.ch.ExchangeDeclare(name, kind, true, false, false, false, nil)
+}
+
+func (a *AMQP) DeclareQueue(name string, opts QueueOptions) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	q, err := a.ch.QueueDeclare(name, opts.Durable, opts.AutoDelete, opts.Exclusive, false, amqp091.Table(opts.Args))
+	if err != nil {
+		return "", err
+	}
+	return q.Name, nil
+}
+
+func (a *AMQP) BindQueue(queue, key, exchange string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.ch.QueueBind(queue, key, exchange, false, nil)
+}
+
+func (a *AMQP) Publish(ctx context.Context, exchange, key string, msg Message) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.ch.PublishWithContext(ctx, exchange, key, false, false, amqp091.Publishing{
+		ContentType:   msg.ContentType,
+		CorrelationId: msg.CorrelationID,
+		ReplyTo:       msg.ReplyTo,
+		MessageId:     msg.MessageID,
+		Headers:       amqp091.Table(msg.Headers),
+		Body:          msg.Body,
+	})
+}
+
+// Consume delivers the messages on queue. A delivery nacked for requeueing
+// is only returned to the queue after a delay, which grows while the
+// consumer's deliveries keep failing and resets on the next ack, so a
+// failing dependency is not retried in a hot loop.
+func (a *AMQP) Consume(ctx context.Context, queue string) (<-chan Delivery, error) {
+	ch, err := a.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Qos(prefetch, 0, false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	msgs, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	backoff := &requeueBackoff{min: minRequeueDelay, max: maxRequeueDelay}
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		defer ch.Close()
+		for {
+			select {
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case out <- fromAMQP(queue, msg, backoff):
+				case <-ctx.Done():
+					msg.Nack(false, true)
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (a *AMQP) RPC(ctx context.Context, exchange, key string, msg Message) (Delivery, error) {
+	return rpc(ctx, a, exchange, key, msg)
+}
+
+// Close closes the shared channel. The connection belongs to the caller.
+func (a *AMQP) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.ch.Close()
+}
+
+func fromAMQP(queue string, msg amqp091.Delivery, backoff *requeueBackoff) Delivery {
+	return Delivery{
+		Message: Message{
+			ContentType:   msg.ContentType,
+			CorrelationID: msg.CorrelationId,
+			ReplyTo:       msg.ReplyTo,
+			MessageID:     msg.MessageId,
+			Headers:       map[string]interface{}(msg.Headers),
+			Body:          msg.Body,
+		},
+		Queue:       queue,
+		Exchange:    msg.Exchange,
+		RoutingKey:  msg.RoutingKey,
+		Redelivered: msg.Redelivered,
+		ack:         amqpAcknowledger{msg, backoff},
+	}
+}
+
+type amqpAcknowledger struct {
+	msg     amqp091.Delivery
+	backoff *requeueBackoff
+}
+
+func (a amqpAcknowledger) ack() error {
+	a.backoff.reset()
+	return a.msg.Ack(false)
+}
+
+// nack rejects the delivery at once, or requeues it after the backoff
+// delay. Until then it counts against the consumer's prefetch, which slows
+// the consumer down. Should the channel close first, RabbitMQ requeues the
+// delivery itself.
+func (a amqpAcknowledger) nack(requeue bool) error {
+	if !requeue {
+		return a.msg.Nack(false, false)
+	}
+	time.AfterFunc(a.backoff.next(), func() { a.msg.Nack(false, true) })
+	return nil
+}
+
+// requeueBackoff is the delay before a consumer's failed deliveries are
+// requeued. It doubles with every consecutive failure, from min up to max.
+type requeueBackoff struct {
+	min, max time.Duration
+
+	mu    sync.Mutex
+	delay time.Duration
+}
+
+// next returns the delay for a failure and doubles the one after it.
+func (b *requeueBackoff) next() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.delay == 0 {
+		b.delay = b.min
+	}
+	d := b.delay
+	b.delay = min(2*b.delay, b.max)
+	return d
+}
+
+// reset starts the delays over from min after a success.
+func (b *requeueBackoff) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.delay = 0
+}
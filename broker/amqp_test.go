@@ -0,0 +1,19 @@
+package broker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequeueBackoff(t *testing.T) {
+	b := &requeueBackoff{min: time.Second, max: 5 * time.Second}
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if d := b.next(); d != want {
+			t.Errorf("failure %d: delay = %s, want %s", i+1, d, want)
+		}
+	}
+	b.reset()
+	if d := b.next(); d != time.Second {
+		t.Errorf("delay after a success = %s, want %s", d, time.Second)
+	}
+}
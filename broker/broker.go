@@ -0,0 +1,202 @@
+// Package broker is the messaging layer shared by the services. It hides
+// RabbitMQ behind a small interface so handlers can be exercised against
+// the in-process Memory broker in tests.
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Exchange kinds understood by both implementations.
+const (
+	Direct = "direct"
+	Fanout = "fanout"
+	Topic  = "topic"
+)
+
+// ErrClosed is returned by operations on a broker that has been closed.
+var ErrClosed = errors.New("broker: closed")
+
+// Message is a message to publish.
+type Message struct {
+	ContentType   string
+	CorrelationID string
+	ReplyTo       string
+	MessageID     string
+	Headers       map[string]interface{}
+	Body          []byte
+}
+
+// Delivery is a message received from a queue. It must be settled with
+// exactly one call to Ack or Nack.
+type Delivery struct {
+	Message
+	Queue       string
+	Exchange    string
+	RoutingKey  string
+	Redelivered bool
+
+	ack acknowledger
+	tag uint64 // identifies the delivery within a Memory broker
+}
+
+type acknowledger interface {
+	ack() error
+	nack(requeue bool) error
+}
+
+// Ack confirms the delivery was handled.
+func (d Delivery) Ack() error {
+	if d.ack == nil {
+		return nil
+	}
+	return d.ack.ack()
+}
+
+// Nack rejects the delivery. With requeue it is delivered again, after a
+// backoff on RabbitMQ, otherwise it is dead-lettered if the queue has a
+// dead-letter exchange, or dropped.
+func (d Delivery) Nack(requeue bool) error {
+	if d.ack == nil {
+		return nil
+	}
+	return d.ack.nack(requeue)
+}
+
+// QueueOptions are the properties a queue is declared with. Args holds
+// RabbitMQ x-arguments such as x-dead-letter-exchange.
+type QueueOptions struct {
+	Durable    bool
+	AutoDelete bool
+	Exclusive  bool
+	Args       map[string]interface{}
+}
+
+// Broker is the messaging interface used by the services.
+type Broker interface {
+	// DeclareExchange creates an exchange of the given kind if it does not
+	// exist yet.
+	DeclareExchange(name, kind string) error
+	// DeclareQueue creates a queue if it does not exist yet and returns its
+	// name. An empty name asks the broker to generate one.
+	DeclareQueue(name string, opts QueueOptions) (string, error)
+	// BindQueue routes messages published to exchange with a matching key
+	// to queue.
+	BindQueue(queue, key, exchange string) error
+	// Publish sends msg to exchange with routing key. The empty exchange
+	// delivers straight to the queue named key.
+	Publish(ctx context.Context, exchange, key string, msg Message) error
+	// Consume delivers messages from queue until ctx is cancelled, after
+	// which the returned channel is closed.
+	Consume(ctx context.Context, queue string) (<-chan Delivery, error)
+	// RPC publishes msg with a private reply queue and waits for the first
+	// reply carrying the same correlation ID, or until ctx is done.
+	RPC(ctx context.Context, exchange, key string, msg Message) (Delivery, error)
+	// Close releases the broker's resources.
+	Close() error
+}
+
+var idCounter atomic.Uint64
+
+// newID returns a process-unique identifier with the given prefix.
+func newID(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, idCounter.Add(1))
+}
+
+// rpc implements Broker.RPC on top of the other Broker methods.
+func rpc(ctx context.Context, b Broker, exchange, key string, msg Message) (Delivery, error) {
+	replyQueue, err := b.DeclareQueue("", QueueOptions{AutoDelete: true, Exclusive: true})
+	if err != nil {
+		return Delivery{}, fmt.Errorf("declare reply queue: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	replies, err := b.Consume(ctx, replyQueue)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("consume reply queue: %w", err)
+	}
+
+	if msg.CorrelationID == "" {
+		msg.CorrelationID = newID("rpc")
+	}
+	msg.ReplyTo = replyQueue
+	if err := b.Publish(ctx, exchange, key, msg); err != nil {
+		return Delivery{}, fmt.Errorf("publish request: %w", err)
+	}
+
+	for {
+		select {
+		case reply, ok := <-replies:
+			if !ok {
+				return Delivery{}, ctx.Err()
+			}
+			reply.Ack()
+			if reply.CorrelationID == msg.CorrelationID {
+				return reply, nil
+			}
+		case <-ctx.Done():
+			return Delivery{}, ctx.Err()
+		}
+	}
+}
+
+// HeaderCarrier adapts message headers to the OpenTelemetry TextMapCarrier
+// interface so trace context can travel with a message.
+type HeaderCarrier map[string]interface{}
+
+func (c HeaderCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+func (c HeaderCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// DeadLetterExchange is the direct exchange that queues declared with
+// DeclareWorkQueue dead-letter to.
+const DeadLetterExchange = "dead_letter_exchange"
+
+// DeadLetterQueue returns the name of the queue that holds messages
+// rejected from queue.
+func DeadLetterQueue(queue string) string {
+	return queue + "_dlq"
+}
+
+// DeclareWorkQueue declares a durable queue whose rejected messages go to
+// its dead-letter queue, along with the dead-letter exchange and queue.
+func DeclareWorkQueue(b Broker, queue string) error {
+	if err := b.DeclareExchange(DeadLetterExchange, Direct); err != nil {
+		return fmt.Errorf("declare dead-letter exchange: %w", err)
+	}
+	dlq := DeadLetterQueue(queue)
+	if _, err := b.DeclareQueue(dlq, QueueOptions{Durable: true}); err != nil {
+		return fmt.Errorf("declare %s queue: %w", dlq, err)
+	}
+	if err := b.BindQueue(dlq, queue, DeadLetterExchange); err != nil {
+		return fmt.Errorf("bind %s queue: %w", dlq, err)
+	}
+	_, err := b.DeclareQueue(queue, QueueOptions{
+		Durable: true,
+		Args: map[string]interface{}{
+			"x-dead-letter-exchange":    DeadLetterExchange,
+			"x-dead-letter-routing-key": queue,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("declare %s queue: %w", queue, err)
+	}
+	return nil
+}
@@ -0,0 +1,401 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process Broker for tests. It supports the default, direct,
+// fanout and topic exchanges, competing consumers on work queues, reply-to
+// queues, redelivery of nacked messages, dead-lettering through the
+// x-dead-letter-exchange and x-dead-letter-routing-key queue arguments and
+// message expiry through the x-message-ttl queue argument.
+type Memory struct {
+	mu        sync.Mutex
+	closed    bool
+	exchanges map[string]*memExchange
+	queues    map[string]*memQueue
+	lastTag   uint64 // of the last enqueued delivery
+}
+
+type memExchange struct {
+	kind     string
+	bindings []memBinding
+}
+
+type memBinding struct {
+	queue string
+	key   string
+}
+
+type memQueue struct {
+	name      string
+	opts      QueueOptions
+	ready     []Delivery
+	consumers int
+	changed   chan struct{} // closed and replaced whenever ready grows
+}
+
+// NewMemory returns an empty in-process broker.
+func NewMemory() *Memory {
+	return &Memory{
+		exchanges: map[string]*memExchange{},
+		queues:    map[string]*memQueue{},
+	}
+}
+
+func (m *Memory) DeclareExchange(name, kind string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	switch kind {
+	case Direct, Fanout, Topic:
+	default:
+		return fmt.Errorf("broker: unsupported exchange kind %q", kind)
+	}
+	if ex, ok := m.exchanges[name]; ok {
+		if ex.kind != kind {
+			return fmt.Errorf("broker: exchange %q already declared as %s", name, ex.kind)
+		}
+		return nil
+	}
+	m.exchanges[name] = &memExchange{kind: kind}
+	return nil
+}
+
+func (m *Memory) DeclareQueue(name string, opts QueueOptions) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return "", ErrClosed
+	}
+	if name == "" {
+		name = newID("amq.gen")
+	}
+	if _, ok := m.queues[name]; !ok {
+		m.queues[name] = &memQueue{name: name, opts: opts, changed: make(chan struct{})}
+	}
+	return name, nil
+}
+
+func (m *Memory) BindQueue(queue, key, exchange string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	ex, ok := m.exchanges[exchange]
+	if !ok {
+		return fmt.Errorf("broker: no exchange %q", exchange)
+	}
+	if _, ok := m.queues[queue]; !ok {
+		return fmt.Errorf("broker: no queue %q", queue)
+	}
+	for _, b := range ex.bindings {
+		if b.queue == queue && b.key == key {
+			return nil
+		}
+	}
+	ex.bindings = append(ex.bindings, memBinding{queue: queue, key: key})
+	return nil
+}
+
+func (m *Memory) Publish(ctx context.Context, exchange, key string, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	return m.route(exchange, key, msg)
+}
+
+// route delivers msg to every queue bound to exchange with a matching key.
+// Unroutable messages are dropped, as RabbitMQ does for non-mandatory
+// publishes. m.mu must be held.
+func (m *Memory) route(exchange, key string, msg Message) error {
+	msg.Headers = copyHeaders(msg.Headers)
+	msg.Body = append([]byte(nil), msg.Body...)
+
+	if exchange == "" {
+		if q, ok := m.queues[key]; ok {
+			m.enqueue(q, Delivery{Message: msg, Exchange: exchange, RoutingKey: key}, false)
+		}
+		return nil
+	}
+	ex, ok := m.exchanges[exchange]
+	if !ok {
+		return fmt.Errorf("broker: no exchange %q", exchange)
+	}
+	seen := map[string]bool{}
+	for _, b := range ex.bindings {
+		if seen[b.queue] || !bindingMatches(ex.kind, b.key, key) {
+			continue
+		}
+		if q, ok := m.queues[b.queue]; ok {
+			seen[b.queue] = true
+			m.enqueue(q, Delivery{Message: msg, Exchange: exchange, RoutingKey: key}, false)
+		}
+	}
+	return nil
+}
+
+// enqueue adds d to q, at the front when it is being redelivered. Messages
+// new to a queue with a TTL expire once it has passed. m.mu must be held.
+func (m *Memory) enqueue(q *memQueue, d Delivery, front bool) {
+	d.Queue = q.name
+	if front {
+		q.ready = append([]Delivery{d}, q.ready...)
+	} else {
+		m.lastTag++
+		d.tag = m.lastTag
+		q.ready = append(q.ready, d)
+		if ttl, ok := messageTTL(q.opts); ok {
+			tag := d.tag
+			time.AfterFunc(ttl, func() { m.expire(q, tag) })
+		}
+	}
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// messageTTL returns how long messages may wait in a queue declared with
+// opts, as set by its x-message-ttl argument in milliseconds.
+func messageTTL(opts QueueOptions) (time.Duration, bool) {
+	var ms int64
+	switch v := opts.Args["x-message-ttl"].(type) {
+	case int:
+		ms = int64(v)
+	case int32:
+		ms = int64(v)
+	case int64:
+		ms = v
+	default:
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// expire dead-letters the delivery tagged tag if it is still waiting in q.
+func (m *Memory) expire(q *memQueue, tag uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	for i, d := range q.ready {
+		if d.tag == tag {
+			q.ready = append(q.ready[:i], q.ready[i+1:]...)
+			m.deadLetter(q, d, "expired")
+			return
+		}
+	}
+}
+
+func (m *Memory) Consume(ctx context.Context, queue string) (<-chan Delivery, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrClosed
+	}
+	q, ok := m.queues[queue]
+	if !ok {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("broker: no queue %q", queue)
+	}
+	q.consumers++
+	m.mu.Unlock()
+
+	out := make(chan Delivery)
+	go func() {
+		defer close(out)
+		defer m.cancelConsumer(q)
+		for {
+			d, changed, ok := m.next(q)
+			if !ok {
+				select {
+				case <-changed:
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case out <- d:
+			case <-ctx.Done():
+				m.mu.Lock()
+				m.enqueue(q, d, true)
+				m.mu.Unlock()
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// next pops the first ready delivery of q. When there is none it returns a
+// channel that is closed once one arrives.
+func (m *Memory) next(q *memQueue) (Delivery, <-chan struct{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(q.ready) == 0 {
+		return Delivery{}, q.changed, false
+	}
+	d := q.ready[0]
+	q.ready = q.ready[1:]
+	d.ack = &memAcknowledger{m: m, q: q, d: d}
+	return d, nil, true
+}
+
+func (m *Memory) cancelConsumer(q *memQueue) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q.consumers--
+	if q.consumers == 0 && q.opts.AutoDelete {
+		delete(m.queues, q.name)
+		for _, ex := range m.exchanges {
+			kept := ex.bindings[:0]
+			for _, b := range ex.bindings {
+				if b.queue != q.name {
+					kept = append(kept, b)
+				}
+			}
+			ex.bindings = kept
+		}
+	}
+}
+
+func (m *Memory) RPC(ctx context.Context, exchange, key string, msg Message) (Delivery, error) {
+	return rpc(ctx, m, exchange, key, msg)
+}
+
+// Close stops the broker. Consumers keep running until their contexts are
+// cancelled but receive no new messages.
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+// Pending returns the messages waiting in queue without consuming them, so
+// tests can assert on what a handler published.
+func (m *Memory) Pending(queue string) []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q, ok := m.queues[queue]
+	if !ok {
+		return nil
+	}
+	msgs := make([]Message, len(q.ready))
+	for i, d := range q.ready {
+		msgs[i] = d.Message
+	}
+	return msgs
+}
+
+type memAcknowledger struct {
+	m       *Memory
+	q       *memQueue
+	d       Delivery
+	settled bool
+}
+
+func (a *memAcknowledger) ack() error {
+	a.m.mu.Lock()
+	defer a.m.mu.Unlock()
+	if a.settled {
+		return fmt.Errorf("broker: delivery already settled")
+	}
+	a.settled = true
+	return nil
+}
+
+func (a *memAcknowledger) nack(requeue bool) error {
+	a.m.mu.Lock()
+	defer a.m.mu.Unlock()
+	if a.settled {
+		return fmt.Errorf("broker: delivery already settled")
+	}
+	a.settled = true
+
+	d := a.d
+	d.ack = nil
+	if requeue {
+		d.Redelivered = true
+		a.m.enqueue(a.q, d, true)
+		return nil
+	}
+	return a.m.deadLetter(a.q, d, "rejected")
+}
+
+// deadLetter routes d, which left q for reason, to q's dead-letter
+// exchange, or drops it if q has none. m.mu must be held.
+func (m *Memory) deadLetter(q *memQueue, d Delivery, reason string) error {
+	dlx, ok := q.opts.Args["x-dead-letter-exchange"].(string)
+	if !ok {
+		return nil
+	}
+	key := d.RoutingKey
+	if k, ok := q.opts.Args["x-dead-letter-routing-key"].(string); ok {
+		key = k
+	}
+	d.Headers = copyHeaders(d.Headers)
+	if d.Headers == nil {
+		d.Headers = map[string]interface{}{}
+	}
+	if _, ok := d.Headers["x-first-death-queue"]; !ok {
+		d.Headers["x-first-death-queue"] = q.name
+		d.Headers["x-first-death-reason"] = reason
+	}
+	return m.route(dlx, key, d.Message)
+}
+
+// bindingMatches reports whether a message published with key reaches a
+// binding with pattern on an exchange of the given kind.
+func bindingMatches(kind, pattern, key string) bool {
+	switch kind {
+	case Fanout:
+		return true
+	case Topic:
+		return topicMatches(strings.Split(pattern, "."), strings.Split(key, "."))
+	default:
+		return pattern == key
+	}
+}
+
+// topicMatches implements AMQP topic matching: "*" matches exactly one word
+// and "#" matches zero or more words.
+func topicMatches(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(words); i++ {
+			if topicMatches(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(words) > 0 && topicMatches(pattern[1:], words[1:])
+	default:
+		return len(words) > 0 && pattern[0] == words[0] && topicMatches(pattern[1:], words[1:])
+	}
+}
+
+func copyHeaders(h map[string]interface{}) map[string]interface{} {
+	if h == nil {
+		return nil
+	}
+	c := make(map[string]interface{}, len(h))
+	for k, v := range h {
+		c[k] = v
+	}
+	return c
+}
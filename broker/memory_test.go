@@ -0,0 +1,303 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, msgs <-chan Delivery) Delivery {
+	t.Helper()
+	select {
+	case d, ok := <-msgs:
+		if !ok {
+			t.Fatal("delivery channel closed")
+		}
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+	return Delivery{}
+}
+
+func expectNone(t *testing.T, msgs <-chan Delivery) {
+	t.Helper()
+	select {
+	case d := <-msgs:
+		t.Fatalf("unexpected delivery %q", d.Body)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func consume(t *testing.T, b Broker, queue string) <-chan Delivery {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	msgs, err := b.Consume(ctx, queue)
+	if err != nil {
+		t.Fatalf("Consume(%q): %v", queue, err)
+	}
+	return msgs
+}
+
+func mustDeclareQueue(t *testing.T, b Broker, name string, opts QueueOptions) string {
+	t.Helper()
+	name, err := b.DeclareQueue(name, opts)
+	if err != nil {
+		t.Fatalf("DeclareQueue: %v", err)
+	}
+	return name
+}
+
+func TestMemoryWorkQueueDeliversEachMessageOnce(t *testing.T) {
+	m := NewMemory()
+	mustDeclareQueue(t, m, "work", QueueOptions{Durable: true})
+	first := consume(t, m, "work")
+	second := consume(t, m, "work")
+
+	ctx := context.Background()
+	for _, body := range []string{"a", "b"} {
+		if err := m.Publish(ctx, "", "work", Message{Body: []byte(body)}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case d := <-first:
+			got[string(d.Body)] = true
+			d.Ack()
+		case d := <-second:
+			got[string(d.Body)] = true
+			d.Ack()
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for delivery")
+		}
+	}
+	if !got["a"] || !got["b"] {
+		t.Fatalf("got %v, want both messages", got)
+	}
+	expectNone(t, first)
+	expectNone(t, second)
+}
+
+func TestMemoryFanoutCopiesToEveryBoundQueue(t *testing.T) {
+	m := NewMemory()
+	if err := m.DeclareExchange("events", Fanout); err != nil {
+		t.Fatalf("DeclareExchange: %v", err)
+	}
+	var queues []<-chan Delivery
+	for i := 0; i < 3; i++ {
+		q := mustDeclareQueue(t, m, "", QueueOptions{AutoDelete: true})
+		if err := m.BindQueue(q, "", "events"); err != nil {
+			t.Fatalf("BindQueue: %v", err)
+		}
+		queues = append(queues, consume(t, m, q))
+	}
+
+	if err := m.Publish(context.Background(), "events", "ignored", Message{Body: []byte("ping")}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	for _, q := range queues {
+		if d := receive(t, q); string(d.Body) != "ping" {
+			t.Fatalf("got %q, want ping", d.Body)
+		}
+	}
+}
+
+func TestMemoryTopicRouting(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"order.*", "order.placed", true},
+		{"order.*", "order.placed.eu", false},
+		{"order.#", "order.placed.eu", true},
+		{"order.#", "order", true},
+		{"#.low", "stock.low", true},
+		{"*.low", "low", false},
+		{"stock.low", "stock.high", false},
+	}
+	for _, tt := range tests {
+		if got := bindingMatches(Topic, tt.pattern, tt.key); got != tt.want {
+			t.Errorf("bindingMatches(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+
+	m := NewMemory()
+	if err := m.DeclareExchange("events", Topic); err != nil {
+		t.Fatalf("DeclareExchange: %v", err)
+	}
+	mustDeclareQueue(t, m, "orders", QueueOptions{})
+	if err := m.BindQueue("orders", "order.#", "events"); err != nil {
+		t.Fatalf("BindQueue: %v", err)
+	}
+	msgs := consume(t, m, "orders")
+
+	ctx := context.Background()
+	m.Publish(ctx, "events", "stock.low", Message{Body: []byte("stock")})
+	m.Publish(ctx, "events", "order.placed", Message{Body: []byte("order")})
+	d := receive(t, msgs)
+	if string(d.Body) != "order" || d.RoutingKey != "order.placed" {
+		t.Fatalf("got %q with key %q, want order with key order.placed", d.Body, d.RoutingKey)
+	}
+	expectNone(t, msgs)
+}
+
+func TestMemoryNackRequeueRedelivers(t *testing.T) {
+	m := NewMemory()
+	mustDeclareQueue(t, m, "work", QueueOptions{})
+	msgs := consume(t, m, "work")
+
+	m.Publish(context.Background(), "", "work", Message{Body: []byte("retry me")})
+	d := receive(t, msgs)
+	if d.Redelivered {
+		t.Fatal("first delivery marked as redelivered")
+	}
+	if err := d.Nack(true); err != nil {
+		t.Fatalf("Nack: %v", err)
+	}
+
+	d = receive(t, msgs)
+	if !d.Redelivered || string(d.Body) != "retry me" {
+		t.Fatalf("got %q redelivered=%v, want redelivered copy", d.Body, d.Redelivered)
+	}
+	if err := d.Ack(); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if err := d.Ack(); err == nil {
+		t.Fatal("second Ack succeeded, want error")
+	}
+}
+
+func TestMemoryNackWithoutRequeueDeadLetters(t *testing.T) {
+	m := NewMemory()
+	if err := m.DeclareExchange("dlx", Direct); err != nil {
+		t.Fatalf("DeclareExchange: %v", err)
+	}
+	mustDeclareQueue(t, m, "work.dlq", QueueOptions{})
+	if err := m.BindQueue("work.dlq", "work", "dlx"); err != nil {
+		t.Fatalf("BindQueue: %v", err)
+	}
+	mustDeclareQueue(t, m, "work", QueueOptions{Args: map[string]interface{}{
+		"x-dead-letter-exchange": "dlx",
+	}})
+	msgs := consume(t, m, "work")
+
+	m.Publish(context.Background(), "", "work", Message{Body: []byte("poison")})
+	receive(t, msgs).Nack(false)
+
+	pending := m.Pending("work.dlq")
+	if len(pending) != 1 || string(pending[0].Body) != "poison" {
+		t.Fatalf("dead-letter queue holds %v, want the rejected message", pending)
+	}
+	if got := pending[0].Headers["x-first-death-queue"]; got != "work" {
+		t.Fatalf("x-first-death-queue = %v, want work", got)
+	}
+}
+
+func TestMemoryMessageTTLDeadLettersExpired(t *testing.T) {
+	m := NewMemory()
+	mustDeclareQueue(t, m, "work", QueueOptions{})
+	// A delay queue: nobody consumes it, and expired messages move on to work.
+	mustDeclareQueue(t, m, "work.delay", QueueOptions{Args: map[string]interface{}{
+		"x-message-ttl":             int64(30),
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": "work",
+	}})
+	msgs := consume(t, m, "work")
+
+	start := time.Now()
+	m.Publish(context.Background(), "", "work.delay", Message{Body: []byte("later")})
+	if pending := m.Pending("work.delay"); len(pending) != 1 {
+		t.Fatalf("delay queue holds %d messages, want 1", len(pending))
+	}
+	d := receive(t, msgs)
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("message arrived after %v, before its TTL", elapsed)
+	}
+	if string(d.Body) != "later" || d.Headers["x-first-death-reason"] != "expired" || d.Headers["x-first-death-queue"] != "work.delay" {
+		t.Fatalf("delivery = %q with headers %v, want the expired message", d.Body, d.Headers)
+	}
+	if pending := m.Pending("work.delay"); len(pending) != 0 {
+		t.Fatalf("delay queue still holds %v", pending)
+	}
+}
+
+func TestDeclareWorkQueueDeadLettersRejects(t *testing.T) {
+	m := NewMemory()
+	if err := DeclareWorkQueue(m, "work"); err != nil {
+		t.Fatalf("DeclareWorkQueue: %v", err)
+	}
+	// Declaring again, as every instance of a service does, is harmless.
+	if err := DeclareWorkQueue(m, "work"); err != nil {
+		t.Fatalf("second DeclareWorkQueue: %v", err)
+	}
+	msgs := consume(t, m, "work")
+
+	m.Publish(context.Background(), "", "work", Message{Body: []byte("poison")})
+	receive(t, msgs).Nack(false)
+
+	pending := m.Pending(DeadLetterQueue("work"))
+	if len(pending) != 1 || string(pending[0].Body) != "poison" {
+		t.Fatalf("work_dlq holds %v, want the rejected message", pending)
+	}
+}
+
+func TestMemoryRPC(t *testing.T) {
+	m := NewMemory()
+	mustDeclareQueue(t, m, "echo", QueueOptions{})
+	requests := consume(t, m, "echo")
+
+	go func() {
+		for req := range requests {
+			m.Publish(context.Background(), "", req.ReplyTo, Message{
+				CorrelationID: req.CorrelationID,
+				Body:          append([]byte("echo: "), req.Body...),
+			})
+			req.Ack()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reply, err := m.RPC(ctx, "", "echo", Message{Body: []byte("hi")})
+	if err != nil {
+		t.Fatalf("RPC: %v", err)
+	}
+	if string(reply.Body) != "echo: hi" {
+		t.Fatalf("reply = %q, want %q", reply.Body, "echo: hi")
+	}
+}
+
+func TestMemoryRPCTimesOutWithoutReply(t *testing.T) {
+	m := NewMemory()
+	mustDeclareQueue(t, m, "void", QueueOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := m.RPC(ctx, "", "void", Message{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RPC error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestMemoryAutoDeleteQueueIsRemovedWithLastConsumer(t *testing.T) {
+	m := NewMemory()
+	q := mustDeclareQueue(t, m, "", QueueOptions{AutoDelete: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	msgs, err := m.Consume(ctx, q)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	cancel()
+	for range msgs {
+	}
+
+	if _, err := m.Consume(context.Background(), q); err == nil {
+		t.Fatal("Consume on deleted queue succeeded, want error")
+	}
+}
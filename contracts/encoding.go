@@ -0,0 +1,91 @@
+package contracts
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Encoding is a wire format for envelopes.
+type Encoding string
+
+const (
+	JSON     Encoding = "json"
+	Protobuf Encoding = "protobuf"
+)
+
+// Content types of the encodings, set on every published message.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// ContentType returns the content type of messages in the encoding.
+func (e Encoding) ContentType() string {
+	if e == Protobuf {
+		return ContentTypeProtobuf
+	}
+	return ContentTypeJSON
+}
+
+// EncodingOf returns the encoding of a message with the given content type.
+// Messages without a content type are JSON.
+func EncodingOf(contentType string) (Encoding, error) {
+	switch contentType {
+	case "", ContentTypeJSON:
+		return JSON, nil
+	case ContentTypeProtobuf:
+		return Protobuf, nil
+	}
+	return "", fmt.Errorf("unsupported content type %q", contentType)
+}
+
+// Encodings chooses the encoding of messages published to each queue.
+// Queues not listed use JSON. Consumers decode either encoding, so a queue
+// can be switched by reconfiguring its publishers alone.
+type Encodings map[string]Encoding
+
+// For returns the encoding of messages published to queue.
+func (e Encodings) For(queue string) Encoding {
+	if enc, ok := e[queue]; ok {
+		return enc
+	}
+	return JSON
+}
+
+// ParseEncodings parses a comma-separated list of queue=encoding pairs, for
+// example "check_stock=protobuf,place_order=json".
+func ParseEncodings(s string) (Encodings, error) {
+	encodings := Encodings{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		queue, enc, ok := strings.Cut(pair, "=")
+		queue, enc = strings.TrimSpace(queue), strings.TrimSpace(enc)
+		if !ok || queue == "" {
+			return nil, fmt.Errorf("invalid queue encoding %q, want queue=encoding", pair)
+		}
+		switch Encoding(enc) {
+		case JSON, Protobuf:
+			encodings[queue] = Encoding(enc)
+		default:
+			return nil, fmt.Errorf("queue %s: unknown encoding %q, want json or protobuf", queue, enc)
+		}
+	}
+	return encodings, nil
+}
+
+// LoadEncodings reads the per-queue encodings from MESSAGE_ENCODINGS. An
+// invalid setting is logged and ignored, leaving every queue on JSON.
+func LoadEncodings() Encodings {
+	value := os.Getenv("MESSAGE_ENCODINGS")
+	encodings, err := ParseEncodings(value)
+	if err != nil {
+		slog.Warn("Invalid setting, using default", "key", "MESSAGE_ENCODINGS", "value", value, "error", err)
+		return Encodings{}
+	}
+	return encodings
+}
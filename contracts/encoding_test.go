@@ -0,0 +1,41 @@
+package contracts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEncodings(t *testing.T) {
+	got, err := ParseEncodings(" check_stock=protobuf, place_order = json ,")
+	if err != nil {
+		t.Fatalf("ParseEncodings: %v", err)
+	}
+	want := Encodings{"check_stock": Protobuf, "place_order": JSON}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseEncodings = %v, want %v", got, want)
+	}
+	if got.For("check_stock") != Protobuf || got.For("notifications") != JSON {
+		t.Errorf("For: check_stock=%s notifications=%s", got.For("check_stock"), got.For("notifications"))
+	}
+
+	for _, bad := range []string{"check_stock", "=protobuf", "check_stock=xml"} {
+		if _, err := ParseEncodings(bad); err == nil {
+			t.Errorf("ParseEncodings(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestEncodingOf(t *testing.T) {
+	for contentType, want := range map[string]Encoding{
+		"":                  JSON,
+		ContentTypeJSON:     JSON,
+		ContentTypeProtobuf: Protobuf,
+	} {
+		if got, err := EncodingOf(contentType); err != nil || got != want {
+			t.Errorf("EncodingOf(%q) = %q, %v; want %q", contentType, got, err, want)
+		}
+	}
+	if _, err := EncodingOf("application/xml"); err == nil {
+		t.Error("EncodingOf accepted application/xml")
+	}
+}
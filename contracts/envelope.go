@@ -0,0 +1,157 @@
+// Package contracts defines the messages the services exchange over the
+// broker. Every message body is an Envelope naming the payload's type and
+// schema version. The payload types are defined here once and shared by
+// producers and consumers.
+package contracts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrMalformed is returned for a body that is not a valid envelope or
+	// whose payload does not match its declared type.
+	ErrMalformed = errors.New("malformed message")
+	// ErrUnknownType is returned for an envelope whose type is not
+	// registered.
+	ErrUnknownType = errors.New("unknown message type")
+	// ErrUnsupportedVersion is returned for a registered type at a schema
+	// version this build cannot read.
+	ErrUnsupportedVersion = errors.New("unsupported message version")
+)
+
+// Envelope describes a message: its payload's type and schema version, and
+// where and when it was produced.
+type Envelope struct {
+	Type      string    `json:"type"`
+	Version   int       `json:"version"`
+	MessageID string    `json:"message_id"`
+	Producer  string    `json:"producer"`
+	CreatedAt time.Time `json:"created_at"` // when the message was first created
+	SentAt    time.Time `json:"sent_at"`    // when this copy was published
+
+	payload Payload
+}
+
+// jsonEnvelope is the JSON wire format of an envelope.
+type jsonEnvelope struct {
+	Envelope
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Payload is implemented by every message payload type.
+type Payload interface {
+	// MessageType returns the registered type name of the payload.
+	MessageType() string
+}
+
+// New wraps p in an envelope at its type's current version, with a fresh
+// message ID.
+func New(producer string, p Payload) (Envelope, error) {
+	schema, ok := schemas[p.MessageType()]
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w %q", ErrUnknownType, p.MessageType())
+	}
+	now := time.Now().UTC()
+	return Envelope{
+		Type:      schema.Type,
+		Version:   schema.Version,
+		MessageID: newMessageID(),
+		Producer:  producer,
+		CreatedAt: now,
+		SentAt:    now,
+		payload:   p,
+	}, nil
+}
+
+// Payload returns the message the envelope carries.
+func (env Envelope) Payload() Payload {
+	return env.payload
+}
+
+// Marshal encodes env for publishing in the given encoding, stamping SentAt
+// with the current time.
+func (env Envelope) Marshal(enc Encoding) ([]byte, error) {
+	env.SentAt = time.Now().UTC()
+	switch enc {
+	case JSON:
+		payload, err := json.Marshal(env.payload)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(jsonEnvelope{Envelope: env, Payload: payload})
+	case Protobuf:
+		return marshalProto(env)
+	}
+	return nil, fmt.Errorf("unknown encoding %q", enc)
+}
+
+// Encode wraps p in a new envelope and encodes it. It returns the envelope
+// as well, for its message ID.
+func Encode(enc Encoding, producer string, p Payload) (Envelope, []byte, error) {
+	env, err := New(producer, p)
+	if err != nil {
+		return Envelope{}, nil, err
+	}
+	body, err := env.Marshal(enc)
+	return env, body, err
+}
+
+// Decode parses an enveloped message and its payload, in the encoding
+// named by the message's content type. The payload is returned as a value
+// of the registered type, for example a StockCheck. The envelope is
+// returned even on error when the body could be parsed, so its type and
+// version can be logged.
+func Decode(contentType string, body []byte) (Envelope, Payload, error) {
+	enc, err := EncodingOf(contentType)
+	if err != nil {
+		return Envelope{}, nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if enc == Protobuf {
+		return unmarshalProto(body)
+	}
+	var wire jsonEnvelope
+	if err := json.Unmarshal(body, &wire); err != nil {
+		return Envelope{}, nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	env := wire.Envelope
+	schema, err := env.schema()
+	if err != nil {
+		return env, nil, err
+	}
+	p, err := schema.decode(wire.Payload)
+	if err != nil {
+		return env, nil, fmt.Errorf("%w: %s payload: %v", ErrMalformed, env.Type, err)
+	}
+	env.payload = p
+	return env, p, nil
+}
+
+// schema returns the registry entry for env, checking that this build can
+// read its version.
+func (env Envelope) schema() (Schema, error) {
+	if env.Type == "" {
+		return Schema{}, fmt.Errorf("%w: no message type", ErrMalformed)
+	}
+	schema, ok := schemas[env.Type]
+	if !ok {
+		return Schema{}, fmt.Errorf("%w %q", ErrUnknownType, env.Type)
+	}
+	if !schema.reads(env.Version) {
+		return Schema{}, fmt.Errorf("%w: %s version %d, want %d to %d", ErrUnsupportedVersion, env.Type, env.Version, schema.MinVersion, schema.Version)
+	}
+	return schema, nil
+}
+
+func newMessageID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
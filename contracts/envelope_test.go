@@ -0,0 +1,176 @@
+package contracts
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"ecomm-sample/contracts/pb"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// testTime is a timestamp carried by payloads in tests.
+var testTime = time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+// allPayloads has a non-zero value of every payload type.
+var allPayloads = []Payload{
+	StockCheck{ProductID: 101, Quantity: 2, Reserve: true, OrderID: 42},
+	StockCheckResult{ProductID: 101, IsAvailable: true},
+	StockRelease{ProductID: 101, Quantity: 2, OrderID: 42, Reason: "order cancelled"},
+	StockAdjust{ProductID: 101, Quantity: 40, Type: MovementReceipt, Reason: "delivery", Actor: "ada", Reference: "PO-1001"},
+	MovementList{ProductID: 101},
+	MovementListResult{ProductID: 101, Stock: 36, Movements: []StockMovement{
+		{MovementID: 2, ProductID: 101, Type: MovementReceipt, Quantity: 40, Balance: 36, Reason: "delivery", Actor: "ada", Reference: "PO-1001", CreatedAt: time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)},
+		{MovementID: 1, ProductID: 101, Type: MovementReservation, Quantity: -4, Balance: -4, Actor: "api_gateway", Reference: "order:42", CreatedAt: time.Date(2026, time.October, 19, 11, 0, 0, 0, time.UTC)},
+	}},
+	ProductGet{ProductID: 101},
+	ProductDetails{ProductID: 101, Name: "Laptop", Stock: 6, ReorderThreshold: 8, SafetyStock: 2},
+	ReorderPolicySet{ProductID: 101, ReorderThreshold: 8, SafetyStock: 2},
+	ReorderReport{WindowDays: 30, CoverDays: 14},
+	ReorderReportResult{WindowDays: 30, CoverDays: 14, Suggestions: []ReorderSuggestion{
+		{ProductID: 101, Name: "Laptop", Stock: 6, ReorderThreshold: 8, SafetyStock: 2, Consumed: 45, DailyVelocity: 1.5, Quantity: 17},
+	}},
+	InventoryError{Code: InventoryErrInsufficientStock, Message: "product 101 has 6 units in stock"},
+	OrderPlaced{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2},
+	OrderGet{OrderID: 1},
+	OrderList{UserID: 7},
+	OrderCancel{OrderID: 1},
+	OrderDetails{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2, Status: OrderStatusCancelled},
+	OrderListResult{Orders: []OrderDetails{{OrderID: 1, Status: OrderStatusPlaced}, {OrderID: 2, Status: OrderStatusCancelled}}},
+	OrderError{Code: OrderErrNotFound, Message: "order 1 not found"},
+	NotificationRequested{UserID: 7, Message: "hello", Channel: "email", Template: "order_placed", Locale: "de", Data: map[string]string{"order_id": "42"}, Category: CategoryMarketing, Priority: PriorityLow},
+	NotificationRetry{NotificationID: 3, Attempt: 2, Channels: []string{"email", "sms"}},
+	NotificationDigest{UserID: 7, Category: CategoryTransactional},
+	NotificationList{UserID: 7},
+	NotificationListResult{Notifications: []NotificationDetails{
+		{NotificationID: 1, UserID: 7, Channel: "email", Category: CategoryTransactional, Content: "hello", Status: NotificationStatusDelivered, Attempts: 1, CreatedAt: testTime, UpdatedAt: testTime, DeliveredAt: &testTime},
+		{NotificationID: 2, UserID: 7, Content: "bye", Status: NotificationStatusQueued, CreatedAt: testTime, UpdatedAt: testTime},
+		{NotificationID: 3, UserID: 7, Content: "later", Status: NotificationStatusDigested, CreatedAt: testTime, UpdatedAt: testTime, DigestID: 4},
+	}},
+	NotificationError{Code: NotificationErrUnavailable, Message: "notification database unavailable"},
+	ContactGet{UserID: 7},
+	ContactSet{UserID: 7, Email: "ada@example.com", Push: &PushSubscription{Endpoint: "https://push.example/abc", Keys: PushKeys{P256dh: "BKey", Auth: "secret"}}},
+	ContactDetails{UserID: 7, Email: "ada@example.com", Phone: "+15550100", Locale: "pt-BR"},
+	PreferencesGet{UserID: 7},
+	PreferencesSet{UserID: 7, Categories: map[string]map[string]bool{CategoryMarketing: {"email": true, "sms": false}}, QuietHours: &QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Berlin"}},
+	PreferencesDetails{UserID: 7, Categories: map[string]map[string]bool{CategoryTransactional: {"email": true}}, Unsubscribed: true},
+	Unsubscribe{Token: "7.c2lnbmF0dXJl"},
+	OrderStatusChanged{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2, Status: OrderStatusCancelled},
+	StockLow{ProductID: 101, Stock: 3, Threshold: 5, SafetyStock: 2},
+	WebhookSet{WebhookID: 2, URL: "https://partner.example/hooks", Events: []string{EventOrderPlaced, EventStockLow}, Secret: "s3cret", Active: true},
+	WebhookList{},
+	WebhookDelete{WebhookID: 2},
+	WebhookDetails{WebhookID: 2, URL: "https://partner.example/hooks", Events: []string{EventOrderCancelled}, Secret: "s3cret", Active: true, Failures: 1, CreatedAt: testTime, UpdatedAt: testTime},
+	WebhookListResult{Webhooks: []WebhookDetails{{WebhookID: 2, URL: "https://partner.example/hooks", Events: []string{EventStockLow}, CreatedAt: testTime, UpdatedAt: testTime}}},
+	WebhookDeliveryList{WebhookID: 2},
+	WebhookDeliveryListResult{Deliveries: []WebhookDeliveryDetails{
+		{DeliveryID: 5, WebhookID: 2, EventID: "abc", Event: EventOrderPlaced, Status: WebhookDeliveryDelivered, Attempts: 2, ResponseStatus: 200, CreatedAt: testTime, UpdatedAt: testTime, DeliveredAt: &testTime},
+	}},
+	WebhookRedeliver{WebhookID: 2, DeliveryID: 5},
+	WebhookDeliveryDetails{DeliveryID: 5, WebhookID: 2, EventID: "abc", Event: EventStockLow, Status: WebhookDeliveryFailed, Attempts: 3, ResponseStatus: 500, Error: "boom", CreatedAt: testTime, UpdatedAt: testTime},
+	WebhookDeliver{DeliveryID: 5, Attempt: 2},
+	InboxList{UserID: 7, Archived: true},
+	InboxListResult{Items: []InboxItemDetails{
+		{ItemID: 3, UserID: 7, NotificationID: 1, Category: CategoryTransactional, Subject: "Your order", Content: "hello", Read: true, CreatedAt: testTime, ReadAt: &testTime},
+		{ItemID: 4, UserID: 7, NotificationID: 2, Category: CategoryMarketing, Content: "sale", Archived: true, CreatedAt: testTime},
+	}, Unread: 1},
+	InboxCount{UserID: 7},
+	InboxCountResult{UserID: 7, Unread: 2},
+	InboxUpdate{UserID: 7, ItemID: 3, Action: InboxActionArchive},
+	InboxMarkAllRead{UserID: 7},
+	InboxItemDetails{ItemID: 3, UserID: 7, NotificationID: 1, Category: CategoryTransactional, Content: "hello", Read: true, CreatedAt: testTime, ReadAt: &testTime},
+	InboxItemAdded{ItemID: 4, UserID: 7, NotificationID: 2, Category: CategoryTransactional, Subject: "Your order", Content: "hello", CreatedAt: testTime},
+	HealthCheck{},
+	HealthStatus{Service: "Stock Service", Status: "unhealthy", Database: "down", SchemaVersion: 3, Error: "boom"},
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	for _, enc := range []Encoding{JSON, Protobuf} {
+		for _, want := range allPayloads {
+			sent, body, err := Encode(enc, "api_gateway", want)
+			if err != nil {
+				t.Fatalf("%s: Encode %T: %v", enc, want, err)
+			}
+			env, payload, err := Decode(enc.ContentType(), body)
+			if err != nil {
+				t.Fatalf("%s: Decode %T: %v", enc, want, err)
+			}
+			if env.Type != want.MessageType() || env.Version != 1 || env.Producer != "api_gateway" {
+				t.Errorf("%s: envelope = %+v", enc, env)
+			}
+			if env.MessageID == "" || env.MessageID != sent.MessageID || !env.CreatedAt.Equal(sent.CreatedAt) {
+				t.Errorf("%s: envelope = %+v, want ID %q created at %v", enc, env, sent.MessageID, sent.CreatedAt)
+			}
+			if !reflect.DeepEqual(payload, want) || !reflect.DeepEqual(env.Payload(), want) {
+				t.Errorf("%s: payload = %#v, want %#v", enc, payload, want)
+			}
+		}
+	}
+}
+
+func TestProtobufIsSmaller(t *testing.T) {
+	p := OrderPlaced{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2}
+	_, asJSON, _ := Encode(JSON, "api_gateway", p)
+	_, asProto, _ := Encode(Protobuf, "api_gateway", p)
+	if len(asProto) >= len(asJSON) {
+		t.Errorf("protobuf message is %d bytes, JSON %d", len(asProto), len(asJSON))
+	}
+}
+
+func TestNewAssignsUniqueMessageIDs(t *testing.T) {
+	a, _ := New("test", HealthCheck{})
+	b, _ := New("test", HealthCheck{})
+	if a.MessageID == b.MessageID {
+		t.Errorf("both envelopes got message ID %q", a.MessageID)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	jsonEnv := func(typ string, version int, payload string) []byte {
+		body, _ := json.Marshal(jsonEnvelope{Envelope: Envelope{Type: typ, Version: version}, Payload: json.RawMessage(payload)})
+		return body
+	}
+	protoEnv := func(typ string, version int32, payload []byte) []byte {
+		body, _ := proto.Marshal(&pb.Envelope{Type: typ, Version: version, Payload: payload})
+		return body
+	}
+	for _, tt := range []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        error
+	}{
+		{"not json", ContentTypeJSON, []byte("not json"), ErrMalformed},
+		{"bare payload", ContentTypeJSON, []byte(`{"product_id":101,"quantity":2}`), ErrMalformed},
+		{"bad payload", ContentTypeJSON, jsonEnv(TypeStockCheck, 1, `{"product_id":"101"}`), ErrMalformed},
+		{"unknown type", ContentTypeJSON, jsonEnv("stock.teleport", 1, `{}`), ErrUnknownType},
+		{"future version", ContentTypeJSON, jsonEnv(TypeStockCheck, 2, `{}`), ErrUnsupportedVersion},
+		{"no version", "", jsonEnv(TypeStockCheck, 0, `{}`), ErrUnsupportedVersion},
+		{"unknown content type", "text/plain", jsonEnv(TypeStockCheck, 1, `{}`), ErrMalformed},
+		{"json as protobuf", ContentTypeProtobuf, jsonEnv(TypeStockCheck, 1, `{}`), ErrMalformed},
+		{"bad protobuf payload", ContentTypeProtobuf, protoEnv(TypeStockCheck, 1, []byte{0xff}), ErrMalformed},
+		{"unknown protobuf type", ContentTypeProtobuf, protoEnv("stock.teleport", 1, nil), ErrUnknownType},
+		{"future protobuf version", ContentTypeProtobuf, protoEnv(TypeStockCheck, 2, nil), ErrUnsupportedVersion},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Decode(tt.contentType, tt.body)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Decode error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEveryPayloadIsRegistered(t *testing.T) {
+	for _, p := range allPayloads {
+		if _, ok := Lookup(p.MessageType()); !ok {
+			t.Errorf("%T: type %q is not registered", p, p.MessageType())
+		}
+	}
+	if n := len(Schemas()); n != len(allPayloads) {
+		t.Errorf("Schemas returned %d entries, want %d", n, len(allPayloads))
+	}
+}
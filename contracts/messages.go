@@ -0,0 +1,884 @@
+package contracts
+
+import "time"
+
+// Message types.
+const (
+	TypeStockCheck                = "stock.check"
+	TypeStockCheckResult          = "stock.check_result"
+	TypeStockRelease              = "stock.release"
+	TypeStockAdjust               = "stock.adjust"
+	TypeProductGet                = "product.get"
+	TypeProductDetails            = "product.details"
+	TypeReorderPolicySet          = "reorder.policy_set"
+	TypeReorderReport             = "reorder.report"
+	TypeReorderReportResult       = "reorder.report_result"
+	TypeMovementList              = "movement.list"
+	TypeMovementListResult        = "movement.list_result"
+	TypeInventoryError            = "inventory.error"
+	TypeOrderPlaced               = "order.placed"
+	TypeOrderGet                  = "order.get"
+	TypeOrderList                 = "order.list"
+	TypeOrderCancel               = "order.cancel"
+	TypeOrderDetails              = "order.details"
+	TypeOrderListResult           = "order.list_result"
+	TypeOrderError                = "order.error"
+	TypeNotificationRequested     = "notification.requested"
+	TypeNotificationList          = "notification.list"
+	TypeNotificationListResult    = "notification.list_result"
+	TypeNotificationError         = "notification.error"
+	TypeNotificationRetry         = "notification.retry"
+	TypeNotificationDigest        = "notification.digest"
+	TypeContactGet                = "contact.get"
+	TypeContactSet                = "contact.set"
+	TypeContactDetails            = "contact.details"
+	TypePreferencesGet            = "preferences.get"
+	TypePreferencesSet            = "preferences.set"
+	TypePreferencesDetails        = "preferences.details"
+	TypeUnsubscribe               = "notification.unsubscribe"
+	TypeOrderStatusChanged        = "order.status_changed"
+	TypeStockLow                  = "stock.low"
+	TypeWebhookSet                = "webhook.set"
+	TypeWebhookList               = "webhook.list"
+	TypeWebhookDelete             = "webhook.delete"
+	TypeWebhookDetails            = "webhook.details"
+	TypeWebhookListResult         = "webhook.list_result"
+	TypeWebhookDeliveryList       = "webhook.delivery_list"
+	TypeWebhookDeliveryListResult = "webhook.delivery_list_result"
+	TypeWebhookRedeliver          = "webhook.redeliver"
+	TypeWebhookDeliveryDetails    = "webhook.delivery_details"
+	TypeWebhookDeliver            = "webhook.deliver"
+	TypeInboxList                 = "inbox.list"
+	TypeInboxListResult           = "inbox.list_result"
+	TypeInboxCount                = "inbox.count"
+	TypeInboxCountResult          = "inbox.count_result"
+	TypeInboxUpdate               = "inbox.update"
+	TypeInboxMarkAllRead          = "inbox.mark_all_read"
+	TypeInboxItemDetails          = "inbox.item_details"
+	TypeInboxItemAdded            = "inbox.item_added"
+	TypeHealthCheck               = "health.check"
+	TypeHealthStatus              = "health.status"
+)
+
+func init() {
+	register(Schema{Type: TypeStockCheck, Version: 1, Queue: "check_stock", decode: decoder[StockCheck](), proto: stockCheckProto})
+	register(Schema{Type: TypeStockCheckResult, Version: 1, decode: decoder[StockCheckResult](), proto: stockCheckResultProto})
+	register(Schema{Type: TypeStockRelease, Version: 1, Queue: "release_stock", decode: decoder[StockRelease](), proto: stockReleaseProto})
+	register(Schema{Type: TypeStockAdjust, Version: 1, Queue: "inventory_requests", decode: decoder[StockAdjust](), proto: stockAdjustProto})
+	register(Schema{Type: TypeProductGet, Version: 1, Queue: "inventory_requests", decode: decoder[ProductGet](), proto: productGetProto})
+	register(Schema{Type: TypeProductDetails, Version: 1, decode: decoder[ProductDetails](), proto: productDetailsProto})
+	register(Schema{Type: TypeReorderPolicySet, Version: 1, Queue: "inventory_requests", decode: decoder[ReorderPolicySet](), proto: reorderPolicySetProto})
+	register(Schema{Type: TypeReorderReport, Version: 1, Queue: "inventory_requests", decode: decoder[ReorderReport](), proto: reorderReportProto})
+	register(Schema{Type: TypeReorderReportResult, Version: 1, decode: decoder[ReorderReportResult](), proto: reorderReportResultProto})
+	register(Schema{Type: TypeMovementList, Version: 1, Queue: "inventory_requests", decode: decoder[MovementList](), proto: movementListProto})
+	register(Schema{Type: TypeMovementListResult, Version: 1, decode: decoder[MovementListResult](), proto: movementListResultProto})
+	register(Schema{Type: TypeInventoryError, Version: 1, decode: decoder[InventoryError](), proto: inventoryErrorProto})
+	register(Schema{Type: TypeOrderPlaced, Version: 1, Queue: "place_order", decode: decoder[OrderPlaced](), proto: orderPlacedProto})
+	register(Schema{Type: TypeOrderGet, Version: 1, Queue: "order_requests", decode: decoder[OrderGet](), proto: orderGetProto})
+	register(Schema{Type: TypeOrderList, Version: 1, Queue: "order_requests", decode: decoder[OrderList](), proto: orderListProto})
+	register(Schema{Type: TypeOrderCancel, Version: 1, Queue: "order_requests", decode: decoder[OrderCancel](), proto: orderCancelProto})
+	register(Schema{Type: TypeOrderDetails, Version: 1, decode: decoder[OrderDetails](), proto: orderDetailsProto})
+	register(Schema{Type: TypeOrderListResult, Version: 1, decode: decoder[OrderListResult](), proto: orderListResultProto})
+	register(Schema{Type: TypeOrderError, Version: 1, decode: decoder[OrderError](), proto: orderErrorProto})
+	register(Schema{Type: TypeNotificationRequested, Version: 1, Queue: "notifications", decode: decoder[NotificationRequested](), proto: notificationRequestedProto})
+	register(Schema{Type: TypeNotificationList, Version: 1, Queue: "notification_requests", decode: decoder[NotificationList](), proto: notificationListProto})
+	register(Schema{Type: TypeNotificationListResult, Version: 1, decode: decoder[NotificationListResult](), proto: notificationListResultProto})
+	register(Schema{Type: TypeNotificationError, Version: 1, decode: decoder[NotificationError](), proto: notificationErrorProto})
+	register(Schema{Type: TypeNotificationRetry, Version: 1, Queue: "notifications", decode: decoder[NotificationRetry](), proto: notificationRetryProto})
+	register(Schema{Type: TypeNotificationDigest, Version: 1, Queue: "notifications", decode: decoder[NotificationDigest](), proto: notificationDigestProto})
+	register(Schema{Type: TypeContactGet, Version: 1, Queue: "notification_requests", decode: decoder[ContactGet](), proto: contactGetProto})
+	register(Schema{Type: TypeContactSet, Version: 1, Queue: "notification_requests", decode: decoder[ContactSet](), proto: contactSetProto})
+	register(Schema{Type: TypeContactDetails, Version: 1, decode: decoder[ContactDetails](), proto: contactDetailsProto})
+	register(Schema{Type: TypePreferencesGet, Version: 1, Queue: "notification_requests", decode: decoder[PreferencesGet](), proto: preferencesGetProto})
+	register(Schema{Type: TypePreferencesSet, Version: 1, Queue: "notification_requests", decode: decoder[PreferencesSet](), proto: preferencesSetProto})
+	register(Schema{Type: TypePreferencesDetails, Version: 1, decode: decoder[PreferencesDetails](), proto: preferencesDetailsProto})
+	register(Schema{Type: TypeUnsubscribe, Version: 1, Queue: "notification_requests", decode: decoder[Unsubscribe](), proto: unsubscribeProto})
+	register(Schema{Type: TypeOrderStatusChanged, Version: 1, Queue: EventsExchange, decode: decoder[OrderStatusChanged](), proto: orderStatusChangedProto})
+	register(Schema{Type: TypeStockLow, Version: 1, Queue: EventsExchange, decode: decoder[StockLow](), proto: stockLowProto})
+	register(Schema{Type: TypeWebhookSet, Version: 1, Queue: "notification_requests", decode: decoder[WebhookSet](), proto: webhookSetProto})
+	register(Schema{Type: TypeWebhookList, Version: 1, Queue: "notification_requests", decode: decoder[WebhookList](), proto: webhookListProto})
+	register(Schema{Type: TypeWebhookDelete, Version: 1, Queue: "notification_requests", decode: decoder[WebhookDelete](), proto: webhookDeleteProto})
+	register(Schema{Type: TypeWebhookDetails, Version: 1, decode: decoder[WebhookDetails](), proto: webhookDetailsProto})
+	register(Schema{Type: TypeWebhookListResult, Version: 1, decode: decoder[WebhookListResult](), proto: webhookListResultProto})
+	register(Schema{Type: TypeWebhookDeliveryList, Version: 1, Queue: "notification_requests", decode: decoder[WebhookDeliveryList](), proto: webhookDeliveryListProto})
+	register(Schema{Type: TypeWebhookDeliveryListResult, Version: 1, decode: decoder[WebhookDeliveryListResult](), proto: webhookDeliveryListResultProto})
+	register(Schema{Type: TypeWebhookRedeliver, Version: 1, Queue: "notification_requests", decode: decoder[WebhookRedeliver](), proto: webhookRedeliverProto})
+	register(Schema{Type: TypeWebhookDeliveryDetails, Version: 1, decode: decoder[WebhookDeliveryDetails](), proto: webhookDeliveryDetailsProto})
+	register(Schema{Type: TypeWebhookDeliver, Version: 1, Queue: "webhooks", decode: decoder[WebhookDeliver](), proto: webhookDeliverProto})
+	register(Schema{Type: TypeInboxList, Version: 1, Queue: "notification_requests", decode: decoder[InboxList](), proto: inboxListProto})
+	register(Schema{Type: TypeInboxListResult, Version: 1, decode: decoder[InboxListResult](), proto: inboxListResultProto})
+	register(Schema{Type: TypeInboxCount, Version: 1, Queue: "notification_requests", decode: decoder[InboxCount](), proto: inboxCountProto})
+	register(Schema{Type: TypeInboxCountResult, Version: 1, decode: decoder[InboxCountResult](), proto: inboxCountResultProto})
+	register(Schema{Type: TypeInboxUpdate, Version: 1, Queue: "notification_requests", decode: decoder[InboxUpdate](), proto: inboxUpdateProto})
+	register(Schema{Type: TypeInboxMarkAllRead, Version: 1, Queue: "notification_requests", decode: decoder[InboxMarkAllRead](), proto: inboxMarkAllReadProto})
+	register(Schema{Type: TypeInboxItemDetails, Version: 1, decode: decoder[InboxItemDetails](), proto: inboxItemDetailsProto})
+	register(Schema{Type: TypeInboxItemAdded, Version: 1, Queue: InboxExchange, decode: decoder[InboxItemAdded](), proto: inboxItemAddedProto})
+	register(Schema{Type: TypeHealthCheck, Version: 1, Queue: "health_check_exchange", decode: decoder[HealthCheck](), proto: healthCheckProto})
+	register(Schema{Type: TypeHealthStatus, Version: 1, decode: decoder[HealthStatus](), proto: healthStatusProto})
+}
+
+// StockCheck asks the inventory service whether Quantity units of
+// ProductID are in stock. With Reserve set, the units are also taken out of
+// stock when available, for OrderID if set. The reply is a
+// StockCheckResult.
+type StockCheck struct {
+	ProductID int  `json:"product_id"`
+	Quantity  int  `json:"quantity"`
+	Reserve   bool `json:"reserve,omitempty"`
+	OrderID   int  `json:"order_id,omitempty"`
+}
+
+func (StockCheck) MessageType() string { return TypeStockCheck }
+
+// StockCheckResult answers a StockCheck.
+type StockCheckResult struct {
+	ProductID   int  `json:"product_id"`
+	IsAvailable bool `json:"is_available"`
+}
+
+func (StockCheckResult) MessageType() string { return TypeStockCheckResult }
+
+// StockRelease returns Quantity previously reserved units of ProductID to
+// stock, for orders that could not be placed after all or were cancelled.
+// OrderID is the order they were reserved for, if known, and Reason says
+// why they are returned.
+type StockRelease struct {
+	ProductID int    `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	OrderID   int    `json:"order_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func (StockRelease) MessageType() string { return TypeStockRelease }
+
+// StockAdjust changes a product's stock by Quantity units, positive to
+// add stock and negative to remove it, and records the change in the stock
+// ledger as a movement of Type: MovementReceipt, MovementSale,
+// MovementReturn, MovementTransfer or, if empty, MovementAdjustment, such
+// as after a stock count. Reason says why, Actor who made the change, by
+// default the producer of the message, and Reference what it belongs to,
+// such as a purchase order. The reply is the changed product's
+// ProductDetails or an InventoryError.
+type StockAdjust struct {
+	ProductID int    `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	Type      string `json:"type,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Actor     string `json:"actor,omitempty"`
+	Reference string `json:"reference,omitempty"`
+}
+
+func (StockAdjust) MessageType() string { return TypeStockAdjust }
+
+// Stock movement types, one for each way stock changes.
+const (
+	MovementReceipt     = "receipt"     // goods received, such as for a purchase order
+	MovementReservation = "reservation" // units taken out of stock for an order
+	MovementRelease     = "release"     // reserved units put back
+	MovementSale        = "sale"        // units sold outside of orders
+	MovementAdjustment  = "adjustment"  // a correction, such as after a stock count
+	MovementReturn      = "return"      // sold units returned
+	MovementTransfer    = "transfer"    // units moved to or from another location
+)
+
+// MovementList asks the inventory service for the latest stock movements
+// of a product. The reply is a MovementListResult or an InventoryError.
+type MovementList struct {
+	ProductID int `json:"product_id"`
+}
+
+func (MovementList) MessageType() string { return TypeMovementList }
+
+// StockMovement is one entry in a product's stock ledger: a change of
+// Quantity units, negative when units were taken out, that left Balance
+// units in stock. Reference is what the movement belongs to, such as
+// "order:42" or a purchase order ID.
+type StockMovement struct {
+	MovementID int64     `json:"movement_id"`
+	ProductID  int       `json:"product_id"`
+	Type       string    `json:"type"`
+	Quantity   int       `json:"quantity"`
+	Balance    int       `json:"balance"`
+	Reason     string    `json:"reason,omitempty"`
+	Actor      string    `json:"actor,omitempty"`
+	Reference  string    `json:"reference,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// MovementListResult answers a MovementList with the product's stock and
+// its latest movements, newest first.
+type MovementListResult struct {
+	ProductID int             `json:"product_id"`
+	Stock     int             `json:"stock"`
+	Movements []StockMovement `json:"movements"`
+}
+
+func (MovementListResult) MessageType() string { return TypeMovementListResult }
+
+// ProductGet asks the inventory service for a product's stock and reorder
+// policy. The reply is its ProductDetails or an InventoryError.
+type ProductGet struct {
+	ProductID int `json:"product_id"`
+}
+
+func (ProductGet) MessageType() string { return TypeProductGet }
+
+// ReorderPolicySet sets when a product's stock is low: below its
+// ReorderThreshold, or the inventory service's default threshold if that
+// is 0, or below its SafetyStock, the units kept against surges in demand.
+// The reply is the product's ProductDetails or an InventoryError.
+type ReorderPolicySet struct {
+	ProductID        int `json:"product_id"`
+	ReorderThreshold int `json:"reorder_threshold"`
+	SafetyStock      int `json:"safety_stock"`
+}
+
+func (ReorderPolicySet) MessageType() string { return TypeReorderPolicySet }
+
+// ProductDetails is a product's stock and reorder policy. A
+// ReorderThreshold of 0 means the inventory service's default applies.
+type ProductDetails struct {
+	ProductID        int    `json:"product_id"`
+	Name             string `json:"name"`
+	Stock            int    `json:"stock"`
+	ReorderThreshold int    `json:"reorder_threshold"`
+	SafetyStock      int    `json:"safety_stock"`
+}
+
+func (ProductDetails) MessageType() string { return TypeProductDetails }
+
+// ReorderReport asks the inventory service which products to reorder and
+// how many units of each: enough to last CoverDays days at the rate they
+// were consumed in the last WindowDays days, plus their safety stock. The
+// reply is a ReorderReportResult or an InventoryError.
+type ReorderReport struct {
+	WindowDays int `json:"window_days"`
+	CoverDays  int `json:"cover_days"`
+}
+
+func (ReorderReport) MessageType() string { return TypeReorderReport }
+
+// ReorderSuggestion suggests ordering Quantity units of a product.
+// Consumed is how many units were consumed in the report's window, and
+// DailyVelocity how many per day on average. ReorderThreshold is the
+// threshold that applies to the product.
+type ReorderSuggestion struct {
+	ProductID        int     `json:"product_id"`
+	Name             string  `json:"name"`
+	Stock            int     `json:"stock"`
+	ReorderThreshold int     `json:"reorder_threshold"`
+	SafetyStock      int     `json:"safety_stock"`
+	Consumed         int     `json:"consumed"`
+	DailyVelocity    float64 `json:"daily_velocity"`
+	Quantity         int     `json:"quantity"`
+}
+
+// ReorderReportResult answers a ReorderReport with a suggestion for every
+// product that needs reordering, ordered by product ID.
+type ReorderReportResult struct {
+	WindowDays  int                 `json:"window_days"`
+	CoverDays   int                 `json:"cover_days"`
+	Suggestions []ReorderSuggestion `json:"suggestions"`
+}
+
+func (ReorderReportResult) MessageType() string { return TypeReorderReportResult }
+
+// InventoryError codes.
+const (
+	InventoryErrNotFound          = "not_found"
+	InventoryErrInvalid           = "invalid"            // the request is malformed
+	InventoryErrInsufficientStock = "insufficient_stock" // a movement would take stock below zero
+	InventoryErrUnavailable       = "unavailable"
+)
+
+// InventoryError answers an inventory request that failed. Code is one of
+// the InventoryErr constants.
+type InventoryError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (InventoryError) MessageType() string { return TypeInventoryError }
+
+func (e InventoryError) Error() string { return e.Message }
+
+// OrderPlaced is an order whose stock has been reserved, for the order
+// service to store.
+type OrderPlaced struct {
+	OrderID   int `json:"order_id"`
+	ProductID int `json:"product_id"`
+	UserID    int `json:"user_id"`
+	Quantity  int `json:"quantity"`
+}
+
+func (OrderPlaced) MessageType() string { return TypeOrderPlaced }
+
+// OrderGet asks the order service for one order. The reply is its
+// OrderDetails or an OrderError.
+type OrderGet struct {
+	OrderID int `json:"order_id"`
+}
+
+func (OrderGet) MessageType() string { return TypeOrderGet }
+
+// OrderList asks the order service for a user's orders. The reply is an
+// OrderListResult or an OrderError.
+type OrderList struct {
+	UserID int `json:"user_id"`
+}
+
+func (OrderList) MessageType() string { return TypeOrderList }
+
+// OrderCancel asks the order service to cancel an order and return its
+// stock. The reply is the cancelled order's OrderDetails or an OrderError.
+type OrderCancel struct {
+	OrderID int `json:"order_id"`
+}
+
+func (OrderCancel) MessageType() string { return TypeOrderCancel }
+
+// Order statuses.
+const (
+	OrderStatusPlaced    = "placed"
+	OrderStatusCancelled = "cancelled"
+)
+
+// OrderDetails is a stored order.
+type OrderDetails struct {
+	OrderID   int    `json:"order_id"`
+	ProductID int    `json:"product_id"`
+	UserID    int    `json:"user_id"`
+	Quantity  int    `json:"quantity"`
+	Status    string `json:"status"`
+}
+
+func (OrderDetails) MessageType() string { return TypeOrderDetails }
+
+// OrderListResult answers an OrderList with the user's orders ordered by
+// ID.
+type OrderListResult struct {
+	Orders []OrderDetails `json:"orders"`
+}
+
+func (OrderListResult) MessageType() string { return TypeOrderListResult }
+
+// OrderError codes.
+const (
+	OrderErrNotFound         = "not_found"
+	OrderErrAlreadyCancelled = "already_cancelled"
+	OrderErrUnavailable      = "unavailable"
+)
+
+// OrderError answers an order request that failed. Code is one of the
+// OrderErr constants.
+type OrderError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (OrderError) MessageType() string { return TypeOrderError }
+
+func (e OrderError) Error() string { return e.Message }
+
+// NotificationRequested asks the notification service to tell a user
+// something. Producers name a Template, such as "order_placed", and pass
+// the values it refers to in Data; the notification service renders it in
+// Locale, the user's locale or its default locale, in that order of
+// preference. Message is the text of a notification without a template,
+// and the fallback if its template cannot be rendered. Channel optionally
+// names the channel to deliver on. Category is one of the Category
+// constants and decides which of the user's preferences apply; empty means
+// CategoryTransactional.
+type NotificationRequested struct {
+	UserID   int               `json:"user_id"`
+	Message  string            `json:"message"`
+	Channel  string            `json:"channel,omitempty"`
+	Template string            `json:"template,omitempty"`
+	Locale   string            `json:"locale,omitempty"`
+	Data     map[string]string `json:"data,omitempty"`
+	Category string            `json:"category,omitempty"`
+	Priority string            `json:"priority,omitempty"`
+}
+
+// Notification categories. Transactional notifications, such as order
+// updates, are sent unless the user opted out of a channel; marketing
+// notifications only on channels the user opted in to, and never after the
+// user unsubscribed.
+const (
+	CategoryTransactional = "transactional"
+	CategoryMarketing     = "marketing"
+)
+
+// Notification priorities. Critical notifications, such as payment
+// failures and cancellations, go out at once. Normal ones, the default, go
+// out at once unless the user already got too many of the same category
+// lately, in which case they wait for the next digest, as low-priority ones
+// always do.
+const (
+	PriorityCritical = "critical"
+	PriorityNormal   = "normal"
+	PriorityLow      = "low"
+)
+
+func (NotificationRequested) MessageType() string { return TypeNotificationRequested }
+
+// NotificationRetry asks the notification service to try delivering a
+// stored notification again. The notification service schedules it for
+// itself after a delivery attempt fails with an error that may go away;
+// Attempt is the number of the delivery round it starts, counting the
+// first as 1, and Channels the channels still worth trying, in order. No
+// channels means every channel the notification would be routed to.
+// Notifications that failed for good are dead-lettered as a
+// NotificationRetry, so they can be moved back to the notifications queue
+// to try once more.
+type NotificationRetry struct {
+	NotificationID int      `json:"notification_id"`
+	Attempt        int      `json:"attempt"`
+	Channels       []string `json:"channels,omitempty"`
+}
+
+func (NotificationRetry) MessageType() string { return TypeNotificationRetry }
+
+// NotificationDigest asks the notification service to send a user the
+// notifications of a category it held back for a digest, as one
+// notification. The notification service schedules it for itself when it
+// holds one back; a digest with nothing left to send is dropped.
+type NotificationDigest struct {
+	UserID   int    `json:"user_id"`
+	Category string `json:"category"`
+}
+
+func (NotificationDigest) MessageType() string { return TypeNotificationDigest }
+
+// NotificationList asks the notification service for a user's
+// notifications. The reply is a NotificationListResult or a
+// NotificationError.
+type NotificationList struct {
+	UserID int `json:"user_id"`
+}
+
+func (NotificationList) MessageType() string { return TypeNotificationList }
+
+// Notification delivery statuses.
+const (
+	NotificationStatusQueued    = "queued"
+	NotificationStatusSending   = "sending"
+	NotificationStatusDelivered = "delivered"
+	NotificationStatusFailed    = "failed"
+	// NotificationStatusSuppressed is a notification the user's
+	// preferences kept from every channel it could go to.
+	NotificationStatusSuppressed = "suppressed"
+	// NotificationStatusDigested is a notification held back to be sent
+	// in a digest, a notification of its own.
+	NotificationStatusDigested = "digested"
+)
+
+// NotificationDetails is a stored notification. Attempts counts the
+// delivery attempts made; DeliveredAt is set once one succeeded. DigestID
+// is the digest a digested notification was sent in, once it was.
+type NotificationDetails struct {
+	NotificationID int        `json:"notification_id"`
+	UserID         int        `json:"user_id"`
+	Channel        string     `json:"channel"`
+	Template       string     `json:"template"`
+	Category       string     `json:"category"`
+	Content        string     `json:"content"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	DigestID       int        `json:"digest_id,omitempty"`
+}
+
+// NotificationListResult answers a NotificationList with the user's
+// notifications, oldest first.
+type NotificationListResult struct {
+	Notifications []NotificationDetails `json:"notifications"`
+}
+
+func (NotificationListResult) MessageType() string { return TypeNotificationListResult }
+
+// NotificationError codes.
+const (
+	NotificationErrUnavailable  = "unavailable"
+	NotificationErrInvalid      = "invalid"       // the request is malformed
+	NotificationErrInvalidToken = "invalid_token" // an unsubscribe token is forged or malformed
+	NotificationErrNotFound     = "not_found"     // the webhook, webhook delivery or inbox item does not exist
+)
+
+// NotificationError answers a notification request that failed. Code is
+// one of the NotificationErr constants.
+type NotificationError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (NotificationError) MessageType() string { return TypeNotificationError }
+
+func (e NotificationError) Error() string { return e.Message }
+
+// ContactGet asks the notification service how a user can be reached. The
+// reply is their ContactDetails or a NotificationError.
+type ContactGet struct {
+	UserID int `json:"user_id"`
+}
+
+func (ContactGet) MessageType() string { return TypeContactGet }
+
+// ContactSet replaces how a user can be reached. The reply is the stored
+// ContactDetails or a NotificationError.
+type ContactSet ContactDetails
+
+func (ContactSet) MessageType() string { return TypeContactSet }
+
+// ContactDetails is how a user can be reached: an email address, a phone
+// number in E.164 form and a web push subscription. Empty fields are
+// channels the user cannot be reached on. Locale is the language tag the
+// user's notifications are written in, such as "de" or "pt-BR"; empty
+// means the notification service's default.
+type ContactDetails struct {
+	UserID int               `json:"user_id"`
+	Email  string            `json:"email,omitempty"`
+	Phone  string            `json:"phone,omitempty"`
+	Push   *PushSubscription `json:"push,omitempty"`
+	Locale string            `json:"locale,omitempty"`
+}
+
+func (ContactDetails) MessageType() string { return TypeContactDetails }
+
+// PushSubscription is a browser's web push subscription, as returned by
+// PushSubscription.toJSON().
+type PushSubscription struct {
+	Endpoint string   `json:"endpoint"`
+	Keys     PushKeys `json:"keys"`
+}
+
+// PushKeys are a push subscription's keys, base64url encoded: the user
+// agent's P-256 public key and its authentication secret.
+type PushKeys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// PreferencesGet asks the notification service for a user's notification
+// preferences. The reply is their PreferencesDetails or a
+// NotificationError.
+type PreferencesGet struct {
+	UserID int `json:"user_id"`
+}
+
+func (PreferencesGet) MessageType() string { return TypePreferencesGet }
+
+// PreferencesSet replaces a user's notification preferences. The reply is
+// the stored PreferencesDetails, or a NotificationError with code
+// NotificationErrInvalid for preferences naming an unknown category,
+// channel or time zone.
+type PreferencesSet PreferencesDetails
+
+func (PreferencesSet) MessageType() string { return TypePreferencesSet }
+
+// PreferencesDetails are a user's notification preferences. Categories
+// maps each category to the channels the user opted in to (true) or out of
+// (false); in a reply every category and user-facing channel is present,
+// defaults included. During QuietHours no SMS or push notifications are
+// sent. Unsubscribed stops all marketing notifications.
+type PreferencesDetails struct {
+	UserID       int                        `json:"user_id"`
+	Categories   map[string]map[string]bool `json:"categories"`
+	QuietHours   *QuietHours                `json:"quiet_hours,omitempty"`
+	Unsubscribed bool                       `json:"unsubscribed"`
+}
+
+func (PreferencesDetails) MessageType() string { return TypePreferencesDetails }
+
+// QuietHours is a daily period, from Start to End in TimeZone, such as
+// 22:00 to 07:00 in Europe/Berlin. Times are given as HH:MM; a period
+// ending before it starts ends on the next day.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	TimeZone string `json:"time_zone"`
+}
+
+// Unsubscribe unsubscribes the user a signed unsubscribe token was issued
+// for from marketing notifications. The reply is the user's
+// PreferencesDetails, or a NotificationError with code
+// NotificationErrInvalidToken.
+type Unsubscribe struct {
+	Token string `json:"token"`
+}
+
+func (Unsubscribe) MessageType() string { return TypeUnsubscribe }
+
+// EventsExchange is the topic exchange services announce what happened on,
+// such as an order being placed. Each event is published with its event
+// name as routing key.
+const EventsExchange = "events"
+
+// Event names.
+const (
+	EventOrderPlaced    = "order.placed"
+	EventOrderCancelled = "order.cancelled"
+	EventStockLow       = "stock.low"
+)
+
+// EventNames lists every event name.
+var EventNames = []string{EventOrderPlaced, EventOrderCancelled, EventStockLow}
+
+// Event is a payload published to EventsExchange.
+type Event interface {
+	Payload
+	// EventName is the event's name, one of EventNames, and its routing
+	// key.
+	EventName() string
+}
+
+// OrderStatusChanged announces that an order was placed or cancelled; its
+// Status is the order's new status.
+type OrderStatusChanged OrderDetails
+
+func (OrderStatusChanged) MessageType() string { return TypeOrderStatusChanged }
+
+func (e OrderStatusChanged) EventName() string { return "order." + e.Status }
+
+// StockLow announces that a reservation or adjustment took a product's
+// stock below its reorder threshold or its safety stock.
+type StockLow struct {
+	ProductID   int `json:"product_id"`
+	Stock       int `json:"stock"`
+	Threshold   int `json:"threshold"`
+	SafetyStock int `json:"safety_stock"`
+}
+
+func (StockLow) MessageType() string { return TypeStockLow }
+
+func (StockLow) EventName() string { return EventStockLow }
+
+// WebhookSet creates a webhook if WebhookID is 0 and replaces one
+// otherwise. A webhook receives the events it names, signed with Secret,
+// at URL; an empty Secret is generated for a new webhook and left alone
+// for an existing one. Setting Active re-enables a webhook that was
+// disabled after failing too often. The reply is the stored
+// WebhookDetails, or a NotificationError with code NotificationErrInvalid
+// for a URL that is not http or https or an unknown event, or
+// NotificationErrNotFound.
+type WebhookSet struct {
+	WebhookID int      `json:"webhook_id,omitempty"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Secret    string   `json:"secret,omitempty"`
+	Active    bool     `json:"active"`
+}
+
+func (WebhookSet) MessageType() string { return TypeWebhookSet }
+
+// WebhookList asks the notification service for every webhook. The reply
+// is a WebhookListResult or a NotificationError.
+type WebhookList struct{}
+
+func (WebhookList) MessageType() string { return TypeWebhookList }
+
+// WebhookDelete deletes a webhook and its delivery log. The reply is the
+// deleted WebhookDetails or a NotificationError.
+type WebhookDelete struct {
+	WebhookID int `json:"webhook_id"`
+}
+
+func (WebhookDelete) MessageType() string { return TypeWebhookDelete }
+
+// WebhookDetails is a stored webhook. Secret is only sent back when it was
+// generated or changed. Failures counts the deliveries that failed in a
+// row; a webhook that fails too often is no longer Active.
+type WebhookDetails struct {
+	WebhookID int       `json:"webhook_id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	Failures  int       `json:"failures"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (WebhookDetails) MessageType() string { return TypeWebhookDetails }
+
+// WebhookListResult answers a WebhookList with every webhook ordered by ID.
+type WebhookListResult struct {
+	Webhooks []WebhookDetails `json:"webhooks"`
+}
+
+func (WebhookListResult) MessageType() string { return TypeWebhookListResult }
+
+// WebhookDeliveryList asks the notification service for a webhook's
+// delivery log. The reply is a WebhookDeliveryListResult or a
+// NotificationError.
+type WebhookDeliveryList struct {
+	WebhookID int `json:"webhook_id"`
+}
+
+func (WebhookDeliveryList) MessageType() string { return TypeWebhookDeliveryList }
+
+// Webhook delivery statuses.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDeliveryDetails is one event sent, or to be sent, to a webhook.
+// EventID identifies the event, and is the same for every webhook it goes
+// to. ResponseStatus and Error describe the last attempt.
+type WebhookDeliveryDetails struct {
+	DeliveryID     int        `json:"delivery_id"`
+	WebhookID      int        `json:"webhook_id"`
+	EventID        string     `json:"event_id"`
+	Event          string     `json:"event"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+func (WebhookDeliveryDetails) MessageType() string { return TypeWebhookDeliveryDetails }
+
+// WebhookDeliveryListResult answers a WebhookDeliveryList with the
+// webhook's latest deliveries, newest first.
+type WebhookDeliveryListResult struct {
+	Deliveries []WebhookDeliveryDetails `json:"deliveries"`
+}
+
+func (WebhookDeliveryListResult) MessageType() string { return TypeWebhookDeliveryListResult }
+
+// WebhookRedeliver sends a logged delivery to its webhook again. The reply
+// is the pending WebhookDeliveryDetails, or a NotificationError with code
+// NotificationErrNotFound, or NotificationErrInvalid if the webhook is
+// disabled.
+type WebhookRedeliver struct {
+	WebhookID  int `json:"webhook_id"`
+	DeliveryID int `json:"delivery_id"`
+}
+
+func (WebhookRedeliver) MessageType() string { return TypeWebhookRedeliver }
+
+// WebhookDeliver asks the notification service to send a logged delivery
+// to its webhook. Attempt counts the attempts of this run of the delivery
+// from 1; the notification service schedules the later ones for itself
+// when an attempt fails.
+type WebhookDeliver struct {
+	DeliveryID int `json:"delivery_id"`
+	Attempt    int `json:"attempt"`
+}
+
+func (WebhookDeliver) MessageType() string { return TypeWebhookDeliver }
+
+// InboxList asks the notification service for the items in a user's
+// in-app inbox: those not archived or, if Archived is set, the archived
+// ones. The reply is an InboxListResult or a NotificationError.
+type InboxList struct {
+	UserID   int  `json:"user_id"`
+	Archived bool `json:"archived,omitempty"`
+}
+
+func (InboxList) MessageType() string { return TypeInboxList }
+
+// InboxListResult answers an InboxList with the items asked for, newest
+// first, and how many items in the inbox are unread.
+type InboxListResult struct {
+	Items  []InboxItemDetails `json:"items"`
+	Unread int                `json:"unread"`
+}
+
+func (InboxListResult) MessageType() string { return TypeInboxListResult }
+
+// InboxCount asks the notification service how many unread items are in a
+// user's inbox. The reply is an InboxCountResult or a NotificationError.
+type InboxCount struct {
+	UserID int `json:"user_id"`
+}
+
+func (InboxCount) MessageType() string { return TypeInboxCount }
+
+// InboxCountResult is how many items in a user's inbox are unread.
+// Archived items are not counted.
+type InboxCountResult struct {
+	UserID int `json:"user_id"`
+	Unread int `json:"unread"`
+}
+
+func (InboxCountResult) MessageType() string { return TypeInboxCountResult }
+
+// Inbox actions.
+const (
+	InboxActionRead      = "read"
+	InboxActionUnread    = "unread"
+	InboxActionArchive   = "archive"
+	InboxActionUnarchive = "unarchive"
+)
+
+// InboxUpdate applies Action, one of the InboxAction constants, to an item
+// in a user's inbox. The reply is the updated InboxItemDetails, or a
+// NotificationError with code NotificationErrNotFound if the user has no
+// such item, or NotificationErrInvalid for an unknown action.
+type InboxUpdate struct {
+	UserID int    `json:"user_id"`
+	ItemID int    `json:"item_id"`
+	Action string `json:"action"`
+}
+
+func (InboxUpdate) MessageType() string { return TypeInboxUpdate }
+
+// InboxMarkAllRead marks every item in a user's inbox read. The reply is
+// the user's InboxCountResult or a NotificationError.
+type InboxMarkAllRead struct {
+	UserID int `json:"user_id"`
+}
+
+func (InboxMarkAllRead) MessageType() string { return TypeInboxMarkAllRead }
+
+// InboxItemDetails is a notification in a user's in-app inbox: its
+// subject and text, and whether the user read or archived it.
+type InboxItemDetails struct {
+	ItemID         int        `json:"item_id"`
+	UserID         int        `json:"user_id"`
+	NotificationID int        `json:"notification_id"`
+	Category       string     `json:"category"`
+	Subject        string     `json:"subject,omitempty"`
+	Content        string     `json:"content"`
+	Read           bool       `json:"read"`
+	Archived       bool       `json:"archived"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReadAt         *time.Time `json:"read_at,omitempty"`
+}
+
+func (InboxItemDetails) MessageType() string { return TypeInboxItemDetails }
+
+// InboxExchange is the fanout exchange the notification service announces
+// new inbox items on, so that every gateway instance can pass them on to
+// the users connected to it.
+const InboxExchange = "inbox"
+
+// InboxItemAdded announces an item put in a user's inbox.
+type InboxItemAdded InboxItemDetails
+
+func (InboxItemAdded) MessageType() string { return TypeInboxItemAdded }
+
+// HealthCheck asks every service to reply with its HealthStatus. It is
+// published to the health_check_exchange fanout.
+type HealthCheck struct{}
+
+func (HealthCheck) MessageType() string { return TypeHealthCheck }
+
+// HealthStatus answers a HealthCheck.
+type HealthStatus struct {
+	Service       string `json:"service"`
+	Status        string `json:"status"`
+	Database      string `json:"database"`
+	SchemaVersion int    `json:"schema_version"`
+	Error         string `json:"error,omitempty"`
+}
+
+func (HealthStatus) MessageType() string { return TypeHealthStatus }
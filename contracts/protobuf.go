@@ -0,0 +1,665 @@
+package contracts
+
+//go:generate protoc -I pb --go_out=pb --go_opt=paths=source_relative messages.proto
+
+import (
+	"fmt"
+
+	"ecomm-sample/contracts/pb"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// protoCodec converts a payload type to and from its protobuf encoding.
+type protoCodec struct {
+	marshal   func(Payload) ([]byte, error)
+	unmarshal func([]byte) (Payload, error)
+}
+
+// protoMessage returns the codec for payloads of type T, whose protobuf
+// message is M.
+func protoMessage[T Payload, M proto.Message](to func(T) M, from func(M) T) protoCodec {
+	return protoCodec{
+		marshal: func(p Payload) ([]byte, error) {
+			return proto.Marshal(to(p.(T)))
+		},
+		unmarshal: func(b []byte) (Payload, error) {
+			var m M
+			m = m.ProtoReflect().Type().New().Interface().(M)
+			if err := proto.Unmarshal(b, m); err != nil {
+				return nil, err
+			}
+			return from(m), nil
+		},
+	}
+}
+
+func marshalProto(env Envelope) ([]byte, error) {
+	schema, ok := schemas[env.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownType, env.Type)
+	}
+	payload, err := schema.proto.marshal(env.payload)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(&pb.Envelope{
+		Type:      env.Type,
+		Version:   int32(env.Version),
+		MessageId: env.MessageID,
+		Producer:  env.Producer,
+		CreatedAt: timestamppb.New(env.CreatedAt),
+		SentAt:    timestamppb.New(env.SentAt),
+		Payload:   payload,
+	})
+}
+
+func unmarshalProto(body []byte) (Envelope, Payload, error) {
+	var wire pb.Envelope
+	if err := proto.Unmarshal(body, &wire); err != nil {
+		return Envelope{}, nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	env := Envelope{
+		Type:      wire.Type,
+		Version:   int(wire.Version),
+		MessageID: wire.MessageId,
+		Producer:  wire.Producer,
+		CreatedAt: wire.CreatedAt.AsTime(),
+		SentAt:    wire.SentAt.AsTime(),
+	}
+	schema, err := env.schema()
+	if err != nil {
+		return env, nil, err
+	}
+	p, err := schema.proto.unmarshal(wire.Payload)
+	if err != nil {
+		return env, nil, fmt.Errorf("%w: %s payload: %v", ErrMalformed, env.Type, err)
+	}
+	env.payload = p
+	return env, p, nil
+}
+
+var (
+	stockCheckProto = protoMessage(
+		func(p StockCheck) *pb.StockCheck {
+			return &pb.StockCheck{ProductId: int64(p.ProductID), Quantity: int64(p.Quantity), Reserve: p.Reserve, OrderId: int64(p.OrderID)}
+		},
+		func(m *pb.StockCheck) StockCheck {
+			return StockCheck{ProductID: int(m.ProductId), Quantity: int(m.Quantity), Reserve: m.Reserve, OrderID: int(m.OrderId)}
+		})
+	stockCheckResultProto = protoMessage(
+		func(p StockCheckResult) *pb.StockCheckResult {
+			return &pb.StockCheckResult{ProductId: int64(p.ProductID), IsAvailable: p.IsAvailable}
+		},
+		func(m *pb.StockCheckResult) StockCheckResult {
+			return StockCheckResult{ProductID: int(m.ProductId), IsAvailable: m.IsAvailable}
+		})
+	stockReleaseProto = protoMessage(
+		func(p StockRelease) *pb.StockRelease {
+			return &pb.StockRelease{ProductId: int64(p.ProductID), Quantity: int64(p.Quantity), OrderId: int64(p.OrderID), Reason: p.Reason}
+		},
+		func(m *pb.StockRelease) StockRelease {
+			return StockRelease{ProductID: int(m.ProductId), Quantity: int(m.Quantity), OrderID: int(m.OrderId), Reason: m.Reason}
+		})
+	stockAdjustProto = protoMessage(
+		func(p StockAdjust) *pb.StockAdjust {
+			return &pb.StockAdjust{ProductId: int64(p.ProductID), Quantity: int64(p.Quantity), Type: p.Type, Reason: p.Reason, Actor: p.Actor, Reference: p.Reference}
+		},
+		func(m *pb.StockAdjust) StockAdjust {
+			return StockAdjust{ProductID: int(m.ProductId), Quantity: int(m.Quantity), Type: m.Type, Reason: m.Reason, Actor: m.Actor, Reference: m.Reference}
+		})
+	movementListProto = protoMessage(
+		func(p MovementList) *pb.MovementList { return &pb.MovementList{ProductId: int64(p.ProductID)} },
+		func(m *pb.MovementList) MovementList { return MovementList{ProductID: int(m.ProductId)} })
+	movementListResultProto = protoMessage(
+		func(p MovementListResult) *pb.MovementListResult {
+			m := &pb.MovementListResult{ProductId: int64(p.ProductID), Stock: int64(p.Stock), Movements: make([]*pb.StockMovement, len(p.Movements))}
+			for i, mv := range p.Movements {
+				m.Movements[i] = &pb.StockMovement{
+					MovementId: mv.MovementID,
+					ProductId:  int64(mv.ProductID),
+					Type:       mv.Type,
+					Quantity:   int64(mv.Quantity),
+					Balance:    int64(mv.Balance),
+					Reason:     mv.Reason,
+					Actor:      mv.Actor,
+					Reference:  mv.Reference,
+					CreatedAt:  timestamppb.New(mv.CreatedAt),
+				}
+			}
+			return m
+		},
+		func(m *pb.MovementListResult) MovementListResult {
+			p := MovementListResult{ProductID: int(m.ProductId), Stock: int(m.Stock), Movements: make([]StockMovement, len(m.Movements))}
+			for i, mv := range m.Movements {
+				p.Movements[i] = StockMovement{
+					MovementID: mv.MovementId,
+					ProductID:  int(mv.ProductId),
+					Type:       mv.Type,
+					Quantity:   int(mv.Quantity),
+					Balance:    int(mv.Balance),
+					Reason:     mv.Reason,
+					Actor:      mv.Actor,
+					Reference:  mv.Reference,
+					CreatedAt:  mv.CreatedAt.AsTime(),
+				}
+			}
+			return p
+		})
+	productGetProto = protoMessage(
+		func(p ProductGet) *pb.ProductGet { return &pb.ProductGet{ProductId: int64(p.ProductID)} },
+		func(m *pb.ProductGet) ProductGet { return ProductGet{ProductID: int(m.ProductId)} })
+	productDetailsProto = protoMessage(
+		func(p ProductDetails) *pb.ProductDetails {
+			return &pb.ProductDetails{ProductId: int64(p.ProductID), Name: p.Name, Stock: int64(p.Stock), ReorderThreshold: int64(p.ReorderThreshold), SafetyStock: int64(p.SafetyStock)}
+		},
+		func(m *pb.ProductDetails) ProductDetails {
+			return ProductDetails{ProductID: int(m.ProductId), Name: m.Name, Stock: int(m.Stock), ReorderThreshold: int(m.ReorderThreshold), SafetyStock: int(m.SafetyStock)}
+		})
+	reorderPolicySetProto = protoMessage(
+		func(p ReorderPolicySet) *pb.ReorderPolicySet {
+			return &pb.ReorderPolicySet{ProductId: int64(p.ProductID), ReorderThreshold: int64(p.ReorderThreshold), SafetyStock: int64(p.SafetyStock)}
+		},
+		func(m *pb.ReorderPolicySet) ReorderPolicySet {
+			return ReorderPolicySet{ProductID: int(m.ProductId), ReorderThreshold: int(m.ReorderThreshold), SafetyStock: int(m.SafetyStock)}
+		})
+	reorderReportProto = protoMessage(
+		func(p ReorderReport) *pb.ReorderReport {
+			return &pb.ReorderReport{WindowDays: int64(p.WindowDays), CoverDays: int64(p.CoverDays)}
+		},
+		func(m *pb.ReorderReport) ReorderReport {
+			return ReorderReport{WindowDays: int(m.WindowDays), CoverDays: int(m.CoverDays)}
+		})
+	reorderReportResultProto = protoMessage(
+		func(p ReorderReportResult) *pb.ReorderReportResult {
+			m := &pb.ReorderReportResult{WindowDays: int64(p.WindowDays), CoverDays: int64(p.CoverDays), Suggestions: make([]*pb.ReorderSuggestion, len(p.Suggestions))}
+			for i, s := range p.Suggestions {
+				m.Suggestions[i] = &pb.ReorderSuggestion{
+					ProductId:        int64(s.ProductID),
+					Name:             s.Name,
+					Stock:            int64(s.Stock),
+					ReorderThreshold: int64(s.ReorderThreshold),
+					SafetyStock:      int64(s.SafetyStock),
+					Consumed:         int64(s.Consumed),
+					DailyVelocity:    s.DailyVelocity,
+					Quantity:         int64(s.Quantity),
+				}
+			}
+			return m
+		},
+		func(m *pb.ReorderReportResult) ReorderReportResult {
+			p := ReorderReportResult{WindowDays: int(m.WindowDays), CoverDays: int(m.CoverDays), Suggestions: make([]ReorderSuggestion, len(m.Suggestions))}
+			for i, s := range m.Suggestions {
+				p.Suggestions[i] = ReorderSuggestion{
+					ProductID:        int(s.ProductId),
+					Name:             s.Name,
+					Stock:            int(s.Stock),
+					ReorderThreshold: int(s.ReorderThreshold),
+					SafetyStock:      int(s.SafetyStock),
+					Consumed:         int(s.Consumed),
+					DailyVelocity:    s.DailyVelocity,
+					Quantity:         int(s.Quantity),
+				}
+			}
+			return p
+		})
+	inventoryErrorProto = protoMessage(
+		func(p InventoryError) *pb.InventoryError { return &pb.InventoryError{Code: p.Code, Message: p.Message} },
+		func(m *pb.InventoryError) InventoryError { return InventoryError{Code: m.Code, Message: m.Message} })
+	orderPlacedProto = protoMessage(
+		func(p OrderPlaced) *pb.OrderPlaced {
+			return &pb.OrderPlaced{
+				OrderId:   int64(p.OrderID),
+				ProductId: int64(p.ProductID),
+				UserId:    int64(p.UserID),
+				Quantity:  int64(p.Quantity),
+			}
+		},
+		func(m *pb.OrderPlaced) OrderPlaced {
+			return OrderPlaced{
+				OrderID:   int(m.OrderId),
+				ProductID: int(m.ProductId),
+				UserID:    int(m.UserId),
+				Quantity:  int(m.Quantity),
+			}
+		})
+	orderGetProto = protoMessage(
+		func(p OrderGet) *pb.OrderGet { return &pb.OrderGet{OrderId: int64(p.OrderID)} },
+		func(m *pb.OrderGet) OrderGet { return OrderGet{OrderID: int(m.OrderId)} })
+	orderListProto = protoMessage(
+		func(p OrderList) *pb.OrderList { return &pb.OrderList{UserId: int64(p.UserID)} },
+		func(m *pb.OrderList) OrderList { return OrderList{UserID: int(m.UserId)} })
+	orderCancelProto = protoMessage(
+		func(p OrderCancel) *pb.OrderCancel { return &pb.OrderCancel{OrderId: int64(p.OrderID)} },
+		func(m *pb.OrderCancel) OrderCancel { return OrderCancel{OrderID: int(m.OrderId)} })
+	orderDetailsProto    = protoMessage(orderDetailsToProto, orderDetailsFromProto)
+	orderListResultProto = protoMessage(
+		func(p OrderListResult) *pb.OrderListResult {
+			m := &pb.OrderListResult{Orders: make([]*pb.OrderDetails, len(p.Orders))}
+			for i, o := range p.Orders {
+				m.Orders[i] = orderDetailsToProto(o)
+			}
+			return m
+		},
+		func(m *pb.OrderListResult) OrderListResult {
+			p := OrderListResult{Orders: make([]OrderDetails, len(m.Orders))}
+			for i, o := range m.Orders {
+				p.Orders[i] = orderDetailsFromProto(o)
+			}
+			return p
+		})
+	orderErrorProto = protoMessage(
+		func(p OrderError) *pb.OrderError { return &pb.OrderError{Code: p.Code, Message: p.Message} },
+		func(m *pb.OrderError) OrderError { return OrderError{Code: m.Code, Message: m.Message} })
+	notificationRequestedProto = protoMessage(
+		func(p NotificationRequested) *pb.NotificationRequested {
+			return &pb.NotificationRequested{UserId: int64(p.UserID), Message: p.Message, Channel: p.Channel, Template: p.Template, Locale: p.Locale, Data: p.Data, Category: p.Category, Priority: p.Priority}
+		},
+		func(m *pb.NotificationRequested) NotificationRequested {
+			return NotificationRequested{UserID: int(m.UserId), Message: m.Message, Channel: m.Channel, Template: m.Template, Locale: m.Locale, Data: m.Data, Category: m.Category, Priority: m.Priority}
+		})
+	notificationRetryProto = protoMessage(
+		func(p NotificationRetry) *pb.NotificationRetry {
+			return &pb.NotificationRetry{NotificationId: int64(p.NotificationID), Attempt: int64(p.Attempt), Channels: p.Channels}
+		},
+		func(m *pb.NotificationRetry) NotificationRetry {
+			return NotificationRetry{NotificationID: int(m.NotificationId), Attempt: int(m.Attempt), Channels: m.Channels}
+		})
+	notificationDigestProto = protoMessage(
+		func(p NotificationDigest) *pb.NotificationDigest {
+			return &pb.NotificationDigest{UserId: int64(p.UserID), Category: p.Category}
+		},
+		func(m *pb.NotificationDigest) NotificationDigest {
+			return NotificationDigest{UserID: int(m.UserId), Category: m.Category}
+		})
+	notificationListProto = protoMessage(
+		func(p NotificationList) *pb.NotificationList { return &pb.NotificationList{UserId: int64(p.UserID)} },
+		func(m *pb.NotificationList) NotificationList { return NotificationList{UserID: int(m.UserId)} })
+	notificationListResultProto = protoMessage(
+		func(p NotificationListResult) *pb.NotificationListResult {
+			m := &pb.NotificationListResult{Notifications: make([]*pb.NotificationDetails, len(p.Notifications))}
+			for i, n := range p.Notifications {
+				m.Notifications[i] = notificationDetailsToProto(n)
+			}
+			return m
+		},
+		func(m *pb.NotificationListResult) NotificationListResult {
+			p := NotificationListResult{Notifications: make([]NotificationDetails, len(m.Notifications))}
+			for i, n := range m.Notifications {
+				p.Notifications[i] = notificationDetailsFromProto(n)
+			}
+			return p
+		})
+	notificationErrorProto = protoMessage(
+		func(p NotificationError) *pb.NotificationError {
+			return &pb.NotificationError{Code: p.Code, Message: p.Message}
+		},
+		func(m *pb.NotificationError) NotificationError {
+			return NotificationError{Code: m.Code, Message: m.Message}
+		})
+	contactGetProto = protoMessage(
+		func(p ContactGet) *pb.ContactGet { return &pb.ContactGet{UserId: int64(p.UserID)} },
+		func(m *pb.ContactGet) ContactGet { return ContactGet{UserID: int(m.UserId)} })
+	contactSetProto = protoMessage(
+		func(p ContactSet) *pb.ContactDetails { return contactDetailsToProto(ContactDetails(p)) },
+		func(m *pb.ContactDetails) ContactSet { return ContactSet(contactDetailsFromProto(m)) })
+	contactDetailsProto = protoMessage(contactDetailsToProto, contactDetailsFromProto)
+	preferencesGetProto = protoMessage(
+		func(p PreferencesGet) *pb.PreferencesGet { return &pb.PreferencesGet{UserId: int64(p.UserID)} },
+		func(m *pb.PreferencesGet) PreferencesGet { return PreferencesGet{UserID: int(m.UserId)} })
+	preferencesSetProto = protoMessage(
+		func(p PreferencesSet) *pb.PreferencesDetails { return preferencesDetailsToProto(PreferencesDetails(p)) },
+		func(m *pb.PreferencesDetails) PreferencesSet { return PreferencesSet(preferencesDetailsFromProto(m)) })
+	preferencesDetailsProto = protoMessage(preferencesDetailsToProto, preferencesDetailsFromProto)
+	unsubscribeProto        = protoMessage(
+		func(p Unsubscribe) *pb.Unsubscribe { return &pb.Unsubscribe{Token: p.Token} },
+		func(m *pb.Unsubscribe) Unsubscribe { return Unsubscribe{Token: m.Token} })
+	orderStatusChangedProto = protoMessage(
+		func(p OrderStatusChanged) *pb.OrderDetails { return orderDetailsToProto(OrderDetails(p)) },
+		func(m *pb.OrderDetails) OrderStatusChanged { return OrderStatusChanged(orderDetailsFromProto(m)) })
+	stockLowProto = protoMessage(
+		func(p StockLow) *pb.StockLow {
+			return &pb.StockLow{ProductId: int64(p.ProductID), Stock: int64(p.Stock), Threshold: int64(p.Threshold), SafetyStock: int64(p.SafetyStock)}
+		},
+		func(m *pb.StockLow) StockLow {
+			return StockLow{ProductID: int(m.ProductId), Stock: int(m.Stock), Threshold: int(m.Threshold), SafetyStock: int(m.SafetyStock)}
+		})
+	webhookSetProto = protoMessage(
+		func(p WebhookSet) *pb.WebhookSet {
+			return &pb.WebhookSet{WebhookId: int64(p.WebhookID), Url: p.URL, Events: p.Events, Secret: p.Secret, Active: p.Active}
+		},
+		func(m *pb.WebhookSet) WebhookSet {
+			return WebhookSet{WebhookID: int(m.WebhookId), URL: m.Url, Events: m.Events, Secret: m.Secret, Active: m.Active}
+		})
+	webhookListProto = protoMessage(
+		func(WebhookList) *pb.WebhookList { return &pb.WebhookList{} },
+		func(*pb.WebhookList) WebhookList { return WebhookList{} })
+	webhookDeleteProto = protoMessage(
+		func(p WebhookDelete) *pb.WebhookDelete { return &pb.WebhookDelete{WebhookId: int64(p.WebhookID)} },
+		func(m *pb.WebhookDelete) WebhookDelete { return WebhookDelete{WebhookID: int(m.WebhookId)} })
+	webhookDetailsProto    = protoMessage(webhookDetailsToProto, webhookDetailsFromProto)
+	webhookListResultProto = protoMessage(
+		func(p WebhookListResult) *pb.WebhookListResult {
+			m := &pb.WebhookListResult{Webhooks: make([]*pb.WebhookDetails, len(p.Webhooks))}
+			for i, w := range p.Webhooks {
+				m.Webhooks[i] = webhookDetailsToProto(w)
+			}
+			return m
+		},
+		func(m *pb.WebhookListResult) WebhookListResult {
+			p := WebhookListResult{Webhooks: make([]WebhookDetails, len(m.Webhooks))}
+			for i, w := range m.Webhooks {
+				p.Webhooks[i] = webhookDetailsFromProto(w)
+			}
+			return p
+		})
+	webhookDeliveryListProto = protoMessage(
+		func(p WebhookDeliveryList) *pb.WebhookDeliveryList {
+			return &pb.WebhookDeliveryList{WebhookId: int64(p.WebhookID)}
+		},
+		func(m *pb.WebhookDeliveryList) WebhookDeliveryList {
+			return WebhookDeliveryList{WebhookID: int(m.WebhookId)}
+		})
+	webhookDeliveryListResultProto = protoMessage(
+		func(p WebhookDeliveryListResult) *pb.WebhookDeliveryListResult {
+			m := &pb.WebhookDeliveryListResult{Deliveries: make([]*pb.WebhookDeliveryDetails, len(p.Deliveries))}
+			for i, d := range p.Deliveries {
+				m.Deliveries[i] = webhookDeliveryDetailsToProto(d)
+			}
+			return m
+		},
+		func(m *pb.WebhookDeliveryListResult) WebhookDeliveryListResult {
+			p := WebhookDeliveryListResult{Deliveries: make([]WebhookDeliveryDetails, len(m.Deliveries))}
+			for i, d := range m.Deliveries {
+				p.Deliveries[i] = webhookDeliveryDetailsFromProto(d)
+			}
+			return p
+		})
+	webhookRedeliverProto = protoMessage(
+		func(p WebhookRedeliver) *pb.WebhookRedeliver {
+			return &pb.WebhookRedeliver{WebhookId: int64(p.WebhookID), DeliveryId: int64(p.DeliveryID)}
+		},
+		func(m *pb.WebhookRedeliver) WebhookRedeliver {
+			return WebhookRedeliver{WebhookID: int(m.WebhookId), DeliveryID: int(m.DeliveryId)}
+		})
+	webhookDeliveryDetailsProto = protoMessage(webhookDeliveryDetailsToProto, webhookDeliveryDetailsFromProto)
+	webhookDeliverProto         = protoMessage(
+		func(p WebhookDeliver) *pb.WebhookDeliver {
+			return &pb.WebhookDeliver{DeliveryId: int64(p.DeliveryID), Attempt: int64(p.Attempt)}
+		},
+		func(m *pb.WebhookDeliver) WebhookDeliver {
+			return WebhookDeliver{DeliveryID: int(m.DeliveryId), Attempt: int(m.Attempt)}
+		})
+	inboxListProto = protoMessage(
+		func(p InboxList) *pb.InboxList { return &pb.InboxList{UserId: int64(p.UserID), Archived: p.Archived} },
+		func(m *pb.InboxList) InboxList { return InboxList{UserID: int(m.UserId), Archived: m.Archived} })
+	inboxListResultProto = protoMessage(
+		func(p InboxListResult) *pb.InboxListResult {
+			m := &pb.InboxListResult{Items: make([]*pb.InboxItemDetails, len(p.Items)), Unread: int64(p.Unread)}
+			for i, item := range p.Items {
+				m.Items[i] = inboxItemDetailsToProto(item)
+			}
+			return m
+		},
+		func(m *pb.InboxListResult) InboxListResult {
+			p := InboxListResult{Items: make([]InboxItemDetails, len(m.Items)), Unread: int(m.Unread)}
+			for i, item := range m.Items {
+				p.Items[i] = inboxItemDetailsFromProto(item)
+			}
+			return p
+		})
+	inboxCountProto = protoMessage(
+		func(p InboxCount) *pb.InboxCount { return &pb.InboxCount{UserId: int64(p.UserID)} },
+		func(m *pb.InboxCount) InboxCount { return InboxCount{UserID: int(m.UserId)} })
+	inboxCountResultProto = protoMessage(
+		func(p InboxCountResult) *pb.InboxCountResult {
+			return &pb.InboxCountResult{UserId: int64(p.UserID), Unread: int64(p.Unread)}
+		},
+		func(m *pb.InboxCountResult) InboxCountResult {
+			return InboxCountResult{UserID: int(m.UserId), Unread: int(m.Unread)}
+		})
+	inboxUpdateProto = protoMessage(
+		func(p InboxUpdate) *pb.InboxUpdate {
+			return &pb.InboxUpdate{UserId: int64(p.UserID), ItemId: int64(p.ItemID), Action: p.Action}
+		},
+		func(m *pb.InboxUpdate) InboxUpdate {
+			return InboxUpdate{UserID: int(m.UserId), ItemID: int(m.ItemId), Action: m.Action}
+		})
+	inboxMarkAllReadProto = protoMessage(
+		func(p InboxMarkAllRead) *pb.InboxMarkAllRead { return &pb.InboxMarkAllRead{UserId: int64(p.UserID)} },
+		func(m *pb.InboxMarkAllRead) InboxMarkAllRead { return InboxMarkAllRead{UserID: int(m.UserId)} })
+	inboxItemDetailsProto = protoMessage(inboxItemDetailsToProto, inboxItemDetailsFromProto)
+	inboxItemAddedProto   = protoMessage(
+		func(p InboxItemAdded) *pb.InboxItemDetails { return inboxItemDetailsToProto(InboxItemDetails(p)) },
+		func(m *pb.InboxItemDetails) InboxItemAdded { return InboxItemAdded(inboxItemDetailsFromProto(m)) })
+	healthCheckProto = protoMessage(
+		func(HealthCheck) *pb.HealthCheck { return &pb.HealthCheck{} },
+		func(*pb.HealthCheck) HealthCheck { return HealthCheck{} })
+	healthStatusProto = protoMessage(
+		func(p HealthStatus) *pb.HealthStatus {
+			return &pb.HealthStatus{
+				Service:       p.Service,
+				Status:        p.Status,
+				Database:      p.Database,
+				SchemaVersion: int32(p.SchemaVersion),
+				Error:         p.Error,
+			}
+		},
+		func(m *pb.HealthStatus) HealthStatus {
+			return HealthStatus{
+				Service:       m.Service,
+				Status:        m.Status,
+				Database:      m.Database,
+				SchemaVersion: int(m.SchemaVersion),
+				Error:         m.Error,
+			}
+		})
+)
+
+func orderDetailsToProto(p OrderDetails) *pb.OrderDetails {
+	return &pb.OrderDetails{
+		OrderId:   int64(p.OrderID),
+		ProductId: int64(p.ProductID),
+		UserId:    int64(p.UserID),
+		Quantity:  int64(p.Quantity),
+		Status:    p.Status,
+	}
+}
+
+func orderDetailsFromProto(m *pb.OrderDetails) OrderDetails {
+	return OrderDetails{
+		OrderID:   int(m.OrderId),
+		ProductID: int(m.ProductId),
+		UserID:    int(m.UserId),
+		Quantity:  int(m.Quantity),
+		Status:    m.Status,
+	}
+}
+
+func notificationDetailsToProto(p NotificationDetails) *pb.NotificationDetails {
+	m := &pb.NotificationDetails{
+		NotificationId: int64(p.NotificationID),
+		UserId:         int64(p.UserID),
+		Channel:        p.Channel,
+		Template:       p.Template,
+		Category:       p.Category,
+		Content:        p.Content,
+		Status:         p.Status,
+		Attempts:       int32(p.Attempts),
+		CreatedAt:      timestamppb.New(p.CreatedAt),
+		UpdatedAt:      timestamppb.New(p.UpdatedAt),
+		DigestId:       int64(p.DigestID),
+	}
+	if p.DeliveredAt != nil {
+		m.DeliveredAt = timestamppb.New(*p.DeliveredAt)
+	}
+	return m
+}
+
+func notificationDetailsFromProto(m *pb.NotificationDetails) NotificationDetails {
+	p := NotificationDetails{
+		NotificationID: int(m.NotificationId),
+		UserID:         int(m.UserId),
+		Channel:        m.Channel,
+		Template:       m.Template,
+		Category:       m.Category,
+		Content:        m.Content,
+		Status:         m.Status,
+		Attempts:       int(m.Attempts),
+		CreatedAt:      m.CreatedAt.AsTime(),
+		UpdatedAt:      m.UpdatedAt.AsTime(),
+		DigestID:       int(m.DigestId),
+	}
+	if m.DeliveredAt != nil {
+		t := m.DeliveredAt.AsTime()
+		p.DeliveredAt = &t
+	}
+	return p
+}
+
+func contactDetailsToProto(p ContactDetails) *pb.ContactDetails {
+	m := &pb.ContactDetails{UserId: int64(p.UserID), Email: p.Email, Phone: p.Phone, Locale: p.Locale}
+	if p.Push != nil {
+		m.Push = &pb.PushSubscription{Endpoint: p.Push.Endpoint, P256Dh: p.Push.Keys.P256dh, Auth: p.Push.Keys.Auth}
+	}
+	return m
+}
+
+func contactDetailsFromProto(m *pb.ContactDetails) ContactDetails {
+	p := ContactDetails{UserID: int(m.UserId), Email: m.Email, Phone: m.Phone, Locale: m.Locale}
+	if m.Push != nil {
+		p.Push = &PushSubscription{Endpoint: m.Push.Endpoint, Keys: PushKeys{P256dh: m.Push.P256Dh, Auth: m.Push.Auth}}
+	}
+	return p
+}
+
+func preferencesDetailsToProto(p PreferencesDetails) *pb.PreferencesDetails {
+	m := &pb.PreferencesDetails{UserId: int64(p.UserID), Unsubscribed: p.Unsubscribed}
+	if p.Categories != nil {
+		m.Categories = make(map[string]*pb.ChannelOptIns, len(p.Categories))
+		for category, channels := range p.Categories {
+			m.Categories[category] = &pb.ChannelOptIns{Channels: channels}
+		}
+	}
+	if p.QuietHours != nil {
+		m.QuietHours = &pb.QuietHours{Start: p.QuietHours.Start, End: p.QuietHours.End, TimeZone: p.QuietHours.TimeZone}
+	}
+	return m
+}
+
+func preferencesDetailsFromProto(m *pb.PreferencesDetails) PreferencesDetails {
+	p := PreferencesDetails{UserID: int(m.UserId), Unsubscribed: m.Unsubscribed}
+	if m.Categories != nil {
+		p.Categories = make(map[string]map[string]bool, len(m.Categories))
+		for category, optIns := range m.Categories {
+			p.Categories[category] = optIns.GetChannels()
+		}
+	}
+	if m.QuietHours != nil {
+		p.QuietHours = &QuietHours{Start: m.QuietHours.Start, End: m.QuietHours.End, TimeZone: m.QuietHours.TimeZone}
+	}
+	return p
+}
+
+func webhookDetailsToProto(p WebhookDetails) *pb.WebhookDetails {
+	return &pb.WebhookDetails{
+		WebhookId: int64(p.WebhookID),
+		Url:       p.URL,
+		Events:    p.Events,
+		Secret:    p.Secret,
+		Active:    p.Active,
+		Failures:  int32(p.Failures),
+		CreatedAt: timestamppb.New(p.CreatedAt),
+		UpdatedAt: timestamppb.New(p.UpdatedAt),
+	}
+}
+
+func webhookDetailsFromProto(m *pb.WebhookDetails) WebhookDetails {
+	return WebhookDetails{
+		WebhookID: int(m.WebhookId),
+		URL:       m.Url,
+		Events:    m.Events,
+		Secret:    m.Secret,
+		Active:    m.Active,
+		Failures:  int(m.Failures),
+		CreatedAt: m.CreatedAt.AsTime(),
+		UpdatedAt: m.UpdatedAt.AsTime(),
+	}
+}
+
+func webhookDeliveryDetailsToProto(p WebhookDeliveryDetails) *pb.WebhookDeliveryDetails {
+	m := &pb.WebhookDeliveryDetails{
+		DeliveryId:     int64(p.DeliveryID),
+		WebhookId:      int64(p.WebhookID),
+		EventId:        p.EventID,
+		Event:          p.Event,
+		Status:         p.Status,
+		Attempts:       int32(p.Attempts),
+		ResponseStatus: int32(p.ResponseStatus),
+		Error:          p.Error,
+		CreatedAt:      timestamppb.New(p.CreatedAt),
+		UpdatedAt:      timestamppb.New(p.UpdatedAt),
+	}
+	if p.DeliveredAt != nil {
+		m.DeliveredAt = timestamppb.New(*p.DeliveredAt)
+	}
+	return m
+}
+
+func webhookDeliveryDetailsFromProto(m *pb.WebhookDeliveryDetails) WebhookDeliveryDetails {
+	p := WebhookDeliveryDetails{
+		DeliveryID:     int(m.DeliveryId),
+		WebhookID:      int(m.WebhookId),
+		EventID:        m.EventId,
+		Event:          m.Event,
+		Status:         m.Status,
+		Attempts:       int(m.Attempts),
+		ResponseStatus: int(m.ResponseStatus),
+		Error:          m.Error,
+		CreatedAt:      m.CreatedAt.AsTime(),
+		UpdatedAt:      m.UpdatedAt.AsTime(),
+	}
+	if m.DeliveredAt != nil {
+		t := m.DeliveredAt.AsTime()
+		p.DeliveredAt = &t
+	}
+	return p
+}
+
+func inboxItemDetailsToProto(p InboxItemDetails) *pb.InboxItemDetails {
+	m := &pb.InboxItemDetails{
+		ItemId:         int64(p.ItemID),
+		UserId:         int64(p.UserID),
+		NotificationId: int64(p.NotificationID),
+		Category:       p.Category,
+		Subject:        p.Subject,
+		Content:        p.Content,
+		Read:           p.Read,
+		Archived:       p.Archived,
+		CreatedAt:      timestamppb.New(p.CreatedAt),
+	}
+	if p.ReadAt != nil {
+		m.ReadAt = timestamppb.New(*p.ReadAt)
+	}
+	return m
+}
+
+func inboxItemDetailsFromProto(m *pb.InboxItemDetails) InboxItemDetails {
+	p := InboxItemDetails{
+		ItemID:         int(m.ItemId),
+		UserID:         int(m.UserId),
+		NotificationID: int(m.NotificationId),
+		Category:       m.Category,
+		Subject:        m.Subject,
+		Content:        m.Content,
+		Read:           m.Read,
+		Archived:       m.Archived,
+		CreatedAt:      m.CreatedAt.AsTime(),
+	}
+	if m.ReadAt != nil {
+		t := m.ReadAt.AsTime()
+		p.ReadAt = &t
+	}
+	return p
+}
@@ -0,0 +1,61 @@
+package contracts
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Schema is the registry entry for one message type.
+type Schema struct {
+	Type       string
+	Version    int // version produced by this build
+	MinVersion int // oldest version this build still reads
+	Queue      string
+	decode     func(json.RawMessage) (Payload, error)
+	proto      protoCodec
+}
+
+func (s Schema) reads(version int) bool {
+	return version >= s.MinVersion && version <= s.Version
+}
+
+// decoder returns a function that decodes a payload into a new T.
+func decoder[T Payload]() func(json.RawMessage) (Payload, error) {
+	return func(raw json.RawMessage) (Payload, error) {
+		var p T
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &p); err != nil {
+				return nil, err
+			}
+		}
+		return p, nil
+	}
+}
+
+// schemas is the registry of every message type, keyed by type name. When
+// a payload changes incompatibly, bump Version, teach the decoder to read
+// the old shape, and raise MinVersion once no producer sends it any more.
+var schemas = map[string]Schema{}
+
+func register(s Schema) {
+	if s.MinVersion == 0 {
+		s.MinVersion = 1
+	}
+	schemas[s.Type] = s
+}
+
+// Lookup returns the schema registered for a message type.
+func Lookup(messageType string) (Schema, bool) {
+	s, ok := schemas[messageType]
+	return s, ok
+}
+
+// Schemas returns every registered schema ordered by type.
+func Schemas() []Schema {
+	all := make([]Schema, 0, len(schemas))
+	for _, s := range schemas {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Type < all[j].Type })
+	return all
+}
@@ -0,0 +1,118 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"ecomm-sample/api_gateway/client"
+)
+
+// TestGeneratedClient runs an order's life cycle through the client
+// generated from the gateway's OpenAPI document.
+func TestGeneratedClient(t *testing.T) {
+	cfg := Config()
+	cfg.APIKeys = []string{"secret"}
+	h := NewWithConfig(t, cfg)
+	h.SetStock(t, 101, 10)
+
+	c, err := client.NewClientWithResponses(h.Gateway.URL, client.WithAPIKey("secret"))
+	if err != nil {
+		t.Fatalf("NewClientWithResponses: %v", err)
+	}
+	ctx := context.Background()
+
+	placed, err := c.PlaceOrderV2WithResponse(ctx, client.OrderRequest{OrderId: 1, ProductId: 101, UserId: 7, Quantity: 3})
+	if err != nil || placed.JSON201 == nil {
+		t.Fatalf("PlaceOrderV2 = %v, %v", placed.Status(), err)
+	}
+	want := client.Order{OrderId: 1, ProductId: 101, UserId: 7, Quantity: 3, Status: client.Placed}
+	if placed.JSON201.Order != want || placed.JSON201.Notification.UserId != 7 {
+		t.Errorf("PlaceOrderV2 = %+v", *placed.JSON201)
+	}
+	if loc := placed.HTTPResponse.Header.Get("Location"); loc != "/api/v2/orders/1" {
+		t.Errorf("Location = %q, want /api/v2/orders/1", loc)
+	}
+
+	var got *client.GetOrderV2Response
+	Eventually(t, "the order to be stored", func() bool {
+		got, err = c.GetOrderV2WithResponse(ctx, 1)
+		return err == nil && got.JSON200 != nil
+	})
+	if *got.JSON200 != want {
+		t.Errorf("GetOrderV2 = %+v, want %+v", *got.JSON200, want)
+	}
+
+	list, err := c.ListOrdersV2WithResponse(ctx, &client.ListOrdersV2Params{UserId: 7})
+	if err != nil || list.JSON200 == nil || len(list.JSON200.Orders) != 1 {
+		t.Fatalf("ListOrdersV2 = %v, %v", list.Status(), err)
+	}
+
+	phone := "+15550100"
+	contact, err := c.PutUserContactV2WithResponse(ctx, 7, client.Contact{Phone: &phone})
+	if err != nil || contact.JSON200 == nil || contact.JSON200.Phone == nil || *contact.JSON200.Phone != phone {
+		t.Fatalf("PutUserContactV2 = %v, %v", contact.Status(), err)
+	}
+	stored, err := c.GetUserContactV2WithResponse(ctx, 7)
+	if err != nil || stored.JSON200 == nil || stored.JSON200.Phone == nil || stored.JSON200.Email != nil {
+		t.Errorf("GetUserContactV2 = %v, %v", stored.Status(), err)
+	}
+
+	optIns := client.CategoryOptIns{"marketing": {"email": true}}
+	quiet := client.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Berlin"}
+	prefs, err := c.PutUserPreferencesV2WithResponse(ctx, 7, client.Preferences{Categories: &optIns, QuietHours: &quiet})
+	if err != nil || prefs.JSON200 == nil || !prefs.JSON200.Categories["marketing"]["email"] || !prefs.JSON200.Categories["transactional"]["sms"] {
+		t.Fatalf("PutUserPreferencesV2 = %v, %v", prefs.Status(), err)
+	}
+	badZone := client.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Mars/Olympus"}
+	refused, err := c.PutUserPreferencesV2WithResponse(ctx, 7, client.Preferences{QuietHours: &badZone})
+	if err != nil || refused.StatusCode() != http.StatusBadRequest {
+		t.Errorf("PutUserPreferencesV2 with an unknown time zone = %v, %v; want 400", refused.Status(), err)
+	}
+
+	var history *client.ListUserNotificationsV2Response
+	Eventually(t, "the user's notification to be delivered", func() bool {
+		history, err = c.ListUserNotificationsV2WithResponse(ctx, 7)
+		return err == nil && history.JSON200 != nil && len(history.JSON200.Notifications) == 1 &&
+			history.JSON200.Notifications[0].Status == client.Delivered
+	})
+	if n := history.JSON200.Notifications[0]; n.Template != "order_placed" || !strings.Contains(n.Content, "Order 1: 3 × product 101") {
+		t.Errorf("notification = %+v, want order_placed rendered with the order", n)
+	}
+
+	// The deprecated v1 API serves the same orders.
+	v1, err := c.GetOrderWithResponse(ctx, 1)
+	if err != nil || v1.JSON200 == nil || *v1.JSON200 != want {
+		t.Errorf("GetOrder = %v, %v", v1.Status(), err)
+	}
+	if v1.HTTPResponse.Header.Get("Deprecation") == "" {
+		t.Error("v1 response has no Deprecation header")
+	}
+
+	cancelled, err := c.CancelOrderV2WithResponse(ctx, 1)
+	if err != nil || cancelled.JSON200 == nil || cancelled.JSON200.Status != client.Cancelled {
+		t.Fatalf("CancelOrderV2 = %v, %v", cancelled.Status(), err)
+	}
+	Eventually(t, "the stock to be released", func() bool { return h.Stock(t, 101) == 10 })
+
+	again, err := c.CancelOrderV2WithResponse(ctx, 1)
+	if err != nil || again.StatusCode() != http.StatusConflict {
+		t.Errorf("second CancelOrderV2 = %v, %v; want 409", again.Status(), err)
+	}
+
+	health, err := c.HealthCheckWithResponse(ctx)
+	if err != nil || health.JSON200 == nil || len(*health.JSON200) != 4 {
+		t.Fatalf("HealthCheck = %v, %v", health.Status(), err)
+	}
+	last, err := (*health.JSON200)[3].AsGatewayHealth()
+	if err != nil || last.Service != "API Gateway" || len(last.Breakers) == 0 {
+		t.Errorf("gateway health = %+v, %v", last, err)
+	}
+
+	unauthenticated, _ := client.NewClientWithResponses(h.Gateway.URL)
+	resp, err := unauthenticated.GetOrderV2WithResponse(ctx, 1)
+	if err != nil || resp.StatusCode() != http.StatusUnauthorized {
+		t.Errorf("GetOrderV2 without key = %v, %v; want 401", resp.Status(), err)
+	}
+}
@@ -0,0 +1,178 @@
+// Package e2e runs the API gateway and the inventory, order and notification
+// services together in one process, wired over the in-memory broker and
+// repositories, so the whole order flow can be tested without RabbitMQ or
+// PostgreSQL.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecomm-sample/api_gateway/gateway"
+	"ecomm-sample/broker"
+	"ecomm-sample/inventory_service/inventory"
+	"ecomm-sample/notification_service/notifications"
+	"ecomm-sample/order_service/orders"
+)
+
+// Harness is a running set of services. The repositories are exported so
+// tests can seed them and assert on what the services wrote.
+type Harness struct {
+	Broker        *broker.Memory
+	Inventory     *inventory.MemoryInventory
+	Orders        *orders.MemoryOrders
+	Notifications *notifications.MemoryNotifications
+	Gateway       *httptest.Server
+}
+
+// Config returns the gateway configuration New uses: limits high enough
+// not to interfere with tests, and short timeouts.
+func Config() gateway.Config {
+	return gateway.Config{
+		DefaultLimit: gateway.RateLimit{Rate: 1000, Burst: 1000},
+		Breaker: gateway.BreakerConfig{
+			FailureThreshold: 5,
+			OpenTimeout:      time.Minute,
+			HalfOpenMax:      1,
+			MaxConcurrent:    50,
+		},
+		RPCTimeout:        2 * time.Second,
+		HealthCheckWindow: 100 * time.Millisecond,
+	}
+}
+
+// New starts all four services with the default test configuration. They
+// are stopped when the test ends.
+func New(t testing.TB) *Harness {
+	return NewWithConfig(t, Config())
+}
+
+// NewWithConfig is New with a custom gateway configuration. The order
+// service publishes with the same cfg.Encodings as the gateway.
+func NewWithConfig(t testing.TB, cfg gateway.Config) *Harness {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &Harness{
+		Broker:        broker.NewMemory(),
+		Inventory:     inventory.NewMemoryInventory(),
+		Orders:        orders.NewMemoryOrders(),
+		Notifications: notifications.NewMemoryNotifications(),
+	}
+	t.Cleanup(func() {
+		cancel()
+		h.Broker.Close()
+	})
+
+	ordersSvc := orders.NewService(h.Orders, h.Broker)
+	ordersSvc.SetEncodings(cfg.Encodings)
+	// Partners are test servers on the loopback interface.
+	notificationsSvc := notifications.NewService(h.Notifications, h.Broker)
+	notificationsSvc.SetWebhooks(notifications.WebhookConfig{Attempts: 1, Timeout: 10 * time.Second, AllowPrivate: true})
+	services := []interface{ Start(context.Context) error }{
+		inventory.NewService(h.Inventory, h.Broker),
+		ordersSvc,
+		notificationsSvc,
+	}
+	for _, svc := range services {
+		if err := svc.Start(ctx); err != nil {
+			t.Fatalf("start service: %v", err)
+		}
+	}
+
+	gw := gateway.New(h.Broker, cfg)
+	if err := gw.StartInbox(ctx); err != nil {
+		t.Fatalf("start gateway inbox: %v", err)
+	}
+	h.Gateway = httptest.NewServer(gw)
+	t.Cleanup(h.Gateway.Close)
+	return h
+}
+
+// SetStock seeds the inventory with a product.
+func (h *Harness) SetStock(t testing.TB, productID, stock int) {
+	t.Helper()
+	p := inventory.Product{ProductID: productID, Name: "product", Stock: stock}
+	if err := h.Inventory.SaveProduct(context.Background(), p); err != nil {
+		t.Fatalf("SaveProduct: %v", err)
+	}
+}
+
+// Stock returns the units of productID on hand.
+func (h *Harness) Stock(t testing.TB, productID int) int {
+	t.Helper()
+	stock, err := h.Inventory.Stock(context.Background(), productID)
+	if err != nil {
+		t.Fatalf("Stock(%d): %v", productID, err)
+	}
+	return stock
+}
+
+// Do sends a request with a JSON body, unless body is nil, to the gateway
+// and returns the status code and response body.
+func (h *Harness) Do(t testing.TB, method, path string, body interface{}) (int, []byte) {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, h.Gateway.URL+path, r)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := h.Gateway.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	return resp.StatusCode, respBody
+}
+
+// PlaceOrder posts order to /api/process-order.
+func (h *Harness) PlaceOrder(t testing.TB, order gateway.OrderRequest) (int, []byte) {
+	t.Helper()
+	return h.Do(t, http.MethodPost, "/api/process-order", order)
+}
+
+// Eventually fails the test unless cond becomes true within a second. The
+// backend services handle messages asynchronously, so their effects are
+// only visible some time after the gateway responds.
+func Eventually(t testing.TB, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Never fails the test if cond becomes true within a short grace period,
+// for asserting that a message was not acted on.
+func Never(t testing.TB, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if cond() {
+			t.Fatalf("unexpectedly saw %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,73 @@
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"ecomm-sample/api_gateway/gateway"
+	"ecomm-sample/contracts"
+)
+
+// TestInboxStreamsNewItems follows a user's inbox through the gateway and
+// checks that the notification sent for their order shows up on the
+// stream and in the inbox, and can be read.
+func TestInboxStreamsNewItems(t *testing.T) {
+	h := New(t)
+	h.SetStock(t, 101, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, h.Gateway.URL+"/api/v2/users/7/inbox/stream", nil)
+	resp, err := h.Gateway.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET inbox stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET inbox stream = %d", resp.StatusCode)
+	}
+	data := make(chan string, 10)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if line, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				data <- line
+			}
+		}
+	}()
+	next := func() string {
+		t.Helper()
+		select {
+		case d := <-data:
+			return d
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for an inbox event")
+			return ""
+		}
+	}
+	if d := next(); d != `{"user_id":7,"unread":0}` {
+		t.Fatalf("first event data = %s, want nothing unread", d)
+	}
+
+	if status, body := h.PlaceOrder(t, gateway.OrderRequest{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 3}); status != http.StatusOK {
+		t.Fatalf("place order = %d: %s", status, body)
+	}
+	var item contracts.InboxItemDetails
+	if err := json.Unmarshal([]byte(next()), &item); err != nil || item.UserID != 7 || item.Read {
+		t.Fatalf("streamed item = %+v, %v; want an unread item for user 7", item, err)
+	}
+
+	status, body := h.Do(t, http.MethodGet, "/api/v2/users/7/inbox", nil)
+	var inbox contracts.InboxListResult
+	if err := json.Unmarshal(body, &inbox); status != http.StatusOK || err != nil || len(inbox.Items) == 0 || inbox.Unread != len(inbox.Items) {
+		t.Fatalf("GET inbox = %d: %s", status, body)
+	}
+	if status, body := h.Do(t, http.MethodPost, "/api/v2/users/7/inbox/mark_all_read", nil); status != http.StatusOK || !strings.Contains(string(body), `"unread":0`) {
+		t.Fatalf("mark all read = %d: %s", status, body)
+	}
+}
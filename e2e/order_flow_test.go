@@ -0,0 +1,176 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	"ecomm-sample/api_gateway/gateway"
+	"ecomm-sample/contracts"
+	"ecomm-sample/order_service/orders"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+func TestPlaceOrder(t *testing.T) {
+	h := New(t)
+	h.SetStock(t, 101, 10)
+
+	status, body := h.PlaceOrder(t, gateway.OrderRequest{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 3})
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", status, body)
+	}
+
+	if stock := h.Stock(t, 101); stock != 7 {
+		t.Errorf("stock = %d, want 7", stock)
+	}
+	ctx := context.Background()
+	Eventually(t, "the order to be stored", func() bool {
+		_, err := h.Orders.GetOrder(ctx, 1)
+		return err == nil
+	})
+	order, _ := h.Orders.GetOrder(ctx, 1)
+	if want := (orders.Order{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 3, Status: contracts.OrderStatusPlaced}); order != want {
+		t.Errorf("stored order = %+v, want %+v", order, want)
+	}
+	Eventually(t, "the user to be notified", func() bool {
+		sent, _ := h.Notifications.ListNotifications(ctx, 7)
+		return len(sent) == 1
+	})
+}
+
+func TestPlaceOrderOverProtobuf(t *testing.T) {
+	cfg := Config()
+	cfg.Encodings = contracts.Encodings{
+		"check_stock":   contracts.Protobuf,
+		"place_order":   contracts.Protobuf,
+		"notifications": contracts.Protobuf,
+	}
+	h := NewWithConfig(t, cfg)
+	h.SetStock(t, 101, 10)
+
+	status, body := h.PlaceOrder(t, gateway.OrderRequest{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 3})
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", status, body)
+	}
+	if stock := h.Stock(t, 101); stock != 7 {
+		t.Errorf("stock = %d, want 7", stock)
+	}
+	ctx := context.Background()
+	Eventually(t, "the order to be stored", func() bool {
+		_, err := h.Orders.GetOrder(ctx, 1)
+		return err == nil
+	})
+	Eventually(t, "the user to be notified", func() bool {
+		sent, _ := h.Notifications.ListNotifications(ctx, 7)
+		return len(sent) == 1
+	})
+}
+
+func TestPlaceOrderOutOfStock(t *testing.T) {
+	h := New(t)
+	h.SetStock(t, 101, 2)
+
+	status, body := h.PlaceOrder(t, gateway.OrderRequest{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 3})
+	if status != http.StatusConflict {
+		t.Fatalf("status = %d, want 409: %s", status, body)
+	}
+
+	if stock := h.Stock(t, 101); stock != 2 {
+		t.Errorf("stock = %d, want it unchanged at 2", stock)
+	}
+	ctx := context.Background()
+	Never(t, "an order or notification for a rejected order", func() bool {
+		stored, _ := h.Orders.ListOrdersByUser(ctx, 7)
+		sent, _ := h.Notifications.ListNotifications(ctx, 7)
+		return len(stored) > 0 || len(sent) > 0
+	})
+}
+
+func TestPlaceOrderUnknownProduct(t *testing.T) {
+	h := New(t)
+
+	status, body := h.PlaceOrder(t, gateway.OrderRequest{OrderID: 1, ProductID: 404, UserID: 7, Quantity: 1})
+	if status != http.StatusConflict {
+		t.Fatalf("status = %d, want 409: %s", status, body)
+	}
+}
+
+func TestConcurrentOrdersDoNotOversell(t *testing.T) {
+	h := New(t)
+	h.SetStock(t, 101, 5)
+
+	const attempts = 12
+	var wg sync.WaitGroup
+	statuses := make([]int, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body, _ := json.Marshal(gateway.OrderRequest{OrderID: i + 1, ProductID: 101, UserID: 7, Quantity: 1})
+			resp, err := h.Gateway.Client().Post(h.Gateway.URL+"/api/process-order", "application/json", bytes.NewReader(body))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			statuses[i] = resp.StatusCode
+		}(i)
+	}
+	wg.Wait()
+
+	placed := 0
+	for _, status := range statuses {
+		switch status {
+		case http.StatusOK:
+			placed++
+		case http.StatusConflict:
+		default:
+			t.Errorf("unexpected status %d", status)
+		}
+	}
+	if placed != 5 {
+		t.Errorf("%d orders placed, want 5", placed)
+	}
+	if stock := h.Stock(t, 101); stock != 0 {
+		t.Errorf("stock = %d, want 0", stock)
+	}
+	Eventually(t, "all placed orders to be stored", func() bool {
+		stored, _ := h.Orders.ListOrdersByUser(context.Background(), 7)
+		return len(stored) == placed
+	})
+}
+
+func TestHealthCheckReachesEveryService(t *testing.T) {
+	h := New(t)
+
+	status, body := h.Do(t, http.MethodGet, "/api/health-check", nil)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", status, body)
+	}
+	var results []struct {
+		Service string `json:"service"`
+		Status  string `json:"status"`
+	}
+	if err := json.Unmarshal(body, &results); err != nil {
+		t.Fatalf("invalid response %s: %v", body, err)
+	}
+	got := map[string]string{}
+	for _, r := range results {
+		got[r.Service] = r.Status
+	}
+	for _, service := range []string{"Stock Service", "Order Service", "Notification Service", "API Gateway"} {
+		if got[service] != "healthy" {
+			t.Errorf("%s status = %q, want healthy (all results: %v)", service, got[service], got)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package e2e
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"ecomm-sample/api_gateway/gateway"
+	"ecomm-sample/contracts"
+	"ecomm-sample/notification_service/notifications"
+)
+
+// TestWebhookReceivesOrderEvents registers a partner's webhook through the
+// gateway and checks that it gets signed callbacks as an order is placed
+// and cancelled.
+func TestWebhookReceivesOrderEvents(t *testing.T) {
+	h := New(t)
+	h.SetStock(t, 101, 10)
+
+	type event struct {
+		ID    string                       `json:"id"`
+		Event string                       `json:"event"`
+		Data  contracts.OrderStatusChanged `json:"data"`
+	}
+	var mu sync.Mutex
+	var received []event
+	var secret string
+	partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if err := notifications.VerifyWebhook(secret, r.Header.Get("Webhook-Signature"), body, time.Now(), time.Minute); err != nil {
+			t.Errorf("callback signature: %v", err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		var e event
+		if err := json.Unmarshal(body, &e); err != nil || e.ID != r.Header.Get("Webhook-Id") {
+			t.Errorf("callback body %s: %v", body, err)
+		}
+		received = append(received, e)
+	}))
+	defer partner.Close()
+
+	status, body := h.Do(t, http.MethodPost, "/api/v2/webhooks", gateway.Webhook{URL: partner.URL, Events: []string{contracts.EventOrderPlaced, contracts.EventOrderCancelled}})
+	var webhook contracts.WebhookDetails
+	if err := json.Unmarshal(body, &webhook); status != http.StatusCreated || err != nil || webhook.Secret == "" {
+		t.Fatalf("POST /api/v2/webhooks = %d: %s", status, body)
+	}
+	mu.Lock()
+	secret = webhook.Secret
+	mu.Unlock()
+
+	if status, body := h.Do(t, http.MethodPost, "/api/v2/orders", gateway.OrderRequest{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 3}); status != http.StatusCreated {
+		t.Fatalf("POST /api/v2/orders = %d: %s", status, body)
+	}
+	Eventually(t, "the order to be stored", func() bool {
+		status, _ := h.Do(t, http.MethodGet, "/api/v2/orders/1", nil)
+		return status == http.StatusOK
+	})
+	if status, body := h.Do(t, http.MethodPost, "/api/v2/orders/1/cancel", nil); status != http.StatusOK {
+		t.Fatalf("cancel = %d: %s", status, body)
+	}
+
+	Eventually(t, "the partner to be called back twice", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	})
+	mu.Lock()
+	events := map[string]contracts.OrderStatusChanged{}
+	for _, e := range received {
+		events[e.Event] = e.Data
+	}
+	mu.Unlock()
+	if placed := events[contracts.EventOrderPlaced]; placed.OrderID != 1 || placed.Quantity != 3 || placed.Status != contracts.OrderStatusPlaced {
+		t.Errorf("order.placed data = %+v", placed)
+	}
+	if cancelled := events[contracts.EventOrderCancelled]; cancelled.OrderID != 1 || cancelled.Status != contracts.OrderStatusCancelled {
+		t.Errorf("order.cancelled data = %+v", cancelled)
+	}
+
+	// The partner may be called back before the delivery is recorded.
+	var log contracts.WebhookDeliveryListResult
+	Eventually(t, "both deliveries to be recorded", func() bool {
+		status, body := h.Do(t, http.MethodGet, "/api/v2/webhooks/1/deliveries", nil)
+		if err := json.Unmarshal(body, &log); status != http.StatusOK || err != nil {
+			t.Fatalf("delivery log = %d: %s", status, body)
+		}
+		for _, d := range log.Deliveries {
+			if d.Status != contracts.WebhookDeliveryDelivered {
+				return false
+			}
+		}
+		return len(log.Deliveries) == 2
+	})
+	for _, d := range log.Deliveries {
+		if d.Attempts != 1 || d.DeliveredAt == nil {
+			t.Errorf("delivery = %+v, want delivered on the first attempt", d)
+		}
+	}
+}
@@ -10,17 +10,14 @@ until pg_isready -h localhost -p 5432 -U postgres; do
 done
 echo "PostgreSQL is ready!"
 
-# Create user, database, and tables
+# Create user and database
 su - postgres -c "psql -c \"CREATE USER inventory_user WITH PASSWORD 'password';\""
 su - postgres -c "psql -c \"CREATE DATABASE inventory_db OWNER inventory_user;\""
 su - postgres -c "psql -c \"GRANT ALL PRIVILEGES ON DATABASE inventory_db TO inventory_user;\""
-su - postgres -c "psql inventory_db -c \"CREATE TABLE inventory (
-    product_id SERIAL PRIMARY KEY,
-    name VARCHAR(100) NOT NULL,
-    stock INT NOT NULL
-);\""
-su - postgres -c "psql inventory_db -c \"ALTER TABLE inventory OWNER TO inventory_user;\""
-su - postgres -c "psql inventory_db -c \"INSERT INTO inventory (product_id, name, stock) VALUES (101, 'Broccoli', 10), (102, 'Beer', 5), (103, 'Snacks', 0);\""
+
+# Create the tables from the service's embedded migrations, then add sample data
+/root/main migrate up
+su - postgres -c "psql inventory_db -c \"INSERT INTO inventory (product_id, name, stock) VALUES (101, 'Broccoli', 10), (102, 'Beer', 5), (103, 'Snacks', 0) ON CONFLICT (product_id) DO NOTHING;\""
 
 service postgresql restart
 
@@ -0,0 +1,153 @@
+package inventory
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+)
+
+// movementListLimit is how many of a product's latest movements a
+// contracts.MovementList returns.
+const movementListLimit = 100
+
+// producerKey is the context key of the service that sent the message
+// being handled.
+type producerKey struct{}
+
+// withProducer returns ctx carrying producer, the service that sent the
+// message being handled, which is the actor of the movements it causes
+// unless it names one.
+func withProducer(ctx context.Context, producer string) context.Context {
+	return context.WithValue(ctx, producerKey{}, producer)
+}
+
+// producer returns the producer withProducer attached to ctx.
+func producer(ctx context.Context) string {
+	p, _ := ctx.Value(producerKey{}).(string)
+	return p
+}
+
+// orderReference returns the movement reference of an order, or "" if
+// orderID is 0.
+func orderReference(orderID int) string {
+	if orderID == 0 {
+		return ""
+	}
+	return "order:" + strconv.Itoa(orderID)
+}
+
+// adjustmentSigns lists the movement types a contracts.StockAdjust may
+// record, with the sign their quantity must have: 1 for units coming in,
+// -1 for units going out, and 0 for either. Reservations and releases only
+// come from orders.
+var adjustmentSigns = map[string]int{
+	contracts.MovementReceipt:    1,
+	contracts.MovementSale:       -1,
+	contracts.MovementAdjustment: 0,
+	contracts.MovementReturn:     1,
+	contracts.MovementTransfer:   0,
+}
+
+// adjustment returns the movement req records, or an InventoryError if
+// req is invalid.
+func adjustment(ctx context.Context, req contracts.StockAdjust) (Movement, *contracts.InventoryError) {
+	m := Movement{
+		ProductID: req.ProductID,
+		Type:      req.Type,
+		Quantity:  req.Quantity,
+		Reason:    req.Reason,
+		Actor:     req.Actor,
+		Reference: req.Reference,
+	}
+	if m.Type == "" {
+		m.Type = contracts.MovementAdjustment
+	}
+	if m.Actor == "" {
+		m.Actor = producer(ctx)
+	}
+	sign, ok := adjustmentSigns[m.Type]
+	switch {
+	case !ok:
+		return m, &contracts.InventoryError{Code: contracts.InventoryErrInvalid, Message: "type must be receipt, sale, adjustment, return or transfer"}
+	case m.Quantity == 0:
+		return m, &contracts.InventoryError{Code: contracts.InventoryErrInvalid, Message: "quantity must not be 0"}
+	case sign > 0 && m.Quantity < 0:
+		return m, &contracts.InventoryError{Code: contracts.InventoryErrInvalid, Message: m.Type + " quantity must be positive"}
+	case sign < 0 && m.Quantity > 0:
+		return m, &contracts.InventoryError{Code: contracts.InventoryErrInvalid, Message: m.Type + " quantity must be negative"}
+	}
+	return m, nil
+}
+
+// handleMovementList replies with a product's stock and its latest
+// movements.
+func (s *Service) handleMovementList(ctx context.Context, queue string, msg broker.Delivery, req contracts.MovementList) {
+	ctx = observability.WithLogFields(ctx, slog.Int("product_id", req.ProductID))
+	p, err := s.repo.Product(ctx, req.ProductID)
+	if err != nil {
+		s.answer(ctx, queue, msg, inventoryError(ctx, req.ProductID, err))
+		return
+	}
+	movements, err := s.repo.Movements(ctx, req.ProductID, movementListLimit)
+	if err != nil {
+		s.answer(ctx, queue, msg, inventoryError(ctx, req.ProductID, err))
+		return
+	}
+	result := contracts.MovementListResult{ProductID: p.ProductID, Stock: p.Stock, Movements: make([]contracts.StockMovement, len(movements))}
+	for i, m := range movements {
+		result.Movements[i] = contracts.StockMovement{
+			MovementID: m.MovementID,
+			ProductID:  m.ProductID,
+			Type:       m.Type,
+			Quantity:   m.Quantity,
+			Balance:    m.Balance,
+			Reason:     m.Reason,
+			Actor:      m.Actor,
+			Reference:  m.Reference,
+			CreatedAt:  m.CreatedAt,
+		}
+	}
+	s.answer(ctx, queue, msg, result)
+}
+
+// Reconcile compares every product's stock with the sum of its movements
+// and logs each product where they differ, which only a change made
+// around the repository, such as by hand in the database, can cause. It
+// returns the products that differ.
+func (s *Service) Reconcile(ctx context.Context) ([]Drift, error) {
+	drifts, err := s.repo.Reconcile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ledgerDrift.Set(float64(len(drifts)))
+	for _, d := range drifts {
+		slog.ErrorContext(ctx, "Stock differs from its ledger", "product_id", d.ProductID, "stock", d.Stock, "ledger", d.Ledger, "drift", d.Stock-d.Ledger)
+	}
+	if len(drifts) == 0 {
+		slog.InfoContext(ctx, "Stock matches its ledger")
+	}
+	return drifts, nil
+}
+
+// ReconcileEvery reconciles the stock with its ledger now and then every
+// interval until ctx is cancelled. Failures are logged and retried at the
+// next interval.
+func (s *Service) ReconcileEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Reconcile(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to reconcile stock with its ledger", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
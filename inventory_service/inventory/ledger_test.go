@@ -0,0 +1,96 @@
+package inventory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+func TestStockMovementsAreRecorded(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryInventory()
+	repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 10})
+	b, replyQueue := newTestBroker(t)
+	svc := NewService(repo, b)
+
+	request := func(queue string, p contracts.Payload) contracts.Payload {
+		t.Helper()
+		svc.dispatch(queue, deliver(t, b, queue, broker.Message{ReplyTo: replyQueue, Body: envelope(t, p)}))
+		replies := b.Pending(replyQueue)
+		if len(replies) == 0 {
+			return nil
+		}
+		_, payload, err := contracts.Decode(replies[len(replies)-1].ContentType, replies[len(replies)-1].Body)
+		if err != nil {
+			t.Fatalf("invalid reply: %v", err)
+		}
+		return payload
+	}
+
+	request("check_stock", contracts.StockCheck{ProductID: 101, Quantity: 4, Reserve: true, OrderID: 42})
+	request("check_stock", contracts.StockCheck{ProductID: 101, Quantity: 40, Reserve: true, OrderID: 43})
+	request("release_stock", contracts.StockRelease{ProductID: 101, Quantity: 4, OrderID: 42, Reason: "order cancelled"})
+	request("inventory_requests", contracts.StockAdjust{ProductID: 101, Quantity: 30, Type: contracts.MovementReceipt, Reason: "delivery", Actor: "ada", Reference: "PO-1001"})
+	request("inventory_requests", contracts.StockAdjust{ProductID: 101, Quantity: -2, Reason: "damaged"})
+
+	result, ok := request("inventory_requests", contracts.MovementList{ProductID: 101}).(contracts.MovementListResult)
+	if !ok || result.ProductID != 101 || result.Stock != 38 {
+		t.Fatalf("movement list = %+v, want product 101 with 38 in stock", result)
+	}
+	type entry struct {
+		Type                     string
+		Quantity, Balance        int
+		Reason, Actor, Reference string
+	}
+	var got []entry
+	for _, m := range result.Movements {
+		got = append(got, entry{m.Type, m.Quantity, m.Balance, m.Reason, m.Actor, m.Reference})
+	}
+	// The reservation of 40 was refused and left no trace.
+	want := []entry{
+		{contracts.MovementAdjustment, -2, 38, "damaged", "test", ""},
+		{contracts.MovementReceipt, 30, 40, "delivery", "ada", "PO-1001"},
+		{contracts.MovementRelease, 4, 10, "order cancelled", "test", "order:42"},
+		{contracts.MovementReservation, -4, 6, "", "test", "order:42"},
+		{contracts.MovementAdjustment, 10, 10, savedReason, "", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("movements = %+v, want %+v", got, want)
+	}
+
+	for _, req := range []contracts.StockAdjust{
+		{ProductID: 101, Quantity: 1, Type: contracts.MovementReservation},
+		{ProductID: 101, Quantity: 1, Type: "theft"},
+		{ProductID: 101, Quantity: -1, Type: contracts.MovementReceipt},
+		{ProductID: 101, Quantity: 1, Type: contracts.MovementSale},
+		{ProductID: 101, Type: contracts.MovementTransfer},
+	} {
+		if reply, ok := request("inventory_requests", req).(contracts.InventoryError); !ok || reply.Code != contracts.InventoryErrInvalid {
+			t.Errorf("%+v reply = %+v, want an invalid error", req, reply)
+		}
+	}
+	if reply, ok := request("inventory_requests", contracts.MovementList{ProductID: 404}).(contracts.InventoryError); !ok || reply.Code != contracts.InventoryErrNotFound {
+		t.Errorf("movement list of unknown product = %+v, want a not_found error", reply)
+	}
+	if drifts, err := svc.Reconcile(ctx); err != nil || len(drifts) != 0 {
+		t.Errorf("Reconcile = %+v, %v; want stock to match its ledger", drifts, err)
+	}
+}
+
+func TestReconcileFlagsDrift(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryInventory()
+	repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 10})
+	repo.SaveProduct(ctx, Product{ProductID: 102, Name: "Beer", Stock: 5})
+	repo.products[102] = Product{ProductID: 102, Name: "Beer", Stock: 3}
+	b, _ := newTestBroker(t)
+	svc := NewService(repo, b)
+
+	drifts, err := svc.Reconcile(ctx)
+	if want := []Drift{{ProductID: 102, Stock: 3, Ledger: 5}}; err != nil || !reflect.DeepEqual(drifts, want) {
+		t.Fatalf("Reconcile = %+v, %v; want %+v", drifts, err, want)
+	}
+}
@@ -0,0 +1,90 @@
+package inventory
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// registry holds this service's metrics. It is separate from the default
+// registry so that several services can share a process in tests.
+var registry = prometheus.NewRegistry()
+
+var factory = promauto.With(registry)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	messagesConsumed = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messages_consumed_total",
+		Help: "Messages received from a queue.",
+	}, []string{"queue"})
+	messagesPublished = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messages_published_total",
+		Help: "Messages published, by destination queue.",
+	}, []string{"queue"})
+	messagesAcked = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messages_acked_total",
+		Help: "Messages acknowledged after successful handling.",
+	}, []string{"queue"})
+	messagesNacked = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messages_nacked_total",
+		Help: "Messages negatively acknowledged.",
+	}, []string{"queue"})
+	messagesDeadLettered = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messages_dead_lettered_total",
+		Help: "Messages rejected without requeue, dead-lettered if the queue has a DLX.",
+	}, []string{"queue"})
+	handlerDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "message_handler_duration_seconds",
+		Help:    "Time spent handling a single message.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"queue"})
+	stockChecks = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "stock_checks_total",
+		Help: "Stock checks answered, by whether the quantity was available.",
+	}, []string{"available"})
+	stockOuts = factory.NewCounter(prometheus.CounterOpts{
+		Name: "stock_outs_total",
+		Help: "Stock checks that found too little stock.",
+	})
+	stockLow = factory.NewCounter(prometheus.CounterOpts{
+		Name: "stock_low_total",
+		Help: "Reservations and adjustments that took a product's stock below its reorder threshold or safety stock.",
+	})
+	stockAdjustments = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "stock_adjustments_total",
+		Help: "Stock adjustments applied, by movement type.",
+	}, []string{"type"})
+	ledgerDrift = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "stock_ledger_drift_products",
+		Help: "Products whose stock differed from the sum of their stock movements at the last reconciliation.",
+	})
+)
+
+// RegisterDBMetrics exports the connection pool statistics of db.
+func RegisterDBMetrics(db *sql.DB, name string) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ServeMetrics exposes /metrics on addr in the background.
+func ServeMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	go func() {
+		slog.Info("Serving metrics", "addr", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Error("Metrics server stopped", "error", err)
+		}
+	}()
+}
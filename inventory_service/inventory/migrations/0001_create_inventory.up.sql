@@ -0,0 +1,6 @@
+-- IF NOT EXISTS adopts databases created by init-db.sh before migrations.
+CREATE TABLE IF NOT EXISTS inventory (
+    product_id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    stock INT NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE inventory DROP CONSTRAINT inventory_stock_not_negative;
@@ -0,0 +1 @@
+ALTER TABLE inventory ADD CONSTRAINT inventory_stock_not_negative CHECK (stock >= 0);
@@ -0,0 +1,2 @@
+DROP TABLE stock_consumption;
+ALTER TABLE inventory DROP COLUMN reorder_threshold, DROP COLUMN safety_stock;
@@ -0,0 +1,14 @@
+-- A reorder_threshold of 0 leaves the product at the service's
+-- LOW_STOCK_THRESHOLD.
+ALTER TABLE inventory
+    ADD COLUMN reorder_threshold INT NOT NULL DEFAULT 0 CHECK (reorder_threshold >= 0),
+    ADD COLUMN safety_stock INT NOT NULL DEFAULT 0 CHECK (safety_stock >= 0);
+
+-- Units reserved and not released, per product and UTC day, from which
+-- reorder suggestions estimate how fast stock runs out.
+CREATE TABLE stock_consumption (
+    product_id INT NOT NULL REFERENCES inventory (product_id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    quantity INT NOT NULL,
+    PRIMARY KEY (product_id, day)
+);
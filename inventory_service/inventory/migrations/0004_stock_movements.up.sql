@@ -0,0 +1,30 @@
+-- The stock ledger: every change to a product's stock, appended in the
+-- same transaction as the change, so that stock always equals the sum of
+-- the product's movements.
+CREATE TABLE stock_movements (
+    movement_id BIGSERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES inventory (product_id),
+    type TEXT NOT NULL CHECK (type IN ('receipt', 'reservation', 'release', 'sale', 'adjustment', 'return', 'transfer')),
+    quantity INT NOT NULL CHECK (quantity <> 0),
+    reason TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL DEFAULT '',
+    reference TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX stock_movements_product_id_idx ON stock_movements (product_id, movement_id);
+
+-- Movements are never changed or removed; a mistake is corrected with
+-- another movement.
+CREATE FUNCTION stock_movements_append_only() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END
+$$;
+
+CREATE TRIGGER stock_movements_append_only BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+-- Open the ledger with the stock on hand.
+INSERT INTO stock_movements (product_id, type, quantity, reason, actor)
+    SELECT product_id, 'adjustment', stock, 'opening balance', 'migration' FROM inventory WHERE stock <> 0;
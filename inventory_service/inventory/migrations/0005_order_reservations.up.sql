@@ -0,0 +1,12 @@
+-- The units reserved for each order, so that a retried stock check
+-- reserves them once and a repeated release returns them once. A release
+-- that arrives before its reservation is stored as released, so that the
+-- late reservation is refused; it need not name a known product.
+CREATE TABLE reservations (
+    order_id INT PRIMARY KEY,
+    product_id INT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    released BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,348 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ecomm-sample/contracts"
+	"ecomm-sample/migrate"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PostgresInventory is the InventoryRepository backed by inventory_db.
+type PostgresInventory struct {
+	db *sql.DB
+}
+
+// NewPostgresInventory returns a repository over the inventory table of db.
+func NewPostgresInventory(db *sql.DB) *PostgresInventory {
+	return &PostgresInventory{db: db}
+}
+
+// startDBSpan starts a client span for a single query.
+func startDBSpan(ctx context.Context, name, query string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append([]attribute.KeyValue{
+		semconv.DBSystemPostgreSQL,
+		semconv.DBName("inventory_db"),
+		semconv.DBStatement(query),
+	}, attrs...)
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endDBSpan records err, if any, on span and ends it.
+func endDBSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, ErrProductNotFound) && !errors.Is(err, ErrInsufficientStock) && !errors.Is(err, ErrReservationConflict) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "query failed")
+	}
+	span.End()
+}
+
+func (p *PostgresInventory) Stock(ctx context.Context, productID int) (stock int, err error) {
+	const query = "SELECT stock FROM inventory WHERE product_id = $1"
+	ctx, span := startDBSpan(ctx, "InventoryRepository.Stock", query, attribute.Int("product_id", productID))
+	defer func() { endDBSpan(span, err) }()
+
+	err = p.db.QueryRowContext(ctx, query, productID).Scan(&stock)
+	if err == sql.ErrNoRows {
+		return 0, ErrProductNotFound
+	}
+	return stock, err
+}
+
+// productColumns are the inventory columns scanProduct reads, in order.
+const productColumns = "product_id, name, stock, reorder_threshold, safety_stock"
+
+// scanProduct reads a row of productColumns.
+func scanProduct(row interface{ Scan(...interface{}) error }) (Product, error) {
+	var product Product
+	err := row.Scan(&product.ProductID, &product.Name, &product.Stock, &product.ReorderThreshold, &product.SafetyStock)
+	return product, err
+}
+
+func (p *PostgresInventory) Product(ctx context.Context, productID int) (product Product, err error) {
+	const query = "SELECT " + productColumns + " FROM inventory WHERE product_id = $1"
+	ctx, span := startDBSpan(ctx, "InventoryRepository.Product", query, attribute.Int("product_id", productID))
+	defer func() { endDBSpan(span, err) }()
+
+	product, err = scanProduct(p.db.QueryRowContext(ctx, query, productID))
+	if err == sql.ErrNoRows {
+		return Product{}, ErrProductNotFound
+	}
+	return product, err
+}
+
+// recordConsumption is the statement that counts $2 units of product $1
+// as consumed today.
+const recordConsumption = `INSERT INTO stock_consumption (product_id, day, quantity) VALUES ($1, (now() AT TIME ZONE 'UTC')::date, $2)
+	ON CONFLICT (product_id, day) DO UPDATE SET quantity = stock_consumption.quantity + EXCLUDED.quantity`
+
+// recordMovement is the statement that appends a movement to the ledger.
+const recordMovement = `INSERT INTO stock_movements (product_id, type, quantity, reason, actor, reference) VALUES ($1, $2, $3, $4, $5, $6)`
+
+// inTx runs fn in a transaction, which is committed if fn succeeds and
+// rolled back otherwise.
+func (p *PostgresInventory) inTx(ctx context.Context, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+// moveStock is the statement that applies a movement to the stock.
+const moveStock = "UPDATE inventory SET stock = stock + $2 WHERE product_id = $1 AND stock + $2 >= 0 RETURNING " + productColumns
+
+func (p *PostgresInventory) Move(ctx context.Context, m Movement) (product Product, err error) {
+	ctx, span := startDBSpan(ctx, "InventoryRepository.Move", moveStock+"; "+recordMovement, attribute.Int("product_id", m.ProductID), attribute.String("movement.type", m.Type))
+	defer func() { endDBSpan(span, err) }()
+
+	err = p.inTx(ctx, func(tx *sql.Tx) error {
+		product, err = p.move(ctx, tx, m)
+		return err
+	})
+	if err != nil {
+		return Product{}, err
+	}
+	return product, nil
+}
+
+// move applies m like Move within tx.
+func (p *PostgresInventory) move(ctx context.Context, tx *sql.Tx, m Movement) (Product, error) {
+	product, err := scanProduct(tx.QueryRowContext(ctx, moveStock, m.ProductID, m.Quantity))
+	if err == sql.ErrNoRows {
+		// Nothing was updated: either the product is unknown or it has
+		// too little stock.
+		if _, err := p.Stock(ctx, m.ProductID); err != nil {
+			return Product{}, err
+		}
+		return Product{}, ErrInsufficientStock
+	}
+	if err != nil {
+		return Product{}, err
+	}
+	if _, err := tx.ExecContext(ctx, recordMovement, m.ProductID, m.Type, m.Quantity, m.Reason, m.Actor, m.Reference); err != nil {
+		return Product{}, err
+	}
+	if consumes(m.Type) {
+		if _, err := tx.ExecContext(ctx, recordConsumption, m.ProductID, -m.Quantity); err != nil {
+			return Product{}, err
+		}
+	}
+	return product, nil
+}
+
+func (p *PostgresInventory) Reserve(ctx context.Context, orderID int, m Movement) (product Product, reserved bool, err error) {
+	// The claim waits for a concurrent one for the same order to finish,
+	// and claims nothing if the order already has a reservation.
+	const claim = "INSERT INTO reservations (order_id, product_id, quantity) VALUES ($1, $2, $3) ON CONFLICT (order_id) DO NOTHING"
+	const held = "SELECT product_id, quantity, released FROM reservations WHERE order_id = $1"
+	const current = "SELECT " + productColumns + " FROM inventory WHERE product_id = $1"
+	ctx, span := startDBSpan(ctx, "InventoryRepository.Reserve", claim+"; "+moveStock, attribute.Int("order_id", orderID), attribute.Int("product_id", m.ProductID))
+	defer func() { endDBSpan(span, err) }()
+
+	err = p.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, claim, orderID, m.ProductID, -m.Quantity)
+		if err != nil {
+			return err
+		}
+		claimed, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if claimed == 1 {
+			product, err = p.move(ctx, tx, m)
+			reserved = err == nil
+			return err
+		}
+		var productID, quantity int
+		var released bool
+		if err := tx.QueryRowContext(ctx, held, orderID).Scan(&productID, &quantity, &released); err != nil {
+			return err
+		}
+		if released || productID != m.ProductID || quantity != -m.Quantity {
+			return ErrReservationConflict
+		}
+		product, err = scanProduct(tx.QueryRowContext(ctx, current, m.ProductID))
+		return err
+	})
+	if err != nil {
+		return Product{}, false, err
+	}
+	return product, reserved, nil
+}
+
+func (p *PostgresInventory) Release(ctx context.Context, orderID int, m Movement) (released bool, err error) {
+	// An order without a reservation is claimed as released.
+	const claim = "INSERT INTO reservations (order_id, product_id, quantity, released) VALUES ($1, $2, $3, true) ON CONFLICT (order_id) DO NOTHING"
+	const release = `UPDATE reservations SET released = true, updated_at = now()
+		WHERE order_id = $1 AND product_id = $2 AND quantity = $3 AND NOT released`
+	ctx, span := startDBSpan(ctx, "InventoryRepository.Release", claim+"; "+release+"; "+moveStock, attribute.Int("order_id", orderID), attribute.Int("product_id", m.ProductID))
+	defer func() { endDBSpan(span, err) }()
+
+	err = p.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, claim, orderID, m.ProductID, m.Quantity)
+		if err != nil {
+			return err
+		}
+		if claimed, err := result.RowsAffected(); err != nil || claimed == 1 {
+			// A claimed order had nothing to release.
+			return err
+		}
+		result, err = tx.ExecContext(ctx, release, orderID, m.ProductID, m.Quantity)
+		if err != nil {
+			return err
+		}
+		if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+			// The reservation was released already or is of other units.
+			return err
+		}
+		_, err = p.move(ctx, tx, m)
+		released = err == nil
+		return err
+	})
+	return released, err
+}
+
+func (p *PostgresInventory) Movements(ctx context.Context, productID, limit int) (movements []Movement, err error) {
+	// The balance is summed over the whole ledger before the latest
+	// movements are picked.
+	const query = `SELECT movement_id, product_id, type, quantity, SUM(quantity) OVER (ORDER BY movement_id), reason, actor, reference, created_at
+		FROM stock_movements WHERE product_id = $1 ORDER BY movement_id DESC LIMIT $2`
+	ctx, span := startDBSpan(ctx, "InventoryRepository.Movements", query, attribute.Int("product_id", productID))
+	defer func() { endDBSpan(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, query, productID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	movements = []Movement{}
+	for rows.Next() {
+		var m Movement
+		if err = rows.Scan(&m.MovementID, &m.ProductID, &m.Type, &m.Quantity, &m.Balance, &m.Reason, &m.Actor, &m.Reference, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+	return movements, rows.Err()
+}
+
+func (p *PostgresInventory) Reconcile(ctx context.Context) (drifts []Drift, err error) {
+	const query = `SELECT i.product_id, i.stock, COALESCE(SUM(m.quantity), 0) FROM inventory i
+		LEFT JOIN stock_movements m ON m.product_id = i.product_id
+		GROUP BY i.product_id HAVING i.stock <> COALESCE(SUM(m.quantity), 0) ORDER BY i.product_id`
+	ctx, span := startDBSpan(ctx, "InventoryRepository.Reconcile", query)
+	defer func() { endDBSpan(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	drifts = []Drift{}
+	for rows.Next() {
+		var d Drift
+		if err = rows.Scan(&d.ProductID, &d.Stock, &d.Ledger); err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, d)
+	}
+	return drifts, rows.Err()
+}
+
+func (p *PostgresInventory) SetReorderPolicy(ctx context.Context, productID, threshold, safetyStock int) (product Product, err error) {
+	const query = "UPDATE inventory SET reorder_threshold = $2, safety_stock = $3 WHERE product_id = $1 RETURNING " + productColumns
+	ctx, span := startDBSpan(ctx, "InventoryRepository.SetReorderPolicy", query, attribute.Int("product_id", productID))
+	defer func() { endDBSpan(span, err) }()
+
+	product, err = scanProduct(p.db.QueryRowContext(ctx, query, productID, threshold, safetyStock))
+	if err == sql.ErrNoRows {
+		return Product{}, ErrProductNotFound
+	}
+	return product, err
+}
+
+func (p *PostgresInventory) Consumption(ctx context.Context, since time.Time) (consumed map[int]int, err error) {
+	const query = `SELECT product_id, SUM(quantity) FROM stock_consumption WHERE day >= $1::date
+		GROUP BY product_id HAVING SUM(quantity) <> 0`
+	ctx, span := startDBSpan(ctx, "InventoryRepository.Consumption", query)
+	defer func() { endDBSpan(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, query, since.UTC().Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	consumed = make(map[int]int)
+	for rows.Next() {
+		var productID, quantity int
+		if err = rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		consumed[productID] = quantity
+	}
+	return consumed, rows.Err()
+}
+
+func (p *PostgresInventory) SaveProduct(ctx context.Context, product Product) (err error) {
+	const query = `INSERT INTO inventory (product_id, name, stock, reorder_threshold, safety_stock) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (product_id) DO UPDATE SET name = EXCLUDED.name, stock = EXCLUDED.stock,
+			reorder_threshold = EXCLUDED.reorder_threshold, safety_stock = EXCLUDED.safety_stock`
+	ctx, span := startDBSpan(ctx, "InventoryRepository.SaveProduct", query+"; "+recordMovement, attribute.Int("product_id", product.ProductID))
+	defer func() { endDBSpan(span, err) }()
+
+	return p.inTx(ctx, func(tx *sql.Tx) error {
+		var stock int
+		err := tx.QueryRowContext(ctx, "SELECT stock FROM inventory WHERE product_id = $1 FOR UPDATE", product.ProductID).Scan(&stock)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, query, product.ProductID, product.Name, product.Stock, product.ReorderThreshold, product.SafetyStock); err != nil {
+			return err
+		}
+		if change := product.Stock - stock; change != 0 {
+			_, err = tx.ExecContext(ctx, recordMovement, product.ProductID, contracts.MovementAdjustment, change, savedReason, "", "")
+			return err
+		}
+		return nil
+	})
+}
+
+func (p *PostgresInventory) ListProducts(ctx context.Context) (products []Product, err error) {
+	const query = "SELECT " + productColumns + " FROM inventory ORDER BY product_id"
+	ctx, span := startDBSpan(ctx, "InventoryRepository.ListProducts", query)
+	defer func() { endDBSpan(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	products = []Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
+
+func (p *PostgresInventory) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+func (p *PostgresInventory) SchemaVersion(ctx context.Context) (int, error) {
+	return migrate.CurrentVersion(ctx, p.db)
+}
@@ -0,0 +1,136 @@
+package inventory
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+)
+
+// Reorder report windows, in days, when a contracts.ReorderReport leaves
+// them at 0, and the longest one allowed.
+const (
+	defaultWindowDays = 30
+	defaultCoverDays  = 30
+	maxReportDays     = 365
+)
+
+// details returns p as a contracts.ProductDetails.
+func (p Product) details() contracts.ProductDetails {
+	return contracts.ProductDetails{
+		ProductID:        p.ProductID,
+		Name:             p.Name,
+		Stock:            p.Stock,
+		ReorderThreshold: p.ReorderThreshold,
+		SafetyStock:      p.SafetyStock,
+	}
+}
+
+// threshold returns the reorder threshold that applies to p: its own, or
+// the service's default.
+func (s *Service) threshold(p Product) int {
+	if p.ReorderThreshold > 0 {
+		return p.ReorderThreshold
+	}
+	return s.lowStock
+}
+
+// checkLowStock announces that p's stock is low, as a contracts.StockLow
+// event, if a change from before units took it below its reorder
+// threshold or its safety stock. Failures are only logged: the change
+// stands either way.
+func (s *Service) checkLowStock(ctx context.Context, p Product, before int) {
+	threshold := s.threshold(p)
+	crossed := func(level int) bool {
+		return level > 0 && p.Stock < level && before >= level
+	}
+	if !crossed(threshold) && !crossed(p.SafetyStock) {
+		return
+	}
+	stockLow.Inc()
+	slog.WarnContext(ctx, "Stock is low", "stock", p.Stock, "threshold", threshold, "safety_stock", p.SafetyStock)
+	event := contracts.StockLow{ProductID: p.ProductID, Stock: p.Stock, Threshold: threshold, SafetyStock: p.SafetyStock}
+	if err := s.announce(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "Failed to announce low stock", "error", err)
+	}
+}
+
+// handleReorderPolicySet sets a product's reorder threshold and safety
+// stock and replies with the product.
+func (s *Service) handleReorderPolicySet(ctx context.Context, queue string, msg broker.Delivery, req contracts.ReorderPolicySet) {
+	ctx = observability.WithLogFields(ctx, slog.Int("product_id", req.ProductID))
+	if req.ReorderThreshold < 0 || req.SafetyStock < 0 {
+		s.answer(ctx, queue, msg, contracts.InventoryError{Code: contracts.InventoryErrInvalid, Message: "reorder_threshold and safety_stock must not be negative"})
+		return
+	}
+	p, err := s.repo.SetReorderPolicy(ctx, req.ProductID, req.ReorderThreshold, req.SafetyStock)
+	if err != nil {
+		s.answer(ctx, queue, msg, inventoryError(ctx, req.ProductID, err))
+		return
+	}
+	msgLog.InfoContext(ctx, "Reorder policy set", "reorder_threshold", p.ReorderThreshold, "safety_stock", p.SafetyStock)
+	s.answer(ctx, queue, msg, p.details())
+}
+
+// handleReorderReport replies with reorder suggestions for every product
+// that needs reordering.
+func (s *Service) handleReorderReport(ctx context.Context, queue string, msg broker.Delivery, req contracts.ReorderReport) {
+	if req.WindowDays == 0 {
+		req.WindowDays = defaultWindowDays
+	}
+	if req.CoverDays == 0 {
+		req.CoverDays = defaultCoverDays
+	}
+	if req.WindowDays < 0 || req.WindowDays > maxReportDays || req.CoverDays < 0 || req.CoverDays > maxReportDays {
+		s.answer(ctx, queue, msg, contracts.InventoryError{Code: contracts.InventoryErrInvalid, Message: "window_days and cover_days must be between 1 and 365"})
+		return
+	}
+	products, err := s.repo.ListProducts(ctx)
+	if err != nil {
+		s.answer(ctx, queue, msg, inventoryError(ctx, 0, err))
+		return
+	}
+	// The window ends with today.
+	consumed, err := s.repo.Consumption(ctx, time.Now().AddDate(0, 0, 1-req.WindowDays))
+	if err != nil {
+		s.answer(ctx, queue, msg, inventoryError(ctx, 0, err))
+		return
+	}
+	result := contracts.ReorderReportResult{WindowDays: req.WindowDays, CoverDays: req.CoverDays, Suggestions: []contracts.ReorderSuggestion{}}
+	for _, p := range products {
+		if suggestion, ok := s.suggestReorder(p, consumed[p.ProductID], req.WindowDays, req.CoverDays); ok {
+			result.Suggestions = append(result.Suggestions, suggestion)
+		}
+	}
+	msgLog.InfoContext(ctx, "Reorder report built", "window_days", req.WindowDays, "cover_days", req.CoverDays, "suggestions", len(result.Suggestions))
+	s.answer(ctx, queue, msg, result)
+}
+
+// suggestReorder suggests how many units of p to order so that its stock
+// lasts coverDays at the rate consumed units were consumed over windowDays,
+// with its safety stock left over. It reports false if p needs none.
+func (s *Service) suggestReorder(p Product, consumed, windowDays, coverDays int) (contracts.ReorderSuggestion, bool) {
+	// Releases of units reserved before the window can outweigh the
+	// reservations in it.
+	consumed = max(consumed, 0)
+	// Round the demand over the cover up, in whole units.
+	demand := (consumed*coverDays + windowDays - 1) / windowDays
+	quantity := demand + p.SafetyStock - p.Stock
+	if quantity <= 0 {
+		return contracts.ReorderSuggestion{}, false
+	}
+	return contracts.ReorderSuggestion{
+		ProductID:        p.ProductID,
+		Name:             p.Name,
+		Stock:            p.Stock,
+		ReorderThreshold: s.threshold(p),
+		SafetyStock:      p.SafetyStock,
+		Consumed:         consumed,
+		DailyVelocity:    math.Round(float64(consumed)/float64(windowDays)*100) / 100,
+		Quantity:         quantity,
+	}, true
+}
@@ -0,0 +1,122 @@
+package inventory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+func TestLowStockFollowsReorderPolicy(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryInventory()
+	repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 30, ReorderThreshold: 20, SafetyStock: 5})
+	repo.SaveProduct(ctx, Product{ProductID: 102, Name: "Beer", Stock: 12})
+	b, replyQueue := newTestBroker(t)
+	b.DeclareQueue("events", broker.QueueOptions{})
+	b.BindQueue("events", "stock.*", contracts.EventsExchange)
+	svc := NewService(repo, b)
+	svc.SetLowStockThreshold(10)
+
+	// 101 crosses its own threshold at 30 -> 18 and its safety stock at
+	// 18 -> 3; 102 crosses the default threshold when adjusted 12 -> 9.
+	for _, req := range []struct {
+		queue string
+		p     contracts.Payload
+	}{
+		{"check_stock", contracts.StockCheck{ProductID: 101, Quantity: 12, Reserve: true}},
+		{"inventory_requests", contracts.StockAdjust{ProductID: 101, Quantity: -15, Reason: "damaged"}},
+		{"inventory_requests", contracts.StockAdjust{ProductID: 101, Quantity: 1, Reason: "found"}},
+		{"inventory_requests", contracts.StockAdjust{ProductID: 102, Quantity: -3, Reason: "stock count"}},
+	} {
+		svc.dispatch(req.queue, deliver(t, b, req.queue, broker.Message{ReplyTo: replyQueue, Body: envelope(t, req.p)}))
+	}
+
+	var events []contracts.StockLow
+	for _, e := range b.Pending("events") {
+		events = append(events, decodeReply[contracts.StockLow](t, e))
+	}
+	want := []contracts.StockLow{
+		{ProductID: 101, Stock: 18, Threshold: 20, SafetyStock: 5},
+		{ProductID: 101, Stock: 3, Threshold: 20, SafetyStock: 5},
+		{ProductID: 102, Stock: 9, Threshold: 10},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %+v, want %+v", events, want)
+	}
+}
+
+func TestInventoryRequests(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryInventory()
+	repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 50})
+	repo.SaveProduct(ctx, Product{ProductID: 102, Name: "Beer", Stock: 40})
+	repo.SaveProduct(ctx, Product{ProductID: 103, Name: "Snacks", Stock: 1, SafetyStock: 4})
+	b, replyQueue := newTestBroker(t)
+	svc := NewService(repo, b)
+	svc.SetLowStockThreshold(10)
+
+	request := func(p contracts.Payload) contracts.Payload {
+		t.Helper()
+		svc.dispatch("inventory_requests", deliver(t, b, "inventory_requests", broker.Message{ReplyTo: replyQueue, Body: envelope(t, p)}))
+		replies := b.Pending(replyQueue)
+		if len(replies) == 0 {
+			t.Fatal("no reply")
+		}
+		_, payload, err := contracts.Decode(replies[len(replies)-1].ContentType, replies[len(replies)-1].Body)
+		if err != nil {
+			t.Fatalf("invalid reply: %v", err)
+		}
+		return payload
+	}
+
+	got := request(contracts.ReorderPolicySet{ProductID: 101, ReorderThreshold: 25, SafetyStock: 10})
+	if want := (contracts.ProductDetails{ProductID: 101, Name: "Broccoli", Stock: 50, ReorderThreshold: 25, SafetyStock: 10}); got != want {
+		t.Fatalf("policy reply = %+v, want %+v", got, want)
+	}
+	if got := request(contracts.StockAdjust{ProductID: 102, Quantity: 5}); got.(contracts.ProductDetails).Stock != 45 {
+		t.Fatalf("adjust reply = %+v, want 45 in stock", got)
+	}
+	if got := request(contracts.ProductGet{ProductID: 102}); got.(contracts.ProductDetails).Stock != 45 {
+		t.Fatalf("get reply = %+v, want 45 in stock", got)
+	}
+
+	// 30 units of 101 consumed in a 30-day window is one a day: 14 days
+	// take 14 units plus 10 safety stock, 24 in all, and 20 are left.
+	// Beer's consumption is covered by its stock, and Snacks were never
+	// consumed but are short of their safety stock.
+	for i := 0; i < 3; i++ {
+		svc.dispatch("check_stock", deliver(t, b, "check_stock", broker.Message{ReplyTo: replyQueue, Body: envelope(t, contracts.StockCheck{ProductID: 101, Quantity: 10, Reserve: true})}))
+	}
+	svc.dispatch("check_stock", deliver(t, b, "check_stock", broker.Message{ReplyTo: replyQueue, Body: envelope(t, contracts.StockCheck{ProductID: 102, Quantity: 5, Reserve: true})}))
+	report, ok := request(contracts.ReorderReport{CoverDays: 14}).(contracts.ReorderReportResult)
+	want := contracts.ReorderReportResult{WindowDays: 30, CoverDays: 14, Suggestions: []contracts.ReorderSuggestion{
+		{ProductID: 101, Name: "Broccoli", Stock: 20, ReorderThreshold: 25, SafetyStock: 10, Consumed: 30, DailyVelocity: 1, Quantity: 4},
+		{ProductID: 103, Name: "Snacks", Stock: 1, ReorderThreshold: 10, SafetyStock: 4, Quantity: 3},
+	}}
+	if !ok || !reflect.DeepEqual(report, want) {
+		t.Fatalf("report = %+v, want %+v", report, want)
+	}
+
+	for _, tt := range []struct {
+		req  contracts.Payload
+		code string
+	}{
+		{contracts.ProductGet{ProductID: 404}, contracts.InventoryErrNotFound},
+		{contracts.StockAdjust{ProductID: 404, Quantity: 1}, contracts.InventoryErrNotFound},
+		{contracts.StockAdjust{ProductID: 103, Quantity: -2}, contracts.InventoryErrInsufficientStock},
+		{contracts.StockAdjust{ProductID: 103}, contracts.InventoryErrInvalid},
+		{contracts.ReorderPolicySet{ProductID: 101, SafetyStock: -1}, contracts.InventoryErrInvalid},
+		{contracts.ReorderPolicySet{ProductID: 404}, contracts.InventoryErrNotFound},
+		{contracts.ReorderReport{WindowDays: 366}, contracts.InventoryErrInvalid},
+	} {
+		if reply, ok := request(tt.req).(contracts.InventoryError); !ok || reply.Code != tt.code {
+			t.Errorf("%T%+v reply = %+v, want a %s error", tt.req, tt.req, reply, tt.code)
+		}
+	}
+	if stock, _ := repo.Stock(ctx, 103); stock != 1 {
+		t.Errorf("stock after refused adjustment = %d, want 1", stock)
+	}
+}
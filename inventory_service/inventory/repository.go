@@ -0,0 +1,342 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"ecomm-sample/contracts"
+)
+
+// ErrProductNotFound is returned when a product has no inventory row.
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrInsufficientStock is returned when a movement would take a product's
+// stock below zero.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrReservationConflict is returned for a reservation for an order that
+// already holds other units, or whose reservation was released.
+var ErrReservationConflict = errors.New("order holds another or a released reservation")
+
+// savedReason is the reason of the adjustments SaveProduct records.
+const savedReason = "product saved"
+
+// Product is one row of the inventory table. ReorderThreshold and
+// SafetyStock are its reorder policy; a ReorderThreshold of 0 leaves it at
+// the service's default.
+type Product struct {
+	ProductID        int    `json:"product_id"`
+	Name             string `json:"name"`
+	Stock            int    `json:"stock"`
+	ReorderThreshold int    `json:"reorder_threshold,omitempty"`
+	SafetyStock      int    `json:"safety_stock,omitempty"`
+}
+
+// Movement is one entry in a product's stock ledger: a change of Quantity
+// units, negative when units are taken out, of one of the
+// contracts.Movement* types. Reference is what it belongs to, such as an
+// order or a purchase order. MovementID, CreatedAt and Balance, the stock
+// the ledger adds up to after the movement, are set by the repository.
+type Movement struct {
+	MovementID int64
+	ProductID  int
+	Type       string
+	Quantity   int
+	Balance    int
+	Reason     string
+	Actor      string
+	Reference  string
+	CreatedAt  time.Time
+}
+
+// Drift is a product whose stock differs from the sum of its movements.
+type Drift struct {
+	ProductID int
+	Stock     int
+	Ledger    int
+}
+
+// consumes reports whether a movement of movementType counts towards
+// consumption: reservations and sales do, and releases and returns take
+// it back.
+func consumes(movementType string) bool {
+	switch movementType {
+	case contracts.MovementReservation, contracts.MovementRelease, contracts.MovementSale, contracts.MovementReturn:
+		return true
+	}
+	return false
+}
+
+// InventoryRepository is the service's view of its stock levels. Stock
+// only changes together with the product's stock ledger.
+type InventoryRepository interface {
+	// Stock returns the units on hand for productID, or ErrProductNotFound.
+	Stock(ctx context.Context, productID int) (int, error)
+	// Product returns productID's row, or ErrProductNotFound.
+	Product(ctx context.Context, productID int) (Product, error)
+	// Move changes the stock of m.ProductID by m.Quantity and appends m to
+	// its ledger in one transaction, counting m as consumed today if it
+	// consumes, and returns the product. It changes nothing and returns
+	// ErrInsufficientStock if m would take stock below zero.
+	Move(ctx context.Context, m Movement) (Product, error)
+	// Reserve moves m, a reservation, like Move and records it as orderID's
+	// reservation, returning true. An order is reserved for once: if
+	// orderID already holds m's units it changes nothing and returns the
+	// product and false, and if it holds other units or was released it
+	// returns ErrReservationConflict.
+	Reserve(ctx context.Context, orderID int, m Movement) (Product, bool, error)
+	// Release moves m, a release, like Move if orderID holds a reservation
+	// of m's units, marks the reservation released and returns true. It
+	// changes nothing and returns false for a released reservation or one
+	// of other units. A release for an order without a reservation is
+	// recorded as a released one, so that a reservation arriving later is
+	// refused.
+	Release(ctx context.Context, orderID int, m Movement) (bool, error)
+	// Movements returns the latest limit movements of productID, newest
+	// first.
+	Movements(ctx context.Context, productID, limit int) ([]Movement, error)
+	// Reconcile returns every product whose stock differs from the sum of
+	// its movements, ordered by ID.
+	Reconcile(ctx context.Context) ([]Drift, error)
+	// SetReorderPolicy sets productID's reorder threshold and safety stock
+	// and returns the product.
+	SetReorderPolicy(ctx context.Context, productID, threshold, safetyStock int) (Product, error)
+	// Consumption returns how many units of each product were consumed
+	// from the UTC day of since on, by product ID. Products without
+	// consumption are left out.
+	Consumption(ctx context.Context, since time.Time) (map[int]int, error)
+	// SaveProduct creates the product or replaces its name, stock and
+	// reorder policy, recording a change in stock as an adjustment.
+	SaveProduct(ctx context.Context, p Product) error
+	// ListProducts returns every product ordered by ID.
+	ListProducts(ctx context.Context) ([]Product, error)
+	// Ping reports whether the underlying store is reachable.
+	Ping(ctx context.Context) error
+	// SchemaVersion returns the version of the store's schema.
+	SchemaVersion(ctx context.Context) (int, error)
+}
+
+// MemoryInventory is an InventoryRepository held in a map, for tests.
+type MemoryInventory struct {
+	mu       sync.Mutex
+	products map[int]Product
+	// consumed holds the units consumed per product and UTC day.
+	consumed     map[int]map[time.Time]int
+	movements    []Movement
+	reservations map[int]reservation
+}
+
+// reservation is the units reserved for an order.
+type reservation struct {
+	productID, quantity int
+	released            bool
+}
+
+// NewMemoryInventory returns an empty in-memory repository.
+func NewMemoryInventory() *MemoryInventory {
+	return &MemoryInventory{
+		products:     make(map[int]Product),
+		consumed:     make(map[int]map[time.Time]int),
+		reservations: make(map[int]reservation),
+	}
+}
+
+// day returns the start of t's UTC day.
+func day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// consume counts quantity units of productID as consumed today. The caller
+// holds m.mu.
+func (m *MemoryInventory) consume(productID, quantity int) {
+	if m.consumed[productID] == nil {
+		m.consumed[productID] = make(map[time.Time]int)
+	}
+	m.consumed[productID][day(time.Now())] += quantity
+}
+
+func (m *MemoryInventory) Product(ctx context.Context, productID int) (Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.products[productID]
+	if !ok {
+		return Product{}, ErrProductNotFound
+	}
+	return p, nil
+}
+
+func (m *MemoryInventory) Stock(ctx context.Context, productID int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.products[productID]
+	if !ok {
+		return 0, ErrProductNotFound
+	}
+	return p.Stock, nil
+}
+
+// record appends mv to the ledger. The caller holds m.mu.
+func (m *MemoryInventory) record(mv Movement) {
+	mv.MovementID = int64(len(m.movements) + 1)
+	mv.CreatedAt = time.Now()
+	m.movements = append(m.movements, mv)
+}
+
+func (m *MemoryInventory) Move(ctx context.Context, mv Movement) (Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.move(mv)
+}
+
+func (m *MemoryInventory) Reserve(ctx context.Context, orderID int, mv Movement) (Product, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if r, ok := m.reservations[orderID]; ok {
+		if r.released || r.productID != mv.ProductID || r.quantity != -mv.Quantity {
+			return Product{}, false, ErrReservationConflict
+		}
+		return m.products[mv.ProductID], false, nil
+	}
+	p, err := m.move(mv)
+	if err != nil {
+		return Product{}, false, err
+	}
+	m.reservations[orderID] = reservation{productID: mv.ProductID, quantity: -mv.Quantity}
+	return p, true, nil
+}
+
+func (m *MemoryInventory) Release(ctx context.Context, orderID int, mv Movement) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.reservations[orderID]
+	if !ok {
+		m.reservations[orderID] = reservation{productID: mv.ProductID, quantity: mv.Quantity, released: true}
+		return false, nil
+	}
+	if r.released || r.productID != mv.ProductID || r.quantity != mv.Quantity {
+		return false, nil
+	}
+	if _, err := m.move(mv); err != nil {
+		return false, err
+	}
+	r.released = true
+	m.reservations[orderID] = r
+	return true, nil
+}
+
+// move applies mv like Move. The caller holds m.mu.
+func (m *MemoryInventory) move(mv Movement) (Product, error) {
+	p, ok := m.products[mv.ProductID]
+	if !ok {
+		return Product{}, ErrProductNotFound
+	}
+	if p.Stock+mv.Quantity < 0 {
+		return Product{}, ErrInsufficientStock
+	}
+	p.Stock += mv.Quantity
+	m.products[mv.ProductID] = p
+	m.record(mv)
+	if consumes(mv.Type) {
+		m.consume(mv.ProductID, -mv.Quantity)
+	}
+	return p, nil
+}
+
+func (m *MemoryInventory) Movements(ctx context.Context, productID, limit int) ([]Movement, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var movements []Movement
+	balance := 0
+	for _, mv := range m.movements {
+		if mv.ProductID == productID {
+			balance += mv.Quantity
+			mv.Balance = balance
+			movements = append(movements, mv)
+		}
+	}
+	latest := make([]Movement, 0, min(len(movements), limit))
+	for i := len(movements) - 1; i >= 0 && len(latest) < limit; i-- {
+		latest = append(latest, movements[i])
+	}
+	return latest, nil
+}
+
+func (m *MemoryInventory) Reconcile(ctx context.Context) ([]Drift, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ledger := make(map[int]int)
+	for _, mv := range m.movements {
+		ledger[mv.ProductID] += mv.Quantity
+	}
+	drifts := []Drift{}
+	for _, p := range m.products {
+		if p.Stock != ledger[p.ProductID] {
+			drifts = append(drifts, Drift{ProductID: p.ProductID, Stock: p.Stock, Ledger: ledger[p.ProductID]})
+		}
+	}
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].ProductID < drifts[j].ProductID })
+	return drifts, nil
+}
+
+func (m *MemoryInventory) SetReorderPolicy(ctx context.Context, productID, threshold, safetyStock int) (Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.products[productID]
+	if !ok {
+		return Product{}, ErrProductNotFound
+	}
+	p.ReorderThreshold, p.SafetyStock = threshold, safetyStock
+	m.products[productID] = p
+	return p, nil
+}
+
+func (m *MemoryInventory) Consumption(ctx context.Context, since time.Time) (map[int]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	consumed := make(map[int]int)
+	for productID, days := range m.consumed {
+		for d, quantity := range days {
+			if !d.Before(day(since)) {
+				consumed[productID] += quantity
+			}
+		}
+		if consumed[productID] == 0 {
+			delete(consumed, productID)
+		}
+	}
+	return consumed, nil
+}
+
+func (m *MemoryInventory) SaveProduct(ctx context.Context, p Product) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if change := p.Stock - m.products[p.ProductID].Stock; change != 0 {
+		m.record(Movement{ProductID: p.ProductID, Type: contracts.MovementAdjustment, Quantity: change, Reason: savedReason})
+	}
+	m.products[p.ProductID] = p
+	return nil
+}
+
+func (m *MemoryInventory) ListProducts(ctx context.Context) ([]Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	products := make([]Product, 0, len(m.products))
+	for _, p := range m.products {
+		products = append(products, p)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ProductID < products[j].ProductID })
+	return products, nil
+}
+
+func (m *MemoryInventory) Ping(ctx context.Context) error {
+	return nil
+}
+
+// SchemaVersion reports the latest version: a memory store has no schema to
+// fall behind.
+func (m *MemoryInventory) SchemaVersion(ctx context.Context) (int, error) {
+	return SchemaVersion, nil
+}
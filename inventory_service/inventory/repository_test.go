@@ -0,0 +1,350 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"ecomm-sample/contracts"
+)
+
+// testInventoryRepository is the contract every InventoryRepository must meet.
+// newRepo returns an empty repository.
+func testInventoryRepository(t *testing.T, newRepo func(t *testing.T) InventoryRepository) {
+	ctx := context.Background()
+
+	t.Run("unknown product", func(t *testing.T) {
+		repo := newRepo(t)
+		if _, err := repo.Stock(ctx, 404); !errors.Is(err, ErrProductNotFound) {
+			t.Fatalf("Stock error = %v, want ErrProductNotFound", err)
+		}
+	})
+
+	t.Run("save and read back", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 10}); err != nil {
+			t.Fatalf("SaveProduct: %v", err)
+		}
+		stock, err := repo.Stock(ctx, 101)
+		if err != nil || stock != 10 {
+			t.Fatalf("Stock = %d, %v; want 10", stock, err)
+		}
+	})
+
+	t.Run("save replaces", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 10})
+		if err := repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Green broccoli", Stock: 3}); err != nil {
+			t.Fatalf("SaveProduct: %v", err)
+		}
+		products, err := repo.ListProducts(ctx)
+		if err != nil {
+			t.Fatalf("ListProducts: %v", err)
+		}
+		want := []Product{{ProductID: 101, Name: "Green broccoli", Stock: 3}}
+		if !reflect.DeepEqual(products, want) {
+			t.Fatalf("ListProducts = %+v, want %+v", products, want)
+		}
+	})
+
+	t.Run("list is ordered by ID", func(t *testing.T) {
+		repo := newRepo(t)
+		if products, err := repo.ListProducts(ctx); err != nil || len(products) != 0 {
+			t.Fatalf("ListProducts on empty repository = %+v, %v", products, err)
+		}
+		for _, id := range []int{103, 101, 102} {
+			repo.SaveProduct(ctx, Product{ProductID: id, Name: "p", Stock: id})
+		}
+		products, err := repo.ListProducts(ctx)
+		if err != nil {
+			t.Fatalf("ListProducts: %v", err)
+		}
+		var ids []int
+		for _, p := range products {
+			ids = append(ids, p.ProductID)
+		}
+		if !reflect.DeepEqual(ids, []int{101, 102, 103}) {
+			t.Fatalf("IDs = %v, want [101 102 103]", ids)
+		}
+	})
+
+	// move changes productID's stock by quantity with a movement of
+	// movementType.
+	move := func(repo InventoryRepository, productID int, movementType string, quantity int) (Product, error) {
+		return repo.Move(ctx, Movement{ProductID: productID, Type: movementType, Quantity: quantity})
+	}
+
+	t.Run("reserve and release", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 5})
+
+		if p, err := move(repo, 101, contracts.MovementReservation, -3); err != nil || p.Stock != 2 {
+			t.Fatalf("reserve 3 = %+v, %v; want 2 left", p, err)
+		}
+		if _, err := move(repo, 101, contracts.MovementReservation, -3); !errors.Is(err, ErrInsufficientStock) {
+			t.Fatalf("reserve 3 with 2 left error = %v, want ErrInsufficientStock", err)
+		}
+		if stock, _ := repo.Stock(ctx, 101); stock != 2 {
+			t.Fatalf("stock after reservation = %d, want 2", stock)
+		}
+		if _, err := move(repo, 101, contracts.MovementRelease, 3); err != nil {
+			t.Fatalf("release: %v", err)
+		}
+		if stock, _ := repo.Stock(ctx, 101); stock != 5 {
+			t.Fatalf("stock after release = %d, want 5", stock)
+		}
+	})
+
+	t.Run("reservations per order", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 5})
+		reservation := Movement{ProductID: 101, Type: contracts.MovementReservation, Quantity: -3}
+		release := Movement{ProductID: 101, Type: contracts.MovementRelease, Quantity: 3}
+
+		if p, reserved, err := repo.Reserve(ctx, 42, reservation); err != nil || !reserved || p.Stock != 2 {
+			t.Fatalf("Reserve = %+v, %v, %v; want 2 left", p, reserved, err)
+		}
+		if p, reserved, err := repo.Reserve(ctx, 42, reservation); err != nil || reserved || p.Stock != 2 {
+			t.Fatalf("Reserve again = %+v, %v, %v; want 2 left and nothing reserved", p, reserved, err)
+		}
+		other := Movement{ProductID: 101, Type: contracts.MovementReservation, Quantity: -1}
+		if _, _, err := repo.Reserve(ctx, 42, other); !errors.Is(err, ErrReservationConflict) {
+			t.Fatalf("Reserve of other units = %v, want ErrReservationConflict", err)
+		}
+		if released, err := repo.Release(ctx, 42, Movement{ProductID: 101, Type: contracts.MovementRelease, Quantity: 1}); err != nil || released {
+			t.Fatalf("Release of other units = %v, %v; want nothing released", released, err)
+		}
+		for i, want := range []bool{true, false} {
+			if released, err := repo.Release(ctx, 42, release); err != nil || released != want {
+				t.Fatalf("Release #%d = %v, %v; want %v", i+1, released, err, want)
+			}
+		}
+		if stock, _ := repo.Stock(ctx, 101); stock != 5 {
+			t.Fatalf("stock after release = %d, want 5", stock)
+		}
+		if _, _, err := repo.Reserve(ctx, 42, reservation); !errors.Is(err, ErrReservationConflict) {
+			t.Fatalf("Reserve after release = %v, want ErrReservationConflict", err)
+		}
+
+		// A release that overtakes its reservation keeps it from
+		// happening.
+		if released, err := repo.Release(ctx, 43, release); err != nil || released {
+			t.Fatalf("Release before Reserve = %v, %v; want nothing released", released, err)
+		}
+		if _, _, err := repo.Reserve(ctx, 43, reservation); !errors.Is(err, ErrReservationConflict) {
+			t.Fatalf("Reserve after an early release = %v, want ErrReservationConflict", err)
+		}
+		if _, _, err := repo.Reserve(ctx, 44, Movement{ProductID: 101, Type: contracts.MovementReservation, Quantity: -9}); !errors.Is(err, ErrInsufficientStock) {
+			t.Fatalf("Reserve beyond stock = %v, want ErrInsufficientStock", err)
+		}
+		if _, _, err := repo.Reserve(ctx, 44, reservation); err != nil {
+			t.Fatalf("Reserve after one refused for stock: %v", err)
+		}
+		if stock, _ := repo.Stock(ctx, 101); stock != 2 {
+			t.Fatalf("stock = %d, want 2", stock)
+		}
+	})
+
+	t.Run("reorder policy", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 5, ReorderThreshold: 8})
+		p, err := repo.SetReorderPolicy(ctx, 101, 12, 3)
+		want := Product{ProductID: 101, Name: "Broccoli", Stock: 5, ReorderThreshold: 12, SafetyStock: 3}
+		if err != nil || p != want {
+			t.Fatalf("SetReorderPolicy = %+v, %v; want %+v", p, err, want)
+		}
+		if p, err := repo.Product(ctx, 101); err != nil || p != want {
+			t.Fatalf("Product = %+v, %v; want %+v", p, err, want)
+		}
+		if _, err := repo.SetReorderPolicy(ctx, 404, 1, 1); !errors.Is(err, ErrProductNotFound) {
+			t.Fatalf("SetReorderPolicy error = %v, want ErrProductNotFound", err)
+		}
+		if _, err := repo.Product(ctx, 404); !errors.Is(err, ErrProductNotFound) {
+			t.Fatalf("Product error = %v, want ErrProductNotFound", err)
+		}
+	})
+
+	t.Run("adjust", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 5})
+		if p, err := move(repo, 101, contracts.MovementAdjustment, -5); err != nil || p.Stock != 0 {
+			t.Fatalf("adjust -5 = %+v, %v; want 0 in stock", p, err)
+		}
+		if _, err := move(repo, 101, contracts.MovementAdjustment, -1); !errors.Is(err, ErrInsufficientStock) {
+			t.Fatalf("adjust -1 with none left error = %v, want ErrInsufficientStock", err)
+		}
+		if p, err := move(repo, 101, contracts.MovementReceipt, 7); err != nil || p.Stock != 7 {
+			t.Fatalf("receive 7 = %+v, %v; want 7 in stock", p, err)
+		}
+		if _, err := move(repo, 404, contracts.MovementReceipt, 1); !errors.Is(err, ErrProductNotFound) {
+			t.Fatalf("receive error = %v, want ErrProductNotFound", err)
+		}
+	})
+
+	t.Run("consumption", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 10})
+		repo.SaveProduct(ctx, Product{ProductID: 102, Name: "Beer", Stock: 10})
+		move(repo, 101, contracts.MovementReservation, -3)
+		move(repo, 101, contracts.MovementReservation, -4)
+		move(repo, 101, contracts.MovementRelease, 2)
+		move(repo, 101, contracts.MovementReservation, -20)
+		move(repo, 101, contracts.MovementSale, -1)
+		move(repo, 102, contracts.MovementReservation, -1)
+		move(repo, 102, contracts.MovementRelease, 1)
+		move(repo, 102, contracts.MovementAdjustment, -5)
+		move(repo, 102, contracts.MovementTransfer, -2)
+		move(repo, 102, contracts.MovementReceipt, 3)
+
+		// Adjustments, transfers and receipts are not consumption, and
+		// neither are reservations that were released again.
+		consumed, err := repo.Consumption(ctx, time.Now())
+		if want := map[int]int{101: 6}; err != nil || !reflect.DeepEqual(consumed, want) {
+			t.Fatalf("Consumption = %v, %v; want %v", consumed, err, want)
+		}
+		if consumed, err := repo.Consumption(ctx, time.Now().AddDate(0, 0, 1)); err != nil || len(consumed) != 0 {
+			t.Fatalf("Consumption from tomorrow = %v, %v; want none", consumed, err)
+		}
+	})
+
+	t.Run("reserve unknown product", func(t *testing.T) {
+		repo := newRepo(t)
+		if _, err := move(repo, 404, contracts.MovementReservation, -1); !errors.Is(err, ErrProductNotFound) {
+			t.Fatalf("reserve error = %v, want ErrProductNotFound", err)
+		}
+		if _, err := move(repo, 404, contracts.MovementRelease, 1); !errors.Is(err, ErrProductNotFound) {
+			t.Fatalf("release error = %v, want ErrProductNotFound", err)
+		}
+	})
+
+	t.Run("ledger", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 10})
+		repo.SaveProduct(ctx, Product{ProductID: 102, Name: "Beer", Stock: 4})
+		repo.Move(ctx, Movement{ProductID: 101, Type: contracts.MovementReservation, Quantity: -3, Actor: "api_gateway", Reference: "order:42"})
+		repo.Move(ctx, Movement{ProductID: 101, Type: contracts.MovementReceipt, Quantity: 20, Reason: "delivery", Actor: "ada", Reference: "PO-1001"})
+		repo.Move(ctx, Movement{ProductID: 101, Type: contracts.MovementReservation, Quantity: -99})
+		repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 25})
+
+		movements, err := repo.Movements(ctx, 101, 3)
+		if err != nil {
+			t.Fatalf("Movements: %v", err)
+		}
+		type entry struct {
+			Type               string
+			Quantity, Balance  int
+			Reason, Actor, Ref string
+		}
+		var got []entry
+		for _, m := range movements {
+			if m.ProductID != 101 || m.MovementID == 0 || m.CreatedAt.IsZero() {
+				t.Errorf("movement %+v lacks its product, ID or time", m)
+			}
+			got = append(got, entry{m.Type, m.Quantity, m.Balance, m.Reason, m.Actor, m.Reference})
+		}
+		// The refused reservation left no trace, and saving the product
+		// recorded the change in stock.
+		want := []entry{
+			{contracts.MovementAdjustment, -2, 25, savedReason, "", ""},
+			{contracts.MovementReceipt, 20, 27, "delivery", "ada", "PO-1001"},
+			{contracts.MovementReservation, -3, 7, "", "api_gateway", "order:42"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Movements = %+v, want %+v", got, want)
+		}
+		if movements, err := repo.Movements(ctx, 404, 3); err != nil || len(movements) != 0 {
+			t.Fatalf("Movements of unknown product = %+v, %v; want none", movements, err)
+		}
+		if drifts, err := repo.Reconcile(ctx); err != nil || len(drifts) != 0 {
+			t.Fatalf("Reconcile = %+v, %v; want no drift", drifts, err)
+		}
+	})
+
+	t.Run("schema version", func(t *testing.T) {
+		if version, err := newRepo(t).SchemaVersion(ctx); err != nil || version != SchemaVersion {
+			t.Fatalf("SchemaVersion = %d, %v; want %d", version, err, SchemaVersion)
+		}
+	})
+
+	t.Run("ping", func(t *testing.T) {
+		if err := newRepo(t).Ping(ctx); err != nil {
+			t.Fatalf("Ping: %v", err)
+		}
+	})
+}
+
+func TestMemoryInventory(t *testing.T) {
+	testInventoryRepository(t, func(*testing.T) InventoryRepository { return NewMemoryInventory() })
+}
+
+func TestMemoryInventoryReconcile(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryInventory()
+	repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 10})
+	repo.SaveProduct(ctx, Product{ProductID: 102, Name: "Beer", Stock: 4})
+	// Change stock around the ledger, as a manual database update would.
+	repo.products[102] = Product{ProductID: 102, Name: "Beer", Stock: 7}
+	repo.products[103] = Product{ProductID: 103, Name: "Snacks", Stock: 1}
+
+	drifts, err := repo.Reconcile(ctx)
+	want := []Drift{{ProductID: 102, Stock: 7, Ledger: 4}, {ProductID: 103, Stock: 1}}
+	if err != nil || !reflect.DeepEqual(drifts, want) {
+		t.Fatalf("Reconcile = %+v, %v; want %+v", drifts, err, want)
+	}
+}
+
+// TestPostgresInventory runs the contract against the database named by
+// TEST_DATABASE_URL, migrated to the latest schema. The inventory table,
+// and the consumption and movements that refer to it, is emptied before
+// each case.
+func TestPostgresInventory(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db := openTestDB(t, dsn, "inventory_test")
+
+	testInventoryRepository(t, func(t *testing.T) InventoryRepository {
+		if _, err := db.Exec("TRUNCATE inventory CASCADE"); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return NewPostgresInventory(db)
+	})
+}
+
+// openTestDB opens dsn with its search_path set to schema and applies the
+// migrations there, so each service's tables and schema_migrations stay
+// apart when the packages share one test database.
+func openTestDB(t *testing.T, dsn, schema string) *sql.DB {
+	t.Helper()
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer admin.Close()
+	if _, err := admin.Exec("CREATE SCHEMA IF NOT EXISTS " + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("TEST_DATABASE_URL must be a postgres:// URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := NewMigrator(db).Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
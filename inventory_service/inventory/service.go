@@ -0,0 +1,473 @@
+// Package inventory answers stock requests for the inventory service.
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "inventory_service"
+
+var tracer = otel.Tracer(serviceName)
+
+// msgLog is the sampled logger for per-message logs.
+var msgLog = slog.Default()
+
+// SetupLogging installs the service's default and per-message loggers, as
+// described at observability.SetupLogging.
+func SetupLogging() {
+	msgLog = observability.SetupLogging(serviceName)
+}
+
+// SetupTracing installs the service's tracer provider, as described at
+// observability.SetupTracing. The returned function flushes pending spans.
+func SetupTracing() func(context.Context) error {
+	return observability.SetupTracing(serviceName)
+}
+
+// Service answers stock, inventory and health-check requests from the
+// repository.
+type Service struct {
+	repo      InventoryRepository
+	broker    broker.Broker
+	encodings contracts.Encodings
+	lowStock  int
+}
+
+// NewService returns a service that announces no low stock until
+// SetLowStockThreshold is called.
+func NewService(repo InventoryRepository, b broker.Broker) *Service {
+	return &Service{repo: repo, broker: b}
+}
+
+// SetEncodings sets the encoding of the messages the service publishes to
+// each queue or exchange. Replies are always encoded like the request.
+func (s *Service) SetEncodings(e contracts.Encodings) {
+	s.encodings = e
+}
+
+// SetLowStockThreshold sets the stock level below which the stock of a
+// product without a reorder threshold of its own is low; 0 leaves such
+// products without one. See checkLowStock.
+func (s *Service) SetLowStockThreshold(n int) {
+	s.lowStock = n
+}
+
+// declareTopology declares the queues and exchanges this service consumes,
+// and the events exchange it publishes to.
+func declareTopology(b broker.Broker) error {
+	for _, queue := range []string{"check_stock", "release_stock", "inventory_requests"} {
+		if err := broker.DeclareWorkQueue(b, queue); err != nil {
+			return err
+		}
+	}
+	if err := b.DeclareExchange("health_check_exchange", broker.Fanout); err != nil {
+		return fmt.Errorf("declare fanout exchange: %w", err)
+	}
+	if err := b.DeclareExchange(contracts.EventsExchange, broker.Topic); err != nil {
+		return fmt.Errorf("declare events exchange: %w", err)
+	}
+	return nil
+}
+
+// Start declares the service's topology and handles its queues in the
+// background until ctx is cancelled.
+func (s *Service) Start(ctx context.Context) error {
+	if err := declareTopology(s.broker); err != nil {
+		return err
+	}
+
+	stockRequests, err := s.broker.Consume(ctx, "check_stock")
+	if err != nil {
+		return fmt.Errorf("consume check_stock queue: %w", err)
+	}
+	releases, err := s.broker.Consume(ctx, "release_stock")
+	if err != nil {
+		return fmt.Errorf("consume release_stock queue: %w", err)
+	}
+	inventoryRequests, err := s.broker.Consume(ctx, "inventory_requests")
+	if err != nil {
+		return fmt.Errorf("consume inventory_requests queue: %w", err)
+	}
+
+	// Declare a unique, auto-deleted queue for this instance and bind it
+	// to the fanout exchange
+	healthQueue, err := s.broker.DeclareQueue("", broker.QueueOptions{AutoDelete: true, Exclusive: true})
+	if err != nil {
+		return fmt.Errorf("declare health-check queue: %w", err)
+	}
+	if err := s.broker.BindQueue(healthQueue, "", "health_check_exchange"); err != nil {
+		return fmt.Errorf("bind health-check queue: %w", err)
+	}
+	healthChecks, err := s.broker.Consume(ctx, healthQueue)
+	if err != nil {
+		return fmt.Errorf("consume health-check queue: %w", err)
+	}
+
+	go s.serve("check_stock", stockRequests)
+	go s.serve("release_stock", releases)
+	go s.serve("inventory_requests", inventoryRequests)
+	go s.serve("health_check", healthChecks)
+	slog.Info("Stock Service processing check_stock, release_stock, inventory_requests and health_check queues")
+	return nil
+}
+
+// serve dispatches every message from msgs.
+func (s *Service) serve(queue string, msgs <-chan broker.Delivery) {
+	for msg := range msgs {
+		messagesConsumed.WithLabelValues(queue).Inc()
+		start := time.Now()
+		s.dispatch(queue, msg)
+		handlerDuration.WithLabelValues(queue).Observe(time.Since(start).Seconds())
+	}
+}
+
+// dispatch decodes msg's envelope and passes its payload to the handler
+// for its type, which settles the message. Messages that are malformed, of
+// an unknown type or version, or of a type this service does not handle
+// are rejected to the queue's dead-letter queue.
+func (s *Service) dispatch(queue string, msg broker.Delivery) {
+	ctx, span := observability.StartConsumeSpan(tracer, msg, queue)
+	defer span.End()
+	ctx = observability.WithLogFields(ctx, slog.String("correlation_id", msg.CorrelationID))
+
+	env, payload, err := contracts.Decode(msg.ContentType, msg.Body)
+	if err == nil {
+		ctx = observability.WithLogFields(ctx, slog.String("message_id", env.MessageID), slog.String("message_type", env.Type))
+		ctx = withProducer(ctx, env.Producer)
+		switch p := payload.(type) {
+		case contracts.StockCheck:
+			s.handleStockCheck(ctx, queue, msg, p)
+			return
+		case contracts.StockRelease:
+			s.handleStockRelease(ctx, queue, msg, p)
+			return
+		case contracts.ProductGet:
+			s.handleProductGet(ctx, queue, msg, p)
+			return
+		case contracts.StockAdjust:
+			s.handleStockAdjust(ctx, queue, msg, p)
+			return
+		case contracts.ReorderPolicySet:
+			s.handleReorderPolicySet(ctx, queue, msg, p)
+			return
+		case contracts.ReorderReport:
+			s.handleReorderReport(ctx, queue, msg, p)
+			return
+		case contracts.MovementList:
+			s.handleMovementList(ctx, queue, msg, p)
+			return
+		case contracts.HealthCheck:
+			s.handleHealthCheck(ctx, msg)
+			return
+		}
+		err = fmt.Errorf("%w %q", errUnhandledType, env.Type)
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "rejected message")
+	slog.ErrorContext(ctx, "Rejected message", "queue", queue, "content_type", msg.ContentType, "type", env.Type, "version", env.Version, "error", err)
+	msg.Nack(false)
+	messagesNacked.WithLabelValues(queue).Inc()
+	messagesDeadLettered.WithLabelValues(queue).Inc()
+}
+
+// errUnhandledType is returned for a valid message this service has no
+// handler for.
+var errUnhandledType = errors.New("no handler for message type")
+
+// reply publishes p in an envelope to msg's reply queue, in the same
+// encoding as msg.
+func (s *Service) reply(ctx context.Context, msg broker.Delivery, p contracts.Payload) error {
+	enc, err := contracts.EncodingOf(msg.ContentType)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, "reply", "", msg.ReplyTo, msg.CorrelationID, enc, p)
+}
+
+// announce publishes e to the events exchange.
+func (s *Service) announce(ctx context.Context, e contracts.Event) error {
+	return s.send(ctx, contracts.EventsExchange, contracts.EventsExchange, e.EventName(), "", s.encodings.For(contracts.EventsExchange), e)
+}
+
+// send publishes p in an envelope to exchange with routing key key. label
+// names the destination in spans and metrics.
+func (s *Service) send(ctx context.Context, label, exchange, key, correlationID string, enc contracts.Encoding, p contracts.Payload) error {
+	env, body, err := contracts.Encode(enc, serviceName, p)
+	if err != nil {
+		return err
+	}
+	ctx, span, headers := observability.StartPublishSpan(ctx, tracer, label)
+	defer span.End()
+	err = s.broker.Publish(ctx, exchange, key, broker.Message{
+		ContentType:   enc.ContentType(),
+		CorrelationID: correlationID,
+		MessageID:     env.MessageID,
+		Headers:       headers,
+		Body:          body,
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "publish failed")
+		return err
+	}
+	messagesPublished.WithLabelValues(label).Inc()
+	return nil
+}
+
+// checkStock reports whether the units req asks for are on hand,
+// reserving them for its order if it says so, or returns an InventoryError
+// if req asks for no units. A new reservation also returns the product as
+// it left it; a check repeated for an order that holds its units is
+// answered as available without reserving again. Unknown products and
+// lookup errors count as out of stock.
+func (s *Service) checkStock(ctx context.Context, req contracts.StockCheck) (available bool, reserved *Product, invalid *contracts.InventoryError) {
+	if req.Quantity <= 0 {
+		return false, nil, &contracts.InventoryError{Code: contracts.InventoryErrInvalid, Message: "quantity must be positive"}
+	}
+	var err error
+	if req.Reserve {
+		var p Product
+		var fresh bool
+		p, fresh, err = s.reserve(ctx, req)
+		available = err == nil
+		if fresh {
+			reserved = &p
+		}
+		switch {
+		case errors.Is(err, ErrInsufficientStock):
+			err = nil
+		case errors.Is(err, ErrReservationConflict):
+			slog.WarnContext(ctx, "Refused stock for an order with another or a released reservation", "order_id", req.OrderID)
+			err = nil
+		}
+	} else {
+		var stock int
+		stock, err = s.repo.Stock(ctx, req.ProductID)
+		available = stock >= req.Quantity
+	}
+	if err != nil {
+		if !errors.Is(err, ErrProductNotFound) {
+			slog.ErrorContext(ctx, "Error checking stock", "error", err)
+		}
+		return false, nil, nil
+	}
+	return available, reserved, nil
+}
+
+// reserve takes the units req asks for out of stock for its order and
+// reports whether it did so now. Checks without an order ID are not
+// tracked per order, so each of them reserves.
+func (s *Service) reserve(ctx context.Context, req contracts.StockCheck) (Product, bool, error) {
+	m := Movement{
+		ProductID: req.ProductID,
+		Type:      contracts.MovementReservation,
+		Quantity:  -req.Quantity,
+		Actor:     producer(ctx),
+		Reference: orderReference(req.OrderID),
+	}
+	if req.OrderID == 0 {
+		p, err := s.repo.Move(ctx, m)
+		return p, err == nil, err
+	}
+	return s.repo.Reserve(ctx, req.OrderID, m)
+}
+
+// release puts the units r names back in stock if its order holds them,
+// and reports whether it did. Releases without an order ID are not
+// tracked per order, so each of them puts its units back.
+func (s *Service) release(ctx context.Context, r contracts.StockRelease) (bool, error) {
+	m := Movement{
+		ProductID: r.ProductID,
+		Type:      contracts.MovementRelease,
+		Quantity:  r.Quantity,
+		Reason:    r.Reason,
+		Actor:     producer(ctx),
+		Reference: orderReference(r.OrderID),
+	}
+	if r.OrderID == 0 {
+		_, err := s.repo.Move(ctx, m)
+		return err == nil, err
+	}
+	return s.repo.Release(ctx, r.OrderID, m)
+}
+
+// handleStockCheck answers a single stock check and settles it. Invalid
+// checks are answered with an InventoryError.
+func (s *Service) handleStockCheck(ctx context.Context, queue string, msg broker.Delivery, req contracts.StockCheck) {
+	ctx = observability.WithLogFields(ctx, slog.Int("product_id", req.ProductID))
+	available, reserved, invalid := s.checkStock(ctx, req)
+	if invalid != nil {
+		slog.WarnContext(ctx, "Refused invalid stock check", "quantity", req.Quantity, "reason", invalid.Message)
+		s.answer(ctx, queue, msg, *invalid)
+		return
+	}
+	msgLog.InfoContext(ctx, "Stock checked", "quantity", req.Quantity, "reserve", req.Reserve, "available", available)
+	stockChecks.WithLabelValues(strconv.FormatBool(available)).Inc()
+	if !available {
+		stockOuts.Inc()
+	}
+
+	err := s.reply(ctx, msg, contracts.StockCheckResult{
+		ProductID:   req.ProductID,
+		IsAvailable: available,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to publish stock response", "error", err)
+		if reserved != nil && req.OrderID == 0 {
+			// The redelivered request would reserve again, so undo the
+			// reservation first. One for an order is found and answered
+			// again instead.
+			undo := contracts.StockRelease{ProductID: req.ProductID, Quantity: req.Quantity, Reason: "stock check reply failed"}
+			if _, err := s.release(ctx, undo); err != nil {
+				slog.ErrorContext(ctx, "Failed to undo reservation", "error", err)
+			}
+		}
+		msg.Nack(true)
+		messagesNacked.WithLabelValues(queue).Inc()
+		return
+	}
+	if reserved != nil {
+		s.checkLowStock(ctx, *reserved, reserved.Stock+req.Quantity)
+	}
+	msg.Ack()
+	messagesAcked.WithLabelValues(queue).Inc()
+}
+
+// handleStockRelease returns reserved stock and settles the message.
+func (s *Service) handleStockRelease(ctx context.Context, queue string, msg broker.Delivery, release contracts.StockRelease) {
+	ctx = observability.WithLogFields(ctx, slog.Int("product_id", release.ProductID))
+	if release.Quantity <= 0 {
+		slog.WarnContext(ctx, "Ignored release of no stock", "quantity", release.Quantity)
+		msg.Ack()
+		messagesAcked.WithLabelValues(queue).Inc()
+		return
+	}
+	released, err := s.release(ctx, release)
+	if err != nil && !errors.Is(err, ErrProductNotFound) {
+		trace.SpanFromContext(ctx).RecordError(err)
+		trace.SpanFromContext(ctx).SetStatus(codes.Error, "failed to release stock")
+		slog.ErrorContext(ctx, "Failed to release stock", "error", err)
+		msg.Nack(true)
+		messagesNacked.WithLabelValues(queue).Inc()
+		return
+	}
+	switch {
+	case err != nil:
+		slog.WarnContext(ctx, "Released stock for unknown product", "quantity", release.Quantity)
+	case !released:
+		msgLog.InfoContext(ctx, "Nothing reserved to release", "order_id", release.OrderID, "quantity", release.Quantity)
+	default:
+		msgLog.InfoContext(ctx, "Stock released", "quantity", release.Quantity)
+	}
+	msg.Ack()
+	messagesAcked.WithLabelValues(queue).Inc()
+}
+
+// inventoryError turns a repository error for productID into the reply to
+// an inventory request.
+func inventoryError(ctx context.Context, productID int, err error) contracts.InventoryError {
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		return contracts.InventoryError{Code: contracts.InventoryErrNotFound, Message: fmt.Sprintf("product %d not found", productID)}
+	case errors.Is(err, ErrInsufficientStock):
+		return contracts.InventoryError{Code: contracts.InventoryErrInsufficientStock, Message: fmt.Sprintf("product %d has too little stock", productID)}
+	}
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "inventory request failed")
+	slog.ErrorContext(ctx, "Inventory request failed", "error", err)
+	return contracts.InventoryError{Code: contracts.InventoryErrUnavailable, Message: "inventory database unavailable"}
+}
+
+// answer replies to an inventory request and acknowledges it. The request
+// is acknowledged even when the reply cannot be sent: the caller has then
+// timed out or will, and retrying would not help it.
+func (s *Service) answer(ctx context.Context, queue string, msg broker.Delivery, p contracts.Payload) {
+	if err := s.reply(ctx, msg, p); err != nil {
+		slog.ErrorContext(ctx, "Failed to publish inventory reply", "error", err)
+	}
+	msg.Ack()
+	messagesAcked.WithLabelValues(queue).Inc()
+}
+
+// handleProductGet replies with a product's stock and reorder policy.
+func (s *Service) handleProductGet(ctx context.Context, queue string, msg broker.Delivery, req contracts.ProductGet) {
+	ctx = observability.WithLogFields(ctx, slog.Int("product_id", req.ProductID))
+	p, err := s.repo.Product(ctx, req.ProductID)
+	if err != nil {
+		s.answer(ctx, queue, msg, inventoryError(ctx, req.ProductID, err))
+		return
+	}
+	s.answer(ctx, queue, msg, p.details())
+}
+
+// handleStockAdjust records a stock movement other than a reservation or
+// release, announces it if that leaves the stock low, and replies with the
+// product.
+func (s *Service) handleStockAdjust(ctx context.Context, queue string, msg broker.Delivery, req contracts.StockAdjust) {
+	ctx = observability.WithLogFields(ctx, slog.Int("product_id", req.ProductID))
+	m, invalid := adjustment(ctx, req)
+	if invalid != nil {
+		s.answer(ctx, queue, msg, *invalid)
+		return
+	}
+	p, err := s.repo.Move(ctx, m)
+	if err != nil {
+		s.answer(ctx, queue, msg, inventoryError(ctx, req.ProductID, err))
+		return
+	}
+	stockAdjustments.WithLabelValues(m.Type).Inc()
+	msgLog.InfoContext(ctx, "Stock adjusted", "type", m.Type, "quantity", m.Quantity, "reason", m.Reason, "actor", m.Actor, "reference", m.Reference, "stock", p.Stock)
+	s.checkLowStock(ctx, p, p.Stock-req.Quantity)
+	s.answer(ctx, queue, msg, p.details())
+}
+
+// handleHealthCheck replies to a single health-check request.
+func (s *Service) handleHealthCheck(ctx context.Context, msg broker.Delivery) {
+	defer msg.Ack()
+	slog.DebugContext(ctx, "Received health check request")
+
+	dbStatus := "connected"
+	if err := s.repo.Ping(ctx); err != nil {
+		dbStatus = "disconnected"
+	}
+
+	response := contracts.HealthStatus{
+		Service:  "Stock Service",
+		Status:   "healthy",
+		Database: dbStatus,
+	}
+	if dbStatus == "disconnected" {
+		response.Status = "unhealthy"
+		response.Error = "Database connection failed"
+	} else if version, err := s.repo.SchemaVersion(ctx); err != nil {
+		response.Status = "unhealthy"
+		response.Error = "Schema version unavailable"
+	} else {
+		response.SchemaVersion = version
+		if version < SchemaVersion {
+			response.Status = "unhealthy"
+			response.Error = fmt.Sprintf("Schema version %d is behind %d", version, SchemaVersion)
+		}
+	}
+
+	if err := s.reply(ctx, msg, response); err != nil {
+		slog.ErrorContext(ctx, "Failed to publish health response", "error", err)
+		return
+	}
+	slog.DebugContext(ctx, "Health response published", "status", response.Status, "database", response.Database, "schema_version", response.SchemaVersion)
+}
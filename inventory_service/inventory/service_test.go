@@ -0,0 +1,360 @@
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	msgLog = slog.Default()
+	os.Exit(m.Run())
+}
+
+// newTestBroker returns an in-memory broker with this service's topology
+// and a reply queue for the handler under test to answer on.
+func newTestBroker(t *testing.T) (*broker.Memory, string) {
+	t.Helper()
+	b := broker.NewMemory()
+	if err := declareTopology(b); err != nil {
+		t.Fatalf("declareTopology: %v", err)
+	}
+	replyQueue, err := b.DeclareQueue("", broker.QueueOptions{})
+	if err != nil {
+		t.Fatalf("DeclareQueue: %v", err)
+	}
+	return b, replyQueue
+}
+
+// deliver publishes msg to queue and returns the resulting delivery.
+func deliver(t *testing.T, b *broker.Memory, queue string, msg broker.Message) broker.Delivery {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgs, err := b.Consume(ctx, queue)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if err := b.Publish(ctx, "", queue, msg); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	select {
+	case d := <-msgs:
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+	return broker.Delivery{}
+}
+
+// envelope encodes p as another service would publish it.
+func envelope(t *testing.T, p contracts.Payload) []byte {
+	t.Helper()
+	_, body, err := contracts.Encode(contracts.JSON, "test", p)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return body
+}
+
+// decodeReply decodes the payload of a reply this service published.
+func decodeReply[T contracts.Payload](t *testing.T, reply broker.Message) T {
+	t.Helper()
+	env, payload, err := contracts.Decode(reply.ContentType, reply.Body)
+	if err != nil {
+		t.Fatalf("invalid reply %s: %v", reply.Body, err)
+	}
+	if env.Producer != serviceName || reply.MessageID != env.MessageID {
+		t.Errorf("reply envelope = %+v, message ID %q; want it produced by %s", env, reply.MessageID, serviceName)
+	}
+	p, ok := payload.(T)
+	if !ok {
+		t.Fatalf("reply payload is %T", payload)
+	}
+	return p
+}
+
+// pingInventory is a repository whose Ping returns err and whose schema is
+// at version, or at the latest version when version is 0.
+type pingInventory struct {
+	*MemoryInventory
+	err     error
+	version int
+}
+
+func (u pingInventory) Ping(context.Context) error { return u.err }
+
+func (u pingInventory) SchemaVersion(ctx context.Context) (int, error) {
+	if u.version == 0 {
+		return u.MemoryInventory.SchemaVersion(ctx)
+	}
+	return u.version, nil
+}
+
+func TestHandleStockRequest(t *testing.T) {
+	repo := NewMemoryInventory()
+	repo.SaveProduct(context.Background(), Product{ProductID: 101, Name: "Broccoli", Stock: 10})
+	repo.SaveProduct(context.Background(), Product{ProductID: 102, Name: "Beer", Stock: 0})
+
+	tests := []struct {
+		name      string
+		productID int
+		quantity  int
+		want      bool
+	}{
+		{"in stock", 101, 3, true},
+		{"exactly the stock level", 101, 10, true},
+		{"more than in stock", 101, 11, false},
+		{"sold out", 102, 1, false},
+		{"unknown product", 999, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, replyQueue := newTestBroker(t)
+			svc := NewService(repo, b)
+			msg := deliver(t, b, "check_stock", broker.Message{
+				CorrelationID: "corr-1",
+				ReplyTo:       replyQueue,
+				Body:          envelope(t, contracts.StockCheck{ProductID: tt.productID, Quantity: tt.quantity}),
+			})
+
+			svc.dispatch("check_stock", msg)
+
+			replies := b.Pending(replyQueue)
+			if len(replies) != 1 {
+				t.Fatalf("got %d replies, want 1", len(replies))
+			}
+			if replies[0].CorrelationID != "corr-1" {
+				t.Errorf("reply correlation ID = %q, want corr-1", replies[0].CorrelationID)
+			}
+			resp := decodeReply[contracts.StockCheckResult](t, replies[0])
+			if resp.ProductID != tt.productID || resp.IsAvailable != tt.want {
+				t.Errorf("reply = %+v, want product %d available=%v", resp, tt.productID, tt.want)
+			}
+			if pending := b.Pending("check_stock"); len(pending) != 0 {
+				t.Errorf("request left on queue: %v", pending)
+			}
+		})
+	}
+}
+
+func TestReplyUsesRequestEncoding(t *testing.T) {
+	repo := NewMemoryInventory()
+	repo.SaveProduct(context.Background(), Product{ProductID: 101, Name: "Broccoli", Stock: 10})
+	b, replyQueue := newTestBroker(t)
+	_, body, err := contracts.Encode(contracts.Protobuf, "test", contracts.StockCheck{ProductID: 101, Quantity: 1})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	msg := deliver(t, b, "check_stock", broker.Message{
+		ContentType: contracts.ContentTypeProtobuf,
+		ReplyTo:     replyQueue,
+		Body:        body,
+	})
+
+	NewService(repo, b).dispatch("check_stock", msg)
+
+	replies := b.Pending(replyQueue)
+	if len(replies) != 1 {
+		t.Fatalf("got %d replies, want 1", len(replies))
+	}
+	if replies[0].ContentType != contracts.ContentTypeProtobuf {
+		t.Errorf("reply content type = %q, want %q", replies[0].ContentType, contracts.ContentTypeProtobuf)
+	}
+	if resp := decodeReply[contracts.StockCheckResult](t, replies[0]); !resp.IsAvailable {
+		t.Errorf("reply = %+v, want available", resp)
+	}
+}
+
+func TestHandleStockRequestReserves(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryInventory()
+	repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 5})
+	b, replyQueue := newTestBroker(t)
+	svc := NewService(repo, b)
+
+	for _, want := range []bool{true, false} {
+		body := envelope(t, contracts.StockCheck{ProductID: 101, Quantity: 3, Reserve: true})
+		svc.dispatch("check_stock", deliver(t, b, "check_stock", broker.Message{ReplyTo: replyQueue, Body: body}))
+
+		replies := b.Pending(replyQueue)
+		resp := decodeReply[contracts.StockCheckResult](t, replies[len(replies)-1])
+		if resp.IsAvailable != want {
+			t.Fatalf("reply = %+v, want available=%v", resp, want)
+		}
+	}
+	if stock, _ := repo.Stock(ctx, 101); stock != 2 {
+		t.Fatalf("stock = %d, want 2 after one reservation of 3", stock)
+	}
+
+	body := envelope(t, contracts.StockRelease{ProductID: 101, Quantity: 3})
+	svc.dispatch("release_stock", deliver(t, b, "release_stock", broker.Message{Body: body}))
+	if stock, _ := repo.Stock(ctx, 101); stock != 5 {
+		t.Fatalf("stock = %d, want 5 after release", stock)
+	}
+
+	// Orders of no units, or fewer, are refused rather than reported
+	// available without a reservation.
+	for _, quantity := range []int{0, -2} {
+		body := envelope(t, contracts.StockCheck{ProductID: 101, Quantity: quantity, Reserve: true, OrderID: 7})
+		svc.dispatch("check_stock", deliver(t, b, "check_stock", broker.Message{ReplyTo: replyQueue, Body: body}))
+		replies := b.Pending(replyQueue)
+		if resp := decodeReply[contracts.InventoryError](t, replies[len(replies)-1]); resp.Code != contracts.InventoryErrInvalid {
+			t.Errorf("reserving %d units: reply = %+v, want an invalid error", quantity, resp)
+		}
+	}
+	if stock, _ := repo.Stock(ctx, 101); stock != 5 {
+		t.Errorf("stock = %d, want 5 after refused reservations", stock)
+	}
+}
+
+func TestStockReservationsAreKeptPerOrder(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryInventory()
+	repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 5})
+	b, replyQueue := newTestBroker(t)
+	svc := NewService(repo, b)
+	check := func(orderID int) contracts.StockCheckResult {
+		body := envelope(t, contracts.StockCheck{ProductID: 101, Quantity: 3, Reserve: true, OrderID: orderID})
+		svc.dispatch("check_stock", deliver(t, b, "check_stock", broker.Message{ReplyTo: replyQueue, Body: body}))
+		replies := b.Pending(replyQueue)
+		return decodeReply[contracts.StockCheckResult](t, replies[len(replies)-1])
+	}
+	release := func(orderID int) {
+		body := envelope(t, contracts.StockRelease{ProductID: 101, Quantity: 3, OrderID: orderID})
+		svc.dispatch("release_stock", deliver(t, b, "release_stock", broker.Message{Body: body}))
+	}
+
+	// A retried check for the order is answered again without reserving
+	// twice.
+	for i := 0; i < 2; i++ {
+		if resp := check(42); !resp.IsAvailable {
+			t.Fatalf("check #%d = %+v, want available", i+1, resp)
+		}
+	}
+	if stock, _ := repo.Stock(ctx, 101); stock != 2 {
+		t.Fatalf("stock = %d, want 2 after one reservation of 3", stock)
+	}
+	release(42)
+	release(42)
+	if stock, _ := repo.Stock(ctx, 101); stock != 5 {
+		t.Fatalf("stock = %d, want 5 after releasing once", stock)
+	}
+
+	// The gateway releases an order whose check timed out, which may reach
+	// the inventory before the check does.
+	release(43)
+	if resp := check(43); resp.IsAvailable {
+		t.Errorf("check after its release = %+v, want unavailable", resp)
+	}
+	if stock, _ := repo.Stock(ctx, 101); stock != 5 {
+		t.Errorf("stock = %d, want 5", stock)
+	}
+}
+
+func TestHandleStockRequestAnnouncesLowStock(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryInventory()
+	repo.SaveProduct(ctx, Product{ProductID: 101, Name: "Broccoli", Stock: 12})
+	b, replyQueue := newTestBroker(t)
+	b.DeclareQueue("events", broker.QueueOptions{})
+	b.BindQueue("events", "stock.*", contracts.EventsExchange)
+	svc := NewService(repo, b)
+	svc.SetLowStockThreshold(10)
+
+	// Only the reservation that crosses the threshold is announced: 12 -> 11
+	// is above it, 11 -> 8 crosses it and 8 -> 5 was already below.
+	for _, quantity := range []int{1, 3, 3} {
+		body := envelope(t, contracts.StockCheck{ProductID: 101, Quantity: quantity, Reserve: true})
+		svc.dispatch("check_stock", deliver(t, b, "check_stock", broker.Message{ReplyTo: replyQueue, Body: body}))
+	}
+
+	events := b.Pending("events")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	want := contracts.StockLow{ProductID: 101, Stock: 8, Threshold: 10}
+	if event := decodeReply[contracts.StockLow](t, events[0]); event != want {
+		t.Errorf("event = %+v, want %+v", event, want)
+	}
+}
+
+func TestDispatchRejectsToDeadLetterQueue(t *testing.T) {
+	unknownVersion := envelope(t, contracts.StockCheck{ProductID: 101, Quantity: 1})
+	unknownVersion = bytes.Replace(unknownVersion, []byte(`"version":1`), []byte(`"version":99`), 1)
+
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"malformed body", []byte("{")},
+		{"bare payload without envelope", []byte(`{"product_id":101,"quantity":1}`)},
+		{"unknown version", unknownVersion},
+		{"unknown type", []byte(`{"type":"stock.teleport","version":1,"payload":{}}`)},
+		{"type this service does not handle", envelope(t, contracts.OrderPlaced{OrderID: 1})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, replyQueue := newTestBroker(t)
+			msg := deliver(t, b, "check_stock", broker.Message{ReplyTo: replyQueue, Body: tt.body})
+
+			NewService(NewMemoryInventory(), b).dispatch("check_stock", msg)
+
+			if replies := b.Pending(replyQueue); len(replies) != 0 {
+				t.Fatalf("got replies %v to a rejected request", replies)
+			}
+			if pending := b.Pending("check_stock"); len(pending) != 0 {
+				t.Fatalf("rejected request was requeued: %v", pending)
+			}
+			if dead := b.Pending(broker.DeadLetterQueue("check_stock")); len(dead) != 1 {
+				t.Fatalf("dead-letter queue holds %d messages, want 1", len(dead))
+			}
+		})
+	}
+}
+
+func TestHandleHealthCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		pingErr     error
+		version     int
+		wantStatus  string
+		wantVersion int
+	}{
+		{"database up", nil, 0, "healthy", SchemaVersion},
+		{"database down", errors.New("connection refused"), 0, "unhealthy", 0},
+		{"schema behind", nil, SchemaVersion - 1, "unhealthy", SchemaVersion - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, replyQueue := newTestBroker(t)
+			svc := NewService(pingInventory{NewMemoryInventory(), tt.pingErr, tt.version}, b)
+			queue, _ := b.DeclareQueue("", broker.QueueOptions{})
+			msg := deliver(t, b, queue, broker.Message{
+				CorrelationID: "health",
+				ReplyTo:       replyQueue,
+				Body:          envelope(t, contracts.HealthCheck{}),
+			})
+
+			svc.dispatch("health_check", msg)
+
+			replies := b.Pending(replyQueue)
+			if len(replies) != 1 {
+				t.Fatalf("got %d replies, want 1", len(replies))
+			}
+			resp := decodeReply[contracts.HealthStatus](t, replies[0])
+			if resp.Service != "Stock Service" || resp.Status != tt.wantStatus || resp.SchemaVersion != tt.wantVersion {
+				t.Errorf("reply = %+v, want Stock Service %s at schema version %d", resp, tt.wantStatus, tt.wantVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Usage describes the arguments Run accepts.
+const Usage = "migrate up | down [n] | version"
+
+// Run carries out a migrate subcommand and writes what it did to w:
+//
+//	up         apply every pending migration
+//	down [n]   revert the newest n migrations (default 1)
+//	version    print the current and latest schema versions
+func Run(ctx context.Context, m *Migrator, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: %s", Usage)
+	}
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Fprintf(w, "applied %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(w, "schema is up to date")
+		}
+		return nil
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("down: invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := m.Down(ctx, steps)
+		for _, mig := range reverted {
+			fmt.Fprintf(w, "reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "version":
+		version, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "schema version %d (latest %d)\n", version, m.Latest())
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q; usage: %s", args[0], Usage)
+	}
+}
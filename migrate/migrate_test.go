@@ -0,0 +1,170 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/lib/pq"
+)
+
+func file(body string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(body)} }
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"0002_add_index.up.sql":      file("CREATE INDEX"),
+		"0002_add_index.down.sql":    file("DROP INDEX"),
+		"0001_create_table.up.sql":   file("CREATE TABLE"),
+		"0001_create_table.down.sql": file("DROP TABLE"),
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []Migration{
+		{Version: 1, Name: "create_table", Up: "CREATE TABLE", Down: "DROP TABLE"},
+		{Version: 2, Name: "add_index", Up: "CREATE INDEX", Down: "DROP INDEX"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("got %d migrations, want %d", len(migrations), len(want))
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, migrations[i], want[i])
+		}
+	}
+	if got := Latest(migrations); got != 2 {
+		t.Errorf("Latest = %d, want 2", got)
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{"missing down", fstest.MapFS{"0001_a.up.sql": file("x")}, "needs both"},
+		{"bad name", fstest.MapFS{"create.sql": file("x")}, "unexpected file"},
+		{"version zero", fstest.MapFS{"0000_a.up.sql": file("x"), "0000_a.down.sql": file("x")}, "start at 1"},
+		{"duplicate version", fstest.MapFS{
+			"0001_a.up.sql": file("x"), "0001_a.down.sql": file("x"),
+			"0001_b.up.sql": file("x"), "0001_b.down.sql": file("x"),
+		}, "used by both"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunRejectsBadArguments(t *testing.T) {
+	m := New(nil, "test", nil)
+	for _, args := range [][]string{nil, {"sideways"}, {"down", "0"}, {"down", "two"}} {
+		if err := Run(context.Background(), m, args, &bytes.Buffer{}); err == nil {
+			t.Errorf("Run(%q) succeeded, want an error", args)
+		}
+	}
+}
+
+// TestMigratorPostgres applies and reverts migrations against the database
+// named by TEST_DATABASE_URL. It uses its own tables, which it drops first.
+func TestMigratorPostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer admin.Close()
+	for _, stmt := range []string{
+		"CREATE SCHEMA IF NOT EXISTS migrate_test",
+		"DROP TABLE IF EXISTS migrate_test.widgets, migrate_test.schema_migrations",
+	} {
+		if _, err := admin.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	// Work in the test schema on every pooled connection.
+	db, err := sql.Open("postgres", dsn+sep(dsn)+"search_path=migrate_test")
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	ctx := context.Background()
+
+	migrations := []Migration{
+		{Version: 1, Name: "create_widgets", Up: "CREATE TABLE widgets (id INT PRIMARY KEY)", Down: "DROP TABLE widgets"},
+		{Version: 2, Name: "add_name", Up: "ALTER TABLE widgets ADD COLUMN name TEXT", Down: "ALTER TABLE widgets DROP COLUMN name"},
+	}
+
+	// Instances starting together must apply each migration once between
+	// them.
+	var wg sync.WaitGroup
+	applied := make([]int, 3)
+	for i := range applied {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			done, err := New(db, "migrate_test", migrations).Up(ctx)
+			if err != nil {
+				t.Errorf("Up: %v", err)
+			}
+			applied[i] = len(done)
+		}(i)
+	}
+	wg.Wait()
+	if total := applied[0] + applied[1] + applied[2]; total != 2 {
+		t.Fatalf("%d migrations applied in total, want 2", total)
+	}
+
+	m := New(db, "migrate_test", migrations)
+	if v, err := m.Version(ctx); err != nil || v != 2 {
+		t.Fatalf("Version = %d, %v; want 2", v, err)
+	}
+	if _, err := db.Exec("INSERT INTO widgets (id, name) VALUES (1, 'a')"); err != nil {
+		t.Fatalf("schema not applied: %v", err)
+	}
+
+	reverted, err := m.Down(ctx, 1)
+	if err != nil || len(reverted) != 1 || reverted[0].Version != 2 {
+		t.Fatalf("Down(1) = %+v, %v; want version 2 reverted", reverted, err)
+	}
+	if v, _ := m.Version(ctx); v != 1 {
+		t.Fatalf("Version after Down(1) = %d, want 1", v)
+	}
+
+	// A failing migration leaves no trace.
+	broken := append(migrations[:1:1], Migration{Version: 2, Name: "broken", Up: "ALTER TABLE nope ADD COLUMN x INT", Down: ""})
+	if _, err := New(db, "migrate_test", broken).Up(ctx); err == nil {
+		t.Fatal("Up with a broken migration succeeded")
+	}
+	if v, _ := m.Version(ctx); v != 1 {
+		t.Fatalf("Version after failed Up = %d, want 1", v)
+	}
+
+	if _, err := m.Down(ctx, 5); err != nil {
+		t.Fatalf("Down(5): %v", err)
+	}
+	if v, _ := m.Version(ctx); v != 0 {
+		t.Fatalf("Version after reverting everything = %d, want 0", v)
+	}
+}
+
+func sep(dsn string) string {
+	if strings.Contains(dsn, "?") {
+		return "&"
+	}
+	return "?"
+}
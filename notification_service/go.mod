@@ -1,8 +0,0 @@
-module notification_service
-
-go 1.20
-
-require (
-	github.com/lib/pq v1.10.9
-	github.com/rabbitmq/amqp091-go v1.10.0
-)
@@ -0,0 +1,66 @@
+package notifications
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"ecomm-sample/contracts"
+)
+
+// Staff roles that events alert.
+const (
+	RoleWarehouse = "warehouse" // alerted when stock runs low
+)
+
+// alertFor returns the role an event alerts and the notification each of
+// its members gets, or false if the event alerts no one.
+func alertFor(e contracts.Event) (string, contracts.NotificationRequested, bool) {
+	switch e := e.(type) {
+	case contracts.StockLow:
+		return RoleWarehouse, contracts.NotificationRequested{
+			Template: "low_stock",
+			Data: map[string]string{
+				"product_id":   strconv.Itoa(e.ProductID),
+				"available":    strconv.Itoa(e.Stock),
+				"threshold":    strconv.Itoa(e.Threshold),
+				"safety_stock": strconv.Itoa(e.SafetyStock),
+			},
+		}, true
+	}
+	return "", contracts.NotificationRequested{}, false
+}
+
+// alert sends req to every member of role by queueing a notification for
+// each, so that their preferences, digests and inboxes apply as for any
+// other notification. Each member is recorded as alerted about eventID,
+// so a retried event skips the members it already reached. A role
+// without members is only logged.
+func (s *Service) alert(ctx context.Context, eventID, role string, req contracts.NotificationRequested) error {
+	members := s.roles[role]
+	if len(members) == 0 {
+		slog.WarnContext(ctx, "No one to alert", "role", role, "template", req.Template)
+		return nil
+	}
+	alerted := 0
+	for _, userID := range members {
+		sent, err := s.repo.AlertSent(ctx, eventID, userID)
+		if err != nil {
+			return err
+		}
+		if sent {
+			continue
+		}
+		req.UserID = userID
+		if err := s.send(ctx, "notifications", "", "notifications", "", s.encodings.For("notifications"), nil, req); err != nil {
+			return err
+		}
+		alertsSent.WithLabelValues(role).Inc()
+		alerted++
+		if err := s.repo.SaveAlert(ctx, eventID, userID); err != nil {
+			return err
+		}
+	}
+	msgLog.InfoContext(ctx, "Role alerted", "role", role, "template", req.Template, "users", alerted, "already_alerted", len(members)-alerted)
+	return nil
+}
@@ -0,0 +1,89 @@
+package notifications
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+func TestLoadRoles(t *testing.T) {
+	t.Setenv("NOTIFICATION_ROLES", "warehouse=90, 91,x; support=5;")
+	want := map[string][]int{RoleWarehouse: {90, 91}, "support": {5}}
+	if roles := LoadRoles(); !reflect.DeepEqual(roles, want) {
+		t.Fatalf("LoadRoles() = %v, want %v", roles, want)
+	}
+}
+
+func TestStockLowAlertsWarehouse(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBroker(t)
+	repo := NewMemoryNotifications()
+	svc := NewService(repo, b)
+	svc.SetRoles(map[string][]int{RoleWarehouse: {90, 91}})
+
+	event := contracts.StockLow{ProductID: 101, Stock: 3, Threshold: 10, SafetyStock: 5}
+	svc.dispatch("webhook_events", deliver(t, b, "webhook_events", broker.Message{Body: envelope(t, event)}))
+
+	if alerts := b.Pending("notifications"); len(alerts) != 2 {
+		t.Fatalf("%d alerts queued, want one per warehouse user", len(alerts))
+	}
+	consumeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	alerts, _ := b.Consume(consumeCtx, "notifications")
+	for i := 0; i < 2; i++ {
+		svc.dispatch("notifications", <-alerts)
+	}
+	for _, userID := range []int{90, 91} {
+		stored, _ := repo.ListNotifications(ctx, userID)
+		if len(stored) != 1 || stored[0].Template != "low_stock" || !strings.Contains(stored[0].Content, "3 left in stock") {
+			t.Errorf("user %d notifications = %+v, want the low-stock alert", userID, stored)
+		}
+	}
+	if pending := b.Pending("webhook_events"); len(pending) != 0 {
+		t.Errorf("event was requeued: %v", pending)
+	}
+}
+
+func TestRedeliveredStockLowSkipsAlertedMembers(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBroker(t)
+	repo := NewMemoryNotifications()
+	svc := NewService(repo, b)
+	svc.SetRoles(map[string][]int{RoleWarehouse: {90, 91}})
+
+	env, body, err := contracts.Encode(contracts.JSON, "test", contracts.StockLow{ProductID: 101, Stock: 3, Threshold: 10})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	// An earlier attempt alerted user 90 before failing.
+	repo.SaveAlert(ctx, env.MessageID, 90)
+
+	svc.dispatch("webhook_events", deliver(t, b, "webhook_events", broker.Message{Body: body}))
+	svc.dispatch("webhook_events", deliver(t, b, "webhook_events", broker.Message{Body: body}))
+
+	alerts := b.Pending("notifications")
+	if len(alerts) != 1 {
+		t.Fatalf("%d alerts queued, want one for the user not yet alerted", len(alerts))
+	}
+	if _, p, err := contracts.Decode(alerts[0].ContentType, alerts[0].Body); err != nil || p.(contracts.NotificationRequested).UserID != 91 {
+		t.Errorf("alert = %+v, %v; want one for user 91", p, err)
+	}
+}
+
+func TestStockLowWithoutWarehouse(t *testing.T) {
+	b := newTestBroker(t)
+	svc := NewService(NewMemoryNotifications(), b)
+
+	svc.dispatch("webhook_events", deliver(t, b, "webhook_events", broker.Message{Body: envelope(t, contracts.StockLow{ProductID: 101, Stock: 3, Threshold: 10})}))
+
+	if alerts := b.Pending("notifications"); len(alerts) != 0 {
+		t.Errorf("%d alerts queued with no one in the warehouse role", len(alerts))
+	}
+	if pending := b.Pending("webhook_events"); len(pending) != 0 {
+		t.Errorf("event was requeued: %v", pending)
+	}
+}
@@ -0,0 +1,208 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"ecomm-sample/contracts"
+)
+
+// Channel names. The inbox is not delivered on by a Channel: the service
+// keeps it itself.
+const (
+	ChannelLog   = "log"
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+	ChannelInbox = "inbox"
+)
+
+// Contact is how a user can be reached. Empty fields are channels the user
+// cannot be reached on.
+type Contact struct {
+	UserID int
+	Email  string
+	Phone  string // E.164, such as +15550100
+	Push   *contracts.PushSubscription
+	Locale string // language tag of the user's notifications, such as "de"
+}
+
+// details returns c as a contracts.ContactDetails.
+func (c Contact) details() contracts.ContactDetails {
+	return contracts.ContactDetails{UserID: c.UserID, Email: c.Email, Phone: c.Phone, Push: c.Push, Locale: c.Locale}
+}
+
+// address returns where channel delivers to c, or "" if c cannot be
+// reached on it.
+func (c Contact) address(channel string) string {
+	switch channel {
+	case ChannelLog:
+		return "user:" + strconv.Itoa(c.UserID)
+	case ChannelEmail:
+		return c.Email
+	case ChannelSMS:
+		return c.Phone
+	case ChannelPush:
+		if c.Push != nil {
+			return c.Push.Endpoint
+		}
+	}
+	return ""
+}
+
+// Channel delivers notifications to users on one medium.
+type Channel interface {
+	// Name is the channel's name, such as ChannelEmail.
+	Name() string
+	// Send delivers n to the user at to.
+	Send(ctx context.Context, to Contact, n Notification) error
+}
+
+// errUnreachable is returned when a user cannot be reached on any channel.
+var errUnreachable = errors.New("user has no contact details for any channel")
+
+// PermanentError is a delivery error that trying again cannot fix, such as
+// an address the provider rejects. Channels wrap such errors in it; any
+// other error, such as a timeout or a provider outage, may go away and the
+// delivery is retried.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// permanent marks err as a PermanentError.
+func permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+// retryable reports whether a delivery that failed with err may succeed if
+// tried again.
+func retryable(err error) bool {
+	var p *PermanentError
+	return !errors.As(err, &p)
+}
+
+// providerError returns the error for a provider's unsuccessful HTTP
+// response. Timeouts, rate limiting and server errors are retryable; other
+// client errors, such as an unknown number or an expired push
+// subscription, are permanent.
+func providerError(provider string, resp *http.Response) error {
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err := fmt.Errorf("%s answered %s: %s", provider, resp.Status, bytes.TrimSpace(detail))
+	switch code := resp.StatusCode; {
+	case code == http.StatusRequestTimeout, code == http.StatusTooEarly, code == http.StatusTooManyRequests, code >= 500:
+		return err
+	}
+	return permanent(err)
+}
+
+// Router picks the channels to deliver a notification on: the channel the
+// notification asks for, if any, then the fallback order. Channels the
+// user has no contact details for are skipped.
+type Router struct {
+	channels map[string]Channel
+	fallback []string
+}
+
+// NewRouter returns a router over channels that falls back in the order of
+// the channels given.
+func NewRouter(channels ...Channel) *Router {
+	r := &Router{channels: map[string]Channel{}}
+	for _, ch := range channels {
+		r.channels[ch.Name()] = ch
+		r.fallback = append(r.fallback, ch.Name())
+	}
+	return r
+}
+
+// route returns the channels to try for a notification to contact that
+// asks for hint, in order.
+func (r *Router) route(contact Contact, hint string) []Channel {
+	var route []Channel
+	seen := map[string]bool{}
+	for _, name := range append([]string{hint}, r.fallback...) {
+		ch, ok := r.channels[name]
+		if !ok || seen[name] || contact.address(name) == "" {
+			continue
+		}
+		seen[name] = true
+		route = append(route, ch)
+	}
+	return route
+}
+
+// only returns the channels of route that are named in names, in order.
+func only(route []Channel, names []string) []Channel {
+	var kept []Channel
+	for _, ch := range route {
+		if contains(names, ch.Name()) {
+			kept = append(kept, ch)
+		}
+	}
+	return kept
+}
+
+// LogChannel delivers notifications by writing them to the log. Every user
+// can be reached on it.
+type LogChannel struct{}
+
+func (LogChannel) Name() string { return ChannelLog }
+
+func (LogChannel) Send(ctx context.Context, to Contact, n Notification) error {
+	msgLog.InfoContext(ctx, "Notification sent", "notification_id", n.NotificationID, "channel", ChannelLog, "content_length", len(n.Content))
+	return nil
+}
+
+// FileChannel stands in for a channel in development by appending what it
+// would send to a file, one JSON object per line.
+type FileChannel struct {
+	name string
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileChannel returns a stand-in for the channel called name that
+// writes to the file at path.
+func NewFileChannel(name, path string) *FileChannel {
+	return &FileChannel{name: name, path: path}
+}
+
+func (f *FileChannel) Name() string { return f.name }
+
+func (f *FileChannel) Send(ctx context.Context, to Contact, n Notification) error {
+	line, err := json.Marshal(map[string]interface{}{
+		"channel":         f.name,
+		"to":              to.address(f.name),
+		"notification_id": n.NotificationID,
+		"template":        n.Template,
+		"subject":         n.Subject,
+		"content":         n.Content,
+		"sent_at":         time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open %s outbox: %w", f.name, err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,288 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ecomm-sample/contracts"
+)
+
+func TestRouterRoute(t *testing.T) {
+	router := NewRouter(&fakeChannel{name: ChannelPush}, &fakeChannel{name: ChannelEmail}, &fakeChannel{name: ChannelSMS}, LogChannel{})
+	everywhere := Contact{
+		UserID: 7,
+		Email:  "ada@example.com",
+		Phone:  "+15550100",
+		Push:   &contracts.PushSubscription{Endpoint: "https://push.example/abc"},
+	}
+
+	tests := []struct {
+		name    string
+		contact Contact
+		hint    string
+		want    []string
+	}{
+		{"fallback order", everywhere, "", []string{"push", "email", "sms", "log"}},
+		{"hint first", everywhere, "sms", []string{"sms", "push", "email", "log"}},
+		{"unknown hint ignored", everywhere, "pigeon", []string{"push", "email", "sms", "log"}},
+		{"unreachable channels skipped", Contact{UserID: 7, Phone: "+15550100"}, "email", []string{"sms", "log"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, ch := range router.route(tt.contact, tt.hint) {
+				got = append(got, ch.Name())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("route = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := NewRouter(&fakeChannel{name: ChannelEmail}).route(Contact{UserID: 7}, ""); len(got) != 0 {
+		t.Fatalf("route without contact details = %v, want none", got)
+	}
+}
+
+func TestFileChannel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sms.jsonl")
+	ch := NewFileChannel(ChannelSMS, path)
+	to := Contact{UserID: 7, Phone: "+15550100"}
+	for id := 1; id <= 2; id++ {
+		if err := ch.Send(context.Background(), to, Notification{NotificationID: id, Content: "Order shipped"}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read outbox: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("outbox has %d lines, want 2", len(lines))
+	}
+	var sent struct {
+		Channel        string `json:"channel"`
+		To             string `json:"to"`
+		NotificationID int    `json:"notification_id"`
+		Content        string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &sent); err != nil {
+		t.Fatalf("decode outbox line: %v", err)
+	}
+	if sent.Channel != ChannelSMS || sent.To != "+15550100" || sent.NotificationID != 2 || sent.Content != "Order shipped" {
+		t.Fatalf("outbox line = %+v", sent)
+	}
+}
+
+func TestSMTPEmail(t *testing.T) {
+	sink, err := NewSMTPSink("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewSMTPSink: %v", err)
+	}
+	defer sink.Close()
+
+	email, err := NewSMTPEmail(sink.Addr(), "Shop <shop@example.com>", "", "")
+	if err != nil {
+		t.Fatalf("NewSMTPEmail: %v", err)
+	}
+	to := Contact{UserID: 7, Email: "ada@example.com"}
+	if err := email.Send(context.Background(), to, Notification{NotificationID: 1, Content: "Order 42 shipped.\nThanks!"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	msgs := sink.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("sink has %d messages, want 1", len(msgs))
+	}
+	msg := msgs[0]
+	if msg.From != "shop@example.com" || !reflect.DeepEqual(msg.To, []string{"ada@example.com"}) {
+		t.Fatalf("envelope = %s -> %v", msg.From, msg.To)
+	}
+	if got := msg.Header.Get("Subject"); got != emailSubject {
+		t.Fatalf("Subject = %q, want %q", got, emailSubject)
+	}
+	if msg.Body != "Order 42 shipped.\nThanks!\n" {
+		t.Fatalf("Body = %q", msg.Body)
+	}
+}
+
+func TestSMTPEmailWithHTML(t *testing.T) {
+	sink, err := NewSMTPSink("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewSMTPSink: %v", err)
+	}
+	defer sink.Close()
+	email, _ := NewSMTPEmail(sink.Addr(), "shop@example.com", "", "")
+
+	n := Notification{NotificationID: 1, Subject: "Bestellung 42 ist eingegangen", Content: "Bestellung 42", HTML: "<p>Bestellung <b>42</b></p>"}
+	if err := email.Send(context.Background(), Contact{UserID: 7, Email: "ada@example.com"}, n); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	msg := sink.Messages()[0]
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != n.Subject {
+		t.Errorf("Subject = %q, want %q", subject, n.Subject)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+	parts := multipart.NewReader(strings.NewReader(msg.Body), params["boundary"])
+	var got []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+		body, _ := io.ReadAll(part)
+		got = append(got, part.Header.Get("Content-Type")+": "+strings.TrimSpace(string(body)))
+	}
+	want := []string{"text/plain; charset=utf-8: Bestellung 42", "text/html; charset=utf-8: <p>Bestellung <b>42</b></p>"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parts = %q, want %q", got, want)
+	}
+}
+
+func TestSMTPEmailUnsubscribeLink(t *testing.T) {
+	sink, err := NewSMTPSink("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("NewSMTPSink: %v", err)
+	}
+	defer sink.Close()
+	email, _ := NewSMTPEmail(sink.Addr(), "shop@example.com", "", "")
+
+	url := "https://shop.example/unsubscribe?token=7.abc"
+	n := Notification{NotificationID: 1, Category: contracts.CategoryMarketing, Content: "Spring sale!", UnsubscribeURL: url}
+	if err := email.Send(context.Background(), Contact{UserID: 7, Email: "ada@example.com"}, n); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	msg := sink.Messages()[0]
+	if got := msg.Header.Get("List-Unsubscribe"); got != "<"+url+">" {
+		t.Errorf("List-Unsubscribe = %q", got)
+	}
+	if got := msg.Header.Get("List-Unsubscribe-Post"); got != "List-Unsubscribe=One-Click" {
+		t.Errorf("List-Unsubscribe-Post = %q", got)
+	}
+	if !strings.Contains(msg.Body, "Unsubscribe: "+url) {
+		t.Errorf("Body = %q, want the unsubscribe link", msg.Body)
+	}
+}
+
+func TestSMTPEmailRejectsBadSender(t *testing.T) {
+	if _, err := NewSMTPEmail("localhost:25", "not an address", "", ""); err == nil {
+		t.Fatal("NewSMTPEmail accepted a malformed sender")
+	}
+}
+
+func TestHTTPSMS(t *testing.T) {
+	var got map[string]string
+	var auth string
+	status := http.StatusAccepted
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+		w.WriteHeader(status)
+		io.WriteString(w, "quota exceeded")
+	}))
+	defer srv.Close()
+
+	sms := NewHTTPSMS(srv.URL, "secret", "+15550199")
+	to := Contact{UserID: 7, Phone: "+15550100"}
+	if err := sms.Send(context.Background(), to, Notification{NotificationID: 1, Content: "Order shipped"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	want := map[string]string{"from": "+15550199", "to": "+15550100", "body": "Order shipped"}
+	if !reflect.DeepEqual(got, want) || auth != "Bearer secret" {
+		t.Fatalf("provider got %v with %q, want %v with the bearer token", got, auth, want)
+	}
+
+	status = http.StatusTooManyRequests
+	err := sms.Send(context.Background(), to, Notification{NotificationID: 2, Content: "Order shipped"})
+	if err == nil || !strings.Contains(err.Error(), "quota exceeded") || !retryable(err) {
+		t.Fatalf("Send = %v, want the provider's retryable error", err)
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	tests := []struct {
+		status        int
+		wantRetryable bool
+	}{
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusNotFound, false},
+		{http.StatusGone, false},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusServiceUnavailable, true},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		rec.WriteHeader(tt.status)
+		io.WriteString(rec, "no such number\n")
+		err := providerError("SMS provider", rec.Result())
+		if !strings.HasSuffix(err.Error(), "no such number") {
+			t.Errorf("providerError(%d) = %q, want the provider's answer", tt.status, err)
+		}
+		if got := retryable(err); got != tt.wantRetryable {
+			t.Errorf("retryable(providerError(%d)) = %v, want %v", tt.status, got, tt.wantRetryable)
+		}
+	}
+}
+
+func TestNewChannels(t *testing.T) {
+	names := func(r *Router) []string { return r.fallback }
+
+	t.Run("only configured channels", func(t *testing.T) {
+		r, err := NewChannels(ChannelConfig{Order: []string{"push", "email", "sms", "log"}, SMSOutbox: filepath.Join(t.TempDir(), "sms.jsonl")})
+		if err != nil {
+			t.Fatalf("NewChannels: %v", err)
+		}
+		if got := names(r); !reflect.DeepEqual(got, []string{"sms", "log"}) {
+			t.Fatalf("channels = %v, want [sms log]", got)
+		}
+	})
+
+	t.Run("provider over stand-in", func(t *testing.T) {
+		r, err := NewChannels(ChannelConfig{Order: []string{"sms"}, SMSURL: "http://sms.example", SMSOutbox: "sms.jsonl"})
+		if err != nil {
+			t.Fatalf("NewChannels: %v", err)
+		}
+		if _, ok := r.channels[ChannelSMS].(*HTTPSMS); !ok {
+			t.Fatalf("sms channel = %T, want *HTTPSMS", r.channels[ChannelSMS])
+		}
+	})
+
+	for name, cfg := range map[string]ChannelConfig{
+		"unknown channel":     {Order: []string{"email", "pigeon"}},
+		"nothing configured":  {Order: []string{"email"}},
+		"missing VAPID key":   {Order: []string{"push"}, PushKeyFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"bad sender address":  {Order: []string{"email"}, SMTPAddr: "localhost:25", SMTPFrom: "shop"},
+		"empty channel order": {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewChannels(cfg); err == nil {
+				t.Fatal("NewChannels succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package notifications
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getEnv returns the value of the environment variable key, or fallback when
+// it is unset or empty.
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// ChannelConfig configures the delivery channels. A channel is used if it
+// has either a provider or a stand-in outbox file; the log channel is
+// always available.
+type ChannelConfig struct {
+	// Order is the fallback order of channels, most preferred first.
+	// Notifications that ask for a channel try it before the others.
+	Order []string
+
+	SMTPAddr     string // SMTP server host:port
+	SMTPFrom     string
+	SMTPUsername string
+	SMTPPassword string
+
+	SMSURL    string // SMS provider endpoint
+	SMSToken  string
+	SMSFrom   string
+	SMSOutbox string // stand-in: file to write text messages to
+
+	PushKeyFile    string // PEM file holding the VAPID private key
+	PushSubscriber string // mailto: or https: contact for push services
+	PushOutbox     string // stand-in: file to write push messages to
+}
+
+// LoadChannelConfig reads the channel configuration from the environment.
+func LoadChannelConfig() ChannelConfig {
+	var order []string
+	for _, name := range strings.Split(getEnv("NOTIFICATION_CHANNELS", "push,email,sms,log"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			order = append(order, name)
+		}
+	}
+	return ChannelConfig{
+		Order:          order,
+		SMTPAddr:       os.Getenv("SMTP_ADDR"),
+		SMTPFrom:       getEnv("SMTP_FROM", "Shop <shop@example.com>"),
+		SMTPUsername:   os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:   os.Getenv("SMTP_PASSWORD"),
+		SMSURL:         os.Getenv("SMS_URL"),
+		SMSToken:       os.Getenv("SMS_TOKEN"),
+		SMSFrom:        os.Getenv("SMS_FROM"),
+		SMSOutbox:      os.Getenv("SMS_OUTBOX"),
+		PushKeyFile:    os.Getenv("PUSH_VAPID_KEY_FILE"),
+		PushSubscriber: getEnv("PUSH_SUBSCRIBER", "mailto:ops@example.com"),
+		PushOutbox:     os.Getenv("PUSH_OUTBOX"),
+	}
+}
+
+// NewChannels returns a router over the channels cfg configures, in its
+// fallback order. Providers take precedence over stand-ins.
+func NewChannels(cfg ChannelConfig) (*Router, error) {
+	available := map[string]Channel{ChannelLog: LogChannel{}}
+
+	if cfg.SMTPAddr != "" {
+		email, err := NewSMTPEmail(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUsername, cfg.SMTPPassword)
+		if err != nil {
+			return nil, err
+		}
+		available[ChannelEmail] = email
+	}
+
+	switch {
+	case cfg.SMSURL != "":
+		available[ChannelSMS] = NewHTTPSMS(cfg.SMSURL, cfg.SMSToken, cfg.SMSFrom)
+	case cfg.SMSOutbox != "":
+		available[ChannelSMS] = NewFileChannel(ChannelSMS, cfg.SMSOutbox)
+	}
+
+	switch {
+	case cfg.PushKeyFile != "":
+		key, err := LoadVAPIDKey(cfg.PushKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load VAPID key: %w", err)
+		}
+		push, err := NewWebPush(key, cfg.PushSubscriber)
+		if err != nil {
+			return nil, err
+		}
+		available[ChannelPush] = push
+	case cfg.PushOutbox != "":
+		available[ChannelPush] = NewFileChannel(ChannelPush, cfg.PushOutbox)
+	}
+
+	var channels []Channel
+	for _, name := range cfg.Order {
+		switch ch, ok := available[name]; {
+		case ok:
+			channels = append(channels, ch)
+			delete(available, name)
+		case name != ChannelEmail && name != ChannelSMS && name != ChannelPush:
+			return nil, fmt.Errorf("unknown notification channel %q", name)
+		}
+	}
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("no notification channel configured in %q", strings.Join(cfg.Order, ","))
+	}
+	return NewRouter(channels...), nil
+}
+
+// TemplateConfig configures where notification templates come from.
+type TemplateConfig struct {
+	// Dir is a directory of templates laid out as Templates describes,
+	// watched for changes. Empty means the built-in templates.
+	Dir           string
+	DefaultLocale string
+	// ReloadInterval is how often Dir is checked for changes.
+	ReloadInterval time.Duration
+}
+
+// LoadTemplateConfig reads the template configuration from the environment.
+func LoadTemplateConfig() TemplateConfig {
+	cfg := TemplateConfig{
+		Dir:            os.Getenv("NOTIFICATION_TEMPLATES_DIR"),
+		DefaultLocale:  getEnv("NOTIFICATION_DEFAULT_LOCALE", "en"),
+		ReloadInterval: durationEnv("NOTIFICATION_TEMPLATES_RELOAD", 5*time.Second),
+	}
+	return cfg
+}
+
+// durationEnv returns the positive duration in the environment variable
+// key, or fallback when it is unset or invalid.
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		slog.Warn("Invalid setting, using default", "key", key, "value", value, "default", fallback.String())
+		return fallback
+	}
+	return d
+}
+
+// intEnv returns the non-negative integer in the environment variable
+// key, or fallback if it is unset or invalid.
+func intEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		slog.Warn("Invalid setting, using default", "key", key, "value", value, "default", fallback)
+		return fallback
+	}
+	return n
+}
+
+// NewTemplates returns the templates cfg configures. Templates loaded from
+// a directory are reloaded when it changes until ctx is cancelled.
+func NewTemplates(ctx context.Context, cfg TemplateConfig) (*Templates, error) {
+	if cfg.Dir == "" {
+		files, err := fs.Sub(templateFiles, "templates")
+		if err != nil {
+			return nil, err
+		}
+		return LoadTemplates(files, cfg.DefaultLocale)
+	}
+	t, err := LoadTemplates(os.DirFS(cfg.Dir), cfg.DefaultLocale)
+	if err != nil {
+		return nil, fmt.Errorf("load templates from %s: %w", cfg.Dir, err)
+	}
+	go t.Watch(ctx, cfg.ReloadInterval)
+	return t, nil
+}
+
+// RetryConfig configures how deliveries that fail with a retryable error
+// are retried.
+type RetryConfig struct {
+	// Attempts is how many times a notification is tried on each channel,
+	// the first attempt included. Channels not listed are tried once.
+	Attempts map[string]int
+	// BaseDelay is the wait before the first retry. Each further retry
+	// waits twice as long as the one before, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// LoadRetryConfig reads the retry configuration from the environment.
+func LoadRetryConfig() RetryConfig {
+	cfg := RetryConfig{
+		Attempts:  map[string]int{},
+		BaseDelay: durationEnv("NOTIFICATION_RETRY_DELAY", 30*time.Second),
+		MaxDelay:  durationEnv("NOTIFICATION_RETRY_MAX_DELAY", 10*time.Minute),
+	}
+	const key = "NOTIFICATION_RETRY_ATTEMPTS"
+	for _, pair := range strings.Split(getEnv(key, "email=5,sms=3,push=3"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		channel, value, _ := strings.Cut(pair, "=")
+		attempts, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || attempts < 1 {
+			slog.Warn("Invalid setting, ignoring it", "key", key, "value", pair)
+			continue
+		}
+		cfg.Attempts[strings.TrimSpace(channel)] = attempts
+	}
+	return cfg
+}
+
+// DigestConfig configures which notifications are held back and sent
+// together in digests.
+type DigestConfig struct {
+	// Window is how long a held notification waits for its digest; 0
+	// sends every notification at once.
+	Window time.Duration
+	// ThrottleLimit is how many notifications of a category a user gets
+	// within ThrottleWindow before normal-priority ones are held too; 0
+	// never throttles.
+	ThrottleLimit  int
+	ThrottleWindow time.Duration
+}
+
+// LoadDigestConfig reads the digest configuration from the environment.
+func LoadDigestConfig() DigestConfig {
+	return DigestConfig{
+		Window:         durationEnv("NOTIFICATION_DIGEST_WINDOW", time.Hour),
+		ThrottleLimit:  intEnv("NOTIFICATION_THROTTLE_LIMIT", 5),
+		ThrottleWindow: durationEnv("NOTIFICATION_THROTTLE_WINDOW", time.Hour),
+	}
+}
+
+// WebhookConfig configures how events are delivered to webhooks.
+type WebhookConfig struct {
+	// Attempts is how many times a delivery is tried, the first attempt
+	// included.
+	Attempts int
+	// BaseDelay is the wait before the first retry. Each further retry
+	// waits twice as long as the one before, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// DisableAfter is how many deliveries in a row may fail before the
+	// webhook is disabled; 0 never disables it.
+	DisableAfter int
+	// Timeout bounds a single attempt.
+	Timeout time.Duration
+	// AllowPrivate lets webhooks reach loopback, link-local and private
+	// addresses, which they can't by default so that partners can't make
+	// the service call its own network. It is meant for development and
+	// tests.
+	AllowPrivate bool
+}
+
+// LoadWebhookConfig reads the webhook configuration from the environment.
+func LoadWebhookConfig() WebhookConfig {
+	cfg := WebhookConfig{
+		Attempts:     intEnv("WEBHOOK_ATTEMPTS", 6),
+		BaseDelay:    durationEnv("WEBHOOK_RETRY_DELAY", time.Minute),
+		MaxDelay:     durationEnv("WEBHOOK_RETRY_MAX_DELAY", time.Hour),
+		DisableAfter: intEnv("WEBHOOK_DISABLE_AFTER", 5),
+		Timeout:      durationEnv("WEBHOOK_TIMEOUT", 10*time.Second),
+	}
+	cfg.AllowPrivate, _ = strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE"))
+	if cfg.Attempts < 1 {
+		cfg.Attempts = 1
+	}
+	return cfg
+}
+
+// LoadRoles reads the users in each staff role from NOTIFICATION_ROLES,
+// semicolon-separated role=user_id,user_id pairs such as
+// "warehouse=90,91".
+func LoadRoles() map[string][]int {
+	const key = "NOTIFICATION_ROLES"
+	roles := map[string][]int{}
+	for _, pair := range strings.Split(os.Getenv(key), ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		role, members, _ := strings.Cut(pair, "=")
+		role = strings.TrimSpace(role)
+		for _, member := range strings.Split(members, ",") {
+			userID, err := strconv.Atoi(strings.TrimSpace(member))
+			if err != nil || userID <= 0 {
+				slog.Warn("Invalid setting, ignoring it", "key", key, "role", role, "value", member)
+				continue
+			}
+			roles[role] = append(roles[role], userID)
+		}
+	}
+	return roles
+}
+
+// LoadUnsubscribeLinks returns the unsubscribe links configured by
+// UNSUBSCRIBE_SECRET, the key they are signed with, and UNSUBSCRIBE_URL,
+// the gateway endpoint they point to. Without a secret a random one is
+// used, so links stop working when the service restarts.
+func LoadUnsubscribeLinks() (*UnsubscribeLinks, error) {
+	secret := []byte(os.Getenv("UNSUBSCRIBE_SECRET"))
+	if len(secret) == 0 {
+		slog.Warn("UNSUBSCRIBE_SECRET is not set, unsubscribe links will not survive a restart")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+	return NewUnsubscribeLinks(secret, getEnv("UNSUBSCRIBE_URL", "http://localhost:8080/api/v2/unsubscribe")), nil
+}
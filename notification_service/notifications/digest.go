@@ -0,0 +1,169 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Notifications that are not urgent can be held back and sent together.
+// Low-priority notifications always are, and normal ones once the user got
+// DigestConfig.ThrottleLimit notifications of the same category within
+// DigestConfig.ThrottleWindow; critical ones never are. A held notification
+// is stored as digested and a notification.digest message for its user and
+// category goes through a delay queue, as retries do:
+//
+//	notifications_retry_1h0m0s --(TTL 1h)--> notifications
+//
+// When it comes back, everything held for that user and category since is
+// sent as one notification rendered from the digest template, and each
+// held notification records it as its digest. The messages of the
+// notifications held later find nothing left, or start the next digest, so
+// no notification waits much longer than the window.
+
+// digestTemplate is the template digests are rendered from.
+const digestTemplate = "digest"
+
+// priorities are the notification priorities.
+var priorities = []string{contracts.PriorityCritical, contracts.PriorityNormal, contracts.PriorityLow}
+
+// held reports whether a stored notification of priority is held for a
+// digest rather than sent at once. Throttling fails open: if the user's
+// latest notifications cannot be counted, n is sent.
+func (s *Service) held(ctx context.Context, n Notification, priority string) bool {
+	if s.digests.Window <= 0 {
+		return false
+	}
+	switch priority {
+	case contracts.PriorityCritical:
+		return false
+	case contracts.PriorityLow:
+		notificationsHeld.WithLabelValues("low_priority").Inc()
+		return true
+	}
+	if s.digests.ThrottleLimit <= 0 {
+		return false
+	}
+	sent, err := s.repo.CountRecent(ctx, n.UserID, n.Category, time.Now().Add(-s.digests.ThrottleWindow))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to count recent notifications, not throttling", "error", err)
+		return false
+	}
+	if sent < s.digests.ThrottleLimit {
+		return false
+	}
+	notificationsHeld.WithLabelValues("throttled").Inc()
+	return true
+}
+
+// scheduleDigest schedules the digest a held notification goes out in.
+func (s *Service) scheduleDigest(ctx context.Context, n Notification) error {
+	digest := contracts.NotificationDigest{UserID: n.UserID, Category: n.Category}
+	if err := s.send(ctx, "notifications_digest", "", delayQueue("notifications", s.digests.Window), "", s.encodings.For("notifications"), nil, digest); err != nil {
+		return err
+	}
+	msgLog.InfoContext(ctx, "Notification held for a digest", "category", n.Category, "delay", s.digests.Window.String())
+	return nil
+}
+
+// hold settles a notification stored as digested: it schedules its
+// digest or, failing that, sends it at once.
+func (s *Service) hold(ctx context.Context, n Notification, contact Contact, prefs Preferences) {
+	if err := s.scheduleDigest(ctx, n); err != nil {
+		slog.ErrorContext(ctx, "Failed to schedule digest, sending the notification now", "error", err)
+		s.setStatus(ctx, n.NotificationID, contracts.NotificationStatusQueued)
+		s.deliver(ctx, n, contact, prefs, 1, nil)
+	}
+}
+
+// handleNotificationDigest sends the notifications held for a user's
+// digest of a category as one notification and settles the message. A
+// digest that lost some of its notifications to another one in the
+// meantime is requeued to send those left.
+func (s *Service) handleNotificationDigest(ctx context.Context, queue string, msg broker.Delivery, req contracts.NotificationDigest) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int("user_id", req.UserID), attribute.String("category", req.Category))
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", req.UserID))
+
+	held, err := s.repo.ListHeld(ctx, req.UserID, req.Category)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to load held notifications")
+		slog.ErrorContext(ctx, "Failed to load notifications held for a digest", "error", err)
+		msg.Nack(true)
+		messagesNacked.WithLabelValues(queue).Inc()
+		return
+	}
+	if len(held) == 0 {
+		slog.DebugContext(ctx, "No notification left to digest", "category", req.Category)
+		msg.Ack()
+		messagesAcked.WithLabelValues(queue).Inc()
+		return
+	}
+
+	ids := make([]int, len(held))
+	items := make([]string, len(held))
+	for i, n := range held {
+		ids[i] = n.NotificationID
+		items[i] = "- " + n.summary()
+	}
+	list := strings.Join(items, "\n")
+
+	contact, prefs := s.recipient(ctx, req.UserID)
+	digest := Notification{
+		UserID:   req.UserID,
+		Template: digestTemplate,
+		Category: req.Category,
+		Content:  list,
+		Status:   contracts.NotificationStatusQueued,
+	}
+	// The list is the digest's message: a digest that cannot be rendered
+	// is sent as the bare list, so rendering does not fail.
+	s.render(ctx, &digest, contracts.NotificationRequested{
+		Template: digestTemplate,
+		Message:  list,
+		Data:     map[string]string{"count": strconv.Itoa(len(held)), "items": list},
+	}, contact)
+
+	id, err := s.repo.SaveDigest(ctx, digest, ids)
+	if err != nil {
+		if !errors.Is(err, ErrAlreadyDigested) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to store digest")
+			slog.ErrorContext(ctx, "Failed to store digest", "error", err)
+		}
+		msg.Nack(true)
+		messagesNacked.WithLabelValues(queue).Inc()
+		return
+	}
+	digest.NotificationID = id
+	ctx = observability.WithLogFields(ctx, slog.Int("notification_id", id))
+	span.SetAttributes(attribute.Int("notification_id", id))
+	digestsSent.Inc()
+	msgLog.InfoContext(ctx, "Digest stored", "category", req.Category, "notifications", len(held))
+
+	s.deliver(ctx, digest, contact, prefs, 1, nil)
+	msg.Ack()
+	messagesAcked.WithLabelValues(queue).Inc()
+}
+
+// summary returns n in a line: its subject, or else the first line of its
+// content.
+func (n Notification) summary() string {
+	if n.Subject != "" {
+		return n.Subject
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(n.Content), "\n")
+	return line
+}
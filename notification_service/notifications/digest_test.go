@@ -0,0 +1,100 @@
+package notifications
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+func TestLoadDigestConfig(t *testing.T) {
+	t.Setenv("NOTIFICATION_DIGEST_WINDOW", "30m")
+	t.Setenv("NOTIFICATION_THROTTLE_LIMIT", "-3")
+	cfg := LoadDigestConfig()
+	want := DigestConfig{Window: 30 * time.Minute, ThrottleLimit: 5, ThrottleWindow: time.Hour}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("LoadDigestConfig() = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestNotificationDigests(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBroker(t)
+	window := time.Hour
+	if err := declareDelayQueues(b, "notifications", []time.Duration{window}); err != nil {
+		t.Fatalf("declareDelayQueues: %v", err)
+	}
+	repo := NewMemoryNotifications()
+	repo.SaveContact(ctx, Contact{UserID: 7, Email: "ada@example.com"})
+	email := &fakeChannel{name: ChannelEmail}
+	svc := NewService(repo, b)
+	svc.SetChannels(NewRouter(email))
+	svc.SetDigests(DigestConfig{Window: window, ThrottleLimit: 2, ThrottleWindow: time.Hour})
+
+	data := map[string]string{"order_id": "1", "product_id": "101", "quantity": "3"}
+	requests := []contracts.NotificationRequested{
+		{UserID: 7, Template: "order_placed", Data: data},
+		{UserID: 7, Template: "order_processed", Data: data, Priority: contracts.PriorityLow},
+		{UserID: 7, Template: "order_placed", Data: data},
+		{UserID: 7, Template: "order_placed", Data: data},
+		{UserID: 7, Template: "order_cancelled", Data: data, Priority: contracts.PriorityCritical},
+		{UserID: 7, Template: "order_placed", Data: data, Priority: "urgent"},
+	}
+	for _, req := range requests {
+		msg := deliver(t, b, "notifications", broker.Message{Body: envelope(t, req)})
+		svc.dispatch("notifications", msg)
+	}
+
+	stored, _ := repo.ListNotifications(ctx, 7)
+	var statuses []string
+	for _, n := range stored {
+		statuses = append(statuses, n.Status)
+	}
+	want := []string{
+		contracts.NotificationStatusDelivered, // under the throttle limit
+		contracts.NotificationStatusDigested,  // low priority
+		contracts.NotificationStatusDelivered, // under the throttle limit
+		contracts.NotificationStatusDigested,  // throttled
+		contracts.NotificationStatusDelivered, // critical
+		contracts.NotificationStatusFailed,    // unknown priority
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("statuses = %v, want %v", statuses, want)
+	}
+	if len(email.sent) != 3 {
+		t.Fatalf("sent %d emails, want 3 before the digest", len(email.sent))
+	}
+
+	scheduled := b.Pending(delayQueue("notifications", window))
+	if len(scheduled) != 2 {
+		t.Fatalf("%d digests scheduled, want one per held notification", len(scheduled))
+	}
+	for _, m := range scheduled {
+		msg := deliver(t, b, "notifications", m)
+		svc.dispatch("notifications", msg)
+		if err := msg.Ack(); err == nil {
+			t.Fatal("digest was not settled by the handler")
+		}
+	}
+
+	if len(email.sent) != 4 {
+		t.Fatalf("sent %d emails, want one digest", len(email.sent))
+	}
+	stored, _ = repo.ListNotifications(ctx, 7)
+	digest := stored[len(stored)-1]
+	if digest.Template != digestTemplate || digest.Status != contracts.NotificationStatusDelivered || digest.Subject != "2 updates from the shop" {
+		t.Fatalf("digest = %+v, want 2 updates delivered", digest)
+	}
+	if !strings.Contains(digest.Content, "- Your order has been processed\n- Your order 1 has been placed") {
+		t.Errorf("digest content = %q, want the held notifications' subjects", digest.Content)
+	}
+	for _, i := range []int{1, 3} {
+		if n := stored[i]; n.DigestID != digest.NotificationID {
+			t.Errorf("held notification %d = %+v, want sent in digest %d", n.NotificationID, n, digest.NotificationID)
+		}
+	}
+}
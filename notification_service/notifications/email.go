@@ -0,0 +1,308 @@
+package notifications
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SMTPEmail delivers notifications by email through an SMTP server.
+type SMTPEmail struct {
+	addr   string // host:port
+	from   string // From header, such as "Shop <shop@example.com>"
+	sender string // envelope sender, the address in from
+	auth   smtp.Auth
+}
+
+// NewSMTPEmail returns an email channel that sends from the address from
+// through the SMTP server at addr. With a username it authenticates with
+// PLAIN, which net/smtp only allows over TLS or to localhost.
+func NewSMTPEmail(addr, from, username, password string) (*SMTPEmail, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", from, err)
+	}
+	e := &SMTPEmail{addr: addr, from: sender.String(), sender: sender.Address}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		e.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return e, nil
+}
+
+func (e *SMTPEmail) Name() string { return ChannelEmail }
+
+// emailSubject is the subject of notification emails whose template has
+// none.
+const emailSubject = "Notification from your shop"
+
+func (e *SMTPEmail) Send(ctx context.Context, to Contact, n Notification) error {
+	msg, err := composeEmail(e.from, to.Email, n)
+	if err != nil {
+		return permanent(err)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", e.addr)
+	if err != nil {
+		return fmt.Errorf("connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(30 * time.Second))
+	}
+	host, _, _ := net.SplitHostPort(e.addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP greeting: %w", err)
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return smtpError("STARTTLS", err)
+		}
+	}
+	if e.auth != nil {
+		if err := c.Auth(e.auth); err != nil {
+			return smtpError("auth", err)
+		}
+	}
+	if err := c.Mail(e.sender); err != nil {
+		return smtpError("MAIL", err)
+	}
+	if err := c.Rcpt(to.Email); err != nil {
+		return smtpError("RCPT", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return smtpError("DATA", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return smtpError("DATA", err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError("DATA", err)
+	}
+	return c.Quit()
+}
+
+// smtpError returns the error for a failed SMTP step. Replies with a 5xx
+// code, such as an unknown mailbox, are permanent; 4xx replies and broken
+// connections are retryable.
+func smtpError(step string, err error) error {
+	err = fmt.Errorf("SMTP %s: %w", step, err)
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return permanent(err)
+	}
+	return err
+}
+
+// composeEmail returns the email message for n, with a plain-text body, or
+// with plain-text and HTML alternatives if n has HTML. A notification with
+// an unsubscribe link gets it in a footer and in the List-Unsubscribe
+// headers of RFC 8058, so mail clients can offer one-click unsubscribing.
+func composeEmail(from, to string, n Notification) ([]byte, error) {
+	subject := n.Subject
+	if subject == "" {
+		subject = emailSubject
+	}
+	text, body := n.Content, n.HTML
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	if n.UnsubscribeURL != "" {
+		fmt.Fprintf(&b, "List-Unsubscribe: <%s>\r\n", n.UnsubscribeURL)
+		b.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+		text += "\n\nUnsubscribe: " + n.UnsubscribeURL
+		if body != "" {
+			body += fmt.Sprintf("\n<p><a href=\"%s\">Unsubscribe</a></p>\n", html.EscapeString(n.UnsubscribeURL))
+		}
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	if body == "" {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+		b.WriteString(crlf(text))
+		return b.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", body},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		io.WriteString(w, crlf(part.body))
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// crlf returns s with CRLF line endings and a final line break, as SMTP
+// requires.
+func crlf(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(strings.TrimRight(s, "\r\n"), "\r\n", "\n"), "\n", "\r\n") + "\r\n"
+}
+
+// SinkMessage is an email received by an SMTPSink.
+type SinkMessage struct {
+	From   string // envelope sender
+	To     []string
+	Header mail.Header
+	Body   string
+}
+
+// SMTPSink is an SMTP server that accepts every email and keeps it, for
+// development and tests. It does not relay anything.
+type SMTPSink struct {
+	ln       net.Listener
+	mu       sync.Mutex
+	messages []SinkMessage
+}
+
+// NewSMTPSink starts an SMTP sink listening on addr, such as
+// "localhost:1025" or "127.0.0.1:0" for any free port.
+func NewSMTPSink(addr string) (*SMTPSink, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &SMTPSink{ln: ln}
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address the sink listens on.
+func (s *SMTPSink) Addr() string { return s.ln.Addr().String() }
+
+// Close stops the sink.
+func (s *SMTPSink) Close() error { return s.ln.Close() }
+
+// Messages returns the emails received so far, oldest first.
+func (s *SMTPSink) Messages() []SinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SinkMessage(nil), s.messages...)
+}
+
+func (s *SMTPSink) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(conn)
+	}
+}
+
+// session speaks just enough SMTP to take emails from net/smtp and other
+// well-behaved clients.
+func (s *SMTPSink) session(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(code int, msg string) { tp.PrintfLine("%d %s", code, msg) }
+	reply(220, "smtp sink ready")
+
+	var from string
+	var to []string
+	for {
+		conn.SetDeadline(time.Now().Add(time.Minute))
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			tp.PrintfLine("250-smtp sink")
+			reply(250, "8BITMIME")
+		case "HELO":
+			reply(250, "smtp sink")
+		case "MAIL":
+			from, to = pathArg(arg), nil
+			reply(250, "OK")
+		case "RCPT":
+			to = append(to, pathArg(arg))
+			reply(250, "OK")
+		case "DATA":
+			if from == "" || len(to) == 0 {
+				reply(503, "need MAIL and RCPT first")
+				continue
+			}
+			reply(354, "end data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			if err := s.keep(from, to, data); err != nil {
+				reply(554, "malformed message")
+			} else {
+				reply(250, "OK")
+			}
+			from, to = "", nil
+		case "RSET":
+			from, to = "", nil
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "command not implemented")
+		}
+	}
+}
+
+// pathArg returns the address of a MAIL FROM:<addr> or RCPT TO:<addr>
+// argument.
+func pathArg(arg string) string {
+	_, path, _ := strings.Cut(arg, ":")
+	path, _, _ = strings.Cut(strings.TrimSpace(path), " ")
+	return strings.Trim(path, "<>")
+}
+
+func (s *SMTPSink) keep(from string, to []string, data []byte) error {
+	msg, err := mail.ReadMessage(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return err
+	}
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.messages = append(s.messages, SinkMessage{From: from, To: to, Header: msg.Header, Body: string(body)})
+	s.mu.Unlock()
+	slog.Info("SMTP sink received email", "from", from, "to", to, "subject", msg.Header.Get("Subject"))
+	return nil
+}
@@ -0,0 +1,154 @@
+package notifications
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+)
+
+// Every notification stored for a user, unless it fails to render, also
+// goes into the user's in-app inbox if their preferences allow the inbox
+// channel for its category, which by default they do for transactional
+// notifications only. Held notifications go in at once; digests, which
+// only repeat them, do not. Each new item is announced on the
+// contracts.InboxExchange fanout, from which the gateway passes it on to
+// the user if they are connected.
+
+// inboxLimit is how many items an inbox listing shows.
+const inboxLimit = 100
+
+// InboxItem is a notification in a user's in-app inbox. ReadAt is when the
+// user read it, nil while unread.
+type InboxItem struct {
+	ItemID         int
+	UserID         int
+	NotificationID int
+	Category       string
+	Subject        string
+	Content        string
+	Archived       bool
+	CreatedAt      time.Time
+	ReadAt         *time.Time
+}
+
+// details returns item as a contracts.InboxItemDetails.
+func (item InboxItem) details() contracts.InboxItemDetails {
+	return contracts.InboxItemDetails{
+		ItemID:         item.ItemID,
+		UserID:         item.UserID,
+		NotificationID: item.NotificationID,
+		Category:       item.Category,
+		Subject:        item.Subject,
+		Content:        item.Content,
+		Read:           item.ReadAt != nil,
+		Archived:       item.Archived,
+		CreatedAt:      item.CreatedAt,
+		ReadAt:         item.ReadAt,
+	}
+}
+
+// addToInbox puts a stored notification in its user's inbox, if prefs
+// allow it, and announces the new item. Failures are only logged: the
+// notification is delivered on its channels either way.
+func (s *Service) addToInbox(ctx context.Context, n Notification, prefs Preferences) {
+	if !prefs.allows(n.Category, ChannelInbox, time.Now()) {
+		return
+	}
+	item, err := s.repo.SaveInboxItem(ctx, InboxItem{
+		UserID:         n.UserID,
+		NotificationID: n.NotificationID,
+		Category:       n.Category,
+		Subject:        n.Subject,
+		Content:        n.Content,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to put notification in the inbox", "error", err)
+		return
+	}
+	inboxItemsAdded.Inc()
+	ctx = observability.WithLogFields(ctx, slog.Int("item_id", item.ItemID))
+	added := contracts.InboxItemAdded(item.details())
+	if err := s.send(ctx, contracts.InboxExchange, contracts.InboxExchange, "", "", s.encodings.For(contracts.InboxExchange), nil, added); err != nil {
+		slog.ErrorContext(ctx, "Failed to announce inbox item", "error", err)
+		return
+	}
+	msgLog.InfoContext(ctx, "Notification put in the inbox")
+}
+
+// handleInboxList replies with the items in a user's inbox and how many
+// are unread.
+func (s *Service) handleInboxList(ctx context.Context, queue string, msg broker.Delivery, req contracts.InboxList) {
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", req.UserID))
+	items, err := s.repo.ListInboxItems(ctx, req.UserID, req.Archived, inboxLimit)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	unread, err := s.repo.CountUnread(ctx, req.UserID)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	result := contracts.InboxListResult{Items: make([]contracts.InboxItemDetails, len(items)), Unread: unread}
+	for i, item := range items {
+		result.Items[i] = item.details()
+	}
+	s.answer(ctx, queue, msg, result)
+}
+
+// handleInboxCount replies with how many items in a user's inbox are
+// unread.
+func (s *Service) handleInboxCount(ctx context.Context, queue string, msg broker.Delivery, req contracts.InboxCount) {
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", req.UserID))
+	unread, err := s.repo.CountUnread(ctx, req.UserID)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	s.answer(ctx, queue, msg, contracts.InboxCountResult{UserID: req.UserID, Unread: unread})
+}
+
+// handleInboxUpdate marks an item in a user's inbox read or unread, or
+// archives or unarchives it, and replies with the item.
+func (s *Service) handleInboxUpdate(ctx context.Context, queue string, msg broker.Delivery, req contracts.InboxUpdate) {
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", req.UserID), slog.Int("item_id", req.ItemID))
+	var item InboxItem
+	var err error
+	switch req.Action {
+	case contracts.InboxActionRead, contracts.InboxActionUnread:
+		item, err = s.repo.SetInboxItemRead(ctx, req.UserID, req.ItemID, req.Action == contracts.InboxActionRead)
+	case contracts.InboxActionArchive, contracts.InboxActionUnarchive:
+		item, err = s.repo.SetInboxItemArchived(ctx, req.UserID, req.ItemID, req.Action == contracts.InboxActionArchive)
+	default:
+		s.answer(ctx, queue, msg, contracts.NotificationError{Code: contracts.NotificationErrInvalid, Message: "unknown inbox action " + req.Action})
+		return
+	}
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	msgLog.InfoContext(ctx, "Inbox item updated", "action", req.Action)
+	s.answer(ctx, queue, msg, item.details())
+}
+
+// handleInboxMarkAllRead marks every item in a user's inbox read and
+// replies with the unread count left, which counts items that arrived in
+// the meantime.
+func (s *Service) handleInboxMarkAllRead(ctx context.Context, queue string, msg broker.Delivery, req contracts.InboxMarkAllRead) {
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", req.UserID))
+	if err := s.repo.MarkAllRead(ctx, req.UserID); err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	unread, err := s.repo.CountUnread(ctx, req.UserID)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	msgLog.InfoContext(ctx, "Inbox marked read")
+	s.answer(ctx, queue, msg, contracts.InboxCountResult{UserID: req.UserID, Unread: unread})
+}
@@ -0,0 +1,119 @@
+package notifications
+
+import (
+	"context"
+	"testing"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+func TestInbox(t *testing.T) {
+	b := newTestBroker(t)
+	repo := NewMemoryNotifications()
+	svc := NewService(repo, b)
+	announced, _ := b.DeclareQueue("", broker.QueueOptions{})
+	if err := b.BindQueue(announced, "", contracts.InboxExchange); err != nil {
+		t.Fatalf("BindQueue: %v", err)
+	}
+	replyQueue, _ := b.DeclareQueue("", broker.QueueOptions{})
+
+	request := func(p contracts.Payload) contracts.Payload {
+		t.Helper()
+		msg := deliver(t, b, "notification_requests", broker.Message{ReplyTo: replyQueue, Body: envelope(t, p)})
+		svc.dispatch("notification_requests", msg)
+		replies := b.Pending(replyQueue)
+		if len(replies) == 0 {
+			t.Fatal("no reply")
+		}
+		_, payload, err := contracts.Decode(replies[len(replies)-1].ContentType, replies[len(replies)-1].Body)
+		if err != nil {
+			t.Fatalf("invalid reply: %v", err)
+		}
+		return payload
+	}
+
+	data := map[string]string{"order_id": "1", "product_id": "101", "quantity": "3"}
+	for _, req := range []contracts.NotificationRequested{
+		{UserID: 7, Template: "order_placed", Data: data},
+		{UserID: 7, Message: "Spring sale", Category: contracts.CategoryMarketing},
+		{UserID: 7, Template: "no_such_template"},
+		{UserID: 7, Template: "order_processed", Data: data},
+		{UserID: 8, Template: "order_placed", Data: data},
+	} {
+		msg := deliver(t, b, "notifications", broker.Message{Body: envelope(t, req)})
+		svc.dispatch("notifications", msg)
+	}
+
+	// Marketing is not in the inbox by default and failed notifications
+	// never are.
+	pending := b.Pending(announced)
+	if len(pending) != 3 {
+		t.Fatalf("%d inbox items announced, want 3", len(pending))
+	}
+	_, payload, err := contracts.Decode(pending[0].ContentType, pending[0].Body)
+	if added, ok := payload.(contracts.InboxItemAdded); err != nil || !ok || added.UserID != 7 || added.NotificationID != 1 || added.Subject != "Your order 1 has been placed" || added.Read {
+		t.Fatalf("announcement = %+v, %v; want the unread order_placed item", payload, err)
+	}
+
+	list, ok := request(contracts.InboxList{UserID: 7}).(contracts.InboxListResult)
+	if !ok || len(list.Items) != 2 || list.Unread != 2 || list.Items[0].NotificationID != 4 {
+		t.Fatalf("inbox = %+v, want the two transactional items, newest first", list)
+	}
+	first := list.Items[1].ItemID
+
+	if item, ok := request(contracts.InboxUpdate{UserID: 7, ItemID: first, Action: contracts.InboxActionRead}).(contracts.InboxItemDetails); !ok || !item.Read || item.ReadAt == nil {
+		t.Fatalf("read reply = %+v, want the item read", item)
+	}
+	if count, ok := request(contracts.InboxCount{UserID: 7}).(contracts.InboxCountResult); !ok || count.Unread != 1 {
+		t.Fatalf("unread count = %+v, want 1", count)
+	}
+	if item, ok := request(contracts.InboxUpdate{UserID: 7, ItemID: first, Action: contracts.InboxActionArchive}).(contracts.InboxItemDetails); !ok || !item.Archived {
+		t.Fatalf("archive reply = %+v, want the item archived", item)
+	}
+	if archived, ok := request(contracts.InboxList{UserID: 7, Archived: true}).(contracts.InboxListResult); !ok || len(archived.Items) != 1 || archived.Items[0].ItemID != first {
+		t.Fatalf("archived items = %+v, want the archived item", archived)
+	}
+	if count, ok := request(contracts.InboxMarkAllRead{UserID: 7}).(contracts.InboxCountResult); !ok || count.UserID != 7 || count.Unread != 0 {
+		t.Fatalf("mark all read reply = %+v, want nothing unread", count)
+	}
+	if count, ok := request(contracts.InboxCount{UserID: 8}).(contracts.InboxCountResult); !ok || count.Unread != 1 {
+		t.Fatalf("other user's unread count = %+v, want 1", count)
+	}
+
+	for _, tt := range []struct {
+		req  contracts.InboxUpdate
+		code string
+	}{
+		{contracts.InboxUpdate{UserID: 8, ItemID: first, Action: contracts.InboxActionRead}, contracts.NotificationErrNotFound},
+		{contracts.InboxUpdate{UserID: 7, ItemID: 99, Action: contracts.InboxActionUnarchive}, contracts.NotificationErrNotFound},
+		{contracts.InboxUpdate{UserID: 7, ItemID: first, Action: "star"}, contracts.NotificationErrInvalid},
+	} {
+		if reply, ok := request(tt.req).(contracts.NotificationError); !ok || reply.Code != tt.code {
+			t.Errorf("%+v reply = %+v, want a %s error", tt.req, reply, tt.code)
+		}
+	}
+}
+
+func TestInboxFollowsPreferences(t *testing.T) {
+	b := newTestBroker(t)
+	repo := NewMemoryNotifications()
+	svc := NewService(repo, b)
+	repo.SavePreferences(context.Background(), Preferences{UserID: 7, OptIns: map[string]map[string]bool{
+		contracts.CategoryTransactional: {ChannelInbox: false},
+		contracts.CategoryMarketing:     {ChannelInbox: true},
+	}})
+
+	for _, req := range []contracts.NotificationRequested{
+		{UserID: 7, Message: "Your order shipped"},
+		{UserID: 7, Message: "Spring sale", Category: contracts.CategoryMarketing},
+	} {
+		msg := deliver(t, b, "notifications", broker.Message{Body: envelope(t, req)})
+		svc.dispatch("notifications", msg)
+	}
+
+	items, _ := repo.ListInboxItems(context.Background(), 7, false, inboxLimit)
+	if len(items) != 1 || items[0].Category != contracts.CategoryMarketing || items[0].Content != "Spring sale" {
+		t.Fatalf("inbox = %+v, want only the marketing notification the user opted in to", items)
+	}
+}
@@ -0,0 +1,110 @@
+package notifications
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// registry holds this service's metrics. It is separate from the default
+// registry so that several services can share a process in tests.
+var registry = prometheus.NewRegistry()
+
+var factory = promauto.With(registry)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	messagesConsumed = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messages_consumed_total",
+		Help: "Messages received from a queue.",
+	}, []string{"queue"})
+	messagesPublished = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messages_published_total",
+		Help: "Messages published, by destination queue.",
+	}, []string{"queue"})
+	messagesAcked = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messages_acked_total",
+		Help: "Messages acknowledged after successful handling.",
+	}, []string{"queue"})
+	messagesNacked = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messages_nacked_total",
+		Help: "Messages negatively acknowledged.",
+	}, []string{"queue"})
+	messagesDeadLettered = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "messages_dead_lettered_total",
+		Help: "Messages rejected without requeue, dead-lettered if the queue has a DLX.",
+	}, []string{"queue"})
+	handlerDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "message_handler_duration_seconds",
+		Help:    "Time spent handling a single message.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"queue"})
+	notificationsSent = factory.NewCounter(prometheus.CounterOpts{
+		Name: "notifications_sent_total",
+		Help: "Notifications delivered to users.",
+	})
+	notificationsFailed = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifications_failed_total",
+		Help: "Notification deliveries that failed, by channel.",
+	}, []string{"channel"})
+	notificationsSuppressed = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifications_suppressed_total",
+		Help: "Notifications not delivered because of the user's preferences, by category.",
+	}, []string{"category"})
+	notificationsHeld = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifications_held_total",
+		Help: "Notifications held back, by reason: low_priority or throttled for a digest, or quiet_hours.",
+	}, []string{"reason"})
+	digestsSent = factory.NewCounter(prometheus.CounterOpts{
+		Name: "notification_digests_total",
+		Help: "Digests of held notifications stored to be delivered.",
+	})
+	notificationsRetried = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifications_retried_total",
+		Help: "Notification deliveries scheduled to be tried again, by channel.",
+	}, []string{"channel"})
+	webhookDeliveries = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_deliveries_total",
+		Help: "Webhook delivery attempts, by result: delivered, retried or failed.",
+	}, []string{"result"})
+	webhooksDisabled = factory.NewCounter(prometheus.CounterOpts{
+		Name: "webhooks_disabled_total",
+		Help: "Webhooks disabled after failing too many deliveries in a row.",
+	})
+	inboxItemsAdded = factory.NewCounter(prometheus.CounterOpts{
+		Name: "inbox_items_total",
+		Help: "Notifications put in users' in-app inboxes.",
+	})
+	alertsSent = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "alerts_sent_total",
+		Help: "Notifications queued for the members of a staff role about an event, by role.",
+	}, []string{"role"})
+)
+
+// RegisterDBMetrics exports the connection pool statistics of db.
+func RegisterDBMetrics(db *sql.DB, name string) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ServeMetrics exposes /metrics on addr in the background.
+func ServeMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	go func() {
+		slog.Info("Serving metrics", "addr", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Error("Metrics server stopped", "error", err)
+		}
+	}()
+}
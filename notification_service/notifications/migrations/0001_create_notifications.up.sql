@@ -0,0 +1,6 @@
+-- IF NOT EXISTS adopts databases created by init-db.sh before migrations.
+CREATE TABLE IF NOT EXISTS notifications (
+    notification_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    message VARCHAR(255) NOT NULL
+);
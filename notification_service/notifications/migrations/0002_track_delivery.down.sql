@@ -0,0 +1,11 @@
+DROP INDEX notifications_user_id_idx;
+ALTER TABLE notifications
+    DROP COLUMN delivered_at,
+    DROP COLUMN updated_at,
+    DROP COLUMN created_at,
+    DROP COLUMN attempts,
+    DROP COLUMN status,
+    DROP COLUMN template,
+    DROP COLUMN channel,
+    ALTER COLUMN content TYPE VARCHAR(255);
+ALTER TABLE notifications RENAME COLUMN content TO message;
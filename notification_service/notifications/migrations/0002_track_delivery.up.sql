@@ -0,0 +1,17 @@
+-- Notifications stored before delivery was tracked were logged as sent.
+ALTER TABLE notifications RENAME COLUMN message TO content;
+ALTER TABLE notifications
+    ALTER COLUMN content TYPE TEXT,
+    ADD COLUMN channel VARCHAR(32) NOT NULL DEFAULT 'log',
+    ADD COLUMN template VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'delivered'
+        CHECK (status IN ('queued', 'sending', 'delivered', 'failed')),
+    ADD COLUMN attempts INT NOT NULL DEFAULT 1,
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN delivered_at TIMESTAMPTZ;
+UPDATE notifications SET delivered_at = created_at;
+ALTER TABLE notifications
+    ALTER COLUMN status SET DEFAULT 'queued',
+    ALTER COLUMN attempts SET DEFAULT 0;
+CREATE INDEX notifications_user_id_idx ON notifications (user_id, notification_id);
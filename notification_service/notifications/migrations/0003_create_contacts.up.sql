@@ -0,0 +1,9 @@
+CREATE TABLE contacts (
+    user_id INT PRIMARY KEY,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    phone VARCHAR(20) NOT NULL DEFAULT '',
+    push_endpoint TEXT NOT NULL DEFAULT '',
+    push_p256dh VARCHAR(128) NOT NULL DEFAULT '',
+    push_auth VARCHAR(64) NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
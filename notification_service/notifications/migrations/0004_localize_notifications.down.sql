@@ -0,0 +1,5 @@
+ALTER TABLE contacts DROP COLUMN locale;
+ALTER TABLE notifications
+    DROP COLUMN html,
+    DROP COLUMN subject,
+    DROP COLUMN locale;
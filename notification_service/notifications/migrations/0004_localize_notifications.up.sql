@@ -0,0 +1,5 @@
+ALTER TABLE notifications
+    ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT '',
+    ADD COLUMN subject TEXT NOT NULL DEFAULT '',
+    ADD COLUMN html TEXT NOT NULL DEFAULT '';
+ALTER TABLE contacts ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+UPDATE notifications SET status = 'failed' WHERE status = 'suppressed';
+ALTER TABLE notifications
+    DROP CONSTRAINT notifications_status_check,
+    ADD CONSTRAINT notifications_status_check
+        CHECK (status IN ('queued', 'sending', 'delivered', 'failed')),
+    DROP COLUMN category;
+DROP TABLE notification_preferences;
@@ -0,0 +1,14 @@
+CREATE TABLE notification_preferences (
+    user_id INT PRIMARY KEY,
+    opt_ins JSONB NOT NULL DEFAULT '{}',
+    quiet_start VARCHAR(5) NOT NULL DEFAULT '',
+    quiet_end VARCHAR(5) NOT NULL DEFAULT '',
+    time_zone VARCHAR(64) NOT NULL DEFAULT '',
+    unsubscribed BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+ALTER TABLE notifications
+    ADD COLUMN category VARCHAR(32) NOT NULL DEFAULT 'transactional',
+    DROP CONSTRAINT notifications_status_check,
+    ADD CONSTRAINT notifications_status_check
+        CHECK (status IN ('queued', 'sending', 'delivered', 'failed', 'suppressed'));
@@ -0,0 +1,9 @@
+-- Notifications still held for a digest were never sent.
+UPDATE notifications SET status = 'failed' WHERE status = 'digested' AND digest_id IS NULL;
+UPDATE notifications SET status = 'delivered' WHERE status = 'digested';
+DROP INDEX notifications_user_category_idx;
+ALTER TABLE notifications
+    DROP CONSTRAINT notifications_status_check,
+    ADD CONSTRAINT notifications_status_check
+        CHECK (status IN ('queued', 'sending', 'delivered', 'failed', 'suppressed')),
+    DROP COLUMN digest_id;
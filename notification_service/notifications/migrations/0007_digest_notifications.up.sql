@@ -0,0 +1,8 @@
+ALTER TABLE notifications
+    ADD COLUMN digest_id INT REFERENCES notifications (notification_id),
+    DROP CONSTRAINT notifications_status_check,
+    ADD CONSTRAINT notifications_status_check
+        CHECK (status IN ('queued', 'sending', 'delivered', 'failed', 'suppressed', 'digested'));
+-- Throttling counts a user's latest notifications of a category; digests
+-- collect the ones held back.
+CREATE INDEX notifications_user_category_idx ON notifications (user_id, category, created_at);
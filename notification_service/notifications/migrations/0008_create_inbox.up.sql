@@ -0,0 +1,14 @@
+CREATE TABLE inbox_items (
+    item_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    notification_id INT NOT NULL REFERENCES notifications ON DELETE CASCADE,
+    category VARCHAR(32) NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    content TEXT NOT NULL,
+    archived BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    read_at TIMESTAMPTZ,
+    UNIQUE (notification_id)
+);
+-- The inbox and its unread count are read by user, newest first.
+CREATE INDEX inbox_items_user_idx ON inbox_items (user_id, archived, created_at);
@@ -0,0 +1,8 @@
+-- One row per staff member alerted about an event, so a retried event
+-- skips those already alerted.
+CREATE TABLE alerts (
+    event_id VARCHAR(64) NOT NULL,
+    user_id INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (event_id, user_id)
+);
@@ -0,0 +1,612 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"ecomm-sample/contracts"
+	"ecomm-sample/migrate"
+
+	"github.com/lib/pq"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PostgresNotifications is the NotificationRepository backed by
+// notification_db.
+type PostgresNotifications struct {
+	db *sql.DB
+}
+
+// NewPostgresNotifications returns a repository over the notifications table
+// of db.
+func NewPostgresNotifications(db *sql.DB) *PostgresNotifications {
+	return &PostgresNotifications{db: db}
+}
+
+// startDBSpan starts a client span for a single query.
+func startDBSpan(ctx context.Context, name, query string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append([]attribute.KeyValue{
+		semconv.DBSystemPostgreSQL,
+		semconv.DBName("notification_db"),
+		semconv.DBStatement(query),
+	}, attrs...)
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endDBSpan records err, if any, on span and ends it.
+func endDBSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "query failed")
+	}
+	span.End()
+}
+
+func (p *PostgresNotifications) SaveNotification(ctx context.Context, n Notification) (id int, err error) {
+	const query = `INSERT INTO notifications (user_id, channel, template, category, locale, subject, content, html, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING notification_id`
+	ctx, span := startDBSpan(ctx, "NotificationRepository.SaveNotification", query, attribute.Int("user_id", n.UserID))
+	defer func() { endDBSpan(span, err) }()
+
+	err = p.db.QueryRowContext(ctx, query, n.UserID, n.Channel, n.Template, n.Category, n.Locale, n.Subject, n.Content, n.HTML, n.Status).Scan(&id)
+	return id, err
+}
+
+func (p *PostgresNotifications) StartAttempt(ctx context.Context, id int, channel string) (err error) {
+	const query = `UPDATE notifications SET status = 'sending', channel = $2, attempts = attempts + 1, updated_at = now()
+		WHERE notification_id = $1`
+	ctx, span := startDBSpan(ctx, "NotificationRepository.StartAttempt", query, attribute.Int("notification_id", id), attribute.String("channel", channel))
+	defer func() { endDBSpan(span, err) }()
+
+	res, err := p.db.ExecContext(ctx, query, id, channel)
+	if err != nil {
+		return err
+	}
+	return notificationUpdated(res)
+}
+
+func (p *PostgresNotifications) SetStatus(ctx context.Context, id int, status string) (err error) {
+	const query = `UPDATE notifications SET status = $2, updated_at = now(),
+		delivered_at = CASE WHEN $2 = 'delivered' THEN now() ELSE delivered_at END
+		WHERE notification_id = $1`
+	ctx, span := startDBSpan(ctx, "NotificationRepository.SetStatus", query, attribute.Int("notification_id", id), attribute.String("status", status))
+	defer func() { endDBSpan(span, err) }()
+
+	res, err := p.db.ExecContext(ctx, query, id, status)
+	if err != nil {
+		return err
+	}
+	return notificationUpdated(res)
+}
+
+// notificationUpdated returns ErrNotificationNotFound if res updated no
+// rows.
+func notificationUpdated(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// notificationColumns are the columns scanNotification reads.
+const notificationColumns = "notification_id, user_id, channel, template, category, locale, subject, content, html, status, attempts, created_at, updated_at, delivered_at, digest_id"
+
+// scanNotification reads a notification selected as notificationColumns.
+func scanNotification(row interface{ Scan(...interface{}) error }) (Notification, error) {
+	var n Notification
+	var deliveredAt sql.NullTime
+	var digestID sql.NullInt64
+	err := row.Scan(&n.NotificationID, &n.UserID, &n.Channel, &n.Template, &n.Category, &n.Locale, &n.Subject, &n.Content, &n.HTML, &n.Status, &n.Attempts, &n.CreatedAt, &n.UpdatedAt, &deliveredAt, &digestID)
+	if deliveredAt.Valid {
+		n.DeliveredAt = &deliveredAt.Time
+	}
+	n.DigestID = int(digestID.Int64)
+	return n, err
+}
+
+func (p *PostgresNotifications) GetNotification(ctx context.Context, id int) (n Notification, err error) {
+	const query = "SELECT " + notificationColumns + " FROM notifications WHERE notification_id = $1"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.GetNotification", query, attribute.Int("notification_id", id))
+	defer func() { endDBSpan(span, err) }()
+
+	n, err = scanNotification(p.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Notification{}, ErrNotificationNotFound
+	}
+	return n, err
+}
+
+func (p *PostgresNotifications) ListNotifications(ctx context.Context, userID int) (notifications []Notification, err error) {
+	const query = "SELECT " + notificationColumns + " FROM notifications WHERE user_id = $1 ORDER BY notification_id"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.ListNotifications", query, attribute.Int("user_id", userID))
+	defer func() { endDBSpan(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	notifications = []Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func (p *PostgresNotifications) CountRecent(ctx context.Context, userID int, category string, since time.Time) (count int, err error) {
+	const query = `SELECT count(*) FROM notifications
+		WHERE user_id = $1 AND category = $2 AND created_at >= $3 AND status <> 'digested'`
+	ctx, span := startDBSpan(ctx, "NotificationRepository.CountRecent", query, attribute.Int("user_id", userID), attribute.String("category", category))
+	defer func() { endDBSpan(span, err) }()
+
+	err = p.db.QueryRowContext(ctx, query, userID, category, since).Scan(&count)
+	return count, err
+}
+
+func (p *PostgresNotifications) ListHeld(ctx context.Context, userID int, category string) (held []Notification, err error) {
+	const query = "SELECT " + notificationColumns + ` FROM notifications
+		WHERE user_id = $1 AND category = $2 AND status = 'digested' AND digest_id IS NULL ORDER BY notification_id`
+	ctx, span := startDBSpan(ctx, "NotificationRepository.ListHeld", query, attribute.Int("user_id", userID), attribute.String("category", category))
+	defer func() { endDBSpan(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, query, userID, category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	held = []Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		held = append(held, n)
+	}
+	return held, rows.Err()
+}
+
+// SaveDigest stores the digest and links the held notifications to it in
+// one transaction. Linking only notifications not yet in a digest means
+// that of two digests racing for the same notifications, the second one
+// waits for the first and then finds them taken.
+func (p *PostgresNotifications) SaveDigest(ctx context.Context, digest Notification, held []int) (id int, err error) {
+	const insert = `INSERT INTO notifications (user_id, channel, template, category, locale, subject, content, html, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING notification_id`
+	const link = `UPDATE notifications SET digest_id = $2, updated_at = now()
+		WHERE notification_id = ANY($1) AND digest_id IS NULL`
+	ctx, span := startDBSpan(ctx, "NotificationRepository.SaveDigest", insert+"; "+link, attribute.Int("user_id", digest.UserID), attribute.Int("held", len(held)))
+	defer func() { endDBSpan(span, err) }()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+	err = tx.QueryRowContext(ctx, insert, digest.UserID, digest.Channel, digest.Template, digest.Category, digest.Locale, digest.Subject, digest.Content, digest.HTML, digest.Status).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, link, pq.Array(held), id)
+	if err != nil {
+		return 0, err
+	}
+	linked, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if linked != int64(len(held)) {
+		return 0, ErrAlreadyDigested
+	}
+	return id, tx.Commit()
+}
+
+func (p *PostgresNotifications) SaveContact(ctx context.Context, c Contact) (err error) {
+	const query = `INSERT INTO contacts (user_id, email, phone, push_endpoint, push_p256dh, push_auth, locale)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE SET email = $2, phone = $3, push_endpoint = $4, push_p256dh = $5, push_auth = $6, locale = $7, updated_at = now()`
+	ctx, span := startDBSpan(ctx, "NotificationRepository.SaveContact", query, attribute.Int("user_id", c.UserID))
+	defer func() { endDBSpan(span, err) }()
+
+	var push contracts.PushSubscription
+	if c.Push != nil {
+		push = *c.Push
+	}
+	_, err = p.db.ExecContext(ctx, query, c.UserID, c.Email, c.Phone, push.Endpoint, push.Keys.P256dh, push.Keys.Auth, c.Locale)
+	return err
+}
+
+func (p *PostgresNotifications) GetContact(ctx context.Context, userID int) (c Contact, err error) {
+	const query = "SELECT email, phone, push_endpoint, push_p256dh, push_auth, locale FROM contacts WHERE user_id = $1"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.GetContact", query, attribute.Int("user_id", userID))
+	defer func() { endDBSpan(span, err) }()
+
+	c.UserID = userID
+	var push contracts.PushSubscription
+	err = p.db.QueryRowContext(ctx, query, userID).Scan(&c.Email, &c.Phone, &push.Endpoint, &push.Keys.P256dh, &push.Keys.Auth, &c.Locale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, nil
+	}
+	if push.Endpoint != "" {
+		c.Push = &push
+	}
+	return c, err
+}
+
+func (p *PostgresNotifications) SavePreferences(ctx context.Context, prefs Preferences) (err error) {
+	const query = `INSERT INTO notification_preferences (user_id, opt_ins, quiet_start, quiet_end, time_zone, unsubscribed)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET opt_ins = $2, quiet_start = $3, quiet_end = $4, time_zone = $5, unsubscribed = $6, updated_at = now()`
+	ctx, span := startDBSpan(ctx, "NotificationRepository.SavePreferences", query, attribute.Int("user_id", prefs.UserID))
+	defer func() { endDBSpan(span, err) }()
+
+	optIns, err := json.Marshal(prefs.OptIns)
+	if err != nil {
+		return err
+	}
+	var quiet contracts.QuietHours
+	if prefs.QuietHours != nil {
+		quiet = *prefs.QuietHours
+	}
+	_, err = p.db.ExecContext(ctx, query, prefs.UserID, optIns, quiet.Start, quiet.End, quiet.TimeZone, prefs.Unsubscribed)
+	return err
+}
+
+func (p *PostgresNotifications) GetPreferences(ctx context.Context, userID int) (prefs Preferences, err error) {
+	const query = "SELECT opt_ins, quiet_start, quiet_end, time_zone, unsubscribed FROM notification_preferences WHERE user_id = $1"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.GetPreferences", query, attribute.Int("user_id", userID))
+	defer func() { endDBSpan(span, err) }()
+
+	prefs.UserID = userID
+	var optIns []byte
+	var quiet contracts.QuietHours
+	err = p.db.QueryRowContext(ctx, query, userID).Scan(&optIns, &quiet.Start, &quiet.End, &quiet.TimeZone, &prefs.Unsubscribed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return prefs, nil
+	}
+	if err != nil {
+		return prefs, err
+	}
+	if quiet.TimeZone != "" {
+		prefs.QuietHours = &quiet
+	}
+	return prefs, json.Unmarshal(optIns, &prefs.OptIns)
+}
+
+// webhookColumns are the columns scanWebhook reads.
+const webhookColumns = "webhook_id, url, events, secret, active, failures, created_at, updated_at"
+
+// scanWebhook reads a webhook selected as webhookColumns.
+func scanWebhook(row interface{ Scan(...interface{}) error }) (Webhook, error) {
+	var w Webhook
+	var events []byte
+	if err := row.Scan(&w.WebhookID, &w.URL, &events, &w.Secret, &w.Active, &w.Failures, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return w, err
+	}
+	return w, json.Unmarshal(events, &w.Events)
+}
+
+func (p *PostgresNotifications) SaveWebhook(ctx context.Context, w Webhook) (saved Webhook, err error) {
+	const insert = `INSERT INTO webhooks (url, events, secret, active, failures)
+		VALUES ($1, $2, $3, $4, $5) RETURNING ` + webhookColumns
+	const update = `UPDATE webhooks SET url = $2, events = $3, secret = $4, active = $5, failures = $6, updated_at = now()
+		WHERE webhook_id = $1 RETURNING ` + webhookColumns
+	query := insert
+	if w.WebhookID != 0 {
+		query = update
+	}
+	ctx, span := startDBSpan(ctx, "NotificationRepository.SaveWebhook", query, attribute.Int("webhook_id", w.WebhookID))
+	defer func() { endDBSpan(span, err) }()
+
+	events, err := json.Marshal(w.Events)
+	if err != nil {
+		return Webhook{}, err
+	}
+	var row *sql.Row
+	if w.WebhookID == 0 {
+		row = p.db.QueryRowContext(ctx, insert, w.URL, events, w.Secret, w.Active, w.Failures)
+	} else {
+		row = p.db.QueryRowContext(ctx, update, w.WebhookID, w.URL, events, w.Secret, w.Active, w.Failures)
+	}
+	saved, err = scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	return saved, err
+}
+
+func (p *PostgresNotifications) GetWebhook(ctx context.Context, id int) (w Webhook, err error) {
+	const query = "SELECT " + webhookColumns + " FROM webhooks WHERE webhook_id = $1"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.GetWebhook", query, attribute.Int("webhook_id", id))
+	defer func() { endDBSpan(span, err) }()
+
+	w, err = scanWebhook(p.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	return w, err
+}
+
+func (p *PostgresNotifications) ListWebhooks(ctx context.Context) (webhooks []Webhook, err error) {
+	const query = "SELECT " + webhookColumns + " FROM webhooks ORDER BY webhook_id"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.ListWebhooks", query)
+	defer func() { endDBSpan(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	webhooks = []Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+func (p *PostgresNotifications) DeleteWebhook(ctx context.Context, id int) (w Webhook, err error) {
+	const query = "DELETE FROM webhooks WHERE webhook_id = $1 RETURNING " + webhookColumns
+	ctx, span := startDBSpan(ctx, "NotificationRepository.DeleteWebhook", query, attribute.Int("webhook_id", id))
+	defer func() { endDBSpan(span, err) }()
+
+	w, err = scanWebhook(p.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	return w, err
+}
+
+func (p *PostgresNotifications) RecordWebhookResult(ctx context.Context, id int, ok bool, disableAfter int) (w Webhook, err error) {
+	const query = `UPDATE webhooks SET
+		failures = CASE WHEN $2 THEN 0 ELSE failures + 1 END,
+		active = CASE WHEN NOT $2 AND $3 > 0 AND failures + 1 >= $3 THEN false ELSE active END,
+		updated_at = now()
+		WHERE webhook_id = $1 RETURNING ` + webhookColumns
+	ctx, span := startDBSpan(ctx, "NotificationRepository.RecordWebhookResult", query, attribute.Int("webhook_id", id), attribute.Bool("ok", ok))
+	defer func() { endDBSpan(span, err) }()
+
+	w, err = scanWebhook(p.db.QueryRowContext(ctx, query, id, ok, disableAfter))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	return w, err
+}
+
+func (p *PostgresNotifications) SaveWebhookDelivery(ctx context.Context, d WebhookDelivery) (id int, created bool, err error) {
+	// On conflict RETURNING yields no row, and the stored delivery's ID is
+	// looked up instead.
+	const query = `INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload, status)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING
+		RETURNING delivery_id`
+	const stored = "SELECT delivery_id FROM webhook_deliveries WHERE webhook_id = $1 AND event_id = $2"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.SaveWebhookDelivery", query, attribute.Int("webhook_id", d.WebhookID), attribute.String("event_id", d.EventID))
+	defer func() { endDBSpan(span, err) }()
+
+	err = p.db.QueryRowContext(ctx, query, d.WebhookID, d.EventID, d.Event, d.Payload, d.Status).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = p.db.QueryRowContext(ctx, stored, d.WebhookID, d.EventID).Scan(&id)
+		return id, false, err
+	}
+	return id, err == nil, err
+}
+
+// webhookDeliveryColumns are the columns scanWebhookDelivery reads.
+const webhookDeliveryColumns = "delivery_id, webhook_id, event_id, event, payload, status, attempts, response_status, error, created_at, updated_at, delivered_at"
+
+// scanWebhookDelivery reads a delivery selected as webhookDeliveryColumns.
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	var deliveredAt sql.NullTime
+	err := row.Scan(&d.DeliveryID, &d.WebhookID, &d.EventID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.ResponseStatus, &d.Error, &d.CreatedAt, &d.UpdatedAt, &deliveredAt)
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return d, err
+}
+
+func (p *PostgresNotifications) GetWebhookDelivery(ctx context.Context, id int) (d WebhookDelivery, err error) {
+	const query = "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries WHERE delivery_id = $1"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.GetWebhookDelivery", query, attribute.Int("delivery_id", id))
+	defer func() { endDBSpan(span, err) }()
+
+	d, err = scanWebhookDelivery(p.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return WebhookDelivery{}, ErrWebhookDeliveryNotFound
+	}
+	return d, err
+}
+
+func (p *PostgresNotifications) ListWebhookDeliveries(ctx context.Context, webhookID, limit int) (deliveries []WebhookDelivery, err error) {
+	const query = "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY delivery_id DESC LIMIT $2"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.ListWebhookDeliveries", query, attribute.Int("webhook_id", webhookID))
+	defer func() { endDBSpan(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	deliveries = []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (p *PostgresNotifications) RecordWebhookAttempt(ctx context.Context, id int, status string, responseStatus int, errMsg string) (err error) {
+	const query = `UPDATE webhook_deliveries SET status = $2, attempts = attempts + 1, response_status = $3, error = $4, updated_at = now(),
+		delivered_at = CASE WHEN $2 = 'delivered' THEN now() ELSE delivered_at END
+		WHERE delivery_id = $1`
+	ctx, span := startDBSpan(ctx, "NotificationRepository.RecordWebhookAttempt", query, attribute.Int("delivery_id", id), attribute.String("status", status))
+	defer func() { endDBSpan(span, err) }()
+
+	res, err := p.db.ExecContext(ctx, query, id, status, responseStatus, errMsg)
+	if err != nil {
+		return err
+	}
+	return webhookDeliveryUpdated(res)
+}
+
+func (p *PostgresNotifications) SetWebhookDeliveryStatus(ctx context.Context, id int, status string) (err error) {
+	const query = "UPDATE webhook_deliveries SET status = $2, updated_at = now() WHERE delivery_id = $1"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.SetWebhookDeliveryStatus", query, attribute.Int("delivery_id", id), attribute.String("status", status))
+	defer func() { endDBSpan(span, err) }()
+
+	res, err := p.db.ExecContext(ctx, query, id, status)
+	if err != nil {
+		return err
+	}
+	return webhookDeliveryUpdated(res)
+}
+
+// webhookDeliveryUpdated returns ErrWebhookDeliveryNotFound if res updated
+// no rows.
+func webhookDeliveryUpdated(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+// inboxColumns are the columns scanInboxItem reads.
+const inboxColumns = "item_id, user_id, notification_id, category, subject, content, archived, created_at, read_at"
+
+func scanInboxItem(row interface{ Scan(...interface{}) error }) (InboxItem, error) {
+	var item InboxItem
+	var readAt sql.NullTime
+	err := row.Scan(&item.ItemID, &item.UserID, &item.NotificationID, &item.Category, &item.Subject, &item.Content, &item.Archived, &item.CreatedAt, &readAt)
+	if readAt.Valid {
+		item.ReadAt = &readAt.Time
+	}
+	return item, err
+}
+
+func (p *PostgresNotifications) SaveInboxItem(ctx context.Context, item InboxItem) (saved InboxItem, err error) {
+	const query = `INSERT INTO inbox_items (user_id, notification_id, category, subject, content)
+		VALUES ($1, $2, $3, $4, $5) RETURNING ` + inboxColumns
+	ctx, span := startDBSpan(ctx, "NotificationRepository.SaveInboxItem", query, attribute.Int("user_id", item.UserID), attribute.Int("notification_id", item.NotificationID))
+	defer func() { endDBSpan(span, err) }()
+
+	return scanInboxItem(p.db.QueryRowContext(ctx, query, item.UserID, item.NotificationID, item.Category, item.Subject, item.Content))
+}
+
+func (p *PostgresNotifications) ListInboxItems(ctx context.Context, userID int, archived bool, limit int) (items []InboxItem, err error) {
+	const query = "SELECT " + inboxColumns + " FROM inbox_items WHERE user_id = $1 AND archived = $2 ORDER BY item_id DESC LIMIT $3"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.ListInboxItems", query, attribute.Int("user_id", userID))
+	defer func() { endDBSpan(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, query, userID, archived, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items = []InboxItem{}
+	for rows.Next() {
+		item, err := scanInboxItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (p *PostgresNotifications) CountUnread(ctx context.Context, userID int) (count int, err error) {
+	const query = "SELECT count(*) FROM inbox_items WHERE user_id = $1 AND NOT archived AND read_at IS NULL"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.CountUnread", query, attribute.Int("user_id", userID))
+	defer func() { endDBSpan(span, err) }()
+
+	err = p.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+func (p *PostgresNotifications) SetInboxItemRead(ctx context.Context, userID, id int, read bool) (item InboxItem, err error) {
+	const query = `UPDATE inbox_items SET read_at = CASE WHEN $3 THEN COALESCE(read_at, now()) END
+		WHERE item_id = $1 AND user_id = $2 RETURNING ` + inboxColumns
+	ctx, span := startDBSpan(ctx, "NotificationRepository.SetInboxItemRead", query, attribute.Int("user_id", userID), attribute.Int("item_id", id))
+	defer func() { endDBSpan(span, err) }()
+
+	item, err = scanInboxItem(p.db.QueryRowContext(ctx, query, id, userID, read))
+	if errors.Is(err, sql.ErrNoRows) {
+		return InboxItem{}, ErrInboxItemNotFound
+	}
+	return item, err
+}
+
+func (p *PostgresNotifications) SetInboxItemArchived(ctx context.Context, userID, id int, archived bool) (item InboxItem, err error) {
+	const query = `UPDATE inbox_items SET archived = $3 WHERE item_id = $1 AND user_id = $2 RETURNING ` + inboxColumns
+	ctx, span := startDBSpan(ctx, "NotificationRepository.SetInboxItemArchived", query, attribute.Int("user_id", userID), attribute.Int("item_id", id))
+	defer func() { endDBSpan(span, err) }()
+
+	item, err = scanInboxItem(p.db.QueryRowContext(ctx, query, id, userID, archived))
+	if errors.Is(err, sql.ErrNoRows) {
+		return InboxItem{}, ErrInboxItemNotFound
+	}
+	return item, err
+}
+
+func (p *PostgresNotifications) MarkAllRead(ctx context.Context, userID int) (err error) {
+	const query = "UPDATE inbox_items SET read_at = now() WHERE user_id = $1 AND read_at IS NULL"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.MarkAllRead", query, attribute.Int("user_id", userID))
+	defer func() { endDBSpan(span, err) }()
+
+	_, err = p.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+func (p *PostgresNotifications) AlertSent(ctx context.Context, eventID string, userID int) (sent bool, err error) {
+	const query = "SELECT EXISTS (SELECT 1 FROM alerts WHERE event_id = $1 AND user_id = $2)"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.AlertSent", query, attribute.String("event_id", eventID), attribute.Int("user_id", userID))
+	defer func() { endDBSpan(span, err) }()
+
+	err = p.db.QueryRowContext(ctx, query, eventID, userID).Scan(&sent)
+	return sent, err
+}
+
+func (p *PostgresNotifications) SaveAlert(ctx context.Context, eventID string, userID int) (err error) {
+	const query = "INSERT INTO alerts (event_id, user_id) VALUES ($1, $2) ON CONFLICT (event_id, user_id) DO NOTHING"
+	ctx, span := startDBSpan(ctx, "NotificationRepository.SaveAlert", query, attribute.String("event_id", eventID), attribute.Int("user_id", userID))
+	defer func() { endDBSpan(span, err) }()
+
+	_, err = p.db.ExecContext(ctx, query, eventID, userID)
+	return err
+}
+
+func (p *PostgresNotifications) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+func (p *PostgresNotifications) SchemaVersion(ctx context.Context) (int, error) {
+	return migrate.CurrentVersion(ctx, p.db)
+}
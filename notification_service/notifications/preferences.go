@@ -0,0 +1,173 @@
+package notifications
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // quiet hours need time zones wherever the service runs
+
+	"ecomm-sample/contracts"
+)
+
+// userChannels are the channels users set preferences for. The log channel
+// only writes to the service log and is not one of them.
+var userChannels = []string{ChannelEmail, ChannelSMS, ChannelPush, ChannelInbox}
+
+// categories are the notification categories.
+var categories = []string{contracts.CategoryTransactional, contracts.CategoryMarketing}
+
+// interruptingChannels are the channels not used during quiet hours.
+var interruptingChannels = map[string]bool{ChannelSMS: true, ChannelPush: true}
+
+// quietHoursRecheck is how long a notification deferred by quiet hours
+// waits before it checks whether they are over.
+const quietHoursRecheck = 15 * time.Minute
+
+// Preferences are a user's notification preferences. OptIns holds the
+// choices the user made, by category and then channel; channels without
+// one fall back to the category's default.
+type Preferences struct {
+	UserID       int
+	OptIns       map[string]map[string]bool
+	QuietHours   *contracts.QuietHours
+	Unsubscribed bool // from marketing, on every channel
+}
+
+// preferencesFrom returns the preferences in a contracts.PreferencesSet.
+func preferencesFrom(p contracts.PreferencesSet) Preferences {
+	return Preferences{UserID: p.UserID, OptIns: p.Categories, QuietHours: p.QuietHours, Unsubscribed: p.Unsubscribed}
+}
+
+// details returns p as a contracts.PreferencesDetails, with the defaults
+// for the channels the user made no choice for.
+func (p Preferences) details() contracts.PreferencesDetails {
+	d := contracts.PreferencesDetails{
+		UserID:       p.UserID,
+		Categories:   map[string]map[string]bool{},
+		QuietHours:   p.QuietHours,
+		Unsubscribed: p.Unsubscribed,
+	}
+	for _, category := range categories {
+		d.Categories[category] = map[string]bool{}
+		for _, channel := range userChannels {
+			d.Categories[category][channel] = p.optedIn(category, channel)
+		}
+	}
+	return d
+}
+
+// validate reports what is wrong with p, if anything.
+func (p Preferences) validate() error {
+	for category, channels := range p.OptIns {
+		if !contains(categories, category) {
+			return fmt.Errorf("unknown notification category %q", category)
+		}
+		for channel := range channels {
+			if !contains(userChannels, channel) {
+				return fmt.Errorf("unknown notification channel %q", channel)
+			}
+		}
+	}
+	if q := p.QuietHours; q != nil {
+		if _, err := time.Parse("15:04", q.Start); err != nil {
+			return fmt.Errorf("quiet hours start %q is not a time such as 22:00", q.Start)
+		}
+		if _, err := time.Parse("15:04", q.End); err != nil {
+			return fmt.Errorf("quiet hours end %q is not a time such as 07:00", q.End)
+		}
+		if _, err := time.LoadLocation(q.TimeZone); err != nil || q.TimeZone == "" {
+			return fmt.Errorf("unknown time zone %q", q.TimeZone)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// optedIn reports whether the user wants notifications of category on
+// channel: by default every transactional notification and no marketing.
+func (p Preferences) optedIn(category, channel string) bool {
+	if choice, ok := p.OptIns[category][channel]; ok {
+		return choice
+	}
+	return category != contracts.CategoryMarketing
+}
+
+// wants reports whether the user wants notifications of category on
+// channel at all. Marketing notifications go only to channels the user
+// opted in to, never to the log channel and never after the user
+// unsubscribed.
+func (p Preferences) wants(category, channel string) bool {
+	if category == contracts.CategoryMarketing && (p.Unsubscribed || channel == ChannelLog) {
+		return false
+	}
+	return channel == ChannelLog || p.optedIn(category, channel)
+}
+
+// allows reports whether a notification of category may go out on channel
+// at now: the user must want it there, and SMS and push wait out the
+// user's quiet hours.
+func (p Preferences) allows(category, channel string, now time.Time) bool {
+	if interruptingChannels[channel] && p.quiet(now) {
+		return false
+	}
+	return p.wants(category, channel)
+}
+
+// deferred reports whether a notification of category that may go out on
+// none of the channels of route at now is only waiting for the user's
+// quiet hours to end.
+func (p Preferences) deferred(route []Channel, category string, now time.Time) bool {
+	if !p.quiet(now) {
+		return false
+	}
+	for _, ch := range route {
+		if p.wants(category, ch.Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+// quiet reports whether now falls in the user's quiet hours.
+func (p Preferences) quiet(now time.Time) bool {
+	q := p.QuietHours
+	if q == nil {
+		return false
+	}
+	loc, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return false
+	}
+	start, err1 := time.Parse("15:04", q.Start)
+	end, err2 := time.Parse("15:04", q.End)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	from, to := start.Hour()*60+start.Minute(), end.Hour()*60+end.Minute()
+	if from <= to {
+		return minute >= from && minute < to
+	}
+	// The quiet hours span midnight.
+	return minute >= from || minute < to
+}
+
+// filter returns the channels of route a notification of category may go
+// out on at now.
+func (p Preferences) filter(route []Channel, category string, now time.Time) []Channel {
+	var allowed []Channel
+	for _, ch := range route {
+		if p.allows(category, ch.Name(), now) {
+			allowed = append(allowed, ch)
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,131 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+
+	"ecomm-sample/contracts"
+)
+
+func TestPreferencesAllows(t *testing.T) {
+	// 23:30 in Berlin, 17:30 in New York.
+	night := time.Date(2024, time.January, 15, 22, 30, 0, 0, time.UTC)
+	quiet := &contracts.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Berlin"}
+	tests := []struct {
+		name              string
+		prefs             Preferences
+		category, channel string
+		want              bool
+	}{
+		{"transactional by default", Preferences{}, contracts.CategoryTransactional, ChannelSMS, true},
+		{"no marketing by default", Preferences{}, contracts.CategoryMarketing, ChannelEmail, false},
+		{"marketing opt-in", Preferences{OptIns: map[string]map[string]bool{contracts.CategoryMarketing: {ChannelEmail: true}}}, contracts.CategoryMarketing, ChannelEmail, true},
+		{"transactional opt-out", Preferences{OptIns: map[string]map[string]bool{contracts.CategoryTransactional: {ChannelEmail: false}}}, contracts.CategoryTransactional, ChannelEmail, false},
+		{"opt-out is per channel", Preferences{OptIns: map[string]map[string]bool{contracts.CategoryTransactional: {ChannelEmail: false}}}, contracts.CategoryTransactional, ChannelPush, true},
+		{"unsubscribed", Preferences{OptIns: map[string]map[string]bool{contracts.CategoryMarketing: {ChannelEmail: true}}, Unsubscribed: true}, contracts.CategoryMarketing, ChannelEmail, false},
+		{"unsubscribing keeps transactional", Preferences{Unsubscribed: true}, contracts.CategoryTransactional, ChannelEmail, true},
+		{"log takes transactional", Preferences{}, contracts.CategoryTransactional, ChannelLog, true},
+		{"log never takes marketing", Preferences{}, contracts.CategoryMarketing, ChannelLog, false},
+		{"quiet hours hold SMS", Preferences{QuietHours: quiet}, contracts.CategoryTransactional, ChannelSMS, false},
+		{"quiet hours hold push", Preferences{QuietHours: quiet}, contracts.CategoryTransactional, ChannelPush, false},
+		{"quiet hours allow email", Preferences{QuietHours: quiet}, contracts.CategoryTransactional, ChannelEmail, true},
+		{"quiet hours in the user's time zone", Preferences{QuietHours: &contracts.QuietHours{Start: "22:00", End: "07:00", TimeZone: "America/New_York"}}, contracts.CategoryTransactional, ChannelSMS, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.prefs.allows(tt.category, tt.channel, night); got != tt.want {
+				t.Fatalf("allows(%s, %s) = %v, want %v", tt.category, tt.channel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreferencesQuiet(t *testing.T) {
+	at := func(hour, minute int) time.Time { return time.Date(2024, time.July, 1, hour, minute, 0, 0, time.UTC) }
+	tests := []struct {
+		start, end string
+		now        time.Time
+		want       bool
+	}{
+		{"22:00", "07:00", at(23, 0), true},
+		{"22:00", "07:00", at(3, 0), true},
+		{"22:00", "07:00", at(7, 0), false},
+		{"22:00", "07:00", at(21, 59), false},
+		{"13:00", "14:30", at(14, 0), true},
+		{"13:00", "14:30", at(14, 30), false},
+		{"13:00", "14:30", at(12, 0), false},
+	}
+	for _, tt := range tests {
+		p := Preferences{QuietHours: &contracts.QuietHours{Start: tt.start, End: tt.end, TimeZone: "UTC"}}
+		if got := p.quiet(tt.now); got != tt.want {
+			t.Errorf("quiet %s-%s at %s = %v, want %v", tt.start, tt.end, tt.now.Format("15:04"), got, tt.want)
+		}
+	}
+	if (Preferences{}).quiet(at(3, 0)) {
+		t.Error("quiet without quiet hours")
+	}
+}
+
+func TestPreferencesDetails(t *testing.T) {
+	p := Preferences{UserID: 7, OptIns: map[string]map[string]bool{contracts.CategoryMarketing: {ChannelPush: true}}}
+	d := p.details()
+	if d.UserID != 7 || len(d.Categories) != 2 {
+		t.Fatalf("details = %+v", d)
+	}
+	for category, channels := range d.Categories {
+		for _, channel := range userChannels {
+			want := category == contracts.CategoryTransactional || channel == ChannelPush
+			if got, ok := channels[channel]; !ok || got != want {
+				t.Errorf("%s on %s = %v, %v; want %v", category, channel, got, ok, want)
+			}
+		}
+	}
+}
+
+func TestPreferencesValidate(t *testing.T) {
+	valid := Preferences{
+		OptIns:     map[string]map[string]bool{contracts.CategoryMarketing: {ChannelEmail: true}},
+		QuietHours: &contracts.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Berlin"},
+	}
+	if err := valid.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	for name, p := range map[string]Preferences{
+		"unknown category":  {OptIns: map[string]map[string]bool{"gossip": {ChannelEmail: true}}},
+		"unknown channel":   {OptIns: map[string]map[string]bool{contracts.CategoryMarketing: {"fax": true}}},
+		"log channel":       {OptIns: map[string]map[string]bool{contracts.CategoryMarketing: {ChannelLog: true}}},
+		"bad start":         {QuietHours: &contracts.QuietHours{Start: "10pm", End: "07:00", TimeZone: "UTC"}},
+		"bad end":           {QuietHours: &contracts.QuietHours{Start: "22:00", End: "25:00", TimeZone: "UTC"}},
+		"unknown time zone": {QuietHours: &contracts.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Mars/Olympus"}},
+		"no time zone":      {QuietHours: &contracts.QuietHours{Start: "22:00", End: "07:00"}},
+	} {
+		if err := p.validate(); err == nil {
+			t.Errorf("validate with %s succeeded", name)
+		}
+	}
+}
+
+func TestUnsubscribeLinks(t *testing.T) {
+	links := NewUnsubscribeLinks([]byte("secret"), "https://shop.example/api/v2/unsubscribe")
+	token := links.Token(7)
+	if id, err := links.UserID(token); err != nil || id != 7 {
+		t.Fatalf("UserID(Token(7)) = %d, %v", id, err)
+	}
+	if url := links.URL(7); url != "https://shop.example/api/v2/unsubscribe?token="+token {
+		t.Errorf("URL = %s", url)
+	}
+	other := NewUnsubscribeLinks([]byte("other secret"), "")
+	for name, token := range map[string]string{
+		"empty":         "",
+		"no signature":  "7",
+		"other user":    "8." + token[2:],
+		"other secret":  other.Token(7),
+		"bad encoding":  "7.!!!",
+		"not a user id": "seven." + token[2:],
+		"truncated":     token[:len(token)-2],
+	} {
+		if _, err := links.UserID(token); err == nil {
+			t.Errorf("UserID with %s token succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"ecomm-sample/contracts"
+)
+
+// WebPush delivers notifications to browsers through their push services
+// (RFC 8030). Payloads are encrypted for the subscription (RFC 8291) and
+// requests are signed with the application server's VAPID key (RFC 8292).
+type WebPush struct {
+	key        *ecdsa.PrivateKey // VAPID key
+	publicKey  string            // VAPID public key, base64url
+	subscriber string            // contact for push services, mailto: or https: URL
+	ttl        time.Duration     // how long push services keep undelivered messages
+	client     *http.Client
+}
+
+// NewWebPush returns a push channel signing with the P-256 VAPID key key.
+// subscriber is a mailto: or https: URL push services can contact the
+// operator at.
+func NewWebPush(key *ecdsa.PrivateKey, subscriber string) (*WebPush, error) {
+	pub, err := key.PublicKey.ECDH()
+	if err != nil {
+		return nil, fmt.Errorf("VAPID key: %w", err)
+	}
+	return &WebPush{
+		key:        key,
+		publicKey:  base64.RawURLEncoding.EncodeToString(pub.Bytes()),
+		subscriber: subscriber,
+		ttl:        24 * time.Hour,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// LoadVAPIDKey reads a PEM-encoded P-256 private key, in SEC 1 or PKCS #8
+// form, such as one made by
+//
+//	openssl ecparam -name prime256v1 -genkey -noout -out vapid.pem
+func LoadVAPIDKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ECDSA key", path)
+	}
+	return key, nil
+}
+
+// PublicKey returns the VAPID public key, base64url encoded, which browsers
+// pass to pushManager.subscribe as applicationServerKey.
+func (p *WebPush) PublicKey() string { return p.publicKey }
+
+func (p *WebPush) Name() string { return ChannelPush }
+
+func (p *WebPush) Send(ctx context.Context, to Contact, n Notification) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"notification_id": n.NotificationID,
+		"template":        n.Template,
+		"title":           n.Subject,
+		"body":            n.Content,
+	})
+	if err != nil {
+		return permanent(err)
+	}
+	// A subscription whose keys do not work, or a message too large to
+	// push, will not do better next time.
+	body, err := encryptPush(*to.Push, payload)
+	if err != nil {
+		return permanent(err)
+	}
+	auth, err := p.vapidAuthorization(to.Push.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, to.Push.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(p.ttl.Seconds())))
+	req.Header.Set("Authorization", auth)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return providerError("push service", resp)
+	}
+	return nil
+}
+
+// vapidAuthorization returns the Authorization header for a push to
+// endpoint: a JWT signed with the VAPID key for the endpoint's origin, valid
+// for 12 hours from now.
+func (p *WebPush) vapidAuthorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("push endpoint: %w", err)
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": p.subscriber,
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, p.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	jwt := signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+	return "vapid t=" + jwt + ", k=" + p.publicKey, nil
+}
+
+// errPushTooLarge is returned for a payload too large for a push message.
+var errPushTooLarge = errors.New("push payload too large")
+
+// pushRecordSize is the record size of encrypted push messages; a payload
+// always fits in one record.
+const pushRecordSize = 4096
+
+// encryptPush encrypts plaintext for sub with the aes128gcm content coding
+// (RFC 8188) keyed as RFC 8291 describes, with a fresh ephemeral key and
+// salt.
+func encryptPush(sub contracts.PushSubscription, plaintext []byte) ([]byte, error) {
+	uaPublic, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(sub.Keys.P256dh, "="))
+	if err != nil {
+		return nil, fmt.Errorf("push subscription p256dh: %w", err)
+	}
+	authSecret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(sub.Keys.Auth, "="))
+	if err != nil {
+		return nil, fmt.Errorf("push subscription auth: %w", err)
+	}
+	uaKey, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("push subscription p256dh: %w", err)
+	}
+	asKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	ecdhSecret, err := asKey.ECDH(uaKey)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asKey.PublicKey().Bytes()
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0 || ua_public || as_public)
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+	ikm := hkdf(authSecret, ecdhSecret, keyInfo, 32)
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// A single record: the plaintext and the last-record delimiter.
+	record := append(append([]byte(nil), plaintext...), 2)
+	if len(record)+gcm.Overhead() > pushRecordSize {
+		return nil, errPushTooLarge
+	}
+
+	// Header: salt, record size, key ID length and the key ID, which is the
+	// sender's public key.
+	body := append([]byte(nil), salt...)
+	body = binary.BigEndian.AppendUint32(body, pushRecordSize)
+	body = append(body, byte(len(asPublic)))
+	body = append(body, asPublic...)
+	return gcm.Seal(body, nonce, record, nil), nil
+}
+
+// hkdf derives length (at most 32) bytes from ikm with HKDF-SHA-256
+// (RFC 5869).
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:length]
+}
@@ -0,0 +1,230 @@
+package notifications
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ecomm-sample/contracts"
+)
+
+// testSubscription is a browser's side of a push subscription.
+type testSubscription struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+	sub  contracts.PushSubscription
+}
+
+func newTestSubscription(t *testing.T, endpoint string) testSubscription {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return testSubscription{key: key, auth: auth, sub: contracts.PushSubscription{
+		Endpoint: endpoint,
+		Keys: contracts.PushKeys{
+			P256dh: base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+			Auth:   base64.RawURLEncoding.EncodeToString(auth),
+		},
+	}}
+}
+
+// decrypt decrypts a push message body as the browser would (RFC 8291).
+func (s testSubscription) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	if len(body) < 21 {
+		t.Fatalf("push body too short: %d bytes", len(body))
+	}
+	salt, rs, idLen := body[:16], binary.BigEndian.Uint32(body[16:20]), int(body[20])
+	if rs != pushRecordSize {
+		t.Fatalf("record size = %d, want %d", rs, pushRecordSize)
+	}
+	asPublic, ciphertext := body[21:21+idLen], body[21+idLen:]
+	asKey, err := ecdh.P256().NewPublicKey(asPublic)
+	if err != nil {
+		t.Fatalf("sender key: %v", err)
+	}
+	secret, err := s.key.ECDH(asKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyInfo := append(append([]byte("WebPush: info\x00"), s.key.PublicKey().Bytes()...), asPublic...)
+	ikm := hkdf(s.auth, secret, keyInfo, 32)
+	block, _ := aes.NewCipher(hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16))
+	gcm, _ := cipher.NewGCM(block)
+	record, err := gcm.Open(nil, hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12), ciphertext, nil)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if len(record) == 0 || record[len(record)-1] != 2 {
+		t.Fatalf("record does not end with the last-record delimiter")
+	}
+	return record[:len(record)-1]
+}
+
+func TestWebPush(t *testing.T) {
+	vapid, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	push, err := NewWebPush(vapid, "mailto:ops@example.com")
+	if err != nil {
+		t.Fatalf("NewWebPush: %v", err)
+	}
+
+	var req *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	browser := newTestSubscription(t, srv.URL+"/push/abc")
+	to := Contact{UserID: 7, Push: &browser.sub}
+	if err := push.Send(context.Background(), to, Notification{NotificationID: 3, Template: "order_shipped", Content: "Order shipped"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if req.URL.Path != "/push/abc" || req.Header.Get("Content-Encoding") != "aes128gcm" || req.Header.Get("TTL") == "" {
+		t.Fatalf("request = %s %s with %v", req.Method, req.URL, req.Header)
+	}
+	var payload struct {
+		NotificationID int    `json:"notification_id"`
+		Template       string `json:"template"`
+		Body           string `json:"body"`
+	}
+	if err := json.Unmarshal(browser.decrypt(t, body), &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.NotificationID != 3 || payload.Template != "order_shipped" || payload.Body != "Order shipped" {
+		t.Fatalf("payload = %+v", payload)
+	}
+
+	claims := verifyVAPID(t, req.Header.Get("Authorization"), &vapid.PublicKey)
+	if claims.Aud != srv.URL || claims.Sub != "mailto:ops@example.com" {
+		t.Fatalf("claims = %+v, want aud %s", claims, srv.URL)
+	}
+	if exp := time.Unix(claims.Exp, 0); exp.Before(time.Now()) || exp.After(time.Now().Add(24*time.Hour)) {
+		t.Fatalf("exp = %v, want within a day", exp)
+	}
+}
+
+type vapidClaims struct {
+	Aud string `json:"aud"`
+	Exp int64  `json:"exp"`
+	Sub string `json:"sub"`
+}
+
+// verifyVAPID checks a "vapid t=<jwt>, k=<key>" header against pub and
+// returns the JWT's claims.
+func verifyVAPID(t *testing.T, header string, pub *ecdsa.PublicKey) vapidClaims {
+	t.Helper()
+	rest, ok := strings.CutPrefix(header, "vapid t=")
+	jwt, key, ok2 := strings.Cut(rest, ", k=")
+	if !ok || !ok2 {
+		t.Fatalf("Authorization = %q", header)
+	}
+	wantKey, _ := pub.ECDH()
+	if key != base64.RawURLEncoding.EncodeToString(wantKey.Bytes()) {
+		t.Fatalf("k = %s, want the VAPID public key", key)
+	}
+
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("JWT has %d parts", len(parts))
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		t.Fatalf("JWT signature: %v (%d bytes)", err, len(sig))
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		t.Fatal("JWT signature does not verify")
+	}
+	raw, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims vapidClaims
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		t.Fatalf("JWT claims: %v", err)
+	}
+	return claims
+}
+
+func TestWebPushServiceError(t *testing.T) {
+	vapid, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	push, _ := NewWebPush(vapid, "mailto:ops@example.com")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "subscription expired", http.StatusGone)
+	}))
+	defer srv.Close()
+
+	browser := newTestSubscription(t, srv.URL)
+	err := push.Send(context.Background(), Contact{UserID: 7, Push: &browser.sub}, Notification{NotificationID: 1, Content: "hi"})
+	if err == nil || !strings.Contains(err.Error(), "subscription expired") {
+		t.Fatalf("Send = %v, want the push service's error", err)
+	}
+}
+
+func TestEncryptPushRejects(t *testing.T) {
+	browser := newTestSubscription(t, "https://push.example/abc")
+	if _, err := encryptPush(browser.sub, make([]byte, pushRecordSize)); err != errPushTooLarge {
+		t.Fatalf("encryptPush of a large payload = %v, want errPushTooLarge", err)
+	}
+	bad := browser.sub
+	bad.Keys.P256dh = base64.RawURLEncoding.EncodeToString([]byte("not a point"))
+	if _, err := encryptPush(bad, []byte("hi")); err == nil {
+		t.Fatal("encryptPush accepted an invalid p256dh key")
+	}
+}
+
+func TestLoadVAPIDKey(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	sec1, _ := x509.MarshalECPrivateKey(key)
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+	dir := t.TempDir()
+	for name, block := range map[string]*pem.Block{
+		"sec1.pem":  {Type: "EC PRIVATE KEY", Bytes: sec1},
+		"pkcs8.pem": {Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := LoadVAPIDKey(path)
+		if err != nil {
+			t.Fatalf("LoadVAPIDKey(%s): %v", name, err)
+		}
+		if !got.Equal(key) {
+			t.Fatalf("LoadVAPIDKey(%s) returned a different key", name)
+		}
+	}
+
+	path := filepath.Join(dir, "junk.pem")
+	os.WriteFile(path, []byte("not PEM"), 0o600)
+	if _, err := LoadVAPIDKey(path); err == nil {
+		t.Fatal("LoadVAPIDKey accepted a file without PEM data")
+	}
+}
@@ -0,0 +1,566 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"ecomm-sample/contracts"
+)
+
+// ErrNotificationNotFound is returned for a notification ID that is not
+// stored.
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// ErrAlreadyDigested is returned for a digest of notifications one of
+// which was sent in another digest in the meantime.
+var ErrAlreadyDigested = errors.New("notification already sent in a digest")
+
+// ErrWebhookNotFound is returned for a webhook ID that is not stored.
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// ErrWebhookDeliveryNotFound is returned for a webhook delivery ID that is
+// not stored.
+var ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+// ErrInboxItemNotFound is returned for an inbox item ID that is not stored
+// in the user's inbox.
+var ErrInboxItemNotFound = errors.New("inbox item not found")
+
+// NotificationRepository stores the notifications sent to users and the
+// progress of their delivery.
+type NotificationRepository interface {
+	// SaveNotification stores n, stamped with the current time, and returns
+	// its assigned ID.
+	SaveNotification(ctx context.Context, n Notification) (int, error)
+	// StartAttempt moves a notification to sending on channel and counts
+	// the delivery attempt.
+	StartAttempt(ctx context.Context, id int, channel string) error
+	// SetStatus moves a notification to status. Moving to delivered records
+	// the delivery time.
+	SetStatus(ctx context.Context, id int, status string) error
+	// GetNotification returns the notification with the given ID, or
+	// ErrNotificationNotFound.
+	GetNotification(ctx context.Context, id int) (Notification, error)
+	// ListNotifications returns the user's notifications, oldest first.
+	ListNotifications(ctx context.Context, userID int) ([]Notification, error)
+	// CountRecent counts the user's notifications of category stored since
+	// since, leaving out those held for a digest.
+	CountRecent(ctx context.Context, userID int, category string, since time.Time) (int, error)
+	// ListHeld returns the user's notifications of category that are held
+	// for a digest and not yet sent in one, oldest first.
+	ListHeld(ctx context.Context, userID int, category string) ([]Notification, error)
+	// SaveDigest stores digest like SaveNotification and records that the
+	// held notifications with the given IDs are sent in it. If one of them
+	// already was sent in another digest it stores nothing and returns
+	// ErrAlreadyDigested.
+	SaveDigest(ctx context.Context, digest Notification, held []int) (int, error)
+	// SaveContact creates or replaces the user's contact details.
+	SaveContact(ctx context.Context, c Contact) error
+	// GetContact returns the user's contact details, which are empty for a
+	// user without any.
+	GetContact(ctx context.Context, userID int) (Contact, error)
+	// SavePreferences creates or replaces the user's notification
+	// preferences.
+	SavePreferences(ctx context.Context, p Preferences) error
+	// GetPreferences returns the user's notification preferences, which
+	// are empty for a user who made no choices.
+	GetPreferences(ctx context.Context, userID int) (Preferences, error)
+	// SaveWebhook creates w if its ID is 0, stamped with the current time,
+	// and otherwise replaces the stored webhook with that ID, or returns
+	// ErrWebhookNotFound. It returns the stored webhook.
+	SaveWebhook(ctx context.Context, w Webhook) (Webhook, error)
+	// GetWebhook returns the webhook with the given ID, or
+	// ErrWebhookNotFound.
+	GetWebhook(ctx context.Context, id int) (Webhook, error)
+	// ListWebhooks returns every webhook, ordered by ID.
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	// DeleteWebhook deletes the webhook with the given ID and its
+	// deliveries, and returns it, or ErrWebhookNotFound.
+	DeleteWebhook(ctx context.Context, id int) (Webhook, error)
+	// RecordWebhookResult records whether a delivery to the webhook with
+	// the given ID succeeded and returns the webhook. A success resets its
+	// failures; a failure counts one more, and disables the webhook once
+	// it has failed disableAfter times in a row, unless disableAfter is 0.
+	RecordWebhookResult(ctx context.Context, id int, ok bool, disableAfter int) (Webhook, error)
+	// SaveWebhookDelivery stores d, stamped with the current time, and
+	// returns its assigned ID and true. A webhook is sent each event once:
+	// for an event already stored for d's webhook it returns the stored
+	// delivery's ID and false.
+	SaveWebhookDelivery(ctx context.Context, d WebhookDelivery) (int, bool, error)
+	// GetWebhookDelivery returns the delivery with the given ID, or
+	// ErrWebhookDeliveryNotFound.
+	GetWebhookDelivery(ctx context.Context, id int) (WebhookDelivery, error)
+	// ListWebhookDeliveries returns up to limit of a webhook's deliveries,
+	// newest first.
+	ListWebhookDeliveries(ctx context.Context, webhookID, limit int) ([]WebhookDelivery, error)
+	// RecordWebhookAttempt counts an attempt of the delivery with the given
+	// ID and records its outcome: the delivery's new status and the
+	// response status and error of the attempt. Moving to delivered
+	// records the delivery time.
+	RecordWebhookAttempt(ctx context.Context, id int, status string, responseStatus int, errMsg string) error
+	// SetWebhookDeliveryStatus moves the delivery with the given ID to
+	// status.
+	SetWebhookDeliveryStatus(ctx context.Context, id int, status string) error
+	// SaveInboxItem puts item in its user's inbox, stamped with the current
+	// time, and returns the stored item.
+	SaveInboxItem(ctx context.Context, item InboxItem) (InboxItem, error)
+	// ListInboxItems returns up to limit of the user's inbox items that
+	// are archived, or not, newest first.
+	ListInboxItems(ctx context.Context, userID int, archived bool, limit int) ([]InboxItem, error)
+	// CountUnread counts the unread items in the user's inbox that are not
+	// archived.
+	CountUnread(ctx context.Context, userID int) (int, error)
+	// SetInboxItemRead marks the item with the given ID in the user's
+	// inbox read, recording when, or unread, and returns it, or
+	// ErrInboxItemNotFound.
+	SetInboxItemRead(ctx context.Context, userID, id int, read bool) (InboxItem, error)
+	// SetInboxItemArchived archives or unarchives the item with the given
+	// ID in the user's inbox and returns it, or ErrInboxItemNotFound.
+	SetInboxItemArchived(ctx context.Context, userID, id int, archived bool) (InboxItem, error)
+	// MarkAllRead marks every unread item in the user's inbox read.
+	MarkAllRead(ctx context.Context, userID int) error
+	// AlertSent reports whether the user was alerted about the event with
+	// the given ID.
+	AlertSent(ctx context.Context, eventID string, userID int) (bool, error)
+	// SaveAlert records that the user was alerted about the event with the
+	// given ID. Recording it again changes nothing.
+	SaveAlert(ctx context.Context, eventID string, userID int) error
+	// Ping reports whether the underlying store is reachable.
+	Ping(ctx context.Context) error
+	// SchemaVersion returns the version of the store's schema.
+	SchemaVersion(ctx context.Context) (int, error)
+}
+
+// MemoryNotifications is a NotificationRepository held in a slice, for tests.
+type MemoryNotifications struct {
+	mu            sync.Mutex
+	notifications []Notification
+	contacts      map[int]Contact
+	preferences   map[int]Preferences
+	webhooks      map[int]Webhook
+	deliveries    map[int]WebhookDelivery
+	inbox         map[int]InboxItem
+	lastWebhook   int
+	lastDelivery  int
+	lastInboxItem int
+	alerts        map[alert]bool
+}
+
+// NewMemoryNotifications returns an empty in-memory repository.
+func NewMemoryNotifications() *MemoryNotifications {
+	return &MemoryNotifications{
+		contacts:    map[int]Contact{},
+		preferences: map[int]Preferences{},
+		webhooks:    map[int]Webhook{},
+		deliveries:  map[int]WebhookDelivery{},
+		inbox:       map[int]InboxItem{},
+		alerts:      map[alert]bool{},
+	}
+}
+
+// alert identifies a user alerted about an event.
+type alert struct {
+	eventID string
+	userID  int
+}
+
+func (m *MemoryNotifications) SaveNotification(ctx context.Context, n Notification) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n.NotificationID = len(m.notifications) + 1
+	n.CreatedAt = time.Now().UTC()
+	n.UpdatedAt = n.CreatedAt
+	m.notifications = append(m.notifications, n)
+	return n.NotificationID, nil
+}
+
+func (m *MemoryNotifications) StartAttempt(ctx context.Context, id int, channel string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id < 1 || id > len(m.notifications) {
+		return ErrNotificationNotFound
+	}
+	n := &m.notifications[id-1]
+	n.Status = contracts.NotificationStatusSending
+	n.Channel = channel
+	n.Attempts++
+	n.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (m *MemoryNotifications) SetStatus(ctx context.Context, id int, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id < 1 || id > len(m.notifications) {
+		return ErrNotificationNotFound
+	}
+	n := &m.notifications[id-1]
+	n.Status = status
+	n.UpdatedAt = time.Now().UTC()
+	if status == contracts.NotificationStatusDelivered {
+		deliveredAt := n.UpdatedAt
+		n.DeliveredAt = &deliveredAt
+	}
+	return nil
+}
+
+func (m *MemoryNotifications) GetNotification(ctx context.Context, id int) (Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id < 1 || id > len(m.notifications) {
+		return Notification{}, ErrNotificationNotFound
+	}
+	return m.notifications[id-1], nil
+}
+
+func (m *MemoryNotifications) ListNotifications(ctx context.Context, userID int) ([]Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	notifications := []Notification{}
+	for _, n := range m.notifications {
+		if n.UserID == userID {
+			notifications = append(notifications, n)
+		}
+	}
+	return notifications, nil
+}
+
+func (m *MemoryNotifications) CountRecent(ctx context.Context, userID int, category string, since time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := 0
+	for _, n := range m.notifications {
+		if n.UserID == userID && n.Category == category && !n.CreatedAt.Before(since) && n.Status != contracts.NotificationStatusDigested {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MemoryNotifications) ListHeld(ctx context.Context, userID int, category string) ([]Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	held := []Notification{}
+	for _, n := range m.notifications {
+		if n.UserID == userID && n.Category == category && n.Status == contracts.NotificationStatusDigested && n.DigestID == 0 {
+			held = append(held, n)
+		}
+	}
+	return held, nil
+}
+
+func (m *MemoryNotifications) SaveDigest(ctx context.Context, digest Notification, held []int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range held {
+		if id < 1 || id > len(m.notifications) {
+			return 0, ErrNotificationNotFound
+		}
+		if m.notifications[id-1].DigestID != 0 {
+			return 0, ErrAlreadyDigested
+		}
+	}
+	digest.NotificationID = len(m.notifications) + 1
+	digest.CreatedAt = time.Now().UTC()
+	digest.UpdatedAt = digest.CreatedAt
+	m.notifications = append(m.notifications, digest)
+	for _, id := range held {
+		n := &m.notifications[id-1]
+		n.DigestID = digest.NotificationID
+		n.UpdatedAt = digest.CreatedAt
+	}
+	return digest.NotificationID, nil
+}
+
+func (m *MemoryNotifications) SaveContact(ctx context.Context, c Contact) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.contacts[c.UserID] = c
+	return nil
+}
+
+func (m *MemoryNotifications) GetContact(ctx context.Context, userID int) (Contact, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.contacts[userID]; ok {
+		return c, nil
+	}
+	return Contact{UserID: userID}, nil
+}
+
+func (m *MemoryNotifications) SavePreferences(ctx context.Context, p Preferences) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.preferences[p.UserID] = p
+	return nil
+}
+
+func (m *MemoryNotifications) GetPreferences(ctx context.Context, userID int) (Preferences, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if p, ok := m.preferences[userID]; ok {
+		return p, nil
+	}
+	return Preferences{UserID: userID}, nil
+}
+
+func (m *MemoryNotifications) SaveWebhook(ctx context.Context, w Webhook) (Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().UTC()
+	if w.WebhookID == 0 {
+		m.lastWebhook++
+		w.WebhookID = m.lastWebhook
+		w.CreatedAt = now
+	} else if stored, ok := m.webhooks[w.WebhookID]; ok {
+		w.CreatedAt = stored.CreatedAt
+	} else {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	w.Events = append([]string(nil), w.Events...)
+	w.UpdatedAt = now
+	m.webhooks[w.WebhookID] = w
+	return w, nil
+}
+
+func (m *MemoryNotifications) GetWebhook(ctx context.Context, id int) (Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.webhooks[id]
+	if !ok {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	return w, nil
+}
+
+func (m *MemoryNotifications) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	webhooks := []Webhook{}
+	for _, w := range m.webhooks {
+		webhooks = append(webhooks, w)
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].WebhookID < webhooks[j].WebhookID })
+	return webhooks, nil
+}
+
+func (m *MemoryNotifications) DeleteWebhook(ctx context.Context, id int) (Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.webhooks[id]
+	if !ok {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	delete(m.webhooks, id)
+	for deliveryID, d := range m.deliveries {
+		if d.WebhookID == id {
+			delete(m.deliveries, deliveryID)
+		}
+	}
+	return w, nil
+}
+
+func (m *MemoryNotifications) RecordWebhookResult(ctx context.Context, id int, ok bool, disableAfter int) (Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, found := m.webhooks[id]
+	if !found {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	if ok {
+		w.Failures = 0
+	} else {
+		w.Failures++
+		if disableAfter > 0 && w.Failures >= disableAfter {
+			w.Active = false
+		}
+	}
+	w.UpdatedAt = time.Now().UTC()
+	m.webhooks[id] = w
+	return w, nil
+}
+
+func (m *MemoryNotifications) SaveWebhookDelivery(ctx context.Context, d WebhookDelivery) (int, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, stored := range m.deliveries {
+		if stored.WebhookID == d.WebhookID && stored.EventID == d.EventID {
+			return id, false, nil
+		}
+	}
+	if _, ok := m.webhooks[d.WebhookID]; !ok {
+		return 0, false, ErrWebhookNotFound
+	}
+	m.lastDelivery++
+	d.DeliveryID = m.lastDelivery
+	d.CreatedAt = time.Now().UTC()
+	d.UpdatedAt = d.CreatedAt
+	m.deliveries[d.DeliveryID] = d
+	return d.DeliveryID, true, nil
+}
+
+func (m *MemoryNotifications) GetWebhookDelivery(ctx context.Context, id int) (WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.deliveries[id]
+	if !ok {
+		return WebhookDelivery{}, ErrWebhookDeliveryNotFound
+	}
+	return d, nil
+}
+
+func (m *MemoryNotifications) ListWebhookDeliveries(ctx context.Context, webhookID, limit int) ([]WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deliveries := []WebhookDelivery{}
+	for _, d := range m.deliveries {
+		if d.WebhookID == webhookID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].DeliveryID > deliveries[j].DeliveryID })
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (m *MemoryNotifications) RecordWebhookAttempt(ctx context.Context, id int, status string, responseStatus int, errMsg string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.deliveries[id]
+	if !ok {
+		return ErrWebhookDeliveryNotFound
+	}
+	d.Status = status
+	d.Attempts++
+	d.ResponseStatus = responseStatus
+	d.Error = errMsg
+	d.UpdatedAt = time.Now().UTC()
+	if status == contracts.WebhookDeliveryDelivered {
+		deliveredAt := d.UpdatedAt
+		d.DeliveredAt = &deliveredAt
+	}
+	m.deliveries[id] = d
+	return nil
+}
+
+func (m *MemoryNotifications) SetWebhookDeliveryStatus(ctx context.Context, id int, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.deliveries[id]
+	if !ok {
+		return ErrWebhookDeliveryNotFound
+	}
+	d.Status = status
+	d.UpdatedAt = time.Now().UTC()
+	m.deliveries[id] = d
+	return nil
+}
+
+func (m *MemoryNotifications) SaveInboxItem(ctx context.Context, item InboxItem) (InboxItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastInboxItem++
+	item.ItemID = m.lastInboxItem
+	item.CreatedAt = time.Now().UTC()
+	m.inbox[item.ItemID] = item
+	return item, nil
+}
+
+func (m *MemoryNotifications) ListInboxItems(ctx context.Context, userID int, archived bool, limit int) ([]InboxItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := []InboxItem{}
+	for _, item := range m.inbox {
+		if item.UserID == userID && item.Archived == archived {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ItemID > items[j].ItemID })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+func (m *MemoryNotifications) CountUnread(ctx context.Context, userID int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	unread := 0
+	for _, item := range m.inbox {
+		if item.UserID == userID && !item.Archived && item.ReadAt == nil {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+func (m *MemoryNotifications) SetInboxItemRead(ctx context.Context, userID, id int, read bool) (InboxItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.inbox[id]
+	if !ok || item.UserID != userID {
+		return InboxItem{}, ErrInboxItemNotFound
+	}
+	switch {
+	case !read:
+		item.ReadAt = nil
+	case item.ReadAt == nil:
+		readAt := time.Now().UTC()
+		item.ReadAt = &readAt
+	}
+	m.inbox[id] = item
+	return item, nil
+}
+
+func (m *MemoryNotifications) SetInboxItemArchived(ctx context.Context, userID, id int, archived bool) (InboxItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.inbox[id]
+	if !ok || item.UserID != userID {
+		return InboxItem{}, ErrInboxItemNotFound
+	}
+	item.Archived = archived
+	m.inbox[id] = item
+	return item, nil
+}
+
+func (m *MemoryNotifications) MarkAllRead(ctx context.Context, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	readAt := time.Now().UTC()
+	for id, item := range m.inbox {
+		if item.UserID == userID && item.ReadAt == nil {
+			item.ReadAt = &readAt
+			m.inbox[id] = item
+		}
+	}
+	return nil
+}
+
+func (m *MemoryNotifications) AlertSent(ctx context.Context, eventID string, userID int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.alerts[alert{eventID, userID}], nil
+}
+
+func (m *MemoryNotifications) SaveAlert(ctx context.Context, eventID string, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.alerts[alert{eventID, userID}] = true
+	return nil
+}
+
+func (m *MemoryNotifications) Ping(ctx context.Context) error {
+	return nil
+}
+
+// SchemaVersion reports the latest version: a memory store has no schema to
+// fall behind.
+func (m *MemoryNotifications) SchemaVersion(ctx context.Context) (int, error) {
+	return SchemaVersion, nil
+}
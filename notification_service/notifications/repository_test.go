@@ -0,0 +1,485 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"ecomm-sample/contracts"
+)
+
+// testNotificationRepository is the contract every NotificationRepository
+// must meet. newRepo returns an empty repository.
+func testNotificationRepository(t *testing.T, newRepo func(t *testing.T) NotificationRepository) {
+	ctx := context.Background()
+
+	t.Run("empty", func(t *testing.T) {
+		notifications, err := newRepo(t).ListNotifications(ctx, 7)
+		if err != nil || len(notifications) != 0 {
+			t.Fatalf("ListNotifications = %+v, %v; want none", notifications, err)
+		}
+	})
+
+	t.Run("save assigns increasing IDs", func(t *testing.T) {
+		repo := newRepo(t)
+		first, err := repo.SaveNotification(ctx, Notification{UserID: 7, Content: "placed"})
+		if err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+		second, err := repo.SaveNotification(ctx, Notification{UserID: 7, Content: "shipped"})
+		if err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+		if first <= 0 || second <= first {
+			t.Fatalf("IDs = %d, %d; want positive and increasing", first, second)
+		}
+	})
+
+	t.Run("rendered content round trips", func(t *testing.T) {
+		repo := newRepo(t)
+		saved := Notification{UserID: 7, Template: "order_placed", Category: contracts.CategoryMarketing, Locale: "de", Subject: "Bestellung 42", Content: "Bestellung 42 ist eingegangen.", HTML: "<p>Bestellung 42</p>", Status: contracts.NotificationStatusQueued}
+		repo.SaveNotification(ctx, saved)
+		notifications, err := repo.ListNotifications(ctx, 7)
+		if err != nil || len(notifications) != 1 {
+			t.Fatalf("ListNotifications = %+v, %v", notifications, err)
+		}
+		n := notifications[0]
+		if n.Template != saved.Template || n.Category != saved.Category || n.Locale != saved.Locale || n.Subject != saved.Subject || n.Content != saved.Content || n.HTML != saved.HTML {
+			t.Fatalf("notification = %+v, want %+v", n, saved)
+		}
+	})
+
+	t.Run("list by user oldest first", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveNotification(ctx, Notification{UserID: 7, Content: "placed"})
+		repo.SaveNotification(ctx, Notification{UserID: 8, Content: "other user"})
+		id, _ := repo.SaveNotification(ctx, Notification{UserID: 7, Content: "shipped"})
+
+		notifications, err := repo.ListNotifications(ctx, 7)
+		if err != nil {
+			t.Fatalf("ListNotifications: %v", err)
+		}
+		if len(notifications) != 2 {
+			t.Fatalf("got %d notifications, want 2: %+v", len(notifications), notifications)
+		}
+		if notifications[0].Content != "placed" || notifications[1].Content != "shipped" {
+			t.Fatalf("notifications = %+v, want placed then shipped", notifications)
+		}
+		if notifications[1].NotificationID != id || notifications[1].UserID != 7 {
+			t.Fatalf("notification = %+v, want ID %d for user 7", notifications[1], id)
+		}
+	})
+
+	t.Run("status tracks delivery", func(t *testing.T) {
+		repo := newRepo(t)
+		id, err := repo.SaveNotification(ctx, Notification{UserID: 7, Channel: ChannelLog, Template: "order_placed", Content: "placed", Status: contracts.NotificationStatusQueued})
+		if err != nil {
+			t.Fatalf("SaveNotification: %v", err)
+		}
+		stored, _ := repo.ListNotifications(ctx, 7)
+		if n := stored[0]; n.Status != contracts.NotificationStatusQueued || n.Attempts != 0 || n.CreatedAt.IsZero() || n.DeliveredAt != nil {
+			t.Fatalf("saved notification = %+v, want queued without attempts", n)
+		}
+		if n := stored[0]; n.Channel != ChannelLog || n.Template != "order_placed" {
+			t.Fatalf("saved notification = %+v, want channel and template kept", n)
+		}
+
+		steps := []func() error{
+			func() error { return repo.StartAttempt(ctx, id, ChannelSMS) },
+			func() error { return repo.SetStatus(ctx, id, contracts.NotificationStatusFailed) },
+			func() error { return repo.StartAttempt(ctx, id, ChannelEmail) },
+			func() error { return repo.SetStatus(ctx, id, contracts.NotificationStatusDelivered) },
+		}
+		for i, step := range steps {
+			if err := step(); err != nil {
+				t.Fatalf("step %d: %v", i+1, err)
+			}
+		}
+		stored, _ = repo.ListNotifications(ctx, 7)
+		n := stored[0]
+		if n.Status != contracts.NotificationStatusDelivered || n.Attempts != 2 || n.Channel != ChannelEmail {
+			t.Fatalf("notification = %+v, want delivered by email after 2 attempts", n)
+		}
+		if n.DeliveredAt == nil || n.UpdatedAt.Before(n.CreatedAt) {
+			t.Fatalf("notification = %+v, want delivery and update times", n)
+		}
+	})
+
+	t.Run("get by ID", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.SaveNotification(ctx, Notification{UserID: 7, Content: "placed"})
+		id, _ := repo.SaveNotification(ctx, Notification{UserID: 8, Channel: ChannelSMS, Category: contracts.CategoryTransactional, Content: "shipped", Status: contracts.NotificationStatusQueued})
+		n, err := repo.GetNotification(ctx, id)
+		if err != nil || n.NotificationID != id || n.UserID != 8 || n.Channel != ChannelSMS || n.Content != "shipped" || n.Status != contracts.NotificationStatusQueued {
+			t.Fatalf("GetNotification = %+v, %v", n, err)
+		}
+		if _, err := repo.GetNotification(ctx, id+1); !errors.Is(err, ErrNotificationNotFound) {
+			t.Fatalf("GetNotification of an unknown ID = %v, want ErrNotificationNotFound", err)
+		}
+	})
+
+	t.Run("status of unknown notification", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.StartAttempt(ctx, 42, ChannelLog); !errors.Is(err, ErrNotificationNotFound) {
+			t.Fatalf("StartAttempt = %v, want ErrNotificationNotFound", err)
+		}
+		if err := repo.SetStatus(ctx, 42, contracts.NotificationStatusFailed); !errors.Is(err, ErrNotificationNotFound) {
+			t.Fatalf("SetStatus = %v, want ErrNotificationNotFound", err)
+		}
+	})
+
+	t.Run("digests", func(t *testing.T) {
+		repo := newRepo(t)
+		sent, _ := repo.SaveNotification(ctx, Notification{UserID: 7, Category: contracts.CategoryTransactional, Content: "placed", Status: contracts.NotificationStatusDelivered})
+		first, _ := repo.SaveNotification(ctx, Notification{UserID: 7, Category: contracts.CategoryTransactional, Content: "processed", Status: contracts.NotificationStatusDigested})
+		repo.SaveNotification(ctx, Notification{UserID: 7, Category: contracts.CategoryMarketing, Content: "sale", Status: contracts.NotificationStatusDigested})
+		second, _ := repo.SaveNotification(ctx, Notification{UserID: 7, Category: contracts.CategoryTransactional, Content: "shipped", Status: contracts.NotificationStatusDigested})
+
+		if count, err := repo.CountRecent(ctx, 7, contracts.CategoryTransactional, time.Now().Add(-time.Minute)); err != nil || count != 1 {
+			t.Fatalf("CountRecent = %d, %v; want 1, the notification sent", count, err)
+		}
+		if count, _ := repo.CountRecent(ctx, 7, contracts.CategoryTransactional, time.Now().Add(time.Minute)); count != 0 {
+			t.Fatalf("CountRecent from a minute ahead = %d, want 0", count)
+		}
+		held, err := repo.ListHeld(ctx, 7, contracts.CategoryTransactional)
+		if err != nil || len(held) != 2 || held[0].NotificationID != first || held[1].NotificationID != second {
+			t.Fatalf("ListHeld = %+v, %v; want the two held transactional notifications", held, err)
+		}
+
+		digest := Notification{UserID: 7, Template: "digest", Category: contracts.CategoryTransactional, Content: "- processed\n- shipped", Status: contracts.NotificationStatusQueued}
+		id, err := repo.SaveDigest(ctx, digest, []int{first, second})
+		if err != nil || id <= second {
+			t.Fatalf("SaveDigest = %d, %v", id, err)
+		}
+		if n, _ := repo.GetNotification(ctx, first); n.DigestID != id || n.Status != contracts.NotificationStatusDigested {
+			t.Fatalf("held notification = %+v, want digested in %d", n, id)
+		}
+		if n, _ := repo.GetNotification(ctx, id); n.Template != "digest" || n.Content != digest.Content || n.DigestID != 0 {
+			t.Fatalf("digest = %+v", n)
+		}
+		if held, _ := repo.ListHeld(ctx, 7, contracts.CategoryTransactional); len(held) != 0 {
+			t.Fatalf("ListHeld after the digest = %+v, want none", held)
+		}
+		if count, _ := repo.CountRecent(ctx, 7, contracts.CategoryTransactional, time.Now().Add(-time.Minute)); count != 2 {
+			t.Fatalf("CountRecent after the digest = %d, want 2, counting the digest", count)
+		}
+
+		if _, err := repo.SaveDigest(ctx, digest, []int{second}); !errors.Is(err, ErrAlreadyDigested) {
+			t.Fatalf("SaveDigest of a digested notification = %v, want ErrAlreadyDigested", err)
+		}
+		if stored, _ := repo.ListNotifications(ctx, 7); len(stored) != 5 || stored[0].NotificationID != sent {
+			t.Fatalf("stored %d notifications, want the refused digest not stored", len(stored))
+		}
+	})
+
+	t.Run("contacts", func(t *testing.T) {
+		repo := newRepo(t)
+		if c, err := repo.GetContact(ctx, 7); err != nil || c.UserID != 7 || c.Email != "" || c.Push != nil {
+			t.Fatalf("GetContact before save = %+v, %v; want empty", c, err)
+		}
+		push := &contracts.PushSubscription{Endpoint: "https://push.example/abc", Keys: contracts.PushKeys{P256dh: "key", Auth: "secret"}}
+		if err := repo.SaveContact(ctx, Contact{UserID: 7, Email: "ada@example.com", Push: push}); err != nil {
+			t.Fatalf("SaveContact: %v", err)
+		}
+		if err := repo.SaveContact(ctx, Contact{UserID: 7, Phone: "+15550100", Push: push, Locale: "pt-BR"}); err != nil {
+			t.Fatalf("SaveContact: %v", err)
+		}
+		c, err := repo.GetContact(ctx, 7)
+		if err != nil {
+			t.Fatalf("GetContact: %v", err)
+		}
+		if c.Email != "" || c.Phone != "+15550100" || c.Push == nil || *c.Push != *push || c.Locale != "pt-BR" {
+			t.Fatalf("GetContact = %+v, want the replacement", c)
+		}
+	})
+
+	t.Run("preferences", func(t *testing.T) {
+		repo := newRepo(t)
+		if p, err := repo.GetPreferences(ctx, 7); err != nil || p.UserID != 7 || p.OptIns != nil || p.QuietHours != nil || p.Unsubscribed {
+			t.Fatalf("GetPreferences before save = %+v, %v; want empty", p, err)
+		}
+		saved := Preferences{
+			UserID:       7,
+			OptIns:       map[string]map[string]bool{contracts.CategoryMarketing: {ChannelEmail: true}, contracts.CategoryTransactional: {ChannelSMS: false}},
+			QuietHours:   &contracts.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Berlin"},
+			Unsubscribed: true,
+		}
+		if err := repo.SavePreferences(ctx, saved); err != nil {
+			t.Fatalf("SavePreferences: %v", err)
+		}
+		p, err := repo.GetPreferences(ctx, 7)
+		if err != nil {
+			t.Fatalf("GetPreferences: %v", err)
+		}
+		if !reflect.DeepEqual(p, saved) {
+			t.Fatalf("GetPreferences = %+v, want %+v", p, saved)
+		}
+		if err := repo.SavePreferences(ctx, Preferences{UserID: 7}); err != nil {
+			t.Fatalf("SavePreferences: %v", err)
+		}
+		if p, err := repo.GetPreferences(ctx, 7); err != nil || len(p.OptIns) != 0 || p.QuietHours != nil || p.Unsubscribed {
+			t.Fatalf("GetPreferences after replacing = %+v, %v; want empty", p, err)
+		}
+	})
+
+	t.Run("webhooks", func(t *testing.T) {
+		repo := newRepo(t)
+		saved, err := repo.SaveWebhook(ctx, Webhook{URL: "https://partner.example/hooks", Events: []string{contracts.EventOrderPlaced}, Secret: "whsec_1", Active: true})
+		if err != nil || saved.WebhookID <= 0 || saved.CreatedAt.IsZero() {
+			t.Fatalf("SaveWebhook = %+v, %v; want an ID and creation time", saved, err)
+		}
+		other, _ := repo.SaveWebhook(ctx, Webhook{URL: "https://other.example", Events: []string{contracts.EventStockLow}, Secret: "whsec_2", Active: true})
+
+		saved.Events = []string{contracts.EventOrderPlaced, contracts.EventOrderCancelled}
+		saved.Active = false
+		updated, err := repo.SaveWebhook(ctx, saved)
+		if err != nil || !reflect.DeepEqual(updated.Events, saved.Events) || updated.Active || !updated.CreatedAt.Equal(saved.CreatedAt) {
+			t.Fatalf("SaveWebhook of an update = %+v, %v; want %+v", updated, err, saved)
+		}
+		if _, err := repo.SaveWebhook(ctx, Webhook{WebhookID: other.WebhookID + 1, URL: "https://x.example"}); !errors.Is(err, ErrWebhookNotFound) {
+			t.Fatalf("SaveWebhook of an unknown ID = %v, want ErrWebhookNotFound", err)
+		}
+		if w, err := repo.GetWebhook(ctx, saved.WebhookID); err != nil || w.Secret != "whsec_1" || w.URL != saved.URL {
+			t.Fatalf("GetWebhook = %+v, %v", w, err)
+		}
+		webhooks, err := repo.ListWebhooks(ctx)
+		if err != nil || len(webhooks) != 2 || webhooks[0].WebhookID != saved.WebhookID || webhooks[1].WebhookID != other.WebhookID {
+			t.Fatalf("ListWebhooks = %+v, %v; want both by ID", webhooks, err)
+		}
+
+		if _, err := repo.DeleteWebhook(ctx, other.WebhookID); err != nil {
+			t.Fatalf("DeleteWebhook: %v", err)
+		}
+		if _, err := repo.GetWebhook(ctx, other.WebhookID); !errors.Is(err, ErrWebhookNotFound) {
+			t.Fatalf("GetWebhook after delete = %v, want ErrWebhookNotFound", err)
+		}
+		if _, err := repo.DeleteWebhook(ctx, other.WebhookID); !errors.Is(err, ErrWebhookNotFound) {
+			t.Fatalf("DeleteWebhook twice = %v, want ErrWebhookNotFound", err)
+		}
+	})
+
+	t.Run("webhook failures disable it", func(t *testing.T) {
+		repo := newRepo(t)
+		w, _ := repo.SaveWebhook(ctx, Webhook{URL: "https://partner.example/hooks", Events: []string{contracts.EventStockLow}, Secret: "s", Active: true})
+		for i := 1; i <= 2; i++ {
+			w, _ = repo.RecordWebhookResult(ctx, w.WebhookID, false, 3)
+		}
+		if w, _ = repo.RecordWebhookResult(ctx, w.WebhookID, true, 3); w.Failures != 0 || !w.Active {
+			t.Fatalf("webhook after a success = %+v, want failures reset", w)
+		}
+		for i := 1; i <= 3; i++ {
+			w, _ = repo.RecordWebhookResult(ctx, w.WebhookID, false, 3)
+			if w.Active != (i < 3) || w.Failures != i {
+				t.Fatalf("webhook after %d failures = %+v", i, w)
+			}
+		}
+		if _, err := repo.RecordWebhookResult(ctx, w.WebhookID+1, true, 3); !errors.Is(err, ErrWebhookNotFound) {
+			t.Fatalf("RecordWebhookResult of an unknown ID = %v, want ErrWebhookNotFound", err)
+		}
+	})
+
+	t.Run("webhook deliveries", func(t *testing.T) {
+		repo := newRepo(t)
+		w, _ := repo.SaveWebhook(ctx, Webhook{URL: "https://partner.example/hooks", Events: []string{contracts.EventOrderPlaced}, Secret: "s", Active: true})
+		first, created, err := repo.SaveWebhookDelivery(ctx, WebhookDelivery{WebhookID: w.WebhookID, EventID: "evt-1", Event: contracts.EventOrderPlaced, Payload: []byte(`{"id":"evt-1"}`), Status: contracts.WebhookDeliveryPending})
+		if err != nil || first <= 0 || !created {
+			t.Fatalf("SaveWebhookDelivery = %d, %v, %v", first, created, err)
+		}
+		if again, created, err := repo.SaveWebhookDelivery(ctx, WebhookDelivery{WebhookID: w.WebhookID, EventID: "evt-1", Event: contracts.EventOrderPlaced, Payload: []byte(`{}`), Status: contracts.WebhookDeliveryPending}); err != nil || again != first || created {
+			t.Fatalf("SaveWebhookDelivery of the same event = %d, %v, %v; want %d, not created", again, created, err, first)
+		}
+		second, _, _ := repo.SaveWebhookDelivery(ctx, WebhookDelivery{WebhookID: w.WebhookID, EventID: "evt-2", Event: contracts.EventOrderPlaced, Payload: []byte(`{"id":"evt-2"}`), Status: contracts.WebhookDeliveryPending})
+
+		if err := repo.RecordWebhookAttempt(ctx, first, contracts.WebhookDeliveryPending, 503, "unavailable"); err != nil {
+			t.Fatalf("RecordWebhookAttempt: %v", err)
+		}
+		if err := repo.RecordWebhookAttempt(ctx, first, contracts.WebhookDeliveryDelivered, 200, ""); err != nil {
+			t.Fatalf("RecordWebhookAttempt: %v", err)
+		}
+		d, err := repo.GetWebhookDelivery(ctx, first)
+		if err != nil || d.Status != contracts.WebhookDeliveryDelivered || d.Attempts != 2 || d.ResponseStatus != 200 || d.Error != "" || d.DeliveredAt == nil {
+			t.Fatalf("GetWebhookDelivery = %+v, %v; want delivered on the second attempt", d, err)
+		}
+		if string(d.Payload) != `{"id":"evt-1"}` || d.EventID != "evt-1" {
+			t.Fatalf("delivery = %+v, want the first payload kept", d)
+		}
+		if err := repo.SetWebhookDeliveryStatus(ctx, first, contracts.WebhookDeliveryPending); err != nil {
+			t.Fatalf("SetWebhookDeliveryStatus: %v", err)
+		}
+
+		deliveries, err := repo.ListWebhookDeliveries(ctx, w.WebhookID, 10)
+		if err != nil || len(deliveries) != 2 || deliveries[0].DeliveryID != second || deliveries[1].Status != contracts.WebhookDeliveryPending {
+			t.Fatalf("ListWebhookDeliveries = %+v, %v; want both, newest first", deliveries, err)
+		}
+		if deliveries, _ := repo.ListWebhookDeliveries(ctx, w.WebhookID, 1); len(deliveries) != 1 {
+			t.Fatalf("ListWebhookDeliveries with limit 1 = %+v", deliveries)
+		}
+
+		repo.DeleteWebhook(ctx, w.WebhookID)
+		if _, err := repo.GetWebhookDelivery(ctx, first); !errors.Is(err, ErrWebhookDeliveryNotFound) {
+			t.Fatalf("GetWebhookDelivery after deleting the webhook = %v, want ErrWebhookDeliveryNotFound", err)
+		}
+		if err := repo.RecordWebhookAttempt(ctx, first, contracts.WebhookDeliveryFailed, 0, ""); !errors.Is(err, ErrWebhookDeliveryNotFound) {
+			t.Fatalf("RecordWebhookAttempt of an unknown ID = %v, want ErrWebhookDeliveryNotFound", err)
+		}
+		if err := repo.SetWebhookDeliveryStatus(ctx, first, contracts.WebhookDeliveryFailed); !errors.Is(err, ErrWebhookDeliveryNotFound) {
+			t.Fatalf("SetWebhookDeliveryStatus of an unknown ID = %v, want ErrWebhookDeliveryNotFound", err)
+		}
+	})
+
+	t.Run("inbox", func(t *testing.T) {
+		repo := newRepo(t)
+		n1, _ := repo.SaveNotification(ctx, Notification{UserID: 7, Content: "placed", Status: contracts.NotificationStatusDelivered})
+		n2, _ := repo.SaveNotification(ctx, Notification{UserID: 7, Content: "shipped", Status: contracts.NotificationStatusDelivered})
+		n3, _ := repo.SaveNotification(ctx, Notification{UserID: 8, Content: "other", Status: contracts.NotificationStatusDelivered})
+		first, err := repo.SaveInboxItem(ctx, InboxItem{UserID: 7, NotificationID: n1, Category: contracts.CategoryTransactional, Subject: "Order placed", Content: "placed"})
+		if err != nil || first.ItemID == 0 || first.CreatedAt.IsZero() || first.ReadAt != nil || first.Subject != "Order placed" {
+			t.Fatalf("SaveInboxItem = %+v, %v", first, err)
+		}
+		second, _ := repo.SaveInboxItem(ctx, InboxItem{UserID: 7, NotificationID: n2, Category: contracts.CategoryTransactional, Content: "shipped"})
+		other, _ := repo.SaveInboxItem(ctx, InboxItem{UserID: 8, NotificationID: n3, Category: contracts.CategoryTransactional, Content: "other"})
+
+		items, err := repo.ListInboxItems(ctx, 7, false, 10)
+		if err != nil || len(items) != 2 || items[0].ItemID != second.ItemID || items[1].ItemID != first.ItemID {
+			t.Fatalf("ListInboxItems = %+v, %v; want the user's items, newest first", items, err)
+		}
+		if items, _ := repo.ListInboxItems(ctx, 7, false, 1); len(items) != 1 || items[0].ItemID != second.ItemID {
+			t.Fatalf("ListInboxItems limited to 1 = %+v, want the newest", items)
+		}
+		if unread, err := repo.CountUnread(ctx, 7); err != nil || unread != 2 {
+			t.Fatalf("CountUnread = %d, %v; want 2", unread, err)
+		}
+
+		read, err := repo.SetInboxItemRead(ctx, 7, first.ItemID, true)
+		if err != nil || read.ReadAt == nil {
+			t.Fatalf("SetInboxItemRead = %+v, %v; want read", read, err)
+		}
+		if again, _ := repo.SetInboxItemRead(ctx, 7, first.ItemID, true); again.ReadAt == nil || !again.ReadAt.Equal(*read.ReadAt) {
+			t.Fatalf("reading again = %+v, want it read at %v still", again, read.ReadAt)
+		}
+		if unread, _ := repo.SetInboxItemRead(ctx, 7, first.ItemID, false); unread.ReadAt != nil {
+			t.Fatalf("SetInboxItemRead(false) = %+v, want unread", unread)
+		}
+		if _, err := repo.SetInboxItemRead(ctx, 7, other.ItemID, true); !errors.Is(err, ErrInboxItemNotFound) {
+			t.Fatalf("reading another user's item = %v, want ErrInboxItemNotFound", err)
+		}
+
+		archived, err := repo.SetInboxItemArchived(ctx, 7, second.ItemID, true)
+		if err != nil || !archived.Archived {
+			t.Fatalf("SetInboxItemArchived = %+v, %v; want archived", archived, err)
+		}
+		if items, _ := repo.ListInboxItems(ctx, 7, true, 10); len(items) != 1 || items[0].ItemID != second.ItemID {
+			t.Fatalf("archived items = %+v, want the archived one", items)
+		}
+		if unread, _ := repo.CountUnread(ctx, 7); unread != 1 {
+			t.Fatalf("CountUnread with one archived = %d, want 1", unread)
+		}
+		if _, err := repo.SetInboxItemArchived(ctx, 8, second.ItemID, false); !errors.Is(err, ErrInboxItemNotFound) {
+			t.Fatalf("archiving another user's item = %v, want ErrInboxItemNotFound", err)
+		}
+
+		if err := repo.MarkAllRead(ctx, 7); err != nil {
+			t.Fatalf("MarkAllRead: %v", err)
+		}
+		if unread, _ := repo.CountUnread(ctx, 7); unread != 0 {
+			t.Fatalf("CountUnread after MarkAllRead = %d, want 0", unread)
+		}
+		if unarchived, _ := repo.SetInboxItemArchived(ctx, 7, second.ItemID, false); unarchived.Archived || unarchived.ReadAt == nil {
+			t.Fatalf("unarchived item = %+v, want read and not archived", unarchived)
+		}
+		if unread, _ := repo.CountUnread(ctx, 8); unread != 1 {
+			t.Fatalf("other user's unread count = %d, want 1", unread)
+		}
+	})
+
+	t.Run("alerts", func(t *testing.T) {
+		repo := newRepo(t)
+		if sent, err := repo.AlertSent(ctx, "evt-1", 90); err != nil || sent {
+			t.Fatalf("AlertSent before any alert = %v, %v; want false", sent, err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := repo.SaveAlert(ctx, "evt-1", 90); err != nil {
+				t.Fatalf("SaveAlert #%d: %v", i+1, err)
+			}
+		}
+		if sent, err := repo.AlertSent(ctx, "evt-1", 90); err != nil || !sent {
+			t.Fatalf("AlertSent after SaveAlert = %v, %v; want true", sent, err)
+		}
+		if sent, _ := repo.AlertSent(ctx, "evt-1", 91); sent {
+			t.Fatal("AlertSent for another user = true, want false")
+		}
+		if sent, _ := repo.AlertSent(ctx, "evt-2", 90); sent {
+			t.Fatal("AlertSent for another event = true, want false")
+		}
+	})
+
+	t.Run("schema version", func(t *testing.T) {
+		if version, err := newRepo(t).SchemaVersion(ctx); err != nil || version != SchemaVersion {
+			t.Fatalf("SchemaVersion = %d, %v; want %d", version, err, SchemaVersion)
+		}
+	})
+
+	t.Run("ping", func(t *testing.T) {
+		if err := newRepo(t).Ping(ctx); err != nil {
+			t.Fatalf("Ping: %v", err)
+		}
+	})
+}
+
+func TestMemoryNotifications(t *testing.T) {
+	testNotificationRepository(t, func(*testing.T) NotificationRepository { return NewMemoryNotifications() })
+}
+
+// TestPostgresNotifications runs the contract against the database named by
+// TEST_DATABASE_URL, migrated to the latest schema. The tables are emptied before each case.
+func TestPostgresNotifications(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db := openTestDB(t, dsn, "notifications_test")
+
+	testNotificationRepository(t, func(t *testing.T) NotificationRepository {
+		if _, err := db.Exec("TRUNCATE notifications, contacts, notification_preferences RESTART IDENTITY"); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return NewPostgresNotifications(db)
+	})
+}
+
+// openTestDB opens dsn with its search_path set to schema and applies the
+// migrations there, so each service's tables and schema_migrations stay
+// apart when the packages share one test database.
+func openTestDB(t *testing.T, dsn, schema string) *sql.DB {
+	t.Helper()
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer admin.Close()
+	if _, err := admin.Exec("CREATE SCHEMA IF NOT EXISTS " + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("TEST_DATABASE_URL must be a postgres:// URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := NewMigrator(db).Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,195 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Deliveries that fail with a retryable error are retried in rounds. A
+// round tries the notification's channels in turn; if none accepts it, the
+// channels that failed with a retryable error and have attempts left are
+// tried again in the next round, after a delay that doubles with every
+// round. The delay is a queue per delay whose messages expire into the
+// notifications queue:
+//
+//	notifications_retry_30s --(TTL 30s)--> notifications
+//	notifications_retry_1m0s --(TTL 1m)--> notifications
+//
+// A notification with no channel left to retry is failed and dead-lettered
+// to notifications_dlq.
+
+// attempts returns how many times a notification is tried on channel.
+func (c RetryConfig) attempts(channel string) int {
+	if n := c.Attempts[channel]; n > 1 {
+		return n
+	}
+	return 1
+}
+
+// delay returns the wait after delivery round round, counting from 1,
+// before the next one.
+func (c RetryConfig) delay(round int) time.Duration {
+	return backoff(c.BaseDelay, c.MaxDelay, round)
+}
+
+// delays returns the distinct waits between rounds, shortest first.
+func (c RetryConfig) delays() []time.Duration {
+	rounds := 1
+	for _, n := range c.Attempts {
+		if n > rounds {
+			rounds = n
+		}
+	}
+	return backoffDelays(c.BaseDelay, c.MaxDelay, rounds)
+}
+
+// backoff returns the wait after attempt attempt, counting from 1, before
+// the next one: base, doubled with every attempt up to max, if max is
+// positive.
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 1; i < attempt && (max <= 0 || d < max); i++ {
+		d *= 2
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+	return d
+}
+
+// backoffDelays returns the distinct waits between attempts attempts,
+// shortest first.
+func backoffDelays(base, max time.Duration, attempts int) []time.Duration {
+	if base <= 0 {
+		return nil
+	}
+	var delays []time.Duration
+	for attempt := 1; attempt < attempts; attempt++ {
+		if d := backoff(base, max, attempt); len(delays) == 0 || delays[len(delays)-1] != d {
+			delays = append(delays, d)
+		}
+	}
+	return delays
+}
+
+// delayQueue returns the name of the queue that holds messages for queue
+// until delay has passed. Queues are named by their delay, so changing the
+// delays declares new queues rather than redeclaring old ones with
+// different arguments, which RabbitMQ refuses.
+func delayQueue(queue string, delay time.Duration) string {
+	return queue + "_retry_" + delay.String()
+}
+
+// declareDelayQueues declares the delay queue of queue for each of delays.
+// Nobody consumes them: their messages expire into queue.
+func declareDelayQueues(b broker.Broker, queue string, delays []time.Duration) error {
+	for _, d := range delays {
+		name := delayQueue(queue, d)
+		_, err := b.DeclareQueue(name, broker.QueueOptions{
+			Durable: true,
+			Args: map[string]interface{}{
+				"x-message-ttl":             d.Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queue,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("declare %s queue: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// scheduleRetry schedules delivery round round+1 of n on channels.
+func (s *Service) scheduleRetry(ctx context.Context, n Notification, round int, channels []string) error {
+	delay := s.retries.delay(round)
+	retry := contracts.NotificationRetry{NotificationID: n.NotificationID, Attempt: round + 1, Channels: channels}
+	if err := s.send(ctx, "notifications_retry", "", delayQueue("notifications", delay), "", s.encodings.For("notifications"), nil, retry); err != nil {
+		return err
+	}
+	for _, ch := range channels {
+		notificationsRetried.WithLabelValues(ch).Inc()
+	}
+	slog.WarnContext(ctx, "Delivery failed, retrying later", "channels", channels, "attempt", round+1, "delay", delay.String())
+	return nil
+}
+
+// deferQuiet puts off delivery round round of n on channels, which the
+// user's quiet hours keep from every channel, through the quiet-hours
+// delay queue. The round runs again when it comes back and is put off
+// again if the quiet hours are not over. A notification that can't be put
+// off is failed.
+func (s *Service) deferQuiet(ctx context.Context, n Notification, round int, channels []string) {
+	retry := contracts.NotificationRetry{NotificationID: n.NotificationID, Attempt: round, Channels: channels}
+	if err := s.send(ctx, "notifications_quiet", "", delayQueue("notifications", s.quietRecheck), "", s.encodings.For("notifications"), nil, retry); err != nil {
+		notificationsFailed.WithLabelValues("none").Inc()
+		s.fail(ctx, n, round, fmt.Errorf("defer past quiet hours: %w", err))
+		return
+	}
+	notificationsHeld.WithLabelValues("quiet_hours").Inc()
+	msgLog.InfoContext(ctx, "Notification deferred by quiet hours", "category", n.Category, "delay", s.quietRecheck.String())
+	s.setStatus(ctx, n.NotificationID, contracts.NotificationStatusQueued)
+}
+
+// deadLetter puts n, which failed for good with err after round rounds,
+// in the notifications dead-letter queue. Moving the message back to the
+// notifications queue tries every channel once more.
+func (s *Service) deadLetter(ctx context.Context, n Notification, round int, err error) {
+	retry := contracts.NotificationRetry{NotificationID: n.NotificationID, Attempt: round + 1}
+	headers := map[string]interface{}{
+		"x-first-death-queue":  "notifications",
+		"x-first-death-reason": "delivery_failed",
+		"x-delivery-error":     err.Error(),
+	}
+	if err := s.send(ctx, "notifications_dlq", broker.DeadLetterExchange, "notifications", "", s.encodings.For("notifications"), headers, retry); err != nil {
+		slog.ErrorContext(ctx, "Failed to dead-letter notification", "error", err)
+		return
+	}
+	messagesDeadLettered.WithLabelValues("notifications").Inc()
+}
+
+// handleNotificationRetry runs a delivery round of a stored notification
+// and settles the message. Notifications that were delivered or suppressed
+// in the meantime, such as by a redelivered retry, are left alone; retries
+// of unknown notifications are rejected to the dead-letter queue.
+func (s *Service) handleNotificationRetry(ctx context.Context, queue string, msg broker.Delivery, req contracts.NotificationRetry) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int("notification_id", req.NotificationID), attribute.Int("attempt", req.Attempt))
+	ctx = observability.WithLogFields(ctx, slog.Int("notification_id", req.NotificationID))
+
+	n, err := s.repo.GetNotification(ctx, req.NotificationID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to load notification")
+		slog.ErrorContext(ctx, "Failed to load notification to retry", "error", err)
+		notFound := errors.Is(err, ErrNotificationNotFound)
+		msg.Nack(!notFound)
+		messagesNacked.WithLabelValues(queue).Inc()
+		if notFound {
+			messagesDeadLettered.WithLabelValues(queue).Inc()
+		}
+		return
+	}
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", n.UserID))
+	span.SetAttributes(attribute.Int("user_id", n.UserID))
+
+	if n.Status == contracts.NotificationStatusDelivered || n.Status == contracts.NotificationStatusSuppressed {
+		slog.InfoContext(ctx, "Notification already settled, dropping retry", "status", n.Status)
+	} else {
+		contact, prefs := s.recipient(ctx, n.UserID)
+		s.deliver(ctx, n, contact, prefs, req.Attempt, req.Channels)
+	}
+	msg.Ack()
+	messagesAcked.WithLabelValues(queue).Inc()
+}
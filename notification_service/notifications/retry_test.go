@@ -0,0 +1,152 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+func TestRetryConfigDelays(t *testing.T) {
+	cfg := RetryConfig{
+		Attempts:  map[string]int{ChannelEmail: 6, ChannelSMS: 3},
+		BaseDelay: 30 * time.Second,
+		MaxDelay:  2 * time.Minute,
+	}
+	var got []time.Duration
+	for round := 1; round <= 5; round++ {
+		got = append(got, cfg.delay(round))
+	}
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 2 * time.Minute, 2 * time.Minute}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("delays by round = %v, want %v", got, want)
+	}
+	if got, want := cfg.delays(), want[:3]; !reflect.DeepEqual(got, want) {
+		t.Errorf("delay queues = %v, want %v", got, want)
+	}
+	if n := cfg.attempts(ChannelPush); n != 1 {
+		t.Errorf("attempts on an unlisted channel = %d, want 1", n)
+	}
+	if d := (RetryConfig{}).delays(); len(d) != 0 {
+		t.Errorf("delay queues without retries = %v, want none", d)
+	}
+}
+
+func TestLoadRetryConfig(t *testing.T) {
+	t.Setenv("NOTIFICATION_RETRY_ATTEMPTS", "email=4, sms=x,push=2")
+	t.Setenv("NOTIFICATION_RETRY_DELAY", "5s")
+	t.Setenv("NOTIFICATION_RETRY_MAX_DELAY", "-1m")
+	cfg := LoadRetryConfig()
+	want := RetryConfig{Attempts: map[string]int{ChannelEmail: 4, ChannelPush: 2}, BaseDelay: 5 * time.Second, MaxDelay: 10 * time.Minute}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("LoadRetryConfig() = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestDeliveryRetries(t *testing.T) {
+	down := errors.New("provider down")
+	rejected := permanent(errors.New("unknown address"))
+	tests := []struct {
+		name         string
+		email        *fakeChannel
+		sms          *fakeChannel
+		wantStatus   string
+		wantChannel  string
+		wantAttempts int
+		wantDead     bool
+	}{
+		{"transient failures", &fakeChannel{name: ChannelEmail, err: down, failures: 2}, &fakeChannel{name: ChannelSMS, err: down}, contracts.NotificationStatusDelivered, ChannelEmail, 5, false},
+		{"permanent failure", &fakeChannel{name: ChannelEmail, err: rejected}, &fakeChannel{name: ChannelSMS, err: rejected}, contracts.NotificationStatusFailed, ChannelSMS, 2, true},
+		{"retries only retryable channels", &fakeChannel{name: ChannelEmail, err: rejected}, &fakeChannel{name: ChannelSMS, err: down, failures: 1}, contracts.NotificationStatusDelivered, ChannelSMS, 3, false},
+		{"attempts exhausted", &fakeChannel{name: ChannelEmail, err: down}, &fakeChannel{name: ChannelSMS, err: down}, contracts.NotificationStatusFailed, ChannelEmail, 5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			b := broker.NewMemory()
+			repo := NewMemoryNotifications()
+			repo.SaveContact(ctx, Contact{UserID: 7, Email: "ada@example.com", Phone: "+15550100"})
+			svc := NewService(repo, b)
+			svc.SetChannels(NewRouter(tt.email, tt.sms))
+			svc.SetRetries(RetryConfig{Attempts: map[string]int{ChannelEmail: 3, ChannelSMS: 2}, BaseDelay: 5 * time.Millisecond, MaxDelay: 20 * time.Millisecond})
+			if err := svc.Start(ctx); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+
+			body := envelope(t, contracts.NotificationRequested{UserID: 7, Message: "hello"})
+			if err := b.Publish(ctx, "", "notifications", broker.Message{Body: body}); err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+
+			var n Notification
+			for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+				stored, _ := repo.ListNotifications(ctx, 7)
+				if len(stored) == 1 {
+					n = stored[0]
+					if n.Status == tt.wantStatus && n.Attempts == tt.wantAttempts {
+						break
+					}
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("stored notification = %+v, want %s after %d attempts", n, tt.wantStatus, tt.wantAttempts)
+				}
+			}
+			if n.Channel != tt.wantChannel {
+				t.Errorf("last attempt on %q, want %q", n.Channel, tt.wantChannel)
+			}
+
+			dead := b.Pending(broker.DeadLetterQueue("notifications"))
+			if !tt.wantDead {
+				if len(dead) != 0 {
+					t.Fatalf("dead-letter queue holds %d messages, want none", len(dead))
+				}
+				return
+			}
+			if len(dead) != 1 {
+				t.Fatalf("dead-letter queue holds %d messages, want 1", len(dead))
+			}
+			if reason := dead[0].Headers["x-first-death-reason"]; reason != "delivery_failed" {
+				t.Errorf("dead-letter reason = %v, want delivery_failed", reason)
+			}
+			_, payload, err := contracts.Decode(dead[0].ContentType, dead[0].Body)
+			if retry, ok := payload.(contracts.NotificationRetry); err != nil || !ok || retry.NotificationID != n.NotificationID || len(retry.Channels) != 0 {
+				t.Errorf("dead letter = %+v, %v, want a retry of notification %d on every channel", payload, err, n.NotificationID)
+			}
+		})
+	}
+}
+
+func TestHandleNotificationRetry(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBroker(t)
+	repo := NewMemoryNotifications()
+	repo.SaveContact(ctx, Contact{UserID: 7, Email: "ada@example.com"})
+	delivered, _ := repo.SaveNotification(ctx, Notification{UserID: 7, Content: "hello", Status: contracts.NotificationStatusDelivered})
+	failed, _ := repo.SaveNotification(ctx, Notification{UserID: 7, Content: "hello", Status: contracts.NotificationStatusFailed})
+	email := &fakeChannel{name: ChannelEmail}
+	svc := NewService(repo, b)
+	svc.SetChannels(NewRouter(email))
+
+	for _, id := range []int{delivered, failed, 99} {
+		msg := deliver(t, b, "notifications", broker.Message{Body: envelope(t, contracts.NotificationRetry{NotificationID: id, Attempt: 2})})
+		svc.dispatch("notifications", msg)
+		if err := msg.Ack(); err == nil {
+			t.Fatalf("retry of notification %d was not settled by the handler", id)
+		}
+	}
+
+	if len(email.sent) != 1 {
+		t.Errorf("sent %d emails, want only the failed notification's", len(email.sent))
+	}
+	if n, _ := repo.GetNotification(ctx, failed); n.Status != contracts.NotificationStatusDelivered {
+		t.Errorf("replayed notification is %s, want delivered", n.Status)
+	}
+	if dead := b.Pending(broker.DeadLetterQueue("notifications")); len(dead) != 1 {
+		t.Errorf("dead-letter queue holds %d messages, want the unknown notification's retry", len(dead))
+	}
+}
@@ -0,0 +1,758 @@
+// Package notifications stores and delivers notifications to users.
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Notification is a stored notification, created from a
+// contracts.NotificationRequested message. Content is its text and HTML,
+// if any, its HTML for email, both rendered from Template in Locale when
+// it was received. Category is one of the contracts.Category constants and
+// Status one of the contracts.NotificationStatus constants. DigestID is the
+// digest a digested notification was sent in, once it was.
+type Notification struct {
+	NotificationID int        `json:"notification_id,omitempty"`
+	UserID         int        `json:"user_id"`
+	Channel        string     `json:"channel"`
+	Template       string     `json:"template"`
+	Category       string     `json:"category"`
+	Locale         string     `json:"locale,omitempty"`
+	Subject        string     `json:"subject,omitempty"`
+	Content        string     `json:"content"`
+	HTML           string     `json:"html,omitempty"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	DigestID       int        `json:"digest_id,omitempty"`
+
+	// UnsubscribeURL is the user's unsubscribe link, set on marketing
+	// notifications while they are delivered; it is not stored.
+	UnsubscribeURL string `json:"-"`
+}
+
+// details returns n as a contracts.NotificationDetails.
+func (n Notification) details() contracts.NotificationDetails {
+	return contracts.NotificationDetails{
+		NotificationID: n.NotificationID,
+		UserID:         n.UserID,
+		Channel:        n.Channel,
+		Template:       n.Template,
+		Category:       n.Category,
+		Content:        n.Content,
+		Status:         n.Status,
+		Attempts:       n.Attempts,
+		CreatedAt:      n.CreatedAt,
+		UpdatedAt:      n.UpdatedAt,
+		DeliveredAt:    n.DeliveredAt,
+		DigestID:       n.DigestID,
+	}
+}
+
+const serviceName = "notification_service"
+
+var tracer = otel.Tracer(serviceName)
+
+// msgLog is the sampled logger for per-message logs.
+var msgLog = slog.Default()
+
+// SetupLogging installs the service's default and per-message loggers, as
+// described at observability.SetupLogging.
+func SetupLogging() {
+	msgLog = observability.SetupLogging(serviceName)
+}
+
+// SetupTracing installs the service's tracer provider, as described at
+// observability.SetupTracing. The returned function flushes pending spans.
+func SetupTracing() func(context.Context) error {
+	return observability.SetupTracing(serviceName)
+}
+
+// Service records and delivers notifications and keeps users' in-app
+// inboxes. It also sends events to webhooks, alerts staff roles about
+// them, and answers health checks and requests for notification history,
+// contacts, preferences, inboxes and webhooks.
+type Service struct {
+	repo        NotificationRepository
+	broker      broker.Broker
+	router      *Router
+	templates   *Templates
+	unsubscribe *UnsubscribeLinks
+	retries     RetryConfig
+	digests     DigestConfig
+	encodings   contracts.Encodings
+	roles       map[string][]int
+
+	webhooks      WebhookConfig
+	webhookClient *http.Client
+	lookupIP      func(ctx context.Context, network, host string) ([]net.IP, error)
+
+	// now is the clock quiet hours are checked against, and quietRecheck
+	// how long a notification they defer waits before checking again.
+	now          func() time.Time
+	quietRecheck time.Duration
+}
+
+// NewService returns a service that delivers notifications on the log
+// channel until SetChannels is called, renders them from the built-in
+// templates until SetTemplates is called, sends no unsubscribe links, nor
+// accepts any, until SetUnsubscribeLinks is called, sends every
+// notification at once until SetDigests is called, tries every
+// notification and webhook delivery once until SetRetries and SetWebhooks
+// are called and alerts no one until SetRoles is called.
+func NewService(repo NotificationRepository, b broker.Broker) *Service {
+	s := &Service{
+		repo:         repo,
+		broker:       b,
+		router:       NewRouter(LogChannel{}),
+		templates:    DefaultTemplates(),
+		lookupIP:     net.DefaultResolver.LookupIP,
+		now:          time.Now,
+		quietRecheck: quietHoursRecheck,
+	}
+	s.SetWebhooks(WebhookConfig{Attempts: 1, Timeout: 10 * time.Second})
+	return s
+}
+
+// SetChannels sets the channels notifications are delivered on.
+func (s *Service) SetChannels(r *Router) {
+	s.router = r
+}
+
+// SetTemplates sets the templates notifications are rendered from.
+func (s *Service) SetTemplates(t *Templates) {
+	s.templates = t
+}
+
+// SetUnsubscribeLinks sets the unsubscribe links put in marketing
+// notifications.
+func (s *Service) SetUnsubscribeLinks(u *UnsubscribeLinks) {
+	s.unsubscribe = u
+}
+
+// SetRetries sets how deliveries that fail with a retryable error are
+// retried.
+func (s *Service) SetRetries(cfg RetryConfig) {
+	s.retries = cfg
+}
+
+// SetDigests sets which notifications are held back for digests.
+func (s *Service) SetDigests(cfg DigestConfig) {
+	s.digests = cfg
+}
+
+// SetWebhooks sets how events are delivered to webhooks.
+func (s *Service) SetWebhooks(cfg WebhookConfig) {
+	s.webhooks = cfg
+	s.webhookClient = newWebhookClient(cfg.Timeout, cfg.AllowPrivate)
+}
+
+// SetRoles sets the users in each staff role, such as RoleWarehouse,
+// which events alert.
+func (s *Service) SetRoles(roles map[string][]int) {
+	s.roles = roles
+}
+
+// SetEncodings sets the encoding of the messages the service publishes to
+// each queue. Replies are always encoded like the request.
+func (s *Service) SetEncodings(e contracts.Encodings) {
+	s.encodings = e
+}
+
+// declareTopology declares the queues and exchanges this service consumes.
+// The webhook_events queue gets every event webhooks can subscribe to.
+func declareTopology(b broker.Broker) error {
+	for _, queue := range []string{"notifications", "notification_requests", "webhooks", "webhook_events"} {
+		if err := broker.DeclareWorkQueue(b, queue); err != nil {
+			return err
+		}
+	}
+	if err := b.DeclareExchange("health_check_exchange", broker.Fanout); err != nil {
+		return fmt.Errorf("declare fanout exchange: %w", err)
+	}
+	if err := b.DeclareExchange(contracts.InboxExchange, broker.Fanout); err != nil {
+		return fmt.Errorf("declare inbox exchange: %w", err)
+	}
+	if err := b.DeclareExchange(contracts.EventsExchange, broker.Topic); err != nil {
+		return fmt.Errorf("declare events exchange: %w", err)
+	}
+	for _, event := range contracts.EventNames {
+		if err := b.BindQueue("webhook_events", event, contracts.EventsExchange); err != nil {
+			return fmt.Errorf("bind webhook_events queue: %w", err)
+		}
+	}
+	return nil
+}
+
+// Start declares the service's topology and handles its queues in the
+// background until ctx is cancelled.
+func (s *Service) Start(ctx context.Context) error {
+	if err := declareTopology(s.broker); err != nil {
+		return err
+	}
+	if err := declareDelayQueues(s.broker, "notifications", s.retries.delays()); err != nil {
+		return err
+	}
+	if err := declareDelayQueues(s.broker, "notifications", []time.Duration{s.quietRecheck}); err != nil {
+		return err
+	}
+	if s.digests.Window > 0 {
+		if err := declareDelayQueues(s.broker, "notifications", []time.Duration{s.digests.Window}); err != nil {
+			return err
+		}
+	}
+	if err := declareDelayQueues(s.broker, "webhooks", backoffDelays(s.webhooks.BaseDelay, s.webhooks.MaxDelay, s.webhooks.Attempts)); err != nil {
+		return err
+	}
+
+	notifications, err := s.broker.Consume(ctx, "notifications")
+	if err != nil {
+		return fmt.Errorf("consume notifications queue: %w", err)
+	}
+	requests, err := s.broker.Consume(ctx, "notification_requests")
+	if err != nil {
+		return fmt.Errorf("consume notification_requests queue: %w", err)
+	}
+	events, err := s.broker.Consume(ctx, "webhook_events")
+	if err != nil {
+		return fmt.Errorf("consume webhook_events queue: %w", err)
+	}
+	webhooks, err := s.broker.Consume(ctx, "webhooks")
+	if err != nil {
+		return fmt.Errorf("consume webhooks queue: %w", err)
+	}
+
+	// Declare a unique, auto-deleted queue for this instance and bind it
+	// to the fanout exchange
+	healthQueue, err := s.broker.DeclareQueue("", broker.QueueOptions{AutoDelete: true, Exclusive: true})
+	if err != nil {
+		return fmt.Errorf("declare health-check queue: %w", err)
+	}
+	if err := s.broker.BindQueue(healthQueue, "", "health_check_exchange"); err != nil {
+		return fmt.Errorf("bind health-check queue: %w", err)
+	}
+	healthChecks, err := s.broker.Consume(ctx, healthQueue)
+	if err != nil {
+		return fmt.Errorf("consume health-check queue: %w", err)
+	}
+
+	go s.serve("notifications", notifications)
+	go s.serve("notification_requests", requests)
+	go s.serve("webhook_events", events)
+	go s.serve("webhooks", webhooks)
+	go s.serve("health_check", healthChecks)
+	slog.Info("Notification Service waiting for notifications, notification requests, events, webhook deliveries and health checks")
+	return nil
+}
+
+// serve dispatches every message from msgs.
+func (s *Service) serve(queue string, msgs <-chan broker.Delivery) {
+	for msg := range msgs {
+		messagesConsumed.WithLabelValues(queue).Inc()
+		start := time.Now()
+		s.dispatch(queue, msg)
+		handlerDuration.WithLabelValues(queue).Observe(time.Since(start).Seconds())
+	}
+}
+
+// dispatch decodes msg's envelope and passes its payload to the handler
+// for its type, which settles the message. Messages that are malformed, of
+// an unknown type or version, or of a type this service does not handle
+// are rejected to the queue's dead-letter queue.
+func (s *Service) dispatch(queue string, msg broker.Delivery) {
+	ctx, span := observability.StartConsumeSpan(tracer, msg, queue)
+	defer span.End()
+	ctx = observability.WithLogFields(ctx, slog.String("correlation_id", msg.CorrelationID))
+
+	env, payload, err := contracts.Decode(msg.ContentType, msg.Body)
+	if err == nil {
+		ctx = observability.WithLogFields(ctx, slog.String("message_id", env.MessageID), slog.String("message_type", env.Type))
+		switch p := payload.(type) {
+		case contracts.NotificationRequested:
+			s.handleNotification(ctx, queue, msg, p)
+			return
+		case contracts.NotificationRetry:
+			s.handleNotificationRetry(ctx, queue, msg, p)
+			return
+		case contracts.NotificationDigest:
+			s.handleNotificationDigest(ctx, queue, msg, p)
+			return
+		case contracts.NotificationList:
+			s.handleNotificationList(ctx, queue, msg, p)
+			return
+		case contracts.ContactGet:
+			s.handleContactGet(ctx, queue, msg, p)
+			return
+		case contracts.ContactSet:
+			s.handleContactSet(ctx, queue, msg, p)
+			return
+		case contracts.PreferencesGet:
+			s.handlePreferencesGet(ctx, queue, msg, p)
+			return
+		case contracts.PreferencesSet:
+			s.handlePreferencesSet(ctx, queue, msg, p)
+			return
+		case contracts.Unsubscribe:
+			s.handleUnsubscribe(ctx, queue, msg, p)
+			return
+		case contracts.OrderStatusChanged:
+			s.handleEvent(ctx, queue, msg, env, p)
+			return
+		case contracts.StockLow:
+			s.handleEvent(ctx, queue, msg, env, p)
+			return
+		case contracts.InboxList:
+			s.handleInboxList(ctx, queue, msg, p)
+			return
+		case contracts.InboxCount:
+			s.handleInboxCount(ctx, queue, msg, p)
+			return
+		case contracts.InboxUpdate:
+			s.handleInboxUpdate(ctx, queue, msg, p)
+			return
+		case contracts.InboxMarkAllRead:
+			s.handleInboxMarkAllRead(ctx, queue, msg, p)
+			return
+		case contracts.WebhookDeliver:
+			s.handleWebhookDeliver(ctx, queue, msg, p)
+			return
+		case contracts.WebhookSet:
+			s.handleWebhookSet(ctx, queue, msg, p)
+			return
+		case contracts.WebhookList:
+			s.handleWebhookList(ctx, queue, msg, p)
+			return
+		case contracts.WebhookDelete:
+			s.handleWebhookDelete(ctx, queue, msg, p)
+			return
+		case contracts.WebhookDeliveryList:
+			s.handleWebhookDeliveryList(ctx, queue, msg, p)
+			return
+		case contracts.WebhookRedeliver:
+			s.handleWebhookRedeliver(ctx, queue, msg, p)
+			return
+		case contracts.HealthCheck:
+			s.handleHealthCheck(ctx, msg)
+			return
+		}
+		err = fmt.Errorf("%w %q", errUnhandledType, env.Type)
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "rejected message")
+	slog.ErrorContext(ctx, "Rejected message", "queue", queue, "content_type", msg.ContentType, "type", env.Type, "version", env.Version, "error", err)
+	msg.Nack(false)
+	messagesNacked.WithLabelValues(queue).Inc()
+	messagesDeadLettered.WithLabelValues(queue).Inc()
+}
+
+// errUnhandledType is returned for a valid message this service has no
+// handler for.
+var errUnhandledType = errors.New("no handler for message type")
+
+// reply publishes p in an envelope to msg's reply queue, in the same
+// encoding as msg.
+func (s *Service) reply(ctx context.Context, msg broker.Delivery, p contracts.Payload) error {
+	enc, err := contracts.EncodingOf(msg.ContentType)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, "reply", "", msg.ReplyTo, msg.CorrelationID, enc, nil, p)
+}
+
+// send publishes p in an envelope to exchange with routing key key,
+// counting it under label. headers are added to the message's.
+func (s *Service) send(ctx context.Context, label, exchange, key, correlationID string, enc contracts.Encoding, headers map[string]interface{}, p contracts.Payload) error {
+	env, body, err := contracts.Encode(enc, serviceName, p)
+	if err != nil {
+		return err
+	}
+	ctx, span, traceHeaders := observability.StartPublishSpan(ctx, tracer, label)
+	defer span.End()
+	for k, v := range headers {
+		traceHeaders[k] = v
+	}
+	err = s.broker.Publish(ctx, exchange, key, broker.Message{
+		ContentType:   enc.ContentType(),
+		CorrelationID: correlationID,
+		MessageID:     env.MessageID,
+		Headers:       traceHeaders,
+		Body:          body,
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "publish failed")
+		return err
+	}
+	messagesPublished.WithLabelValues(label).Inc()
+	return nil
+}
+
+// handleNotification renders a single notification, stores it, puts it in
+// the user's inbox, delivers it or holds it for a digest, and settles the
+// message. Each step of the delivery is recorded in the notification's
+// status. Once stored the message is acknowledged even if delivery fails,
+// so that a redelivery does not store the notification twice; the failure
+// is kept in its status. A notification that cannot be rendered, or is of
+// an unknown category or priority, is stored as failed.
+func (s *Service) handleNotification(ctx context.Context, queue string, msg broker.Delivery, req contracts.NotificationRequested) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int("user_id", req.UserID))
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", req.UserID))
+
+	contact, prefs := s.recipient(ctx, req.UserID)
+
+	n := Notification{
+		UserID:   req.UserID,
+		Channel:  req.Channel,
+		Template: req.Template,
+		Category: req.Category,
+		Content:  req.Message,
+		Status:   contracts.NotificationStatusQueued,
+	}
+	if n.Category == "" {
+		n.Category = contracts.CategoryTransactional
+	}
+	priority := req.Priority
+	if priority == "" {
+		priority = contracts.PriorityNormal
+	}
+	var renderErr error
+	switch {
+	case !contains(categories, n.Category):
+		renderErr = fmt.Errorf("unknown notification category %q", n.Category)
+	case !contains(priorities, priority):
+		renderErr = fmt.Errorf("unknown notification priority %q", priority)
+	default:
+		renderErr = s.render(ctx, &n, req, contact)
+	}
+	switch {
+	case renderErr != nil:
+		n.Status = contracts.NotificationStatusFailed
+	case s.held(ctx, n, priority):
+		n.Status = contracts.NotificationStatusDigested
+	}
+	id, err := s.repo.SaveNotification(ctx, n)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to store notification")
+		slog.ErrorContext(ctx, "Failed to store notification", "error", err)
+		msg.Nack(true)
+		messagesNacked.WithLabelValues(queue).Inc()
+		return
+	}
+	n.NotificationID = id
+	ctx = observability.WithLogFields(ctx, slog.Int("notification_id", id))
+	span.SetAttributes(attribute.Int("notification_id", id))
+
+	if renderErr != nil {
+		span.RecordError(renderErr)
+		span.SetStatus(codes.Error, "rendering failed")
+		slog.ErrorContext(ctx, "Failed to render notification", "template", req.Template, "locale", req.Locale, "error", renderErr)
+		notificationsFailed.WithLabelValues("none").Inc()
+	} else if n.Status == contracts.NotificationStatusDigested {
+		s.hold(ctx, n, contact, prefs)
+	} else {
+		s.deliver(ctx, n, contact, prefs, 1, nil)
+	}
+	if renderErr == nil {
+		s.addToInbox(ctx, n, prefs)
+	}
+	msg.Ack()
+	messagesAcked.WithLabelValues(queue).Inc()
+}
+
+// recipient returns a user's contact details and notification preferences.
+func (s *Service) recipient(ctx context.Context, userID int) (Contact, Preferences) {
+	contact, err := s.repo.GetContact(ctx, userID)
+	if err != nil {
+		// Without contact details the user can still be reached on the
+		// channels that need none, in the default locale.
+		slog.WarnContext(ctx, "Failed to load contact details", "error", err)
+		contact = Contact{UserID: userID}
+	}
+	prefs, err := s.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		// Without preferences only transactional notifications, which the
+		// user gets by default, can go out.
+		slog.WarnContext(ctx, "Failed to load notification preferences", "error", err)
+		prefs = Preferences{UserID: userID, Unsubscribed: true}
+	}
+	return contact, prefs
+}
+
+// render fills in n's content from the template req names, in the locale
+// req asks for, else the contact's. A notification whose template cannot be
+// rendered keeps req's message, if it has one.
+func (s *Service) render(ctx context.Context, n *Notification, req contracts.NotificationRequested, contact Contact) error {
+	if req.Template == "" {
+		return nil
+	}
+	r, err := s.templates.Render(req.Template, req.Data, req.Locale, contact.Locale)
+	if err != nil {
+		if req.Message != "" {
+			slog.WarnContext(ctx, "Failed to render notification, sending its message", "template", req.Template, "error", err)
+			return nil
+		}
+		return err
+	}
+	n.Locale, n.Subject, n.Content, n.HTML = r.Locale, r.Subject, r.Text, r.HTML
+	return nil
+}
+
+// deliver runs delivery round round, counting from 1, of a stored
+// notification: it sends it to contact on the channels the router picks
+// for it that prefs allow, in turn, until one succeeds. A retry round only
+// tries channels. Each try is an attempt; the notification ends up
+// delivered, queued until the user's quiet hours are over if they keep it
+// from every channel, suppressed if the user can be reached but not on the
+// channels they want it on, queued for another round if a channel failed
+// with a retryable error and has attempts left, or else failed and
+// dead-lettered.
+func (s *Service) deliver(ctx context.Context, n Notification, contact Contact, prefs Preferences, round int, channels []string) {
+	route := s.router.route(contact, n.Channel)
+	now := s.now()
+	if allowed := prefs.filter(route, n.Category, now); len(allowed) < len(route) {
+		if len(allowed) == 0 && prefs.deferred(route, n.Category, now) {
+			s.deferQuiet(ctx, n, round, channels)
+			return
+		}
+		if len(allowed) == 0 && len(route) > 0 {
+			msgLog.InfoContext(ctx, "Notification suppressed by the user's preferences", "category", n.Category)
+			notificationsSuppressed.WithLabelValues(n.Category).Inc()
+			s.setStatus(ctx, n.NotificationID, contracts.NotificationStatusSuppressed)
+			return
+		}
+		route = allowed
+	}
+	if len(channels) > 0 {
+		route = only(route, channels)
+	}
+	if n.Category == contracts.CategoryMarketing && s.unsubscribe != nil {
+		n.UnsubscribeURL = s.unsubscribe.URL(n.UserID)
+	}
+	if len(route) == 0 {
+		notificationsFailed.WithLabelValues("none").Inc()
+		s.fail(ctx, n, round, errUnreachable)
+		return
+	}
+	var retry []string
+	var err error
+	for i, ch := range route {
+		if err := s.repo.StartAttempt(ctx, n.NotificationID, ch.Name()); err != nil {
+			slog.ErrorContext(ctx, "Failed to record delivery attempt", "channel", ch.Name(), "error", err)
+		}
+		err = ch.Send(ctx, contact, n)
+		if err == nil {
+			notificationsSent.Inc()
+			msgLog.InfoContext(ctx, "Notification delivered", "channel", ch.Name(), "attempt", round)
+			s.setStatus(ctx, n.NotificationID, contracts.NotificationStatusDelivered)
+			return
+		}
+		notificationsFailed.WithLabelValues(ch.Name()).Inc()
+		if retryable(err) && round < s.retries.attempts(ch.Name()) {
+			retry = append(retry, ch.Name())
+		}
+		if i < len(route)-1 {
+			slog.WarnContext(ctx, "Delivery failed, falling back", "channel", ch.Name(), "next_channel", route[i+1].Name(), "error", err)
+		}
+	}
+	if len(retry) > 0 {
+		retryErr := s.scheduleRetry(ctx, n, round, retry)
+		if retryErr == nil {
+			s.setStatus(ctx, n.NotificationID, contracts.NotificationStatusQueued)
+			return
+		}
+		slog.ErrorContext(ctx, "Failed to schedule delivery retry", "error", retryErr)
+	}
+	s.fail(ctx, n, round, err)
+}
+
+// fail records that n could not be delivered, the last time with err, and
+// dead-letters it.
+func (s *Service) fail(ctx context.Context, n Notification, round int, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "delivery failed")
+	slog.ErrorContext(ctx, "Failed to deliver notification", "attempt", round, "error", err)
+	s.setStatus(ctx, n.NotificationID, contracts.NotificationStatusFailed)
+	s.deadLetter(ctx, n, round, err)
+}
+
+// setStatus records a notification's status. A failure is only logged:
+// the delivery it describes has happened either way.
+func (s *Service) setStatus(ctx context.Context, id int, status string) {
+	if err := s.repo.SetStatus(ctx, id, status); err != nil {
+		slog.ErrorContext(ctx, "Failed to record notification status", "status", status, "error", err)
+	}
+}
+
+// handleNotificationList replies with a user's notifications.
+func (s *Service) handleNotificationList(ctx context.Context, queue string, msg broker.Delivery, req contracts.NotificationList) {
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", req.UserID))
+	notifications, err := s.repo.ListNotifications(ctx, req.UserID)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	result := contracts.NotificationListResult{Notifications: make([]contracts.NotificationDetails, len(notifications))}
+	for i, n := range notifications {
+		result.Notifications[i] = n.details()
+	}
+	s.answer(ctx, queue, msg, result)
+}
+
+// handleContactGet replies with a user's contact details.
+func (s *Service) handleContactGet(ctx context.Context, queue string, msg broker.Delivery, req contracts.ContactGet) {
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", req.UserID))
+	contact, err := s.repo.GetContact(ctx, req.UserID)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	s.answer(ctx, queue, msg, contact.details())
+}
+
+// handleContactSet replaces a user's contact details and replies with
+// them.
+func (s *Service) handleContactSet(ctx context.Context, queue string, msg broker.Delivery, req contracts.ContactSet) {
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", req.UserID))
+	contact := Contact{UserID: req.UserID, Email: req.Email, Phone: req.Phone, Push: req.Push, Locale: req.Locale}
+	if err := s.repo.SaveContact(ctx, contact); err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	msgLog.InfoContext(ctx, "Contact details updated")
+	s.answer(ctx, queue, msg, contact.details())
+}
+
+// handlePreferencesGet replies with a user's notification preferences.
+func (s *Service) handlePreferencesGet(ctx context.Context, queue string, msg broker.Delivery, req contracts.PreferencesGet) {
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", req.UserID))
+	prefs, err := s.repo.GetPreferences(ctx, req.UserID)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	s.answer(ctx, queue, msg, prefs.details())
+}
+
+// handlePreferencesSet replaces a user's notification preferences and
+// replies with them. Invalid preferences are refused.
+func (s *Service) handlePreferencesSet(ctx context.Context, queue string, msg broker.Delivery, req contracts.PreferencesSet) {
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", req.UserID))
+	prefs := preferencesFrom(req)
+	if err := prefs.validate(); err != nil {
+		s.answer(ctx, queue, msg, contracts.NotificationError{Code: contracts.NotificationErrInvalid, Message: err.Error()})
+		return
+	}
+	if err := s.repo.SavePreferences(ctx, prefs); err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	msgLog.InfoContext(ctx, "Notification preferences updated")
+	s.answer(ctx, queue, msg, prefs.details())
+}
+
+// handleUnsubscribe unsubscribes the user an unsubscribe token was issued
+// for from marketing notifications and replies with their preferences.
+func (s *Service) handleUnsubscribe(ctx context.Context, queue string, msg broker.Delivery, req contracts.Unsubscribe) {
+	if s.unsubscribe == nil {
+		s.answer(ctx, queue, msg, contracts.NotificationError{Code: contracts.NotificationErrInvalidToken, Message: errInvalidToken.Error()})
+		return
+	}
+	userID, err := s.unsubscribe.UserID(req.Token)
+	if err != nil {
+		s.answer(ctx, queue, msg, contracts.NotificationError{Code: contracts.NotificationErrInvalidToken, Message: err.Error()})
+		return
+	}
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", userID))
+	prefs, err := s.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	prefs.Unsubscribed = true
+	if err := s.repo.SavePreferences(ctx, prefs); err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	msgLog.InfoContext(ctx, "User unsubscribed from marketing notifications")
+	s.answer(ctx, queue, msg, prefs.details())
+}
+
+// notificationError returns the reply for a failed notification request.
+func notificationError(ctx context.Context, err error) contracts.NotificationError {
+	if errors.Is(err, ErrWebhookNotFound) || errors.Is(err, ErrWebhookDeliveryNotFound) || errors.Is(err, ErrInboxItemNotFound) {
+		return contracts.NotificationError{Code: contracts.NotificationErrNotFound, Message: err.Error()}
+	}
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "notification request failed")
+	slog.ErrorContext(ctx, "Notification request failed", "error", err)
+	return contracts.NotificationError{Code: contracts.NotificationErrUnavailable, Message: "notification database unavailable"}
+}
+
+// answer replies to a notification request and acknowledges it. The
+// request is acknowledged even when the reply cannot be sent: the caller
+// has then timed out or will, and retrying would not help it.
+func (s *Service) answer(ctx context.Context, queue string, msg broker.Delivery, p contracts.Payload) {
+	if err := s.reply(ctx, msg, p); err != nil {
+		slog.ErrorContext(ctx, "Failed to publish notification reply", "error", err)
+	}
+	msg.Ack()
+	messagesAcked.WithLabelValues(queue).Inc()
+}
+
+// handleHealthCheck replies to a single health-check request.
+func (s *Service) handleHealthCheck(ctx context.Context, msg broker.Delivery) {
+	defer msg.Ack()
+	slog.DebugContext(ctx, "Received health check request")
+
+	dbStatus := "connected"
+	if err := s.repo.Ping(ctx); err != nil {
+		dbStatus = "disconnected"
+	}
+
+	response := contracts.HealthStatus{
+		Service:  "Notification Service",
+		Status:   "healthy",
+		Database: dbStatus,
+	}
+	if dbStatus == "disconnected" {
+		response.Status = "unhealthy"
+		response.Error = "Database connection failed"
+	} else if version, err := s.repo.SchemaVersion(ctx); err != nil {
+		response.Status = "unhealthy"
+		response.Error = "Schema version unavailable"
+	} else {
+		response.SchemaVersion = version
+		if version < SchemaVersion {
+			response.Status = "unhealthy"
+			response.Error = fmt.Sprintf("Schema version %d is behind %d", version, SchemaVersion)
+		}
+	}
+
+	if err := s.reply(ctx, msg, response); err != nil {
+		slog.ErrorContext(ctx, "Failed to publish health response", "error", err)
+		return
+	}
+	slog.DebugContext(ctx, "Health response published", "status", response.Status, "database", response.Database, "schema_version", response.SchemaVersion)
+}
@@ -0,0 +1,505 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	msgLog = slog.Default()
+	os.Exit(m.Run())
+}
+
+func newTestBroker(t *testing.T) *broker.Memory {
+	t.Helper()
+	b := broker.NewMemory()
+	if err := declareTopology(b); err != nil {
+		t.Fatalf("declareTopology: %v", err)
+	}
+	return b
+}
+
+// deliver publishes msg to queue and returns the resulting delivery.
+func deliver(t *testing.T, b *broker.Memory, queue string, msg broker.Message) broker.Delivery {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgs, err := b.Consume(ctx, queue)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if err := b.Publish(ctx, "", queue, msg); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	select {
+	case d := <-msgs:
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+	return broker.Delivery{}
+}
+
+// envelope encodes p as another service would publish it.
+func envelope(t *testing.T, p contracts.Payload) []byte {
+	t.Helper()
+	_, body, err := contracts.Encode(contracts.JSON, "test", p)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return body
+}
+
+func TestHandleNotification(t *testing.T) {
+	b := newTestBroker(t)
+	repo := NewMemoryNotifications()
+	body := envelope(t, contracts.NotificationRequested{UserID: 7, Message: "hello"})
+	msg := deliver(t, b, "notifications", broker.Message{Body: body})
+
+	NewService(repo, b).dispatch("notifications", msg)
+
+	if err := msg.Ack(); err == nil {
+		t.Fatal("notification was not settled by the handler")
+	}
+	if pending := b.Pending("notifications"); len(pending) != 0 {
+		t.Fatalf("notification left on queue: %v", pending)
+	}
+	stored, _ := repo.ListNotifications(context.Background(), 7)
+	if len(stored) != 1 || stored[0].Content != "hello" || stored[0].NotificationID == 0 {
+		t.Fatalf("stored notifications = %+v, want the one handled", stored)
+	}
+	if n := stored[0]; n.Status != contracts.NotificationStatusDelivered || n.Attempts != 1 || n.Channel != ChannelLog {
+		t.Fatalf("stored notification = %+v, want delivered on the log channel after 1 attempt", n)
+	}
+}
+
+func TestHandleNotificationRendersTemplate(t *testing.T) {
+	data := map[string]string{"order_id": "42", "product_id": "101", "quantity": "3"}
+	tests := []struct {
+		name         string
+		contact      string // locale in the user's contact details
+		req          contracts.NotificationRequested
+		wantStatus   string
+		wantLocale   string
+		wantContains string
+	}{
+		{"default locale", "", contracts.NotificationRequested{Template: "order_placed", Data: data}, contracts.NotificationStatusDelivered, "en", "Your order has been successfully placed! Order 42"},
+		{"user's locale", "de", contracts.NotificationRequested{Template: "order_placed", Data: data}, contracts.NotificationStatusDelivered, "de", "Bestellung 42"},
+		{"requested locale wins", "de", contracts.NotificationRequested{Template: "order_placed", Locale: "en-GB", Data: data}, contracts.NotificationStatusDelivered, "en", "Order 42"},
+		{"unknown template falls back to message", "", contracts.NotificationRequested{Template: "order_lost", Message: "Order lost"}, contracts.NotificationStatusDelivered, "", "Order lost"},
+		{"unknown template without message", "", contracts.NotificationRequested{Template: "order_lost"}, contracts.NotificationStatusFailed, "", ""},
+		{"missing data", "", contracts.NotificationRequested{Template: "order_placed"}, contracts.NotificationStatusFailed, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBroker(t)
+			repo := NewMemoryNotifications()
+			repo.SaveContact(context.Background(), Contact{UserID: 7, Locale: tt.contact})
+			tt.req.UserID = 7
+			msg := deliver(t, b, "notifications", broker.Message{Body: envelope(t, tt.req)})
+
+			NewService(repo, b).dispatch("notifications", msg)
+
+			if err := msg.Ack(); err == nil {
+				t.Fatal("notification was not settled by the handler")
+			}
+			stored, _ := repo.ListNotifications(context.Background(), 7)
+			if len(stored) != 1 {
+				t.Fatalf("stored %d notifications, want 1", len(stored))
+			}
+			n := stored[0]
+			if n.Status != tt.wantStatus || n.Locale != tt.wantLocale || !strings.Contains(n.Content, tt.wantContains) {
+				t.Fatalf("stored notification = %+v, want %s in locale %q containing %q", n, tt.wantStatus, tt.wantLocale, tt.wantContains)
+			}
+			if tt.wantStatus == contracts.NotificationStatusFailed && n.Attempts != 0 {
+				t.Fatalf("unrenderable notification had %d delivery attempts, want none", n.Attempts)
+			}
+		})
+	}
+}
+
+// fakeChannel records what it sends, or fails with err: always, or only
+// the first failures times if failures is set.
+type fakeChannel struct {
+	name     string
+	err      error
+	failures int
+	mu       sync.Mutex
+	tries    int
+	sent     []string // addresses
+	last     Notification
+}
+
+func (f *fakeChannel) Name() string { return f.name }
+
+func (f *fakeChannel) Send(ctx context.Context, to Contact, n Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tries++
+	if f.err != nil && (f.failures == 0 || f.tries <= f.failures) {
+		return f.err
+	}
+	f.sent = append(f.sent, to.address(f.name))
+	f.last = n
+	return nil
+}
+
+func TestHandleNotificationRoutesByHintAndFallsBack(t *testing.T) {
+	down := errors.New("provider down")
+	tests := []struct {
+		name         string
+		hint         string
+		contact      Contact
+		sms, email   error
+		wantStatus   string
+		wantChannel  string
+		wantAttempts int
+	}{
+		{"fallback order", "", Contact{UserID: 7, Email: "ada@example.com", Phone: "+15550100"}, nil, nil, contracts.NotificationStatusDelivered, ChannelEmail, 1},
+		{"hint first", ChannelSMS, Contact{UserID: 7, Email: "ada@example.com", Phone: "+15550100"}, nil, nil, contracts.NotificationStatusDelivered, ChannelSMS, 1},
+		{"hint fails over", ChannelSMS, Contact{UserID: 7, Email: "ada@example.com", Phone: "+15550100"}, down, nil, contracts.NotificationStatusDelivered, ChannelEmail, 2},
+		{"unreachable hint skipped", ChannelSMS, Contact{UserID: 7, Email: "ada@example.com"}, nil, nil, contracts.NotificationStatusDelivered, ChannelEmail, 1},
+		{"every channel fails", "", Contact{UserID: 7, Email: "ada@example.com", Phone: "+15550100"}, down, down, contracts.NotificationStatusFailed, ChannelSMS, 2},
+		{"unreachable", "", Contact{UserID: 7}, nil, nil, contracts.NotificationStatusFailed, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBroker(t)
+			repo := NewMemoryNotifications()
+			repo.SaveContact(context.Background(), tt.contact)
+			body := envelope(t, contracts.NotificationRequested{UserID: 7, Message: "hello", Channel: tt.hint, Template: "order_placed"})
+			msg := deliver(t, b, "notifications", broker.Message{Body: body})
+
+			svc := NewService(repo, b)
+			svc.SetChannels(NewRouter(&fakeChannel{name: ChannelEmail, err: tt.email}, &fakeChannel{name: ChannelSMS, err: tt.sms}))
+			svc.dispatch("notifications", msg)
+
+			if pending := b.Pending("notifications"); len(pending) != 0 {
+				t.Fatalf("notification left on queue: %v", pending)
+			}
+			stored, _ := repo.ListNotifications(context.Background(), 7)
+			if len(stored) != 1 {
+				t.Fatalf("stored %d notifications, want 1", len(stored))
+			}
+			n := stored[0]
+			if n.Status != tt.wantStatus || n.Channel != tt.wantChannel || n.Attempts != tt.wantAttempts {
+				t.Errorf("stored notification = %+v, want %s on %q after %d attempts", n, tt.wantStatus, tt.wantChannel, tt.wantAttempts)
+			}
+			if (n.DeliveredAt != nil) != (tt.wantStatus == contracts.NotificationStatusDelivered) {
+				t.Errorf("DeliveredAt = %v for a %s notification", n.DeliveredAt, n.Status)
+			}
+		})
+	}
+}
+
+func TestHandleNotificationFollowsPreferences(t *testing.T) {
+	// Quiet hours around the current time, in a time zone of their own.
+	zone := "Asia/Kolkata"
+	loc, _ := time.LoadLocation(zone)
+	now := time.Now().In(loc)
+	quiet := &contracts.QuietHours{Start: now.Add(-time.Hour).Format("15:04"), End: now.Add(time.Hour).Format("15:04"), TimeZone: zone}
+	marketingEmail := map[string]map[string]bool{contracts.CategoryMarketing: {ChannelEmail: true}}
+	contact := Contact{UserID: 7, Email: "ada@example.com", Phone: "+15550100"}
+	tests := []struct {
+		name             string
+		hint, category   string
+		prefs            Preferences
+		wantStatus       string
+		wantChannel      string
+		wantUnsubscribed bool // whether the notification came with an unsubscribe link
+	}{
+		{"transactional by default", ChannelSMS, "", Preferences{}, contracts.NotificationStatusDelivered, ChannelSMS, false},
+		{"opted out channel skipped", ChannelSMS, contracts.CategoryTransactional, Preferences{OptIns: map[string]map[string]bool{contracts.CategoryTransactional: {ChannelSMS: false}}}, contracts.NotificationStatusDelivered, ChannelEmail, false},
+		{"quiet hours fall back to email", ChannelSMS, "", Preferences{QuietHours: quiet}, contracts.NotificationStatusDelivered, ChannelEmail, false},
+		{"marketing suppressed by default", "", contracts.CategoryMarketing, Preferences{}, contracts.NotificationStatusSuppressed, "", false},
+		{"marketing opt-in", "", contracts.CategoryMarketing, Preferences{OptIns: marketingEmail}, contracts.NotificationStatusDelivered, ChannelEmail, true},
+		{"marketing after unsubscribing", "", contracts.CategoryMarketing, Preferences{OptIns: marketingEmail, Unsubscribed: true}, contracts.NotificationStatusSuppressed, "", false},
+		{"unknown category", "", "gossip", Preferences{}, contracts.NotificationStatusFailed, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBroker(t)
+			repo := NewMemoryNotifications()
+			repo.SaveContact(context.Background(), contact)
+			tt.prefs.UserID = 7
+			repo.SavePreferences(context.Background(), tt.prefs)
+			body := envelope(t, contracts.NotificationRequested{UserID: 7, Message: "hello", Channel: tt.hint, Category: tt.category})
+			msg := deliver(t, b, "notifications", broker.Message{Body: body})
+
+			email, sms := &fakeChannel{name: ChannelEmail}, &fakeChannel{name: ChannelSMS}
+			svc := NewService(repo, b)
+			svc.SetChannels(NewRouter(email, sms, LogChannel{}))
+			svc.SetUnsubscribeLinks(NewUnsubscribeLinks([]byte("secret"), "https://shop.example/unsubscribe"))
+			svc.dispatch("notifications", msg)
+
+			stored, _ := repo.ListNotifications(context.Background(), 7)
+			if len(stored) != 1 {
+				t.Fatalf("stored %d notifications, want 1", len(stored))
+			}
+			n := stored[0]
+			if n.Status != tt.wantStatus || n.Channel != tt.wantChannel {
+				t.Fatalf("stored notification = %+v, want %s on %q", n, tt.wantStatus, tt.wantChannel)
+			}
+			if got := email.last.UnsubscribeURL != ""; got != tt.wantUnsubscribed {
+				t.Errorf("email unsubscribe link = %q, want one: %v", email.last.UnsubscribeURL, tt.wantUnsubscribed)
+			}
+		})
+	}
+}
+
+func TestQuietHoursDeferDelivery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := broker.NewMemory()
+	repo := NewMemoryNotifications()
+	repo.SaveContact(ctx, Contact{UserID: 7, Phone: "+15550100"})
+	repo.SavePreferences(ctx, Preferences{UserID: 7, QuietHours: &contracts.QuietHours{Start: "22:00", End: "07:00", TimeZone: "UTC"}})
+	sms := &fakeChannel{name: ChannelSMS}
+	svc := NewService(repo, b)
+	svc.SetChannels(NewRouter(sms))
+	var mu sync.Mutex
+	clock := time.Date(2024, time.July, 1, 23, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	}
+	svc.quietRecheck = 5 * time.Millisecond
+	if err := svc.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// An SMS-only user's order update waits out the night rather than
+	// being dropped.
+	body := envelope(t, contracts.NotificationRequested{UserID: 7, Message: "Your order has shipped"})
+	if err := b.Publish(ctx, "", "notifications", broker.Message{Body: body}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	stored, _ := repo.ListNotifications(ctx, 7)
+	if len(stored) != 1 || stored[0].Status != contracts.NotificationStatusQueued {
+		t.Fatalf("notifications during quiet hours = %+v, want one queued", stored)
+	}
+	sms.mu.Lock()
+	if len(sms.sent) != 0 {
+		t.Errorf("SMS sent %d times during quiet hours, want none", len(sms.sent))
+	}
+	sms.mu.Unlock()
+
+	mu.Lock()
+	clock = time.Date(2024, time.July, 2, 7, 15, 0, 0, time.UTC)
+	mu.Unlock()
+	var n Notification
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		stored, _ := repo.ListNotifications(ctx, 7)
+		if n = stored[0]; n.Status == contracts.NotificationStatusDelivered {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("notification after quiet hours = %+v, want it delivered", n)
+		}
+	}
+	if n.Channel != ChannelSMS || n.Attempts != 1 {
+		t.Errorf("notification = %+v, want delivered by SMS on the first attempt", n)
+	}
+}
+
+func TestPreferenceRequests(t *testing.T) {
+	b := newTestBroker(t)
+	repo := NewMemoryNotifications()
+	links := NewUnsubscribeLinks([]byte("secret"), "https://shop.example/unsubscribe")
+	svc := NewService(repo, b)
+	svc.SetUnsubscribeLinks(links)
+	replyQueue, _ := b.DeclareQueue("", broker.QueueOptions{})
+
+	request := func(p contracts.Payload) contracts.Payload {
+		t.Helper()
+		msg := deliver(t, b, "notification_requests", broker.Message{ReplyTo: replyQueue, Body: envelope(t, p)})
+		svc.dispatch("notification_requests", msg)
+		replies := b.Pending(replyQueue)
+		if len(replies) == 0 {
+			t.Fatal("no reply")
+		}
+		_, payload, err := contracts.Decode(replies[len(replies)-1].ContentType, replies[len(replies)-1].Body)
+		if err != nil {
+			t.Fatalf("invalid reply: %v", err)
+		}
+		return payload
+	}
+	defaults := map[string]map[string]bool{
+		contracts.CategoryTransactional: {ChannelEmail: true, ChannelSMS: true, ChannelPush: true, ChannelInbox: true},
+		contracts.CategoryMarketing:     {ChannelEmail: false, ChannelSMS: false, ChannelPush: false, ChannelInbox: false},
+	}
+
+	if got := request(contracts.PreferencesGet{UserID: 7}); !reflect.DeepEqual(got, contracts.PreferencesDetails{UserID: 7, Categories: defaults}) {
+		t.Errorf("preferences before set = %+v, want the defaults", got)
+	}
+
+	set := contracts.PreferencesSet{
+		UserID:     7,
+		Categories: map[string]map[string]bool{contracts.CategoryMarketing: {ChannelEmail: true}},
+		QuietHours: &contracts.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Berlin"},
+	}
+	want := contracts.PreferencesDetails{
+		UserID: 7,
+		Categories: map[string]map[string]bool{
+			contracts.CategoryTransactional: defaults[contracts.CategoryTransactional],
+			contracts.CategoryMarketing:     {ChannelEmail: true, ChannelSMS: false, ChannelPush: false, ChannelInbox: false},
+		},
+		QuietHours: set.QuietHours,
+	}
+	if got := request(set); !reflect.DeepEqual(got, want) {
+		t.Errorf("set reply = %+v, want %+v", got, want)
+	}
+	if got := request(contracts.PreferencesGet{UserID: 7}); !reflect.DeepEqual(got, want) {
+		t.Errorf("preferences after set = %+v, want %+v", got, want)
+	}
+
+	invalid := contracts.PreferencesSet{UserID: 7, QuietHours: &contracts.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Mars/Olympus"}}
+	if got, _ := request(invalid).(contracts.NotificationError); got.Code != contracts.NotificationErrInvalid {
+		t.Errorf("invalid set reply = %+v, want %s", got, contracts.NotificationErrInvalid)
+	}
+
+	want.Unsubscribed = true
+	if got := request(contracts.Unsubscribe{Token: links.Token(7)}); !reflect.DeepEqual(got, want) {
+		t.Errorf("unsubscribe reply = %+v, want %+v", got, want)
+	}
+	if p, _ := repo.GetPreferences(context.Background(), 7); !p.Unsubscribed || p.QuietHours == nil {
+		t.Errorf("preferences after unsubscribing = %+v, want unsubscribed with the rest kept", p)
+	}
+	if got, _ := request(contracts.Unsubscribe{Token: "7.forged"}).(contracts.NotificationError); got.Code != contracts.NotificationErrInvalidToken {
+		t.Errorf("forged unsubscribe reply = %+v, want %s", got, contracts.NotificationErrInvalidToken)
+	}
+}
+
+func TestContactRequests(t *testing.T) {
+	b := newTestBroker(t)
+	repo := NewMemoryNotifications()
+	svc := NewService(repo, b)
+	replyQueue, _ := b.DeclareQueue("", broker.QueueOptions{})
+
+	request := func(p contracts.Payload) contracts.Payload {
+		t.Helper()
+		msg := deliver(t, b, "notification_requests", broker.Message{ReplyTo: replyQueue, Body: envelope(t, p)})
+		svc.dispatch("notification_requests", msg)
+		replies := b.Pending(replyQueue)
+		if len(replies) == 0 {
+			t.Fatal("no reply")
+		}
+		_, payload, err := contracts.Decode(replies[len(replies)-1].ContentType, replies[len(replies)-1].Body)
+		if err != nil {
+			t.Fatalf("invalid reply: %v", err)
+		}
+		return payload
+	}
+
+	if got := request(contracts.ContactGet{UserID: 7}); got != (contracts.ContactDetails{UserID: 7}) {
+		t.Errorf("contact before set = %+v, want empty", got)
+	}
+	set := contracts.ContactSet{UserID: 7, Email: "ada@example.com", Phone: "+15550100", Locale: "de"}
+	if got := request(set); got != contracts.ContactDetails(set) {
+		t.Errorf("set reply = %+v, want %+v", got, set)
+	}
+	if got := request(contracts.ContactGet{UserID: 7}); got != contracts.ContactDetails(set) {
+		t.Errorf("contact after set = %+v, want %+v", got, set)
+	}
+}
+
+func TestHandleNotificationList(t *testing.T) {
+	b := newTestBroker(t)
+	repo := NewMemoryNotifications()
+	repo.SaveNotification(context.Background(), Notification{UserID: 7, Channel: ChannelLog, Content: "placed", Status: contracts.NotificationStatusQueued})
+	repo.SaveNotification(context.Background(), Notification{UserID: 8, Channel: ChannelLog, Content: "other user", Status: contracts.NotificationStatusQueued})
+	replyQueue, _ := b.DeclareQueue("", broker.QueueOptions{})
+	msg := deliver(t, b, "notification_requests", broker.Message{
+		ReplyTo: replyQueue,
+		Body:    envelope(t, contracts.NotificationList{UserID: 7}),
+	})
+
+	NewService(repo, b).dispatch("notification_requests", msg)
+
+	if pending := b.Pending("notification_requests"); len(pending) != 0 {
+		t.Fatalf("request left on queue: %v", pending)
+	}
+	replies := b.Pending(replyQueue)
+	if len(replies) != 1 {
+		t.Fatalf("got %d replies, want 1", len(replies))
+	}
+	_, payload, err := contracts.Decode(replies[0].ContentType, replies[0].Body)
+	if err != nil {
+		t.Fatalf("invalid reply: %v", err)
+	}
+	result, _ := payload.(contracts.NotificationListResult)
+	if len(result.Notifications) != 1 || result.Notifications[0].Content != "placed" || result.Notifications[0].Status != contracts.NotificationStatusQueued {
+		t.Errorf("reply = %+v, want user 7's queued notification", payload)
+	}
+}
+
+func TestDispatchRejectsToDeadLetterQueue(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"malformed body", []byte("not json")},
+		{"bare payload without envelope", []byte(`{"user_id":7,"message":"hello"}`)},
+		{"unknown version", []byte(`{"type":"notification.requested","version":0,"payload":{"user_id":7}}`)},
+		{"type this service does not handle", envelope(t, contracts.StockCheck{ProductID: 101, Quantity: 1})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBroker(t)
+			repo := NewMemoryNotifications()
+			msg := deliver(t, b, "notifications", broker.Message{Body: tt.body})
+
+			NewService(repo, b).dispatch("notifications", msg)
+
+			if pending := b.Pending("notifications"); len(pending) != 0 {
+				t.Fatalf("rejected notification was requeued: %v", pending)
+			}
+			if dead := b.Pending(broker.DeadLetterQueue("notifications")); len(dead) != 1 {
+				t.Fatalf("dead-letter queue holds %d messages, want 1", len(dead))
+			}
+			if stored, _ := repo.ListNotifications(context.Background(), 7); len(stored) != 0 {
+				t.Fatalf("rejected notification was stored: %+v", stored)
+			}
+		})
+	}
+}
+
+func TestHandleHealthCheck(t *testing.T) {
+	b := newTestBroker(t)
+	replyQueue, _ := b.DeclareQueue("", broker.QueueOptions{})
+	queue, _ := b.DeclareQueue("", broker.QueueOptions{})
+	msg := deliver(t, b, queue, broker.Message{
+		CorrelationID: "health",
+		ReplyTo:       replyQueue,
+		Body:          envelope(t, contracts.HealthCheck{}),
+	})
+
+	NewService(NewMemoryNotifications(), b).dispatch("health_check", msg)
+
+	replies := b.Pending(replyQueue)
+	if len(replies) != 1 {
+		t.Fatalf("got %d replies, want 1", len(replies))
+	}
+	_, payload, err := contracts.Decode(replies[0].ContentType, replies[0].Body)
+	if err != nil {
+		t.Fatalf("invalid reply: %v", err)
+	}
+	resp, _ := payload.(contracts.HealthStatus)
+	if resp.Service != "Notification Service" || resp.Status != "healthy" {
+		t.Errorf("reply = %+v", resp)
+	}
+}
@@ -0,0 +1,57 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPSMS delivers notifications as text messages through an SMS provider
+// with an HTTP API. It POSTs
+//
+//	{"from": "+15550100", "to": "+15550199", "body": "..."}
+//
+// to the provider's URL, with the token as a bearer token, and treats any
+// 2xx response as accepted. Most providers take this shape directly or
+// behind a small adapter.
+type HTTPSMS struct {
+	url    string
+	token  string
+	from   string
+	client *http.Client
+}
+
+// NewHTTPSMS returns an SMS channel that sends from the number from through
+// the provider at url.
+func NewHTTPSMS(url, token, from string) *HTTPSMS {
+	return &HTTPSMS{url: url, token: token, from: from, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *HTTPSMS) Name() string { return ChannelSMS }
+
+func (s *HTTPSMS) Send(ctx context.Context, to Contact, n Notification) error {
+	body, err := json.Marshal(map[string]string{"from": s.from, "to": to.Phone, "body": n.Content})
+	if err != nil {
+		return permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("SMS provider: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return providerError("SMS provider", resp)
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+// ErrUnknownTemplate is returned for a template that has no variant in any
+// of the locales tried.
+var ErrUnknownTemplate = errors.New("unknown notification template")
+
+// Templates renders notifications from named templates, such as
+// "order_placed", with a variant per locale. A template directory holds a
+// directory per locale, named by its language tag, with files
+//
+//	<locale>/<name>.txt   the text, a text/template; required
+//	<locale>/<name>.html  the HTML for email, an html/template; optional
+//
+// A text template may define a "subject" template, which is the subject of
+// emails and the title of push messages. Templates refer to the data of a
+// notification as {{.order_id}}; a missing value fails rendering.
+type Templates struct {
+	fsys          fs.FS
+	defaultLocale string
+
+	mu        sync.RWMutex
+	variants  map[string]map[string]*templateVariant // by name, then lowercased locale
+	signature string                                 // of the files variants was loaded from
+}
+
+// templateVariant is a template in one locale.
+type templateVariant struct {
+	locale string
+	text   *texttemplate.Template
+	html   *htmltemplate.Template // nil without an HTML variant
+}
+
+// Rendered is a rendered notification.
+type Rendered struct {
+	Locale  string // the locale of the variant used
+	Subject string
+	Text    string
+	HTML    string // empty without an HTML variant
+}
+
+// LoadTemplates loads the templates in fsys. Notifications in locales it
+// has no variant for fall back to defaultLocale.
+func LoadTemplates(fsys fs.FS, defaultLocale string) (*Templates, error) {
+	t := &Templates{fsys: fsys, defaultLocale: defaultLocale}
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// DefaultTemplates returns the templates built into the service, in English
+// by default.
+func DefaultTemplates() *Templates {
+	files, err := fs.Sub(templateFiles, "templates")
+	if err != nil {
+		panic(err)
+	}
+	t, err := LoadTemplates(files, "en")
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Reload loads the templates again. If any of them fails to parse, the
+// templates loaded before stay in use and the error is returned.
+func (t *Templates) Reload() error {
+	variants, signature, err := parseTemplates(t.fsys)
+	if err != nil {
+		return err
+	}
+	if _, ok := variants[strings.ToLower(t.defaultLocale)]; !ok && len(variants) > 0 {
+		return fmt.Errorf("no templates for the default locale %q", t.defaultLocale)
+	}
+	byName := map[string]map[string]*templateVariant{}
+	for locale, named := range variants {
+		for name, v := range named {
+			if byName[name] == nil {
+				byName[name] = map[string]*templateVariant{}
+			}
+			byName[name][locale] = v
+		}
+	}
+	t.mu.Lock()
+	t.variants, t.signature = byName, signature
+	t.mu.Unlock()
+	return nil
+}
+
+// parseTemplates parses the templates in fsys, by lowercased locale and then
+// name, and returns them with a signature of the files they came from.
+func parseTemplates(fsys fs.FS) (map[string]map[string]*templateVariant, string, error) {
+	variants := map[string]map[string]*templateVariant{}
+	signature, err := templateSignature(fsys)
+	if err != nil {
+		return nil, "", err
+	}
+	locales, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, "", err
+	}
+	for _, dir := range locales {
+		if !dir.IsDir() {
+			continue
+		}
+		locale := dir.Name()
+		files, err := fs.ReadDir(fsys, locale)
+		if err != nil {
+			return nil, "", err
+		}
+		named := map[string]*templateVariant{}
+		// HTML variants are attached to their text variant, so read .txt first.
+		sort.Slice(files, func(i, j int) bool { return path.Ext(files[i].Name()) > path.Ext(files[j].Name()) })
+		for _, f := range files {
+			ext := path.Ext(f.Name())
+			name := strings.TrimSuffix(f.Name(), ext)
+			if f.IsDir() || (ext != ".txt" && ext != ".html") {
+				continue
+			}
+			data, err := fs.ReadFile(fsys, path.Join(locale, f.Name()))
+			if err != nil {
+				return nil, "", err
+			}
+			file := path.Join(locale, f.Name())
+			switch ext {
+			case ".txt":
+				text, err := texttemplate.New(name).Option("missingkey=error").Parse(string(data))
+				if err != nil {
+					return nil, "", fmt.Errorf("template %s: %w", file, err)
+				}
+				named[name] = &templateVariant{locale: locale, text: text}
+			case ".html":
+				v, ok := named[name]
+				if !ok {
+					return nil, "", fmt.Errorf("template %s has no text variant %s.txt", file, name)
+				}
+				html, err := htmltemplate.New(name).Option("missingkey=error").Parse(string(data))
+				if err != nil {
+					return nil, "", fmt.Errorf("template %s: %w", file, err)
+				}
+				v.html = html
+			}
+		}
+		variants[strings.ToLower(locale)] = named
+	}
+	return variants, signature, nil
+}
+
+// templateSignature returns a string that changes whenever a file in fsys
+// is added, removed or modified.
+func templateSignature(fsys fs.FS) (string, error) {
+	var b strings.Builder
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", p, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return b.String(), err
+}
+
+// Watch reloads the templates whenever their files change, checking every
+// interval until ctx is cancelled. Templates that fail to load are logged
+// and the previous ones kept.
+func (t *Templates) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		signature, err := templateSignature(t.fsys)
+		t.mu.RLock()
+		changed := signature != t.signature
+		t.mu.RUnlock()
+		if err != nil || !changed {
+			continue
+		}
+		if err := t.Reload(); err != nil {
+			slog.Error("Failed to reload notification templates, keeping the previous ones", "error", err)
+			// Do not retry until the files change again.
+			t.mu.Lock()
+			t.signature = signature
+			t.mu.Unlock()
+			continue
+		}
+		slog.Info("Reloaded notification templates", "templates", t.Names())
+	}
+}
+
+// Names returns the names of the templates, sorted.
+func (t *Templates) Names() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	names := make([]string, 0, len(t.variants))
+	for name := range t.variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render renders the template called name with data, in the first of
+// locales it has a variant for. A locale such as "de-AT" falls back to
+// "de", and every locale to the default locale.
+func (t *Templates) Render(name string, data map[string]string, locales ...string) (Rendered, error) {
+	t.mu.RLock()
+	variants := t.variants[name]
+	t.mu.RUnlock()
+
+	var v *templateVariant
+	for _, locale := range localeFallbacks(append(locales, t.defaultLocale)) {
+		if v = variants[locale]; v != nil {
+			break
+		}
+	}
+	if v == nil {
+		return Rendered{}, fmt.Errorf("%w %q", ErrUnknownTemplate, name)
+	}
+
+	if data == nil {
+		data = map[string]string{}
+	}
+	r := Rendered{Locale: v.locale}
+	var b bytes.Buffer
+	if err := v.text.Execute(&b, data); err != nil {
+		return Rendered{}, err
+	}
+	r.Text = strings.TrimSpace(b.String())
+	if subject := v.text.Lookup("subject"); subject != nil {
+		b.Reset()
+		if err := subject.Execute(&b, data); err != nil {
+			return Rendered{}, err
+		}
+		r.Subject = strings.TrimSpace(b.String())
+	}
+	if v.html != nil {
+		b.Reset()
+		if err := v.html.Execute(&b, data); err != nil {
+			return Rendered{}, err
+		}
+		r.HTML = b.String()
+	}
+	return r, nil
+}
+
+// localeFallbacks returns the lowercased locales to try for locales, each
+// followed by its less specific forms: "pt-BR" gives "pt-br" and "pt".
+// Empty locales are left out.
+func localeFallbacks(locales []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, locale := range locales {
+		locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+		for locale != "" {
+			if !seen[locale] {
+				seen[locale] = true
+				out = append(out, locale)
+			}
+			i := strings.LastIndex(locale, "-")
+			if i < 0 {
+				break
+			}
+			locale = locale[:i]
+		}
+	}
+	return out
+}
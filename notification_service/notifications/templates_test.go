@@ -0,0 +1,153 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestDefaultTemplates(t *testing.T) {
+	templates := DefaultTemplates()
+	data := map[string]string{"order_id": "42", "product_id": "101", "quantity": "3", "available": "2", "threshold": "10", "safety_stock": "3", "count": "2", "items": "- Order 42"}
+	for _, name := range []string{"order_placed", "order_processed", "order_shipped", "order_cancelled", "low_stock", "digest"} {
+		for _, locale := range []string{"en", "de"} {
+			r, err := templates.Render(name, data, locale)
+			if err != nil {
+				t.Errorf("Render(%s, %s): %v", name, locale, err)
+				continue
+			}
+			if r.Locale != locale || r.Subject == "" || r.Text == "" {
+				t.Errorf("Render(%s, %s) = %+v", name, locale, r)
+			}
+		}
+	}
+}
+
+func TestTemplatesRender(t *testing.T) {
+	templates, err := LoadTemplates(fstest.MapFS{
+		"en/order_placed.txt":  {Data: []byte(`{{define "subject"}}Order {{.order_id}}{{end}}Your order {{.order_id}} has been placed.` + "\n")},
+		"en/order_placed.html": {Data: []byte(`<p>Your order {{.order_id}} has been placed.</p>`)},
+		"de/order_placed.txt":  {Data: []byte(`Ihre Bestellung {{.order_id}} ist eingegangen.`)},
+		"pt-BR/greeting.txt":   {Data: []byte(`Olá!`)},
+		"en/greeting.txt":      {Data: []byte(`Hello!`)},
+	}, "en")
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+
+	tests := []struct {
+		name, template string
+		locales        []string
+		data           map[string]string
+		want           Rendered
+	}{
+		{"default locale", "order_placed", nil, map[string]string{"order_id": "42"},
+			Rendered{Locale: "en", Subject: "Order 42", Text: "Your order 42 has been placed.", HTML: "<p>Your order 42 has been placed.</p>"}},
+		{"requested locale", "order_placed", []string{"de"}, map[string]string{"order_id": "42"},
+			Rendered{Locale: "de", Text: "Ihre Bestellung 42 ist eingegangen."}},
+		{"region falls back to language", "order_placed", []string{"de-AT"}, map[string]string{"order_id": "42"},
+			Rendered{Locale: "de", Text: "Ihre Bestellung 42 ist eingegangen."}},
+		{"first locale with a variant", "order_placed", []string{"fr", "de"}, map[string]string{"order_id": "42"},
+			Rendered{Locale: "de", Text: "Ihre Bestellung 42 ist eingegangen."}},
+		{"locales match in any case", "greeting", []string{"pt_br"}, nil,
+			Rendered{Locale: "pt-BR", Text: "Olá!"}},
+		{"html escapes data", "order_placed", []string{"en"}, map[string]string{"order_id": "<b>42</b>"},
+			Rendered{Locale: "en", Subject: "Order <b>42</b>", Text: "Your order <b>42</b> has been placed.", HTML: "<p>Your order &lt;b&gt;42&lt;/b&gt; has been placed.</p>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := templates.Render(tt.template, tt.data, tt.locales...)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Render = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := templates.Render("order_lost", nil, "en"); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("Render of an unknown template = %v, want ErrUnknownTemplate", err)
+	}
+	if _, err := templates.Render("order_placed", map[string]string{}, "en"); err == nil {
+		t.Error("Render without the data the template refers to succeeded")
+	}
+}
+
+func TestLoadTemplatesRejects(t *testing.T) {
+	for name, fsys := range map[string]fstest.MapFS{
+		"bad syntax":        {"en/order_placed.txt": {Data: []byte(`{{.order_id`)}},
+		"html without text": {"en/order_placed.html": {Data: []byte(`<p>placed</p>`)}},
+		"no default locale": {"de/order_placed.txt": {Data: []byte(`Bestellung`)}},
+	} {
+		if _, err := LoadTemplates(fsys, "en"); err == nil {
+			t.Errorf("LoadTemplates with %s succeeded", name)
+		}
+	}
+}
+
+func TestTemplatesWatchReloads(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("en/greeting.txt", "Hello!")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	templates, err := NewTemplates(ctx, TemplateConfig{Dir: dir, DefaultLocale: "en", ReloadInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewTemplates: %v", err)
+	}
+	text := func() string {
+		r, err := templates.Render("greeting", nil, "en")
+		if err != nil {
+			return err.Error()
+		}
+		return r.Text
+	}
+
+	write("en/greeting.txt", "Hi there!")
+	waitFor(t, "the changed template", func() bool { return text() == "Hi there!" })
+
+	// A broken template keeps the last good ones in use.
+	write("en/greeting.txt", "{{.name")
+	write("en/farewell.txt", "Bye!")
+	time.Sleep(50 * time.Millisecond)
+	if got := text(); got != "Hi there!" {
+		t.Fatalf("after a broken edit greeting = %q, want the previous version", got)
+	}
+	if names := strings.Join(templates.Names(), ","); names != "greeting" {
+		t.Fatalf("after a broken edit templates = %s, want greeting only", names)
+	}
+
+	write("en/greeting.txt", "Hello again!")
+	waitFor(t, "the fixed template", func() bool { return text() == "Hello again!" })
+	if names := strings.Join(templates.Names(), ","); names != "farewell,greeting" {
+		t.Fatalf("templates = %s, want farewell,greeting", names)
+	}
+}
+
+// waitFor fails the test if cond does not hold within a second.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,614 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Events published to contracts.EventsExchange reach the webhook_events
+// queue, where each one is logged as a delivery to every active webhook
+// subscribed to it. Each delivery is then sent from the webhooks queue,
+// POSTed to the webhook's URL as
+//
+//	{"id": "<event ID>", "event": "order.placed", "created_at": "...", "data": {...}}
+//
+// signed with the webhook's secret. A delivery that fails is retried with
+// backoff, through delay queues like notification retries, until it runs
+// out of attempts; a webhook whose deliveries keep failing is disabled.
+
+// webhookDeliveryLimit is how many of a webhook's latest deliveries its
+// delivery log shows.
+const webhookDeliveryLimit = 100
+
+// Webhook is a partner's subscription to events. Failures counts the
+// deliveries that failed in a row.
+type Webhook struct {
+	WebhookID int
+	URL       string
+	Events    []string
+	Secret    string
+	Active    bool
+	Failures  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// details returns w as a contracts.WebhookDetails, without its secret.
+func (w Webhook) details() contracts.WebhookDetails {
+	return contracts.WebhookDetails{
+		WebhookID: w.WebhookID,
+		URL:       w.URL,
+		Events:    w.Events,
+		Active:    w.Active,
+		Failures:  w.Failures,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// subscribes reports whether w receives event.
+func (w Webhook) subscribes(event string) bool {
+	return contains(w.Events, event)
+}
+
+// WebhookDelivery is an event sent, or to be sent, to a webhook. Payload is
+// the request body, kept so that retries and redeliveries send, and sign,
+// the same bytes. Status is one of the contracts.WebhookDelivery
+// constants.
+type WebhookDelivery struct {
+	DeliveryID     int
+	WebhookID      int
+	EventID        string
+	Event          string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	ResponseStatus int
+	Error          string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// details returns d as a contracts.WebhookDeliveryDetails.
+func (d WebhookDelivery) details() contracts.WebhookDeliveryDetails {
+	return contracts.WebhookDeliveryDetails{
+		DeliveryID:     d.DeliveryID,
+		WebhookID:      d.WebhookID,
+		EventID:        d.EventID,
+		Event:          d.Event,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		Error:          d.Error,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+}
+
+// webhookEvent is the body of a webhook request.
+type webhookEvent struct {
+	ID        string          `json:"id"`
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      contracts.Event `json:"data"`
+}
+
+// SignWebhook returns the Webhook-Signature header of a request with body
+// sent at t: the Unix time and the hex HMAC-SHA256, keyed with the
+// webhook's secret, of the time, a dot and the body.
+//
+//	t=1700000000,v1=5257a869...
+//
+// Signing the time lets receivers refuse replayed requests.
+func SignWebhook(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + webhookMAC(secret, ts, body)
+}
+
+// webhookMAC returns the hex signature of body sent at the Unix time ts.
+func webhookMAC(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrWebhookSignature is returned by VerifyWebhook for a request that was
+// not signed with the webhook's secret, or too long ago.
+var ErrWebhookSignature = errors.New("invalid webhook signature")
+
+// VerifyWebhook checks the Webhook-Signature header of a request with body
+// received at now, as a receiver would: the signature must match secret
+// and have been made no more than tolerance away from now.
+func VerifyWebhook(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sigs = append(sigs, value)
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return ErrWebhookSignature
+	}
+	if d := now.Sub(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+		return fmt.Errorf("%w: signed %s ago", ErrWebhookSignature, d.Round(time.Second))
+	}
+	want := []byte(webhookMAC(secret, ts, body))
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), want) {
+			return nil
+		}
+	}
+	return ErrWebhookSignature
+}
+
+// newWebhookSecret returns a random webhook secret.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// errPrivateAddress is returned for a webhook that resolves to an address
+// of the service's own network.
+var errPrivateAddress = errors.New("webhook address is not public")
+
+// deniedPrefixes are the ranges, besides those of the kinds publicAddress
+// checks for, that webhooks may not be sent to.
+var deniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+}
+
+// nat64Prefix is the well-known NAT64 prefix. Its addresses end in the
+// IPv4 address they are translated to.
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// publicAddress reports whether webhooks may be sent to ip: it must not
+// be a loopback, link-local, private, unspecified or multicast address,
+// nor in deniedPrefixes. IPv4-mapped and NAT64 addresses are checked as
+// the IPv4 address they stand for.
+func publicAddress(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	if nat64Prefix.Contains(addr) {
+		b := addr.As16()
+		addr = netip.AddrFrom4([4]byte(b[12:]))
+	}
+	if addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() {
+		return false
+	}
+	for _, p := range deniedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// newWebhookClient returns the client webhooks are called with. It does
+// not follow redirects: a webhook is expected to answer at its URL. Unless
+// allowPrivate is set it refuses to connect to anything but public
+// addresses. The check is made on the address dialed, after the host is
+// resolved, so a host that resolved to a public address when its webhook
+// was registered can't be rebound to an internal one. It uses no proxy,
+// which would be the address checked.
+func newWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicAddress(ip) {
+				return fmt.Errorf("%w: %s", errPrivateAddress, host)
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// validateWebhook reports what is wrong with a webhook's URL and events,
+// if anything. Unless private addresses are allowed, the URL's host must
+// resolve to public addresses only.
+func (s *Service) validateWebhook(ctx context.Context, rawURL string, events []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook URL %q is not an http or https URL", rawURL)
+	}
+	if len(events) == 0 {
+		return errors.New("webhook subscribes to no events")
+	}
+	for _, event := range events {
+		if !contains(contracts.EventNames, event) {
+			return fmt.Errorf("unknown event %q", event)
+		}
+	}
+	if s.webhooks.AllowPrivate {
+		return nil
+	}
+	ips, err := s.lookupIP(ctx, "ip", u.Hostname())
+	if err != nil || len(ips) == 0 {
+		return fmt.Errorf("webhook host %q does not resolve", u.Hostname())
+	}
+	for _, ip := range ips {
+		if !publicAddress(ip) {
+			return fmt.Errorf("webhook host %q resolves to %s, which is not a public address", u.Hostname(), ip)
+		}
+	}
+	return nil
+}
+
+// handleEvent logs an event as a delivery to every active webhook
+// subscribed to it and queues the deliveries, then alerts the staff role
+// the event concerns, if any. The event's message ID identifies it, so a
+// redelivered event is neither logged nor sent twice. A delivery that
+// can't be queued is failed, to be redelivered by hand.
+func (s *Service) handleEvent(ctx context.Context, queue string, msg broker.Delivery, env contracts.Envelope, e contracts.Event) {
+	span := trace.SpanFromContext(ctx)
+	name := e.EventName()
+	span.SetAttributes(attribute.String("event", name))
+
+	body, err := json.Marshal(webhookEvent{ID: env.MessageID, Event: name, CreatedAt: env.CreatedAt, Data: e})
+	if err != nil {
+		s.retryEvent(ctx, queue, msg, "Failed to encode event", err)
+		return
+	}
+	webhooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		s.retryEvent(ctx, queue, msg, "Failed to load webhooks", err)
+		return
+	}
+	for _, w := range webhooks {
+		if !w.Active || !w.subscribes(name) {
+			continue
+		}
+		d := WebhookDelivery{WebhookID: w.WebhookID, EventID: env.MessageID, Event: name, Payload: body, Status: contracts.WebhookDeliveryPending}
+		id, created, err := s.repo.SaveWebhookDelivery(ctx, d)
+		if err != nil {
+			s.retryEvent(ctx, queue, msg, "Failed to store webhook delivery", err)
+			return
+		}
+		if !created {
+			msgLog.InfoContext(ctx, "Webhook delivery already logged, not queueing it again", "event", name, "webhook_id", w.WebhookID, "delivery_id", id)
+			continue
+		}
+		if err := s.queueWebhookDelivery(ctx, 0, contracts.WebhookDeliver{DeliveryID: id, Attempt: 1}); err != nil {
+			s.recordWebhookAttempt(ctx, id, contracts.WebhookDeliveryFailed, 0, "not queued: "+err.Error())
+			s.retryEvent(ctx, queue, msg, "Failed to queue webhook delivery", err)
+			return
+		}
+		msgLog.InfoContext(ctx, "Webhook delivery queued", "event", name, "webhook_id", w.WebhookID, "delivery_id", id)
+	}
+	if role, req, ok := alertFor(e); ok {
+		if err := s.alert(ctx, env.MessageID, role, req); err != nil {
+			s.retryEvent(ctx, queue, msg, "Failed to alert "+role, err)
+			return
+		}
+	}
+	msg.Ack()
+	messagesAcked.WithLabelValues(queue).Inc()
+}
+
+// retryEvent requeues an event that could not be handled because of err.
+func (s *Service) retryEvent(ctx context.Context, queue string, msg broker.Delivery, message string, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "event not handled")
+	slog.ErrorContext(ctx, message, "error", err)
+	msg.Nack(true)
+	messagesNacked.WithLabelValues(queue).Inc()
+}
+
+// queueWebhookDelivery publishes req to the webhooks queue, through the
+// delay queue for delay if it is positive.
+func (s *Service) queueWebhookDelivery(ctx context.Context, delay time.Duration, req contracts.WebhookDeliver) error {
+	label, queue := "webhooks", "webhooks"
+	if delay > 0 {
+		label, queue = "webhooks_retry", delayQueue("webhooks", delay)
+	}
+	return s.send(ctx, label, "", queue, "", s.encodings.For("webhooks"), nil, req)
+}
+
+// handleWebhookDeliver makes an attempt of a logged delivery and settles
+// the message. A failed attempt is retried after a delay while attempts
+// are left; after the last one the delivery is failed and counts against
+// its webhook. Deliveries already delivered, such as by a redelivered
+// message, are left alone; deliveries to a disabled webhook are failed
+// without an attempt, and unknown deliveries are rejected to the
+// dead-letter queue.
+func (s *Service) handleWebhookDeliver(ctx context.Context, queue string, msg broker.Delivery, req contracts.WebhookDeliver) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int("delivery_id", req.DeliveryID), attribute.Int("attempt", req.Attempt))
+	ctx = observability.WithLogFields(ctx, slog.Int("delivery_id", req.DeliveryID))
+
+	d, err := s.repo.GetWebhookDelivery(ctx, req.DeliveryID)
+	var w Webhook
+	if err == nil {
+		w, err = s.repo.GetWebhook(ctx, d.WebhookID)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to load webhook delivery")
+		slog.ErrorContext(ctx, "Failed to load webhook delivery", "error", err)
+		notFound := errors.Is(err, ErrWebhookDeliveryNotFound) || errors.Is(err, ErrWebhookNotFound)
+		msg.Nack(!notFound)
+		messagesNacked.WithLabelValues(queue).Inc()
+		if notFound {
+			messagesDeadLettered.WithLabelValues(queue).Inc()
+		}
+		return
+	}
+	ctx = observability.WithLogFields(ctx, slog.Int("webhook_id", w.WebhookID))
+	span.SetAttributes(attribute.Int("webhook_id", w.WebhookID), attribute.String("event", d.Event))
+
+	switch {
+	case d.Status == contracts.WebhookDeliveryDelivered:
+		msgLog.InfoContext(ctx, "Webhook delivery already delivered, dropping it")
+	case !w.Active:
+		slog.WarnContext(ctx, "Webhook is disabled, failing its delivery")
+		webhookDeliveries.WithLabelValues("failed").Inc()
+		s.setWebhookDeliveryStatus(ctx, d.DeliveryID, contracts.WebhookDeliveryFailed)
+	default:
+		s.attemptWebhook(ctx, w, d, req.Attempt)
+	}
+	msg.Ack()
+	messagesAcked.WithLabelValues(queue).Inc()
+}
+
+// attemptWebhook sends d to w and records the outcome.
+func (s *Service) attemptWebhook(ctx context.Context, w Webhook, d WebhookDelivery, attempt int) {
+	status, err := s.postWebhook(ctx, w, d)
+	if err == nil {
+		webhookDeliveries.WithLabelValues("delivered").Inc()
+		msgLog.InfoContext(ctx, "Webhook delivered", "event", d.Event, "attempt", attempt, "response_status", status)
+		s.recordWebhookAttempt(ctx, d.DeliveryID, contracts.WebhookDeliveryDelivered, status, "")
+		if _, err := s.repo.RecordWebhookResult(ctx, w.WebhookID, true, s.webhooks.DisableAfter); err != nil {
+			slog.ErrorContext(ctx, "Failed to record webhook result", "error", err)
+		}
+		return
+	}
+
+	if attempt < s.webhooks.Attempts {
+		delay := backoff(s.webhooks.BaseDelay, s.webhooks.MaxDelay, attempt)
+		retryErr := s.queueWebhookDelivery(ctx, delay, contracts.WebhookDeliver{DeliveryID: d.DeliveryID, Attempt: attempt + 1})
+		if retryErr == nil {
+			webhookDeliveries.WithLabelValues("retried").Inc()
+			slog.WarnContext(ctx, "Webhook delivery failed, retrying later", "attempt", attempt, "delay", delay.String(), "error", err)
+			s.recordWebhookAttempt(ctx, d.DeliveryID, contracts.WebhookDeliveryPending, status, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx, "Failed to schedule webhook retry", "error", retryErr)
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "webhook delivery failed")
+	webhookDeliveries.WithLabelValues("failed").Inc()
+	slog.ErrorContext(ctx, "Failed to deliver webhook", "attempt", attempt, "error", err)
+	s.recordWebhookAttempt(ctx, d.DeliveryID, contracts.WebhookDeliveryFailed, status, err.Error())
+	w, resultErr := s.repo.RecordWebhookResult(ctx, w.WebhookID, false, s.webhooks.DisableAfter)
+	if resultErr != nil {
+		slog.ErrorContext(ctx, "Failed to record webhook result", "error", resultErr)
+		return
+	}
+	if !w.Active {
+		webhooksDisabled.Inc()
+		slog.WarnContext(ctx, "Webhook disabled after failing too often", "failures", w.Failures)
+	}
+}
+
+// postWebhook sends d to w and returns the response status, if there was a
+// response. Any status but 2xx is a failure.
+func (s *Service) postWebhook(ctx context.Context, w Webhook, d WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ecomm-webhooks/1")
+	req.Header.Set("Webhook-Id", d.EventID)
+	req.Header.Set("Webhook-Event", d.Event)
+	req.Header.Set("Webhook-Delivery", strconv.Itoa(d.DeliveryID))
+	req.Header.Set("Webhook-Signature", SignWebhook(w.Secret, time.Now(), d.Payload))
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, providerError("webhook", resp)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	return resp.StatusCode, nil
+}
+
+// recordWebhookAttempt records an attempt of a delivery. A failure is only
+// logged: the attempt it describes has happened either way.
+func (s *Service) recordWebhookAttempt(ctx context.Context, id int, status string, responseStatus int, errMsg string) {
+	if err := s.repo.RecordWebhookAttempt(ctx, id, status, responseStatus, errMsg); err != nil {
+		slog.ErrorContext(ctx, "Failed to record webhook attempt", "status", status, "error", err)
+	}
+}
+
+// setWebhookDeliveryStatus records a delivery's status, logging a failure.
+func (s *Service) setWebhookDeliveryStatus(ctx context.Context, id int, status string) {
+	if err := s.repo.SetWebhookDeliveryStatus(ctx, id, status); err != nil {
+		slog.ErrorContext(ctx, "Failed to record webhook delivery status", "status", status, "error", err)
+	}
+}
+
+// handleWebhookSet creates or replaces a webhook and replies with it, with
+// its secret if the secret was generated or changed. Re-enabling a
+// disabled webhook clears its failures. Invalid webhooks are refused.
+func (s *Service) handleWebhookSet(ctx context.Context, queue string, msg broker.Delivery, req contracts.WebhookSet) {
+	ctx = observability.WithLogFields(ctx, slog.Int("webhook_id", req.WebhookID))
+	if err := s.validateWebhook(ctx, req.URL, req.Events); err != nil {
+		s.answer(ctx, queue, msg, contracts.NotificationError{Code: contracts.NotificationErrInvalid, Message: err.Error()})
+		return
+	}
+	w := Webhook{WebhookID: req.WebhookID, URL: req.URL, Events: req.Events, Secret: req.Secret, Active: req.Active}
+	if req.WebhookID != 0 {
+		stored, err := s.repo.GetWebhook(ctx, req.WebhookID)
+		if err != nil {
+			s.answer(ctx, queue, msg, notificationError(ctx, err))
+			return
+		}
+		if w.Secret == "" {
+			w.Secret = stored.Secret
+		}
+		if stored.Active || !w.Active {
+			w.Failures = stored.Failures
+		}
+	}
+	if w.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			s.answer(ctx, queue, msg, notificationError(ctx, err))
+			return
+		}
+		w.Secret = secret
+	}
+	saved, err := s.repo.SaveWebhook(ctx, w)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	msgLog.InfoContext(ctx, "Webhook saved", "webhook_id", saved.WebhookID, "events", saved.Events, "active", saved.Active)
+	details := saved.details()
+	if req.WebhookID == 0 || req.Secret != "" {
+		details.Secret = saved.Secret
+	}
+	s.answer(ctx, queue, msg, details)
+}
+
+// handleWebhookList replies with every webhook.
+func (s *Service) handleWebhookList(ctx context.Context, queue string, msg broker.Delivery, req contracts.WebhookList) {
+	webhooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	result := contracts.WebhookListResult{Webhooks: make([]contracts.WebhookDetails, len(webhooks))}
+	for i, w := range webhooks {
+		result.Webhooks[i] = w.details()
+	}
+	s.answer(ctx, queue, msg, result)
+}
+
+// handleWebhookDelete deletes a webhook and replies with it.
+func (s *Service) handleWebhookDelete(ctx context.Context, queue string, msg broker.Delivery, req contracts.WebhookDelete) {
+	ctx = observability.WithLogFields(ctx, slog.Int("webhook_id", req.WebhookID))
+	w, err := s.repo.DeleteWebhook(ctx, req.WebhookID)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	msgLog.InfoContext(ctx, "Webhook deleted")
+	s.answer(ctx, queue, msg, w.details())
+}
+
+// handleWebhookDeliveryList replies with a webhook's latest deliveries.
+func (s *Service) handleWebhookDeliveryList(ctx context.Context, queue string, msg broker.Delivery, req contracts.WebhookDeliveryList) {
+	ctx = observability.WithLogFields(ctx, slog.Int("webhook_id", req.WebhookID))
+	if _, err := s.repo.GetWebhook(ctx, req.WebhookID); err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	deliveries, err := s.repo.ListWebhookDeliveries(ctx, req.WebhookID, webhookDeliveryLimit)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	result := contracts.WebhookDeliveryListResult{Deliveries: make([]contracts.WebhookDeliveryDetails, len(deliveries))}
+	for i, d := range deliveries {
+		result.Deliveries[i] = d.details()
+	}
+	s.answer(ctx, queue, msg, result)
+}
+
+// handleWebhookRedeliver queues a logged delivery to be sent again, with a
+// full set of attempts, and replies with it. Deliveries to a disabled
+// webhook are refused.
+func (s *Service) handleWebhookRedeliver(ctx context.Context, queue string, msg broker.Delivery, req contracts.WebhookRedeliver) {
+	ctx = observability.WithLogFields(ctx, slog.Int("webhook_id", req.WebhookID), slog.Int("delivery_id", req.DeliveryID))
+	d, err := s.repo.GetWebhookDelivery(ctx, req.DeliveryID)
+	if err == nil && d.WebhookID != req.WebhookID {
+		err = ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	w, err := s.repo.GetWebhook(ctx, d.WebhookID)
+	if err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	if !w.Active {
+		s.answer(ctx, queue, msg, contracts.NotificationError{Code: contracts.NotificationErrInvalid, Message: "webhook is disabled"})
+		return
+	}
+	if err := s.repo.SetWebhookDeliveryStatus(ctx, d.DeliveryID, contracts.WebhookDeliveryPending); err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	if err := s.queueWebhookDelivery(ctx, 0, contracts.WebhookDeliver{DeliveryID: d.DeliveryID, Attempt: 1}); err != nil {
+		s.answer(ctx, queue, msg, notificationError(ctx, err))
+		return
+	}
+	msgLog.InfoContext(ctx, "Webhook redelivery queued")
+	d.Status, d.UpdatedAt = contracts.WebhookDeliveryPending, time.Now().UTC()
+	s.answer(ctx, queue, msg, d.details())
+}
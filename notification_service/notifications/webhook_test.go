@@ -0,0 +1,414 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+func TestSignAndVerifyWebhook(t *testing.T) {
+	body := []byte(`{"id":"evt-1"}`)
+	signed := time.Unix(1700000000, 0)
+	header := SignWebhook("whsec_test", signed, body)
+	if !strings.HasPrefix(header, "t=1700000000,v1=") {
+		t.Fatalf("signature header = %q", header)
+	}
+
+	if err := VerifyWebhook("whsec_test", header, body, signed.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Errorf("VerifyWebhook of a valid signature: %v", err)
+	}
+	// Receivers rotating secrets accept either of two signatures.
+	rotated := header + ",v1=" + strings.Repeat("0", 64)
+	if err := VerifyWebhook("whsec_test", rotated, body, signed, time.Minute); err != nil {
+		t.Errorf("VerifyWebhook with a second signature: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   string
+		now    time.Time
+	}{
+		{"wrong secret", "whsec_other", header, string(body), signed},
+		{"tampered body", "whsec_test", header, `{"id":"evt-2"}`, signed},
+		{"too old", "whsec_test", header, string(body), signed.Add(10 * time.Minute)},
+		{"no signature", "whsec_test", "t=1700000000", string(body), signed},
+		{"malformed", "whsec_test", "garbage", string(body), signed},
+	}
+	for _, tt := range tests {
+		if err := VerifyWebhook(tt.secret, tt.header, []byte(tt.body), tt.now, 5*time.Minute); !errors.Is(err, ErrWebhookSignature) {
+			t.Errorf("%s: VerifyWebhook = %v, want ErrWebhookSignature", tt.name, err)
+		}
+	}
+}
+
+func TestLoadWebhookConfig(t *testing.T) {
+	t.Setenv("WEBHOOK_ATTEMPTS", "3")
+	t.Setenv("WEBHOOK_RETRY_DELAY", "10s")
+	t.Setenv("WEBHOOK_DISABLE_AFTER", "many")
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE", "true")
+	want := WebhookConfig{Attempts: 3, BaseDelay: 10 * time.Second, MaxDelay: time.Hour, DisableAfter: 5, Timeout: 10 * time.Second, AllowPrivate: true}
+	if cfg := LoadWebhookConfig(); cfg != want {
+		t.Fatalf("LoadWebhookConfig() = %+v, want %+v", cfg, want)
+	}
+}
+
+// fakeLookupIP resolves IP addresses to themselves and a few names as a
+// resolver inside a container network would.
+func fakeLookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	hosts := map[string]string{
+		"partner.example":   "203.0.113.10",
+		"localhost":         "127.0.0.1",
+		"inventory_service": "172.18.0.4",
+	}
+	if addr, ok := hosts[host]; ok {
+		return []net.IP{net.ParseIP(addr)}, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestWebhookRequests(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBroker(t)
+	repo := NewMemoryNotifications()
+	svc := NewService(repo, b)
+	svc.lookupIP = fakeLookupIP
+	replyQueue, _ := b.DeclareQueue("", broker.QueueOptions{})
+
+	request := func(p contracts.Payload) contracts.Payload {
+		t.Helper()
+		msg := deliver(t, b, "notification_requests", broker.Message{ReplyTo: replyQueue, Body: envelope(t, p)})
+		svc.dispatch("notification_requests", msg)
+		replies := b.Pending(replyQueue)
+		if len(replies) == 0 {
+			t.Fatal("no reply")
+		}
+		_, payload, err := contracts.Decode(replies[len(replies)-1].ContentType, replies[len(replies)-1].Body)
+		if err != nil {
+			t.Fatalf("invalid reply: %v", err)
+		}
+		return payload
+	}
+	errorCode := func(p contracts.Payload) string {
+		e, _ := p.(contracts.NotificationError)
+		return e.Code
+	}
+
+	for _, invalid := range []contracts.WebhookSet{
+		{URL: "ftp://partner.example", Events: []string{contracts.EventOrderPlaced}, Active: true},
+		{URL: "https://", Events: []string{contracts.EventOrderPlaced}, Active: true},
+		{URL: "https://partner.example", Active: true},
+		{URL: "https://partner.example", Events: []string{"order.shipped"}, Active: true},
+		{URL: "http://localhost:8080/hooks", Events: []string{contracts.EventOrderPlaced}, Active: true},
+		{URL: "http://169.254.169.254/latest/meta-data", Events: []string{contracts.EventOrderPlaced}, Active: true},
+		{URL: "http://10.1.2.3/hooks", Events: []string{contracts.EventOrderPlaced}, Active: true},
+		{URL: "http://[::1]/hooks", Events: []string{contracts.EventOrderPlaced}, Active: true},
+		{URL: "http://0.0.0.0/hooks", Events: []string{contracts.EventOrderPlaced}, Active: true},
+		{URL: "http://inventory_service:8080/hooks", Events: []string{contracts.EventOrderPlaced}, Active: true},
+		{URL: "https://nowhere.example/hooks", Events: []string{contracts.EventOrderPlaced}, Active: true},
+	} {
+		if got := request(invalid); errorCode(got) != contracts.NotificationErrInvalid {
+			t.Errorf("set %+v reply = %+v, want %s", invalid, got, contracts.NotificationErrInvalid)
+		}
+	}
+
+	created, ok := request(contracts.WebhookSet{URL: "https://partner.example/hooks", Events: []string{contracts.EventOrderPlaced}, Active: true}).(contracts.WebhookDetails)
+	if !ok || created.WebhookID == 0 || !strings.HasPrefix(created.Secret, "whsec_") || !created.Active {
+		t.Fatalf("create reply = %+v, want an active webhook with a generated secret", created)
+	}
+
+	update := contracts.WebhookSet{WebhookID: created.WebhookID, URL: "https://partner.example/v2", Events: []string{contracts.EventOrderPlaced, contracts.EventStockLow}, Active: true}
+	updated, _ := request(update).(contracts.WebhookDetails)
+	if updated.URL != update.URL || !reflect.DeepEqual(updated.Events, update.Events) || updated.Secret != "" {
+		t.Errorf("update reply = %+v, want the new URL and events without the secret", updated)
+	}
+	if w, _ := repo.GetWebhook(ctx, created.WebhookID); w.Secret != created.Secret {
+		t.Errorf("secret after update = %q, want it kept", w.Secret)
+	}
+	if got := request(contracts.WebhookSet{WebhookID: 99, URL: "https://partner.example", Events: []string{contracts.EventStockLow}}); errorCode(got) != contracts.NotificationErrNotFound {
+		t.Errorf("update of an unknown webhook = %+v, want %s", got, contracts.NotificationErrNotFound)
+	}
+
+	// A disabled webhook comes back with a clean slate.
+	repo.RecordWebhookResult(ctx, created.WebhookID, false, 1)
+	update.Secret = "whsec_rotated"
+	reenabled, _ := request(update).(contracts.WebhookDetails)
+	if !reenabled.Active || reenabled.Failures != 0 || reenabled.Secret != "whsec_rotated" {
+		t.Errorf("re-enable reply = %+v, want active without failures and the new secret", reenabled)
+	}
+
+	list, _ := request(contracts.WebhookList{}).(contracts.WebhookListResult)
+	if len(list.Webhooks) != 1 || list.Webhooks[0].WebhookID != created.WebhookID || list.Webhooks[0].Secret != "" {
+		t.Errorf("list reply = %+v, want the webhook without its secret", list)
+	}
+
+	id, _, _ := repo.SaveWebhookDelivery(ctx, WebhookDelivery{WebhookID: created.WebhookID, EventID: "evt-1", Event: contracts.EventOrderPlaced, Payload: []byte("{}"), Status: contracts.WebhookDeliveryFailed})
+	deliveries, _ := request(contracts.WebhookDeliveryList{WebhookID: created.WebhookID}).(contracts.WebhookDeliveryListResult)
+	if len(deliveries.Deliveries) != 1 || deliveries.Deliveries[0].DeliveryID != id {
+		t.Errorf("delivery list reply = %+v, want delivery %d", deliveries, id)
+	}
+	if got := request(contracts.WebhookDeliveryList{WebhookID: 99}); errorCode(got) != contracts.NotificationErrNotFound {
+		t.Errorf("delivery list of an unknown webhook = %+v, want %s", got, contracts.NotificationErrNotFound)
+	}
+
+	redelivered, _ := request(contracts.WebhookRedeliver{WebhookID: created.WebhookID, DeliveryID: id}).(contracts.WebhookDeliveryDetails)
+	if redelivered.DeliveryID != id || redelivered.Status != contracts.WebhookDeliveryPending {
+		t.Errorf("redeliver reply = %+v, want delivery %d pending", redelivered, id)
+	}
+	queued := b.Pending("webhooks")
+	if len(queued) != 1 {
+		t.Fatalf("webhooks queue holds %d messages, want the redelivery", len(queued))
+	}
+	if _, p, _ := contracts.Decode(queued[0].ContentType, queued[0].Body); p != (contracts.WebhookDeliver{DeliveryID: id, Attempt: 1}) {
+		t.Errorf("queued %+v, want a first attempt of delivery %d", p, id)
+	}
+	if got := request(contracts.WebhookRedeliver{WebhookID: created.WebhookID + 1, DeliveryID: id}); errorCode(got) != contracts.NotificationErrNotFound {
+		t.Errorf("redeliver through another webhook = %+v, want %s", got, contracts.NotificationErrNotFound)
+	}
+	repo.RecordWebhookResult(ctx, created.WebhookID, false, 1)
+	if got := request(contracts.WebhookRedeliver{WebhookID: created.WebhookID, DeliveryID: id}); errorCode(got) != contracts.NotificationErrInvalid {
+		t.Errorf("redeliver to a disabled webhook = %+v, want %s", got, contracts.NotificationErrInvalid)
+	}
+
+	if deleted, _ := request(contracts.WebhookDelete{WebhookID: created.WebhookID}).(contracts.WebhookDetails); deleted.WebhookID != created.WebhookID {
+		t.Errorf("delete reply = %+v, want webhook %d", deleted, created.WebhookID)
+	}
+	if got := request(contracts.WebhookDelete{WebhookID: created.WebhookID}); errorCode(got) != contracts.NotificationErrNotFound {
+		t.Errorf("second delete reply = %+v, want %s", got, contracts.NotificationErrNotFound)
+	}
+}
+
+// webhookReceiver is a partner's webhook endpoint that fails the first
+// failures requests.
+type webhookReceiver struct {
+	secret   string
+	failures int
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	if len(r.requests) <= r.failures {
+		http.Error(w, "try again", http.StatusServiceUnavailable)
+		return
+	}
+	if err := VerifyWebhook(r.secret, req.Header.Get("Webhook-Signature"), body, time.Now(), time.Minute); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		disableAfter int
+		wantStatus   string
+		wantAttempts int
+		wantActive   bool
+	}{
+		{"delivered", 0, 1, contracts.WebhookDeliveryDelivered, 1, true},
+		{"delivered on retry", 2, 1, contracts.WebhookDeliveryDelivered, 3, true},
+		{"attempts exhausted", 3, 2, contracts.WebhookDeliveryFailed, 3, true},
+		{"disabled after failing", 3, 1, contracts.WebhookDeliveryFailed, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			receiver := &webhookReceiver{secret: "whsec_test", failures: tt.failures}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			b := broker.NewMemory()
+			repo := NewMemoryNotifications()
+			w, _ := repo.SaveWebhook(ctx, Webhook{URL: server.URL, Events: []string{contracts.EventOrderPlaced}, Secret: "whsec_test", Active: true})
+			repo.SaveWebhook(ctx, Webhook{URL: server.URL, Events: []string{contracts.EventStockLow}, Secret: "whsec_test", Active: true})
+			svc := NewService(repo, b)
+			svc.SetWebhooks(WebhookConfig{Attempts: 3, BaseDelay: 5 * time.Millisecond, MaxDelay: 20 * time.Millisecond, DisableAfter: tt.disableAfter, Timeout: time.Second, AllowPrivate: true})
+			if err := svc.Start(ctx); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+
+			event := contracts.OrderStatusChanged{OrderID: 42, UserID: 7, ProductID: 3, Quantity: 1, Status: contracts.OrderStatusPlaced}
+			env, body, _ := contracts.Encode(contracts.JSON, "test", event)
+			if err := b.Publish(ctx, contracts.EventsExchange, event.EventName(), broker.Message{Body: body}); err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+
+			var d WebhookDelivery
+			for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+				deliveries, _ := repo.ListWebhookDeliveries(ctx, w.WebhookID, 10)
+				if len(deliveries) == 1 {
+					d = deliveries[0]
+					if d.Status == tt.wantStatus && d.Attempts == tt.wantAttempts {
+						break
+					}
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("delivery = %+v, want %s after %d attempts", d, tt.wantStatus, tt.wantAttempts)
+				}
+			}
+			if d.EventID != env.MessageID || d.Event != contracts.EventOrderPlaced {
+				t.Errorf("delivery = %+v, want event %s", d, env.MessageID)
+			}
+			if w, _ := repo.GetWebhook(ctx, w.WebhookID); w.Active != tt.wantActive {
+				t.Errorf("webhook active = %v, want %v", w.Active, tt.wantActive)
+			}
+
+			receiver.mu.Lock()
+			defer receiver.mu.Unlock()
+			if len(receiver.requests) != tt.wantAttempts {
+				t.Fatalf("receiver got %d requests, want %d: only the subscribed webhook is called", len(receiver.requests), tt.wantAttempts)
+			}
+			req := receiver.requests[len(receiver.requests)-1]
+			if req.Header.Get("Webhook-Event") != contracts.EventOrderPlaced || req.Header.Get("Webhook-Id") != env.MessageID {
+				t.Errorf("request headers = %v", req.Header)
+			}
+			var got struct {
+				ID    string                       `json:"id"`
+				Event string                       `json:"event"`
+				Data  contracts.OrderStatusChanged `json:"data"`
+			}
+			if err := json.Unmarshal(receiver.bodies[len(receiver.bodies)-1], &got); err != nil || got.ID != env.MessageID || got.Event != contracts.EventOrderPlaced || got.Data != event {
+				t.Errorf("request body = %+v, %v; want the event", got, err)
+			}
+		})
+	}
+}
+
+func TestHandleWebhookDeliver(t *testing.T) {
+	ctx := context.Background()
+	receiver := &webhookReceiver{secret: "whsec_test"}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	b := newTestBroker(t)
+	repo := NewMemoryNotifications()
+	svc := NewService(repo, b)
+
+	w, _ := repo.SaveWebhook(ctx, Webhook{URL: server.URL, Events: []string{contracts.EventStockLow}, Secret: "whsec_test", Active: true})
+	disabled, _ := repo.SaveWebhook(ctx, Webhook{URL: server.URL, Events: []string{contracts.EventStockLow}, Secret: "whsec_test"})
+	delivered, _, _ := repo.SaveWebhookDelivery(ctx, WebhookDelivery{WebhookID: w.WebhookID, EventID: "evt-1", Event: contracts.EventStockLow, Payload: []byte("{}")})
+	repo.RecordWebhookAttempt(ctx, delivered, contracts.WebhookDeliveryDelivered, 200, "")
+	toDisabled, _, _ := repo.SaveWebhookDelivery(ctx, WebhookDelivery{WebhookID: disabled.WebhookID, EventID: "evt-1", Event: contracts.EventStockLow, Payload: []byte("{}")})
+
+	for _, id := range []int{delivered, toDisabled, 99} {
+		msg := deliver(t, b, "webhooks", broker.Message{Body: envelope(t, contracts.WebhookDeliver{DeliveryID: id, Attempt: 1})})
+		svc.dispatch("webhooks", msg)
+		if err := msg.Ack(); err == nil {
+			t.Fatalf("delivery %d was not settled by the handler", id)
+		}
+	}
+
+	if len(receiver.requests) != 0 {
+		t.Errorf("receiver got %d requests, want none", len(receiver.requests))
+	}
+	if d, _ := repo.GetWebhookDelivery(ctx, toDisabled); d.Status != contracts.WebhookDeliveryFailed || d.Attempts != 0 {
+		t.Errorf("delivery to a disabled webhook = %+v, want failed without an attempt", d)
+	}
+	if dead := b.Pending(broker.DeadLetterQueue("webhooks")); len(dead) != 1 {
+		t.Errorf("dead-letter queue holds %d messages, want the unknown delivery", len(dead))
+	}
+}
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"64:ff9b::5db8:d822", true}, // NAT64 form of 93.184.216.34
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"0.1.2.3", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"224.0.0.1", false},
+		{"239.255.255.250", false},
+		{"ff01::1", false}, // interface-local multicast
+		{"ff02::1", false},
+		{"ff0e::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"64:ff9b::7f00:1", false},    // NAT64 form of 127.0.0.1
+		{"64:ff9b::a00:1", false},     // NAT64 form of 10.0.0.1
+		{"64:ff9b::a9fe:a9fe", false}, // NAT64 form of 169.254.169.254
+		{"64:ff9b:1::5db8:d822", false},
+	}
+	for _, tt := range tests {
+		if got := publicAddress(net.ParseIP(tt.addr)); got != tt.public {
+			t.Errorf("publicAddress(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	receiver := &webhookReceiver{secret: "whsec_test"}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	// However the host resolved when the webhook was registered, the
+	// address dialed is checked.
+	_, err := newWebhookClient(time.Second, false).Post(server.URL, "application/json", strings.NewReader("{}"))
+	if !errors.Is(err, errPrivateAddress) {
+		t.Errorf("POST to %s = %v, want errPrivateAddress", server.URL, err)
+	}
+	if len(receiver.requests) != 0 {
+		t.Errorf("receiver got %d requests, want none", len(receiver.requests))
+	}
+	resp, err := newWebhookClient(time.Second, true).Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("POST with private addresses allowed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestRedeliveredEventIsNotSentAgain(t *testing.T) {
+	ctx := context.Background()
+	b := newTestBroker(t)
+	repo := NewMemoryNotifications()
+	repo.SaveWebhook(ctx, Webhook{URL: "https://partner.example/hooks", Events: []string{contracts.EventStockLow}, Secret: "whsec_test", Active: true})
+	svc := NewService(repo, b)
+
+	body := envelope(t, contracts.StockLow{ProductID: 101, Stock: 3, Threshold: 10})
+	for i := 0; i < 2; i++ {
+		svc.dispatch("webhook_events", deliver(t, b, "webhook_events", broker.Message{Body: body}))
+	}
+	if queued := b.Pending("webhooks"); len(queued) != 1 {
+		t.Errorf("webhooks queue holds %d messages, want the first delivery only", len(queued))
+	}
+}
@@ -0,0 +1,163 @@
+// Package observability sets up the structured logs and traces every
+// service writes, tagged with the service's name.
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redactedKeys are attribute keys whose values may hold personal data and
+// are never written to the log.
+var redactedKeys = map[string]bool{
+	"message":  true,
+	"body":     true,
+	"content":  true,
+	"email":    true,
+	"phone":    true,
+	"password": true,
+	"token":    true,
+}
+
+// SetupLogging installs a JSON slog logger as the default logger, including
+// for the standard log package. LOG_LEVEL selects debug, info, warn or
+// error. Every record carries the service name and instance. It returns
+// the logger for per-message logs, which are sampled according to
+// LOG_SAMPLE_EVERY.
+func SetupLogging(service string) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(getEnv("LOG_LEVEL", "info"))); err != nil {
+		level = slog.LevelInfo
+	}
+
+	instance := os.Getenv("INSTANCE_ID")
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	})
+	logger := slog.New(contextHandler{handler}).With(
+		slog.String("service", service),
+		slog.String("instance", instance),
+	)
+	slog.SetDefault(logger)
+	return messageLogger()
+}
+
+// getEnv returns the value of the environment variable key, or fallback when
+// it is unset or empty.
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if redactedKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, "[REDACTED]")
+	}
+	return a
+}
+
+type logFieldsKey struct{}
+
+// WithLogFields returns a context whose log records include attrs, in
+// addition to any fields already attached to ctx.
+func WithLogFields(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(logFieldsKey{}).([]slog.Attr)
+	fields := make([]slog.Attr, 0, len(existing)+len(attrs))
+	fields = append(fields, existing...)
+	fields = append(fields, attrs...)
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// contextHandler adds the trace ID and any fields attached with
+// WithLogFields to records logged with a context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	if fields, ok := ctx.Value(logFieldsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(fields...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// samplingHandler passes through one in every n records below warning level
+// for each distinct message, so per-message logs stay affordable under
+// load. Warnings and errors are never dropped.
+type samplingHandler struct {
+	slog.Handler
+	n      uint64
+	mu     *sync.Mutex
+	counts map[string]uint64
+}
+
+// messageLogger returns the logger used for per-message logs, sampled
+// according to LOG_SAMPLE_EVERY.
+func messageLogger() *slog.Logger {
+	n, err := strconv.ParseUint(getEnv("LOG_SAMPLE_EVERY", "10"), 10, 64)
+	if err != nil || n == 0 {
+		n = 1
+	}
+	return slog.New(samplingHandler{
+		Handler: slog.Default().Handler(),
+		n:       n,
+		mu:      &sync.Mutex{},
+		counts:  map[string]uint64{},
+	})
+}
+
+func (h samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn && h.n > 1 {
+		h.mu.Lock()
+		count := h.counts[r.Message]
+		h.counts[r.Message] = count + 1
+		h.mu.Unlock()
+		if count%h.n != 0 {
+			return nil
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h.Handler = h.Handler.WithAttrs(attrs)
+	return h
+}
+
+func (h samplingHandler) WithGroup(name string) slog.Handler {
+	h.Handler = h.Handler.WithGroup(name)
+	return h
+}
+
+// Fatal logs an error and exits, replacing log.Fatalf.
+func Fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,74 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+func TestContextHandlerAddsFieldsAndRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(contextHandler{slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redact})})
+
+	ctx := WithLogFields(context.Background(), slog.Int("order_id", 42))
+	ctx = WithLogFields(ctx, slog.Int("user_id", 7))
+	logger.InfoContext(ctx, "Order placed", "email", "ada@example.com")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log line %q: %v", buf.String(), err)
+	}
+	if record["order_id"] != 42.0 || record["user_id"] != 7.0 {
+		t.Errorf("record = %v, want the context's fields", record)
+	}
+	if record["email"] != "[REDACTED]" {
+		t.Errorf("email = %v, want it redacted", record["email"])
+	}
+}
+
+func TestRedactHidesPersonalData(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redact}))
+
+	logger.Info("Notification sent",
+		"content", "Your order for Ada Lovelace has shipped",
+		"Message", "Hello Ada",
+		"phone", "+15550100",
+		"notification_id", "n-1")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log line %q: %v", buf.String(), err)
+	}
+	for _, key := range []string{"content", "Message", "phone"} {
+		if record[key] != "[REDACTED]" {
+			t.Errorf("%s = %v, want it redacted", key, record[key])
+		}
+	}
+	if record["notification_id"] != "n-1" {
+		t.Errorf("notification_id = %v, want it kept", record["notification_id"])
+	}
+}
+
+func TestSamplingHandlerKeepsWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(samplingHandler{
+		Handler: slog.NewJSONHandler(&buf, nil),
+		n:       3,
+		mu:      &sync.Mutex{},
+		counts:  map[string]uint64{},
+	})
+	for i := 0; i < 6; i++ {
+		logger.Info("Stock checked")
+		logger.Warn("Stock is low")
+	}
+	if lines := bytes.Count(buf.Bytes(), []byte("Stock checked")); lines != 2 {
+		t.Errorf("%d info lines logged, want 1 in 3", lines)
+	}
+	if lines := bytes.Count(buf.Bytes(), []byte("Stock is low")); lines != 6 {
+		t.Errorf("%d warnings logged, want all 6", lines)
+	}
+}
@@ -0,0 +1,95 @@
+package observability
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"ecomm-sample/broker"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SetupTracing installs the global tracer provider, whose spans name
+// service as their origin, and W3C propagators.
+// Spans go to an OTLP collector over HTTP when OTEL_EXPORTER_OTLP_ENDPOINT
+// is set, and are otherwise written as JSON to OTEL_TRACES_FILE, or stdout
+// when that is unset too. The returned function flushes pending spans.
+func SetupTracing(service string) func(context.Context) error {
+	ctx := context.Background()
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" {
+		exporter, err = otlptracehttp.New(ctx)
+	} else {
+		var w io.Writer = os.Stdout
+		if path := os.Getenv("OTEL_TRACES_FILE"); path != "" {
+			w, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				Fatal("Failed to open trace file", err)
+			}
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	}
+	if err != nil {
+		Fatal("Failed to create trace exporter", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(service),
+	))
+	if err != nil {
+		Fatal("Failed to create trace resource", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	return provider.Shutdown
+}
+
+// StartPublishSpan starts a producer span from tracer for a message sent to
+// queue and returns headers carrying its context.
+func StartPublishSpan(ctx context.Context, tracer trace.Tracer, queue string) (context.Context, trace.Span, map[string]interface{}) {
+	ctx, span := tracer.Start(ctx, queue+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingOperationPublish,
+			semconv.MessagingDestinationName(queue),
+		),
+	)
+	headers := map[string]interface{}{}
+	otel.GetTextMapPropagator().Inject(ctx, broker.HeaderCarrier(headers))
+	return ctx, span, headers
+}
+
+// StartConsumeSpan starts a consumer span from tracer for msg, continuing
+// the trace whose context the publisher put in the message headers.
+func StartConsumeSpan(tracer trace.Tracer, msg broker.Delivery, queue string) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), broker.HeaderCarrier(msg.Headers))
+	return tracer.Start(ctx, queue+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingOperationDeliver,
+			semconv.MessagingDestinationName(queue),
+			semconv.MessagingMessageConversationID(msg.CorrelationID),
+		),
+	)
+}
@@ -1,7 +0,0 @@
-module order_service
-
-go 1.20
-
-require github.com/rabbitmq/amqp091-go v1.10.0
-
-require github.com/lib/pq v1.10.9 // indirect
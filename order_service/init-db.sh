@@ -10,15 +10,12 @@ until pg_isready -h localhost -p 5432 -U postgres; do
 done
 echo "PostgreSQL is ready!"
 
-# Create user, database, and tables
+# Create user and database
 su - postgres -c "psql -c \"CREATE USER order_user WITH PASSWORD 'password';\""
 su - postgres -c "psql -c \"CREATE DATABASE order_db OWNER order_user;\""
 su - postgres -c "psql -c \"GRANT ALL PRIVILEGES ON DATABASE order_db TO order_user;\""
-su - postgres -c "psql order_db -c \"CREATE TABLE orders (
-    order_id SERIAL PRIMARY KEY,
-    customer_id INT NOT NULL,
-    stock INT NOT NULL,
-    name VARCHAR(100) NOT NULL
-);\""
+
+# Create the tables from the service's embedded migrations
+/root/main migrate up
 
 service postgresql restart
\ No newline at end of file
@@ -0,0 +1,31 @@
+package orders
+
+import (
+	"database/sql"
+	"embed"
+	"io/fs"
+
+	"ecomm-sample/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrations are the order database's schema changes, embedded in the
+// binary so every build carries the schema it expects.
+var migrations = func() []migrate.Migration {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		panic(err)
+	}
+	return migrate.MustLoad(files)
+}()
+
+// SchemaVersion is the schema version this build expects the database to be
+// at.
+var SchemaVersion = migrate.Latest(migrations)
+
+// NewMigrator returns a migrator for the order database schema.
+func NewMigrator(db *sql.DB) *migrate.Migrator {
+	return migrate.New(db, "order_service", migrations)
+}
@@ -0,0 +1,9 @@
+-- IF NOT EXISTS adopts databases created by init-db.sh before migrations.
+CREATE TABLE IF NOT EXISTS orders (
+    order_id INT PRIMARY KEY,
+    product_id INT NOT NULL,
+    user_id INT NOT NULL,
+    quantity INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS orders_user_id_idx ON orders (user_id);
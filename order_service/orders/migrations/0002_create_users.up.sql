@@ -0,0 +1,5 @@
+CREATE TABLE users (
+    user_id INT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    email VARCHAR(255) NOT NULL UNIQUE
+);
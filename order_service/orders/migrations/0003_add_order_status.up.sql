@@ -0,0 +1 @@
+ALTER TABLE orders ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'placed';
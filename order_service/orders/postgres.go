@@ -0,0 +1,155 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"ecomm-sample/contracts"
+	"ecomm-sample/migrate"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// uniqueViolation is the PostgreSQL error code for a duplicate key.
+const uniqueViolation = "23505"
+
+// PostgresOrders is the OrderRepository backed by order_db.
+type PostgresOrders struct {
+	db *sql.DB
+}
+
+// NewPostgresOrders returns a repository over the orders and users tables
+// of db.
+func NewPostgresOrders(db *sql.DB) *PostgresOrders {
+	return &PostgresOrders{db: db}
+}
+
+// startDBSpan starts a client span for a single query.
+func startDBSpan(ctx context.Context, name, query string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append([]attribute.KeyValue{
+		semconv.DBSystemPostgreSQL,
+		semconv.DBName("order_db"),
+		semconv.DBStatement(query),
+	}, attrs...)
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endDBSpan records err, if any, on span and ends it.
+func endDBSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, ErrOrderNotFound) && !errors.Is(err, ErrOrderCancelled) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "query failed")
+	}
+	span.End()
+}
+
+func (p *PostgresOrders) CreateOrder(ctx context.Context, o Order) (err error) {
+	const query = "INSERT INTO orders (order_id, product_id, user_id, quantity, status) VALUES ($1, $2, $3, $4, $5)"
+	ctx, span := startDBSpan(ctx, "OrderRepository.CreateOrder", query, attribute.Int("order_id", o.OrderID))
+	defer func() { endDBSpan(span, err) }()
+
+	if o.Status == "" {
+		o.Status = contracts.OrderStatusPlaced
+	}
+	_, err = p.db.ExecContext(ctx, query, o.OrderID, o.ProductID, o.UserID, o.Quantity, o.Status)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return ErrOrderExists
+	}
+	return err
+}
+
+func (p *PostgresOrders) GetOrder(ctx context.Context, orderID int) (o Order, err error) {
+	const query = "SELECT order_id, product_id, user_id, quantity, status FROM orders WHERE order_id = $1"
+	ctx, span := startDBSpan(ctx, "OrderRepository.GetOrder", query, attribute.Int("order_id", orderID))
+	defer func() { endDBSpan(span, err) }()
+
+	err = p.db.QueryRowContext(ctx, query, orderID).Scan(&o.OrderID, &o.ProductID, &o.UserID, &o.Quantity, &o.Status)
+	if err == sql.ErrNoRows {
+		return Order{}, ErrOrderNotFound
+	}
+	return o, err
+}
+
+func (p *PostgresOrders) ListOrdersByUser(ctx context.Context, userID int) (orders []Order, err error) {
+	const query = "SELECT order_id, product_id, user_id, quantity, status FROM orders WHERE user_id = $1 ORDER BY order_id"
+	ctx, span := startDBSpan(ctx, "OrderRepository.ListOrdersByUser", query, attribute.Int("user_id", userID))
+	defer func() { endDBSpan(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	orders = []Order{}
+	for rows.Next() {
+		var o Order
+		if err = rows.Scan(&o.OrderID, &o.ProductID, &o.UserID, &o.Quantity, &o.Status); err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+func (p *PostgresOrders) CancelOrder(ctx context.Context, orderID int) (o Order, err error) {
+	const query = `UPDATE orders SET status = $2 WHERE order_id = $1 AND status <> $2
+		RETURNING order_id, product_id, user_id, quantity, status`
+	ctx, span := startDBSpan(ctx, "OrderRepository.CancelOrder", query, attribute.Int("order_id", orderID))
+	defer func() { endDBSpan(span, err) }()
+
+	err = p.db.QueryRowContext(ctx, query, orderID, contracts.OrderStatusCancelled).
+		Scan(&o.OrderID, &o.ProductID, &o.UserID, &o.Quantity, &o.Status)
+	if err != sql.ErrNoRows {
+		return o, err
+	}
+	// Nothing was updated: the order is either missing or already cancelled.
+	if _, err = p.GetOrder(ctx, orderID); err != nil {
+		return Order{}, err
+	}
+	return Order{}, ErrOrderCancelled
+}
+
+func (p *PostgresOrders) SaveUser(ctx context.Context, u User) (err error) {
+	const query = `INSERT INTO users (user_id, name, email) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email`
+	ctx, span := startDBSpan(ctx, "OrderRepository.SaveUser", query, attribute.Int("user_id", u.UserID))
+	defer func() { endDBSpan(span, err) }()
+
+	_, err = p.db.ExecContext(ctx, query, u.UserID, u.Name, u.Email)
+	return err
+}
+
+func (p *PostgresOrders) ListUsers(ctx context.Context) (users []User, err error) {
+	const query = "SELECT user_id, name, email FROM users ORDER BY user_id"
+	ctx, span := startDBSpan(ctx, "OrderRepository.ListUsers", query)
+	defer func() { endDBSpan(span, err) }()
+
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	users = []User{}
+	for rows.Next() {
+		var u User
+		if err = rows.Scan(&u.UserID, &u.Name, &u.Email); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (p *PostgresOrders) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+func (p *PostgresOrders) SchemaVersion(ctx context.Context) (int, error) {
+	return migrate.CurrentVersion(ctx, p.db)
+}
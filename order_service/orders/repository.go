@@ -0,0 +1,141 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"ecomm-sample/contracts"
+)
+
+var (
+	// ErrOrderNotFound is returned when no order has the requested ID.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrOrderExists is returned when an order with the same ID is already
+	// stored, typically because place_order was redelivered.
+	ErrOrderExists = errors.New("order already exists")
+	// ErrOrderCancelled is returned when cancelling an order that is
+	// already cancelled.
+	ErrOrderCancelled = errors.New("order already cancelled")
+)
+
+// User is a customer who can place orders.
+type User struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+}
+
+// OrderRepository stores placed orders and the users who place them.
+type OrderRepository interface {
+	// CreateOrder stores o, or returns ErrOrderExists. An order without a
+	// status is stored as placed.
+	CreateOrder(ctx context.Context, o Order) error
+	// GetOrder returns the order with orderID, or ErrOrderNotFound.
+	GetOrder(ctx context.Context, orderID int) (Order, error)
+	// ListOrdersByUser returns the user's orders ordered by ID.
+	ListOrdersByUser(ctx context.Context, userID int) ([]Order, error)
+	// CancelOrder marks a placed order as cancelled and returns it, or
+	// returns ErrOrderNotFound or ErrOrderCancelled.
+	CancelOrder(ctx context.Context, orderID int) (Order, error)
+	// SaveUser creates the user or replaces their name and email.
+	SaveUser(ctx context.Context, u User) error
+	// ListUsers returns every user ordered by ID.
+	ListUsers(ctx context.Context) ([]User, error)
+	// Ping reports whether the underlying store is reachable.
+	Ping(ctx context.Context) error
+	// SchemaVersion returns the version of the store's schema.
+	SchemaVersion(ctx context.Context) (int, error)
+}
+
+// MemoryOrders is an OrderRepository held in a map, for tests.
+type MemoryOrders struct {
+	mu     sync.Mutex
+	orders map[int]Order
+	users  map[int]User
+}
+
+// NewMemoryOrders returns an empty in-memory repository.
+func NewMemoryOrders() *MemoryOrders {
+	return &MemoryOrders{orders: make(map[int]Order), users: make(map[int]User)}
+}
+
+func (m *MemoryOrders) CreateOrder(ctx context.Context, o Order) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.orders[o.OrderID]; ok {
+		return ErrOrderExists
+	}
+	if o.Status == "" {
+		o.Status = contracts.OrderStatusPlaced
+	}
+	m.orders[o.OrderID] = o
+	return nil
+}
+
+func (m *MemoryOrders) GetOrder(ctx context.Context, orderID int) (Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[orderID]
+	if !ok {
+		return Order{}, ErrOrderNotFound
+	}
+	return o, nil
+}
+
+func (m *MemoryOrders) ListOrdersByUser(ctx context.Context, userID int) ([]Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	orders := []Order{}
+	for _, o := range m.orders {
+		if o.UserID == userID {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].OrderID < orders[j].OrderID })
+	return orders, nil
+}
+
+func (m *MemoryOrders) CancelOrder(ctx context.Context, orderID int) (Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o, ok := m.orders[orderID]
+	if !ok {
+		return Order{}, ErrOrderNotFound
+	}
+	if o.Status == contracts.OrderStatusCancelled {
+		return Order{}, ErrOrderCancelled
+	}
+	o.Status = contracts.OrderStatusCancelled
+	m.orders[orderID] = o
+	return o, nil
+}
+
+func (m *MemoryOrders) SaveUser(ctx context.Context, u User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users[u.UserID] = u
+	return nil
+}
+
+func (m *MemoryOrders) ListUsers(ctx context.Context) ([]User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	users := make([]User, 0, len(m.users))
+	for _, u := range m.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+	return users, nil
+}
+
+func (m *MemoryOrders) Ping(ctx context.Context) error {
+	return nil
+}
+
+// SchemaVersion reports the latest version: a memory store has no schema to
+// fall behind.
+func (m *MemoryOrders) SchemaVersion(ctx context.Context) (int, error) {
+	return SchemaVersion, nil
+}
@@ -0,0 +1,188 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+
+	"ecomm-sample/contracts"
+)
+
+// testOrderRepository is the contract every OrderRepository must meet.
+// newRepo returns an empty repository.
+func testOrderRepository(t *testing.T, newRepo func(t *testing.T) OrderRepository) {
+	ctx := context.Background()
+
+	t.Run("unknown order", func(t *testing.T) {
+		if _, err := newRepo(t).GetOrder(ctx, 404); !errors.Is(err, ErrOrderNotFound) {
+			t.Fatalf("GetOrder error = %v, want ErrOrderNotFound", err)
+		}
+	})
+
+	t.Run("create and read back", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.CreateOrder(ctx, Order{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2}); err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+		want := Order{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2, Status: contracts.OrderStatusPlaced}
+		got, err := repo.GetOrder(ctx, 1)
+		if err != nil || got != want {
+			t.Fatalf("GetOrder = %+v, %v; want %+v", got, err, want)
+		}
+	})
+
+	t.Run("duplicate ID", func(t *testing.T) {
+		repo := newRepo(t)
+		repo.CreateOrder(ctx, Order{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2})
+		err := repo.CreateOrder(ctx, Order{OrderID: 1, ProductID: 102, UserID: 8, Quantity: 1})
+		if !errors.Is(err, ErrOrderExists) {
+			t.Fatalf("CreateOrder error = %v, want ErrOrderExists", err)
+		}
+		if got, _ := repo.GetOrder(ctx, 1); got.ProductID != 101 {
+			t.Fatalf("duplicate overwrote the stored order: %+v", got)
+		}
+	})
+
+	t.Run("list by user", func(t *testing.T) {
+		repo := newRepo(t)
+		if orders, err := repo.ListOrdersByUser(ctx, 7); err != nil || len(orders) != 0 {
+			t.Fatalf("ListOrdersByUser on empty repository = %+v, %v", orders, err)
+		}
+		for _, o := range []Order{
+			{OrderID: 3, ProductID: 101, UserID: 7, Quantity: 1},
+			{OrderID: 1, ProductID: 102, UserID: 7, Quantity: 1},
+			{OrderID: 2, ProductID: 101, UserID: 8, Quantity: 1},
+		} {
+			if err := repo.CreateOrder(ctx, o); err != nil {
+				t.Fatalf("CreateOrder: %v", err)
+			}
+		}
+		orders, err := repo.ListOrdersByUser(ctx, 7)
+		if err != nil {
+			t.Fatalf("ListOrdersByUser: %v", err)
+		}
+		var ids []int
+		for _, o := range orders {
+			ids = append(ids, o.OrderID)
+		}
+		if !reflect.DeepEqual(ids, []int{1, 3}) {
+			t.Fatalf("order IDs = %v, want [1 3]", ids)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		repo := newRepo(t)
+		if _, err := repo.CancelOrder(ctx, 1); !errors.Is(err, ErrOrderNotFound) {
+			t.Fatalf("CancelOrder of unknown order: error = %v, want ErrOrderNotFound", err)
+		}
+		repo.CreateOrder(ctx, Order{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2})
+
+		want := Order{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2, Status: contracts.OrderStatusCancelled}
+		if got, err := repo.CancelOrder(ctx, 1); err != nil || got != want {
+			t.Fatalf("CancelOrder = %+v, %v; want %+v", got, err, want)
+		}
+		if got, _ := repo.GetOrder(ctx, 1); got != want {
+			t.Errorf("GetOrder after cancelling = %+v, want %+v", got, want)
+		}
+		if _, err := repo.CancelOrder(ctx, 1); !errors.Is(err, ErrOrderCancelled) {
+			t.Errorf("second CancelOrder error = %v, want ErrOrderCancelled", err)
+		}
+	})
+
+	t.Run("save users", func(t *testing.T) {
+		repo := newRepo(t)
+		if users, err := repo.ListUsers(ctx); err != nil || len(users) != 0 {
+			t.Fatalf("ListUsers on empty repository = %+v, %v", users, err)
+		}
+		for _, u := range []User{
+			{UserID: 8, Name: "Bob", Email: "bob@example.com"},
+			{UserID: 7, Name: "Alice", Email: "alice@example.com"},
+			{UserID: 8, Name: "Robert", Email: "robert@example.com"},
+		} {
+			if err := repo.SaveUser(ctx, u); err != nil {
+				t.Fatalf("SaveUser: %v", err)
+			}
+		}
+		users, err := repo.ListUsers(ctx)
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		want := []User{
+			{UserID: 7, Name: "Alice", Email: "alice@example.com"},
+			{UserID: 8, Name: "Robert", Email: "robert@example.com"},
+		}
+		if !reflect.DeepEqual(users, want) {
+			t.Fatalf("ListUsers = %+v, want %+v", users, want)
+		}
+	})
+
+	t.Run("schema version", func(t *testing.T) {
+		if version, err := newRepo(t).SchemaVersion(ctx); err != nil || version != SchemaVersion {
+			t.Fatalf("SchemaVersion = %d, %v; want %d", version, err, SchemaVersion)
+		}
+	})
+
+	t.Run("ping", func(t *testing.T) {
+		if err := newRepo(t).Ping(ctx); err != nil {
+			t.Fatalf("Ping: %v", err)
+		}
+	})
+}
+
+func TestMemoryOrders(t *testing.T) {
+	testOrderRepository(t, func(*testing.T) OrderRepository { return NewMemoryOrders() })
+}
+
+// TestPostgresOrders runs the contract against the database named by
+// TEST_DATABASE_URL, migrated to the latest schema. The orders and users
+// tables are emptied before each case.
+func TestPostgresOrders(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db := openTestDB(t, dsn, "orders_test")
+
+	testOrderRepository(t, func(t *testing.T) OrderRepository {
+		if _, err := db.Exec("TRUNCATE orders, users"); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return NewPostgresOrders(db)
+	})
+}
+
+// openTestDB opens dsn with its search_path set to schema and applies the
+// migrations there, so each service's tables and schema_migrations stay
+// apart when the packages share one test database.
+func openTestDB(t *testing.T, dsn, schema string) *sql.DB {
+	t.Helper()
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer admin.Close()
+	if _, err := admin.Exec("CREATE SCHEMA IF NOT EXISTS " + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("TEST_DATABASE_URL must be a postgres:// URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := NewMigrator(db).Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
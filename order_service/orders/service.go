@@ -0,0 +1,475 @@
+// Package orders stores the orders placed through the gateway.
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+	"ecomm-sample/observability"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Order is a stored order. It has the fields of the contracts.OrderPlaced
+// message it was created from, and its status: contracts.OrderStatusPlaced
+// or contracts.OrderStatusCancelled.
+type Order struct {
+	OrderID   int    `json:"order_id"`
+	ProductID int    `json:"product_id"`
+	UserID    int    `json:"user_id"`
+	Quantity  int    `json:"quantity"`
+	Status    string `json:"status,omitempty"`
+}
+
+// orderFromPlaced returns the order to store for a placed order.
+func orderFromPlaced(p contracts.OrderPlaced) Order {
+	return Order{
+		OrderID:   p.OrderID,
+		ProductID: p.ProductID,
+		UserID:    p.UserID,
+		Quantity:  p.Quantity,
+		Status:    contracts.OrderStatusPlaced,
+	}
+}
+
+// placedAs reports whether o was stored for placed, whatever its status
+// has become since.
+func (o Order) placedAs(placed contracts.OrderPlaced) bool {
+	o.Status = contracts.OrderStatusPlaced
+	return o == orderFromPlaced(placed)
+}
+
+// details returns the order as sent in replies.
+func (o Order) details() contracts.OrderDetails {
+	return contracts.OrderDetails{
+		OrderID:   o.OrderID,
+		ProductID: o.ProductID,
+		UserID:    o.UserID,
+		Quantity:  o.Quantity,
+		Status:    o.Status,
+	}
+}
+
+const serviceName = "order_service"
+
+var tracer = otel.Tracer(serviceName)
+
+// msgLog is the sampled logger for per-message logs.
+var msgLog = slog.Default()
+
+// SetupLogging installs the service's default and per-message loggers, as
+// described at observability.SetupLogging.
+func SetupLogging() {
+	msgLog = observability.SetupLogging(serviceName)
+}
+
+// SetupTracing installs the service's tracer provider, as described at
+// observability.SetupTracing. The returned function flushes pending spans.
+func SetupTracing() func(context.Context) error {
+	return observability.SetupTracing(serviceName)
+}
+
+// Service records placed orders, answers queries about them, cancels them
+// and answers health checks.
+type Service struct {
+	repo      OrderRepository
+	broker    broker.Broker
+	encodings contracts.Encodings
+}
+
+func NewService(repo OrderRepository, b broker.Broker) *Service {
+	return &Service{repo: repo, broker: b}
+}
+
+// SetEncodings sets the encoding of the messages the service publishes to
+// each queue. Replies are always encoded like the request.
+func (s *Service) SetEncodings(e contracts.Encodings) {
+	s.encodings = e
+}
+
+// declareTopology declares the queues and exchanges this service consumes,
+// and the events exchange it publishes to.
+func declareTopology(b broker.Broker) error {
+	for _, queue := range []string{"place_order", "order_requests", "response_order_service"} {
+		if err := broker.DeclareWorkQueue(b, queue); err != nil {
+			return err
+		}
+	}
+	if err := b.DeclareExchange("health_check_exchange", broker.Fanout); err != nil {
+		return fmt.Errorf("declare fanout exchange: %w", err)
+	}
+	if err := b.DeclareExchange(contracts.EventsExchange, broker.Topic); err != nil {
+		return fmt.Errorf("declare events exchange: %w", err)
+	}
+	return nil
+}
+
+// Start declares the service's topology and handles its queues in the
+// background until ctx is cancelled.
+func (s *Service) Start(ctx context.Context) error {
+	if err := declareTopology(s.broker); err != nil {
+		return err
+	}
+
+	orders, err := s.broker.Consume(ctx, "place_order")
+	if err != nil {
+		return fmt.Errorf("consume place_order queue: %w", err)
+	}
+	requests, err := s.broker.Consume(ctx, "order_requests")
+	if err != nil {
+		return fmt.Errorf("consume order_requests queue: %w", err)
+	}
+	stockResponses, err := s.broker.Consume(ctx, "response_order_service")
+	if err != nil {
+		return fmt.Errorf("consume response queue: %w", err)
+	}
+
+	healthQueue, err := s.broker.DeclareQueue("", broker.QueueOptions{AutoDelete: true, Exclusive: true})
+	if err != nil {
+		return fmt.Errorf("declare health-check queue: %w", err)
+	}
+	if err := s.broker.BindQueue(healthQueue, "", "health_check_exchange"); err != nil {
+		return fmt.Errorf("bind health-check queue: %w", err)
+	}
+	healthChecks, err := s.broker.Consume(ctx, healthQueue)
+	if err != nil {
+		return fmt.Errorf("consume health-check queue: %w", err)
+	}
+
+	go s.serve("place_order", orders)
+	go s.serve("order_requests", requests)
+	go s.serve("response_order_service", stockResponses)
+	go s.serve("health_check", healthChecks)
+	slog.Info("Order Service waiting for orders, order requests, stock responses and health checks")
+	return nil
+}
+
+// serve dispatches every message from msgs.
+func (s *Service) serve(queue string, msgs <-chan broker.Delivery) {
+	for msg := range msgs {
+		messagesConsumed.WithLabelValues(queue).Inc()
+		start := time.Now()
+		s.dispatch(queue, msg)
+		handlerDuration.WithLabelValues(queue).Observe(time.Since(start).Seconds())
+	}
+}
+
+// dispatch decodes msg's envelope and passes its payload to the handler
+// for its type, which settles the message. Messages that are malformed, of
+// an unknown type or version, or of a type this service does not handle
+// are rejected to the queue's dead-letter queue.
+func (s *Service) dispatch(queue string, msg broker.Delivery) {
+	ctx, span := observability.StartConsumeSpan(tracer, msg, queue)
+	defer span.End()
+	ctx = observability.WithLogFields(ctx, slog.String("correlation_id", msg.CorrelationID))
+
+	env, payload, err := contracts.Decode(msg.ContentType, msg.Body)
+	if err == nil {
+		ctx = observability.WithLogFields(ctx, slog.String("message_id", env.MessageID), slog.String("message_type", env.Type))
+		switch p := payload.(type) {
+		case contracts.OrderPlaced:
+			s.handlePlaceOrder(ctx, queue, msg, p)
+			return
+		case contracts.OrderGet:
+			s.handleOrderGet(ctx, queue, msg, p)
+			return
+		case contracts.OrderList:
+			s.handleOrderList(ctx, queue, msg, p)
+			return
+		case contracts.OrderCancel:
+			s.handleOrderCancel(ctx, queue, msg, p)
+			return
+		case contracts.StockCheckResult:
+			s.handleStockResult(ctx, queue, msg, p)
+			return
+		case contracts.HealthCheck:
+			s.handleHealthCheck(ctx, msg)
+			return
+		}
+		err = fmt.Errorf("%w %q", errUnhandledType, env.Type)
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "rejected message")
+	slog.ErrorContext(ctx, "Rejected message", "queue", queue, "content_type", msg.ContentType, "type", env.Type, "version", env.Version, "error", err)
+	msg.Nack(false)
+	messagesNacked.WithLabelValues(queue).Inc()
+	messagesDeadLettered.WithLabelValues(queue).Inc()
+}
+
+// errUnhandledType is returned for a valid message this service has no
+// handler for.
+var errUnhandledType = errors.New("no handler for message type")
+
+// publish sends p in an envelope to queue, in the queue's encoding.
+func (s *Service) publish(ctx context.Context, queue string, p contracts.Payload) error {
+	return s.send(ctx, queue, "", queue, "", s.encodings.For(queue), p)
+}
+
+// announce publishes e to the events exchange.
+func (s *Service) announce(ctx context.Context, e contracts.Event) error {
+	return s.send(ctx, contracts.EventsExchange, contracts.EventsExchange, e.EventName(), "", s.encodings.For(contracts.EventsExchange), e)
+}
+
+// reply publishes p in an envelope to msg's reply queue, in the same
+// encoding as msg.
+func (s *Service) reply(ctx context.Context, msg broker.Delivery, p contracts.Payload) error {
+	enc, err := contracts.EncodingOf(msg.ContentType)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, "reply", "", msg.ReplyTo, msg.CorrelationID, enc, p)
+}
+
+// send publishes p in an envelope to exchange with routing key key. label
+// names the destination in spans and metrics.
+func (s *Service) send(ctx context.Context, label, exchange, key, correlationID string, enc contracts.Encoding, p contracts.Payload) error {
+	env, body, err := contracts.Encode(enc, serviceName, p)
+	if err != nil {
+		return err
+	}
+	ctx, span, headers := observability.StartPublishSpan(ctx, tracer, label)
+	defer span.End()
+	err = s.broker.Publish(ctx, exchange, key, broker.Message{
+		ContentType:   enc.ContentType(),
+		CorrelationID: correlationID,
+		MessageID:     env.MessageID,
+		Headers:       headers,
+		Body:          body,
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "publish failed")
+		return err
+	}
+	messagesPublished.WithLabelValues(label).Inc()
+	return nil
+}
+
+// handlePlaceOrder stores a single order, announces it and settles the
+// message. A redelivered order that is already stored is announced, as
+// stored, and acknowledged again, so that an announcement that failed is
+// not lost; event consumers may see an order placed twice. A different
+// order that reuses a stored order's ID is rejected.
+func (s *Service) handlePlaceOrder(ctx context.Context, queue string, msg broker.Delivery, placed contracts.OrderPlaced) {
+	order := orderFromPlaced(placed)
+	ctx = observability.WithLogFields(ctx, slog.Int("order_id", order.OrderID), slog.Int("user_id", order.UserID))
+
+	err := s.repo.CreateOrder(ctx, order)
+	switch {
+	case errors.Is(err, ErrOrderExists):
+		if order, err = s.repo.GetOrder(ctx, placed.OrderID); err == nil && !order.placedAs(placed) {
+			s.rejectReusedOrderID(ctx, queue, msg, placed, order)
+			return
+		}
+		slog.WarnContext(ctx, "Order already stored")
+	case err == nil:
+		msgLog.InfoContext(ctx, "Order stored", "product_id", order.ProductID, "quantity", order.Quantity)
+		ordersSaved.Inc()
+	}
+	if err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to store order")
+		slog.ErrorContext(ctx, "Failed to store order", "error", err)
+		msg.Nack(true)
+		messagesNacked.WithLabelValues(queue).Inc()
+		return
+	}
+	if err := s.announce(ctx, contracts.OrderStatusChanged(order.details())); err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to announce order")
+		slog.ErrorContext(ctx, "Failed to announce placed order", "error", err)
+		msg.Nack(true)
+		messagesNacked.WithLabelValues(queue).Inc()
+		return
+	}
+	msg.Ack()
+	messagesAcked.WithLabelValues(queue).Inc()
+}
+
+// rejectReusedOrderID dead-letters placed, a different order than the
+// stored one with its ID, and releases the stock reserved for it. The
+// inventory keeps one reservation per order ID, so when placed asks for
+// the stored order's units those are the stored order's and stay
+// reserved; orders without an ID are not tracked and always released.
+func (s *Service) rejectReusedOrderID(ctx context.Context, queue string, msg broker.Delivery, placed contracts.OrderPlaced, stored Order) {
+	if placed.OrderID == 0 || placed.ProductID != stored.ProductID || placed.Quantity != stored.Quantity {
+		release := contracts.StockRelease{ProductID: placed.ProductID, Quantity: placed.Quantity, OrderID: placed.OrderID, Reason: "order ID already used"}
+		if err := s.publish(ctx, "release_stock", release); err != nil {
+			span := trace.SpanFromContext(ctx)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to release stock")
+			slog.ErrorContext(ctx, "Failed to release stock of rejected order", "error", err)
+			msg.Nack(true)
+			messagesNacked.WithLabelValues(queue).Inc()
+			return
+		}
+	}
+	slog.ErrorContext(ctx, "Rejected order reusing the ID of another order",
+		"product_id", placed.ProductID, "quantity", placed.Quantity,
+		"stored_user_id", stored.UserID, "stored_product_id", stored.ProductID, "stored_quantity", stored.Quantity)
+	msg.Nack(false)
+	messagesNacked.WithLabelValues(queue).Inc()
+	messagesDeadLettered.WithLabelValues(queue).Inc()
+}
+
+// handleOrderGet replies with a single order.
+func (s *Service) handleOrderGet(ctx context.Context, queue string, msg broker.Delivery, req contracts.OrderGet) {
+	ctx = observability.WithLogFields(ctx, slog.Int("order_id", req.OrderID))
+	order, err := s.repo.GetOrder(ctx, req.OrderID)
+	if err != nil {
+		s.answer(ctx, queue, msg, orderError(ctx, req.OrderID, err))
+		return
+	}
+	s.answer(ctx, queue, msg, order.details())
+}
+
+// handleOrderList replies with a user's orders.
+func (s *Service) handleOrderList(ctx context.Context, queue string, msg broker.Delivery, req contracts.OrderList) {
+	ctx = observability.WithLogFields(ctx, slog.Int("user_id", req.UserID))
+	orders, err := s.repo.ListOrdersByUser(ctx, req.UserID)
+	if err != nil {
+		s.answer(ctx, queue, msg, orderError(ctx, 0, err))
+		return
+	}
+	result := contracts.OrderListResult{Orders: make([]contracts.OrderDetails, len(orders))}
+	for i, o := range orders {
+		result.Orders[i] = o.details()
+	}
+	s.answer(ctx, queue, msg, result)
+}
+
+// handleOrderCancel cancels an order, returns its stock to the inventory,
+// announces the cancellation, tells the user and replies with the
+// cancelled order. If the stock cannot be released, the cancellation
+// announced or the user told, the order stays cancelled and the failure is
+// only logged, as in the gateway's releaseStock.
+func (s *Service) handleOrderCancel(ctx context.Context, queue string, msg broker.Delivery, req contracts.OrderCancel) {
+	ctx = observability.WithLogFields(ctx, slog.Int("order_id", req.OrderID))
+	order, err := s.repo.CancelOrder(ctx, req.OrderID)
+	if err != nil {
+		s.answer(ctx, queue, msg, orderError(ctx, req.OrderID, err))
+		return
+	}
+	ordersCancelled.Inc()
+	msgLog.InfoContext(ctx, "Order cancelled", "product_id", order.ProductID, "quantity", order.Quantity)
+
+	release := contracts.StockRelease{ProductID: order.ProductID, Quantity: order.Quantity, OrderID: order.OrderID, Reason: "order cancelled"}
+	if err := s.publish(ctx, "release_stock", release); err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to release stock")
+		slog.ErrorContext(ctx, "Failed to release stock of cancelled order", "product_id", order.ProductID, "quantity", order.Quantity, "error", err)
+	}
+	if err := s.announce(ctx, contracts.OrderStatusChanged(order.details())); err != nil {
+		slog.ErrorContext(ctx, "Failed to announce cancelled order", "error", err)
+	}
+	// A cancellation is not batched with other order updates.
+	err = s.publish(ctx, "notifications", contracts.NotificationRequested{
+		UserID:   order.UserID,
+		Template: "order_cancelled",
+		Data:     map[string]string{"order_id": strconv.Itoa(order.OrderID)},
+		Priority: contracts.PriorityCritical,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to notify user of cancelled order", "error", err)
+	}
+	s.answer(ctx, queue, msg, order.details())
+}
+
+// orderError returns the reply for a failed order request.
+func orderError(ctx context.Context, orderID int, err error) contracts.OrderError {
+	switch {
+	case errors.Is(err, ErrOrderNotFound):
+		return contracts.OrderError{Code: contracts.OrderErrNotFound, Message: fmt.Sprintf("order %d not found", orderID)}
+	case errors.Is(err, ErrOrderCancelled):
+		return contracts.OrderError{Code: contracts.OrderErrAlreadyCancelled, Message: fmt.Sprintf("order %d is already cancelled", orderID)}
+	}
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "order request failed")
+	slog.ErrorContext(ctx, "Order request failed", "error", err)
+	return contracts.OrderError{Code: contracts.OrderErrUnavailable, Message: "order database unavailable"}
+}
+
+// answer replies to an order request and acknowledges it. The request is
+// acknowledged even when the reply cannot be sent: the caller has then
+// timed out or will, and retrying would not help it.
+func (s *Service) answer(ctx context.Context, queue string, msg broker.Delivery, p contracts.Payload) {
+	if err := s.reply(ctx, msg, p); err != nil {
+		slog.ErrorContext(ctx, "Failed to publish order reply", "error", err)
+	}
+	msg.Ack()
+	messagesAcked.WithLabelValues(queue).Inc()
+}
+
+// handleStockResult notifies the user about a processed order and settles
+// the message. The notification is low priority: it waits for the user's
+// next digest of order updates.
+func (s *Service) handleStockResult(ctx context.Context, queue string, msg broker.Delivery, result contracts.StockCheckResult) {
+	msgLog.InfoContext(ctx, "Stock response received", "product_id", result.ProductID, "available", result.IsAvailable)
+
+	err := s.publish(ctx, "notifications", contracts.NotificationRequested{
+		UserID:   1, // Replace with actual user ID
+		Template: "order_processed",
+		Data:     map[string]string{"product_id": strconv.Itoa(result.ProductID)},
+		Priority: contracts.PriorityLow,
+	})
+	if err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to publish notification")
+		slog.ErrorContext(ctx, "Failed to publish notification", "error", err)
+		msg.Nack(true)
+		messagesNacked.WithLabelValues(queue).Inc()
+		return
+	}
+	ordersProcessed.Inc()
+	msg.Ack()
+	messagesAcked.WithLabelValues(queue).Inc()
+}
+
+// handleHealthCheck replies to a single health-check request.
+func (s *Service) handleHealthCheck(ctx context.Context, msg broker.Delivery) {
+	defer msg.Ack()
+
+	dbStatus := "connected"
+	if err := s.repo.Ping(ctx); err != nil {
+		dbStatus = "disconnected"
+	}
+
+	response := contracts.HealthStatus{
+		Service:  "Order Service",
+		Status:   "healthy",
+		Database: dbStatus,
+	}
+	if dbStatus == "disconnected" {
+		response.Status = "unhealthy"
+		response.Error = "Database connection failed"
+	} else if version, err := s.repo.SchemaVersion(ctx); err != nil {
+		response.Status = "unhealthy"
+		response.Error = "Schema version unavailable"
+	} else {
+		response.SchemaVersion = version
+		if version < SchemaVersion {
+			response.Status = "unhealthy"
+			response.Error = fmt.Sprintf("Schema version %d is behind %d", version, SchemaVersion)
+		}
+	}
+
+	if err := s.reply(ctx, msg, response); err != nil {
+		slog.ErrorContext(ctx, "Failed to publish health response", "error", err)
+		return
+	}
+	slog.DebugContext(ctx, "Health response published", "status", response.Status, "database", response.Database, "schema_version", response.SchemaVersion)
+}
@@ -0,0 +1,323 @@
+package orders
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"ecomm-sample/broker"
+	"ecomm-sample/contracts"
+)
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewJSONHandler(io.Discard, nil)))
+	msgLog = slog.Default()
+	os.Exit(m.Run())
+}
+
+func newTestBroker(t *testing.T) *broker.Memory {
+	t.Helper()
+	b := broker.NewMemory()
+	if err := declareTopology(b); err != nil {
+		t.Fatalf("declareTopology: %v", err)
+	}
+	for _, q := range []string{"notifications", "release_stock", "events"} {
+		if _, err := b.DeclareQueue(q, broker.QueueOptions{Durable: true}); err != nil {
+			t.Fatalf("DeclareQueue: %v", err)
+		}
+	}
+	if err := b.BindQueue("events", "#", contracts.EventsExchange); err != nil {
+		t.Fatalf("BindQueue: %v", err)
+	}
+	return b
+}
+
+// deliver publishes msg to queue and returns the resulting delivery.
+func deliver(t *testing.T, b *broker.Memory, queue string, msg broker.Message) broker.Delivery {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgs, err := b.Consume(ctx, queue)
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if err := b.Publish(ctx, "", queue, msg); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	select {
+	case d := <-msgs:
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+	return broker.Delivery{}
+}
+
+// envelope encodes p as another service would publish it.
+func envelope(t *testing.T, p contracts.Payload) []byte {
+	t.Helper()
+	_, body, err := contracts.Encode(contracts.JSON, "test", p)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return body
+}
+
+// decodePayload decodes the payload of a message this service published.
+func decodePayload[T contracts.Payload](t *testing.T, msg broker.Message) T {
+	t.Helper()
+	env, payload, err := contracts.Decode(msg.ContentType, msg.Body)
+	if err != nil {
+		t.Fatalf("invalid message %s: %v", msg.Body, err)
+	}
+	if env.Producer != serviceName || msg.MessageID != env.MessageID {
+		t.Errorf("envelope = %+v, message ID %q; want it produced by %s", env, msg.MessageID, serviceName)
+	}
+	p, ok := payload.(T)
+	if !ok {
+		t.Fatalf("payload is %T", payload)
+	}
+	return p
+}
+
+func TestHandleStockResultPublishesNotification(t *testing.T) {
+	b := newTestBroker(t)
+	msg := deliver(t, b, "response_order_service", broker.Message{
+		Body: envelope(t, contracts.StockCheckResult{ProductID: 101, IsAvailable: true}),
+	})
+
+	NewService(NewMemoryOrders(), b).dispatch("response_order_service", msg)
+
+	notifications := b.Pending("notifications")
+	if len(notifications) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(notifications))
+	}
+	notif := decodePayload[contracts.NotificationRequested](t, notifications[0])
+	if notif.Template != "order_processed" || notif.Data["product_id"] != "101" || notif.Message != "" || notif.Priority != contracts.PriorityLow {
+		t.Errorf("notification = %v", notif)
+	}
+	if pending := b.Pending("response_order_service"); len(pending) != 0 {
+		t.Errorf("message left on queue: %v", pending)
+	}
+}
+
+func TestHandlePlaceOrderStoresOrder(t *testing.T) {
+	b := newTestBroker(t)
+	repo := NewMemoryOrders()
+	svc := NewService(repo, b)
+	body := envelope(t, contracts.OrderPlaced{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgs, _ := b.Consume(ctx, "place_order")
+	// The second delivery is a redelivery of an order already stored.
+	for i := 0; i < 2; i++ {
+		b.Publish(ctx, "", "place_order", broker.Message{Body: body})
+		svc.dispatch("place_order", <-msgs)
+	}
+
+	got, err := repo.GetOrder(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	if want := (Order{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2, Status: contracts.OrderStatusPlaced}); got != want {
+		t.Errorf("stored order = %+v, want %+v", got, want)
+	}
+	if pending := b.Pending("place_order"); len(pending) != 0 {
+		t.Errorf("order left on queue: %v", pending)
+	}
+	events := b.Pending("events")
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want one per delivery", len(events))
+	}
+	want := contracts.OrderStatusChanged{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2, Status: contracts.OrderStatusPlaced}
+	for _, e := range events {
+		if event := decodePayload[contracts.OrderStatusChanged](t, e); event != want {
+			t.Errorf("event = %+v, want %+v", event, want)
+		}
+	}
+}
+
+func TestHandlePlaceOrderRejectsReusedOrderID(t *testing.T) {
+	tests := []struct {
+		name    string
+		placed  contracts.OrderPlaced
+		release bool
+	}{
+		// The inventory holds one reservation per order ID and refuses
+		// other units under a reserved ID, so the stored order's units are
+		// its own and must stay reserved.
+		{"other user, same units", contracts.OrderPlaced{OrderID: 1, ProductID: 101, UserID: 8, Quantity: 2}, false},
+		{"other product", contracts.OrderPlaced{OrderID: 1, ProductID: 102, UserID: 7, Quantity: 2}, true},
+		{"other quantity", contracts.OrderPlaced{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 3}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBroker(t)
+			repo := NewMemoryOrders()
+			stored := Order{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2, Status: contracts.OrderStatusPlaced}
+			if err := repo.CreateOrder(context.Background(), stored); err != nil {
+				t.Fatalf("CreateOrder: %v", err)
+			}
+
+			NewService(repo, b).dispatch("place_order", deliver(t, b, "place_order", broker.Message{Body: envelope(t, tt.placed)}))
+
+			if dead := b.Pending(broker.DeadLetterQueue("place_order")); len(dead) != 1 {
+				t.Errorf("dead-letter queue holds %d messages, want 1", len(dead))
+			}
+			if got, _ := repo.GetOrder(context.Background(), 1); got != stored {
+				t.Errorf("stored order = %+v, want %+v", got, stored)
+			}
+			if events := b.Pending("events"); len(events) != 0 {
+				t.Errorf("rejected order was announced: %v", events)
+			}
+			releases := b.Pending("release_stock")
+			if !tt.release {
+				if len(releases) != 0 {
+					t.Errorf("stored order's stock was released: %v", releases)
+				}
+				return
+			}
+			if len(releases) != 1 {
+				t.Fatalf("got %d stock releases, want 1", len(releases))
+			}
+			release := decodePayload[contracts.StockRelease](t, releases[0])
+			if release.OrderID != 1 || release.ProductID != tt.placed.ProductID || release.Quantity != tt.placed.Quantity {
+				t.Errorf("release = %+v, want the rejected order's units", release)
+			}
+		})
+	}
+}
+
+func TestHandleOrderRequests(t *testing.T) {
+	placed := contracts.OrderDetails{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2, Status: contracts.OrderStatusPlaced}
+	cancelled := placed
+	cancelled.Status = contracts.OrderStatusCancelled
+
+	tests := []struct {
+		name     string
+		requests []contracts.Payload
+		want     contracts.Payload // reply to the last request
+		released bool
+	}{
+		{"get", []contracts.Payload{contracts.OrderGet{OrderID: 1}}, placed, false},
+		{"get unknown order", []contracts.Payload{contracts.OrderGet{OrderID: 2}},
+			contracts.OrderError{Code: contracts.OrderErrNotFound, Message: "order 2 not found"}, false},
+		{"list", []contracts.Payload{contracts.OrderList{UserID: 7}},
+			contracts.OrderListResult{Orders: []contracts.OrderDetails{placed}}, false},
+		{"list for user without orders", []contracts.Payload{contracts.OrderList{UserID: 8}},
+			contracts.OrderListResult{Orders: []contracts.OrderDetails{}}, false},
+		{"cancel", []contracts.Payload{contracts.OrderCancel{OrderID: 1}}, cancelled, true},
+		{"cancel twice", []contracts.Payload{contracts.OrderCancel{OrderID: 1}, contracts.OrderCancel{OrderID: 1}},
+			contracts.OrderError{Code: contracts.OrderErrAlreadyCancelled, Message: "order 1 is already cancelled"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBroker(t)
+			replyQueue, _ := b.DeclareQueue("", broker.QueueOptions{})
+			repo := NewMemoryOrders()
+			repo.CreateOrder(context.Background(), Order{OrderID: 1, ProductID: 101, UserID: 7, Quantity: 2})
+			svc := NewService(repo, b)
+
+			for _, req := range tt.requests {
+				msg := deliver(t, b, "order_requests", broker.Message{ReplyTo: replyQueue, Body: envelope(t, req)})
+				svc.dispatch("order_requests", msg)
+			}
+
+			replies := b.Pending(replyQueue)
+			if len(replies) != len(tt.requests) {
+				t.Fatalf("got %d replies, want %d", len(replies), len(tt.requests))
+			}
+			_, got, err := contracts.Decode(replies[len(replies)-1].ContentType, replies[len(replies)-1].Body)
+			if err != nil {
+				t.Fatalf("invalid reply: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reply = %#v, want %#v", got, tt.want)
+			}
+			releases, events, notifications := b.Pending("release_stock"), b.Pending("events"), b.Pending("notifications")
+			if !tt.released {
+				if len(releases) != 0 || len(events) != 0 || len(notifications) != 0 {
+					t.Errorf("stock released: %v, events: %v, notifications: %v", releases, events, notifications)
+				}
+				return
+			}
+			if len(notifications) != 1 {
+				t.Fatalf("got %d notifications, want the cancellation", len(notifications))
+			}
+			notif := decodePayload[contracts.NotificationRequested](t, notifications[0])
+			if notif.UserID != 7 || notif.Template != "order_cancelled" || notif.Data["order_id"] != "1" || notif.Priority != contracts.PriorityCritical {
+				t.Errorf("notification = %+v, want a critical order_cancelled for user 7", notif)
+			}
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want the cancellation", len(events))
+			}
+			if event := decodePayload[contracts.OrderStatusChanged](t, events[0]); event != contracts.OrderStatusChanged(cancelled) {
+				t.Errorf("event = %+v, want %+v", event, cancelled)
+			}
+			if len(releases) != 1 {
+				t.Fatalf("release_stock holds %d messages, want 1", len(releases))
+			}
+			want := contracts.StockRelease{ProductID: 101, Quantity: 2, OrderID: 1, Reason: "order cancelled"}
+			if release := decodePayload[contracts.StockRelease](t, releases[0]); release != want {
+				t.Errorf("release = %+v, want %+v", release, want)
+			}
+		})
+	}
+}
+
+func TestDispatchRejectsToDeadLetterQueue(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"malformed body", []byte("{")},
+		{"bare payload without envelope", []byte(`{"order_id":1,"product_id":101,"user_id":7,"quantity":2}`)},
+		{"unknown version", []byte(`{"type":"order.placed","version":2,"payload":{"order_id":1,"user_id":7}}`)},
+		{"type this service does not handle", envelope(t, contracts.StockRelease{ProductID: 101, Quantity: 1})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBroker(t)
+			repo := NewMemoryOrders()
+
+			NewService(repo, b).dispatch("place_order", deliver(t, b, "place_order", broker.Message{Body: tt.body}))
+
+			if pending := b.Pending("place_order"); len(pending) != 0 {
+				t.Errorf("rejected order was requeued: %v", pending)
+			}
+			if dead := b.Pending(broker.DeadLetterQueue("place_order")); len(dead) != 1 {
+				t.Errorf("dead-letter queue holds %d messages, want 1", len(dead))
+			}
+			if orders, _ := repo.ListOrdersByUser(context.Background(), 7); len(orders) != 0 {
+				t.Errorf("rejected order was stored: %v", orders)
+			}
+		})
+	}
+}
+
+func TestHandleHealthCheck(t *testing.T) {
+	b := newTestBroker(t)
+	replyQueue, _ := b.DeclareQueue("", broker.QueueOptions{})
+	queue, _ := b.DeclareQueue("", broker.QueueOptions{})
+	msg := deliver(t, b, queue, broker.Message{
+		CorrelationID: "health",
+		ReplyTo:       replyQueue,
+		Body:          envelope(t, contracts.HealthCheck{}),
+	})
+
+	NewService(NewMemoryOrders(), b).dispatch("health_check", msg)
+
+	replies := b.Pending(replyQueue)
+	if len(replies) != 1 {
+		t.Fatalf("got %d replies, want 1", len(replies))
+	}
+	resp := decodePayload[contracts.HealthStatus](t, replies[0])
+	if resp.Service != "Order Service" || resp.Status != "healthy" || replies[0].CorrelationID != "health" {
+		t.Errorf("reply = %+v (correlation %q)", resp, replies[0].CorrelationID)
+	}
+}
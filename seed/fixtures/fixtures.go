@@ -0,0 +1,200 @@
+// Package fixtures loads development and load-test data into the service
+// databases: products and their stock for the inventory service, and users
+// and sample orders for the order service.
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ecomm-sample/inventory_service/inventory"
+	"ecomm-sample/order_service/orders"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures is the contents of one or more fixture files. Products carry
+// their inventory level in Stock and, optionally, their reorder policy.
+type Fixtures struct {
+	Products []inventory.Product `json:"products,omitempty"`
+	Users    []orders.User       `json:"users,omitempty"`
+	Orders   []orders.Order      `json:"orders,omitempty"`
+}
+
+// Append adds the records of other to f.
+func (f *Fixtures) Append(other Fixtures) {
+	f.Products = append(f.Products, other.Products...)
+	f.Users = append(f.Users, other.Users...)
+	f.Orders = append(f.Orders, other.Orders...)
+}
+
+// Load reads a fixture file. Files ending in .yaml or .yml are YAML;
+// anything else is JSON.
+func Load(path string) (Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixtures{}, err
+	}
+	f, err := Parse(data, isYAML(path))
+	if err != nil {
+		return Fixtures{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Parse decodes fixtures from JSON, or from YAML if yamlFormat is set. YAML
+// uses the same field names as JSON.
+func Parse(data []byte, yamlFormat bool) (Fixtures, error) {
+	if yamlFormat {
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return Fixtures{}, err
+		}
+		if doc == nil {
+			return Fixtures{}, nil
+		}
+		var err error
+		if data, err = json.Marshal(doc); err != nil {
+			return Fixtures{}, err
+		}
+	}
+	var f Fixtures
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return Fixtures{}, err
+	}
+	return f, nil
+}
+
+// Write stores f at path, as YAML or JSON depending on its extension.
+func Write(path string, f Fixtures) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if isYAML(path) {
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		if data, err = yaml.Marshal(doc); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Validate reports every record that cannot be loaded: missing or repeated
+// IDs, blank names, negative stock and non-positive order quantities.
+func (f Fixtures) Validate() error {
+	var errs []error
+	products := map[int]bool{}
+	for _, p := range f.Products {
+		switch {
+		case p.ProductID <= 0:
+			errs = append(errs, fmt.Errorf("product %q: product_id must be positive", p.Name))
+		case products[p.ProductID]:
+			errs = append(errs, fmt.Errorf("product %d: listed twice", p.ProductID))
+		case p.Name == "":
+			errs = append(errs, fmt.Errorf("product %d: name is required", p.ProductID))
+		case p.Stock < 0:
+			errs = append(errs, fmt.Errorf("product %d: stock must not be negative", p.ProductID))
+		case p.ReorderThreshold < 0 || p.SafetyStock < 0:
+			errs = append(errs, fmt.Errorf("product %d: reorder_threshold and safety_stock must not be negative", p.ProductID))
+		}
+		products[p.ProductID] = true
+	}
+	users := map[int]bool{}
+	for _, u := range f.Users {
+		switch {
+		case u.UserID <= 0:
+			errs = append(errs, fmt.Errorf("user %q: user_id must be positive", u.Name))
+		case users[u.UserID]:
+			errs = append(errs, fmt.Errorf("user %d: listed twice", u.UserID))
+		case u.Name == "" || u.Email == "":
+			errs = append(errs, fmt.Errorf("user %d: name and email are required", u.UserID))
+		}
+		users[u.UserID] = true
+	}
+	placed := map[int]bool{}
+	for _, o := range f.Orders {
+		switch {
+		case o.OrderID <= 0:
+			errs = append(errs, errors.New("order: order_id must be positive"))
+		case placed[o.OrderID]:
+			errs = append(errs, fmt.Errorf("order %d: listed twice", o.OrderID))
+		case o.ProductID <= 0 || o.UserID <= 0:
+			errs = append(errs, fmt.Errorf("order %d: product_id and user_id are required", o.OrderID))
+		case o.Quantity <= 0:
+			errs = append(errs, fmt.Errorf("order %d: quantity must be positive", o.OrderID))
+		}
+		placed[o.OrderID] = true
+	}
+	return errors.Join(errs...)
+}
+
+// Result counts what Apply wrote.
+type Result struct {
+	Products      int
+	Users         int
+	Orders        int
+	OrdersExisted int
+}
+
+// Apply validates f and writes it to the repositories. Either repository
+// may be nil when f has nothing for it.
+//
+// Applying the same fixtures again leaves the databases unchanged: products
+// and users are upserted, so their stock, names and emails are reset to the
+// fixture values, and orders that are already stored are left alone. Each
+// reset of stock is recorded in the product's stock ledger as an
+// adjustment.
+func Apply(ctx context.Context, f Fixtures, inv inventory.InventoryRepository, ord orders.OrderRepository) (Result, error) {
+	var res Result
+	if err := f.Validate(); err != nil {
+		return res, err
+	}
+	if inv == nil && len(f.Products) > 0 {
+		return res, errors.New("fixtures have products but no inventory database was given")
+	}
+	if ord == nil && len(f.Users)+len(f.Orders) > 0 {
+		return res, errors.New("fixtures have users or orders but no order database was given")
+	}
+
+	for _, p := range f.Products {
+		if err := inv.SaveProduct(ctx, p); err != nil {
+			return res, fmt.Errorf("product %d: %w", p.ProductID, err)
+		}
+		res.Products++
+	}
+	for _, u := range f.Users {
+		if err := ord.SaveUser(ctx, u); err != nil {
+			return res, fmt.Errorf("user %d: %w", u.UserID, err)
+		}
+		res.Users++
+	}
+	for _, o := range f.Orders {
+		err := ord.CreateOrder(ctx, o)
+		if errors.Is(err, orders.ErrOrderExists) {
+			res.OrdersExisted++
+			continue
+		}
+		if err != nil {
+			return res, fmt.Errorf("order %d: %w", o.OrderID, err)
+		}
+		res.Orders++
+	}
+	return res, nil
+}
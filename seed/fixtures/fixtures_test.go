@@ -0,0 +1,187 @@
+package fixtures
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ecomm-sample/contracts"
+	"ecomm-sample/inventory_service/inventory"
+	"ecomm-sample/order_service/orders"
+)
+
+const yamlFixtures = `
+products:
+  - product_id: 101
+    name: Broccoli
+    stock: 10
+users:
+  - user_id: 1
+    name: Ada
+    email: ada@example.com
+orders:
+  - order_id: 9001
+    product_id: 101
+    user_id: 1
+    quantity: 2
+`
+
+const jsonFixtures = `{
+  "products": [{"product_id": 101, "name": "Broccoli", "stock": 10}],
+  "users": [{"user_id": 1, "name": "Ada", "email": "ada@example.com"}],
+  "orders": [{"order_id": 9001, "product_id": 101, "user_id": 1, "quantity": 2}]
+}`
+
+var wantFixtures = Fixtures{
+	Products: []inventory.Product{{ProductID: 101, Name: "Broccoli", Stock: 10}},
+	Users:    []orders.User{{UserID: 1, Name: "Ada", Email: "ada@example.com"}},
+	Orders:   []orders.Order{{OrderID: 9001, ProductID: 101, UserID: 1, Quantity: 2}},
+}
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data string
+		yaml bool
+	}{
+		{"yaml", yamlFixtures, true},
+		{"json", jsonFixtures, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(tt.data), tt.yaml)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(f, wantFixtures) {
+				t.Fatalf("Parse = %+v, want %+v", f, wantFixtures)
+			}
+		})
+	}
+}
+
+func TestParseRejectsUnknownFields(t *testing.T) {
+	_, err := Parse([]byte("products:\n  - product_id: 1\n    name: x\n    stok: 3\n"), true)
+	if err == nil || !strings.Contains(err.Error(), "stok") {
+		t.Fatalf("Parse error = %v, want it to name the unknown field", err)
+	}
+}
+
+func TestWriteAndLoad(t *testing.T) {
+	for _, name := range []string{"fixtures.yaml", "fixtures.json"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := Write(path, wantFixtures); err != nil {
+			t.Fatalf("Write %s: %v", name, err)
+		}
+		f, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load %s: %v", name, err)
+		}
+		if !reflect.DeepEqual(f, wantFixtures) {
+			t.Errorf("%s round trip = %+v, want %+v", name, f, wantFixtures)
+		}
+	}
+}
+
+func TestDevFixturesAreValid(t *testing.T) {
+	f, err := Load("../dev.yaml")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := f.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	f := Fixtures{
+		Products: []inventory.Product{
+			{ProductID: 1, Name: "ok", Stock: 1},
+			{ProductID: 1, Name: "again", Stock: 1},
+			{ProductID: 2, Stock: 1},
+			{ProductID: 3, Name: "negative", Stock: -1},
+			{ProductID: 4, Name: "no safety", Stock: 1, SafetyStock: -2},
+		},
+		Users:  []orders.User{{UserID: 1, Name: "no email"}},
+		Orders: []orders.Order{{OrderID: 1, ProductID: 1, UserID: 1}},
+	}
+	err := f.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted invalid fixtures")
+	}
+	for _, want := range []string{
+		"product 1: listed twice",
+		"product 2: name is required",
+		"product 3: stock must not be negative",
+		"product 4: reorder_threshold and safety_stock must not be negative",
+		"user 1: name and email are required",
+		"order 1: quantity must be positive",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestApplyIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	inv := inventory.NewMemoryInventory()
+	ord := orders.NewMemoryOrders()
+
+	res, err := Apply(ctx, wantFixtures, inv, ord)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if want := (Result{Products: 1, Users: 1, Orders: 1}); res != want {
+		t.Fatalf("first Apply = %+v, want %+v", res, want)
+	}
+
+	// Stock moves between runs; seeding again resets it.
+	inv.Move(ctx, inventory.Movement{ProductID: 101, Type: contracts.MovementReservation, Quantity: -4})
+	res, err = Apply(ctx, wantFixtures, inv, ord)
+	if err != nil {
+		t.Fatalf("second Apply: %v", err)
+	}
+	if want := (Result{Products: 1, Users: 1, OrdersExisted: 1}); res != want {
+		t.Fatalf("second Apply = %+v, want %+v", res, want)
+	}
+	if stock, _ := inv.Stock(ctx, 101); stock != 10 {
+		t.Errorf("stock = %d, want 10", stock)
+	}
+	if drifts, _ := inv.Reconcile(ctx); len(drifts) != 0 {
+		t.Errorf("stock differs from its ledger: %+v", drifts)
+	}
+	if users, _ := ord.ListUsers(ctx); len(users) != 1 {
+		t.Errorf("users = %+v, want one", users)
+	}
+	if placed, _ := ord.ListOrdersByUser(ctx, 1); len(placed) != 1 {
+		t.Errorf("orders = %+v, want one", placed)
+	}
+}
+
+func TestApplyNeedsRepositories(t *testing.T) {
+	if _, err := Apply(context.Background(), wantFixtures, nil, orders.NewMemoryOrders()); err == nil {
+		t.Error("Apply with products but no inventory repository succeeded")
+	}
+	products := Fixtures{Products: wantFixtures.Products}
+	if _, err := Apply(context.Background(), products, inventory.NewMemoryInventory(), nil); err != nil {
+		t.Errorf("Apply with products only: %v", err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	f := Generate(50, 10000, 7)
+	if len(f.Products) != 50 || len(f.Users) != 50 {
+		t.Fatalf("generated %d products and %d users, want 50 each", len(f.Products), len(f.Users))
+	}
+	if f.Products[0].ProductID != 10000 || f.Users[49].UserID != 10049 {
+		t.Errorf("IDs run %d..%d, want 10000..10049", f.Products[0].ProductID, f.Users[49].UserID)
+	}
+	if err := f.Validate(); err != nil {
+		t.Errorf("generated fixtures are invalid: %v", err)
+	}
+	if !reflect.DeepEqual(f, Generate(50, 10000, 7)) {
+		t.Error("the same seed generated different fixtures")
+	}
+}
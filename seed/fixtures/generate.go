@@ -0,0 +1,39 @@
+package fixtures
+
+import (
+	"fmt"
+	"math/rand"
+
+	"ecomm-sample/inventory_service/inventory"
+	"ecomm-sample/order_service/orders"
+)
+
+var (
+	adjectives = []string{"Fresh", "Organic", "Crunchy", "Spicy", "Smoked", "Frozen", "Sparkling", "Roasted"}
+	nouns      = []string{"Apples", "Broccoli", "Coffee", "Crackers", "Lemonade", "Almonds", "Salmon", "Tortillas"}
+)
+
+// Generate returns n synthetic products and n synthetic users with IDs
+// firstID to firstID+n-1. The same seed always yields the same fixtures, so
+// loading them again only resets stock.
+func Generate(n, firstID int, seed int64) Fixtures {
+	r := rand.New(rand.NewSource(seed))
+	f := Fixtures{
+		Products: make([]inventory.Product, 0, n),
+		Users:    make([]orders.User, 0, n),
+	}
+	for i := 0; i < n; i++ {
+		id := firstID + i
+		f.Products = append(f.Products, inventory.Product{
+			ProductID: id,
+			Name:      fmt.Sprintf("%s %s %d", adjectives[r.Intn(len(adjectives))], nouns[r.Intn(len(nouns))], id),
+			Stock:     r.Intn(1000),
+		})
+		f.Users = append(f.Users, orders.User{
+			UserID: id,
+			Name:   fmt.Sprintf("Load Test User %d", id),
+			Email:  fmt.Sprintf("user%d@loadtest.example", id),
+		})
+	}
+	return f
+}
@@ -31,7 +31,7 @@ for SERVICE_DIR in "${SERVICE_DIRS[@]}"; do
     }
     LOG_FILE="$SERVICE_DIR/service.log"
     echo "Logs for $SERVICE_DIR will be written to $LOG_FILE"
-    go run main.go >> "$LOG_FILE" 2>&1 &
+    go run . -migrate >> "$LOG_FILE" 2>&1 &
     echo "Service in $SERVICE_DIR started with PID $!"
   )
 done